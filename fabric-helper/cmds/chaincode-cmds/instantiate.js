@@ -41,6 +41,11 @@ exports.builder = function (yargs) {
         describe: 'Value(s) to pass as argument to instantiation call.',
         requiresArg: true,
         type: 'string'
+    }).option('collections-config', {
+        demandOption: false,
+        describe: 'Path to the private data collections config of the chaincode.',
+        requiresArg: true,
+        type: 'string'
     }).option('upgrade', {
         demandOption: false,
         describe: 'Specify \'true\' if instantiating new version of existing chaincode.',
@@ -55,5 +60,5 @@ exports.handler = function (argv) {
         upgrade = argv.upgrade;
     }
 
-    return instantiateLib.instantiateChaincode(argv['channel'], argv['cc-name'], argv['cc-version'], argv['init-arg'], argv['org'], upgrade);
+    return instantiateLib.instantiateChaincode(argv['channel'], argv['cc-name'], argv['cc-version'], argv['init-arg'], argv['org'], upgrade, argv['collections-config']);
 };
\ No newline at end of file
@@ -26,7 +26,7 @@ const logger = helper.getLogger('install-chaincode');
 
 var instantiateChaincode = async function (channelName,
 	chaincodeName, chaincodeVersion, args,
-	orgName, upgrade) {
+	orgName, upgrade, collectionsConfig) {
 	logger.debug('\n============ Instantiate chaincode on organization ' + orgName + ' ============\n');
 
 	try {
@@ -41,6 +41,9 @@ var instantiateChaincode = async function (channelName,
 			args: args,
 			txId: tx_id
 		};
+		if (collectionsConfig) {
+			request['collections-config'] = path.resolve(collectionsConfig);
+		}
 		if (upgrade) {
 			results = await channel.sendUpgradeProposal(request);
 		} else {
@@ -46,5 +46,5 @@ NODE_ENV=local node fabric-cli.js chaincode install --src-dir ${CC_SRC_DIR} --or
 # I N S T A N T I A T E   C H A I N C O D E
 # Instantiating chaincode on peer0 of org1
 printf "\n\n============ I N S T A N T I A T E    C H A I N C O D E ============\n"
-NODE_ENV=local node fabric-cli.js chaincode instantiate --org org1 --cc-version V1 --channel $CHANNEL_NAME --cc-name $CC_NAME --init-arg ''
+NODE_ENV=local node fabric-cli.js chaincode instantiate --org org1 --cc-version V1 --channel $CHANNEL_NAME --cc-name $CC_NAME --init-arg '' --collections-config ../network/local/collections_config.json
 sleep 10
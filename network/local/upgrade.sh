@@ -33,5 +33,5 @@ NODE_ENV=local node fabric-cli.js chaincode install --src-dir ${CC_SRC_DIR} --or
 NODE_ENV=local node fabric-cli.js chaincode install --src-dir ${CC_SRC_DIR} --org org2 --cc-version $CHAINCODE_VERSION --channel $CHANNEL_NAME --cc-name $CC_NAME
 
 printf "\n\n============ U P G R A D E    C H A I N C O D E ============\n"
-NODE_ENV=local node fabric-cli.js chaincode instantiate --org org1 --cc-version $CHAINCODE_VERSION --channel $CHANNEL_NAME --cc-name $CC_NAME --init-arg '' --upgrade true
+NODE_ENV=local node fabric-cli.js chaincode instantiate --org org1 --cc-version $CHAINCODE_VERSION --channel $CHANNEL_NAME --cc-name $CC_NAME --init-arg '' --collections-config ../network/local/collections_config.json --upgrade true
 sleep 10
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+/*
+* An advance paid by a payer to a payee is recouped from the royalties the payer later owes the payee. The terms of
+* advances are recorded on the ledger under ADVANCE~<payee>~<payer>~<advance UUID>. When a royalty statement is
+* recorded, the recoupment rate of each advance of its parties that is eligible for the income type of the statement
+* is withheld from the statement until the advance is recouped, oldest advance first. The statement keeps the
+* recouped amount and the remaining unrecouped balance and only the rest of it is outstanding.
+*
+* Each recoupment is written once under ADVANCERECOUPMENT~<payee>~<payer>~<advance UUID>~<royalty statement UUID>~<txID>,
+* to the private data collection of the statement when its amounts are private, and the recouped amount and the
+* balance of an advance are folded from the recoupments the caller can read. The terms of an advance are not
+* rewritten by recoupment, so statements of the same parties do not all update one key.
+*
+* The income types of an advance are right categories or usage types, an advance without income types is
+* recouped from all income. An advance without a recoupment rate is recouped from the whole statement.
+ */
+
+// AdvanceResponse : defines response data from blockchain request
+type AdvanceResponse struct {
+	AdvanceUUID string `json:"advanceUUID"`
+	Message     string `json:"message"`
+	Success     bool   `json:"success"`
+}
+
+// AdvanceOutput : defines accumulated output of blockchain requests
+type AdvanceOutput struct {
+	SuccessCount     int               `json:"successCount"`
+	FailureCount     int               `json:"failureCount"`
+	AdvanceResponses []AdvanceResponse `json:"advanceResponses"`
+}
+
+/*
+* addAdvances function inserts new advances to the Ledger, nothing of a new advance is recouped
+*
+* @params   {Array} args
+* @property {string} 0       - stringified JSON array of advances.
+* @return   {pb.Response}    - peer Response
+ */
+func addAdvances(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "addAdvances"
+	logger.Info("ENTERING >", methodName, args)
+	return putAdvances(stub, args, false)
+}
+
+/*
+* updateAdvances function changes the terms of advances on the Ledger, the amount already recouped is kept
+*
+* @params   {Array} args
+* @property {string} 0       - stringified JSON array of advances.
+* @return   {pb.Response}    - peer Response
+ */
+func updateAdvances(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "updateAdvances"
+	logger.Info("ENTERING >", methodName, args)
+	return putAdvances(stub, args, true)
+}
+
+//putAdvances - contains the business logic to insert new or update existing advances
+func putAdvances(stub shim.ChaincodeStubInterface, args []string, updateFlag bool) pb.Response {
+	var methodName = "putAdvances"
+
+	if len(args) != 1 {
+		return getErrorResponse("Missing arguments: Array of Advance objects is required")
+	}
+
+	advanceOutput := AdvanceOutput{}
+	advances := &[]Advance{}
+	advanceResponses := []AdvanceResponse{}
+
+	err := jsonToObject([]byte(args[0]), advances)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	for _, advance := range *advances {
+		advanceResponse := AdvanceResponse{}
+		advanceResponse.AdvanceUUID = advance.AdvanceUUID
+		advanceResponse.Success = true
+
+		err = putAdvanceTerms(stub, &advance, updateFlag)
+		if err != nil {
+			advanceResponse.Success = false
+			advanceResponse.Message = err.Error()
+			advanceResponses = append(advanceResponses, advanceResponse)
+			advanceOutput.FailureCount++
+			continue
+		}
+		advanceOutput.SuccessCount++
+	}
+
+	advanceOutput.AdvanceResponses = advanceResponses
+
+	objBytes, _ := objectToJSON(advanceOutput)
+	logger.Info("EXITING <", methodName, advanceOutput)
+	return shim.Success(objBytes)
+}
+
+/*
+* putAdvanceTerms function validates the terms of an advance and records it on the Ledger. The payee and the
+* payer of an advance cannot be updated.
+*
+* @param    {Advance} - advance
+* @param    {bool}    - updateFlag
+* @return   {error}   - Error
+ */
+func putAdvanceTerms(stub shim.ChaincodeStubInterface, advance *Advance, updateFlag bool) error {
+	advance.DocType = ADVANCE
+	if advance.AdvanceUUID == "" {
+		return fmt.Errorf("Advance UUID is required")
+	}
+	var err error
+	for _, ipi := range []*string{&advance.Payee, &advance.Payer} {
+		*ipi, err = normalizeIpi(*ipi)
+		if err != nil {
+			return err
+		}
+	}
+	if advance.Payee == "" || advance.Payer == "" {
+		return fmt.Errorf("Advance '%s' needs a payee and a payer", advance.AdvanceUUID)
+	}
+	if advance.Amount <= 0 {
+		return fmt.Errorf("Amount %v of advance '%s' is not positive", advance.Amount, advance.AdvanceUUID)
+	}
+	if advance.RecoupmentRate == 0 {
+		advance.RecoupmentRate = 100
+	}
+	if advance.RecoupmentRate < 0 || advance.RecoupmentRate > 100 {
+		return fmt.Errorf("Recoupment rate %v of advance '%s' is not a percentage", advance.RecoupmentRate, advance.AdvanceUUID)
+	}
+	for i, incomeType := range advance.IncomeTypes {
+		advance.IncomeTypes[i] = normalizeUsageType(incomeType)
+	}
+	if advance.AdvanceDate != "" {
+		_, err = parseDate(advance.AdvanceDate, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	previousAdvance, err := getAdvanceFromLedger(stub, advance.Payee, advance.Payer, advance.AdvanceUUID)
+	if err != nil {
+		return err
+	}
+	if !updateFlag && previousAdvance != nil {
+		return fmt.Errorf("Advance '%s' already exists!", advance.AdvanceUUID)
+	}
+	if updateFlag && previousAdvance == nil {
+		return fmt.Errorf("Advance '%s' of '%s' to '%s' does not exist!", advance.AdvanceUUID, advance.Payer, advance.Payee)
+	}
+
+	// only recoupment changes the recouped amount
+	if previousAdvance != nil && previousAdvance.Recouped > advance.Amount {
+		return fmt.Errorf("Amount %v of advance '%s' is less than the %v already recouped", advance.Amount, advance.AdvanceUUID, previousAdvance.Recouped)
+	}
+	return putAdvance(stub, *advance)
+}
+
+//putAdvance - records the terms of an advance on the ledger, the recouped amount and the balance are folded on read
+func putAdvance(stub shim.ChaincodeStubInterface, advance Advance) error {
+	advance.Recouped = 0
+	advance.Balance = 0
+	advanceKey, err := stub.CreateCompositeKey(ADVANCE, []string{advance.Payee, advance.Payer, advance.AdvanceUUID})
+	if err != nil {
+		return err
+	}
+	advanceBytes, err := objectToJSON(advance)
+	if err != nil {
+		return err
+	}
+	return stub.PutState(advanceKey, advanceBytes)
+}
+
+/*
+* getAdvances function returns the advances of a payee, or of a payer to a payee
+*
+* @params   {Array} args
+* @property {string} 0       - payee IPI
+* @property {string} 1       - optional payer IPI
+* @return   {pb.Response}    - peer Response
+ */
+func getAdvances(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "getAdvances"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) < 1 || len(args) > 2 {
+		return getErrorResponse(fmt.Sprintf("%s - Incorrect number of parameters provided '%d'. Needed payee and optional payer", methodName, len(args)))
+	}
+
+	attributes := []string{}
+	for _, arg := range args {
+		ipi, err := normalizeIpi(arg)
+		if err != nil {
+			return getErrorResponse(err.Error())
+		}
+		attributes = append(attributes, ipi)
+	}
+	advances, err := getAdvancesFromLedger(stub, attributes)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	objBytes, err := objectToJSON(advances)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	logger.Info("EXITING <", methodName, len(advances))
+	return shim.Success(objBytes)
+}
+
+//getAdvanceFromLedger - returns the advance recorded on the ledger with its recouped amount or nil
+func getAdvanceFromLedger(stub shim.ChaincodeStubInterface, payee string, payer string, advanceUUID string) (*Advance, error) {
+	advanceKey, err := stub.CreateCompositeKey(ADVANCE, []string{payee, payer, advanceUUID})
+	if err != nil {
+		return nil, err
+	}
+	advanceBytes, err := stub.GetState(advanceKey)
+	if err != nil || advanceBytes == nil {
+		return nil, err
+	}
+	advance := Advance{}
+	err = jsonToObject(advanceBytes, &advance)
+	if err != nil {
+		return nil, err
+	}
+	err = foldAdvanceRecoupments(stub, []string{payee, payer, advanceUUID}, []Advance{advance})
+	if err != nil {
+		return nil, err
+	}
+	return &advance, nil
+}
+
+//getAdvancesFromLedger - returns the advances recorded on the ledger under the partial key of a payee and optional payer
+func getAdvancesFromLedger(stub shim.ChaincodeStubInterface, attributes []string) ([]Advance, error) {
+	advances := []Advance{}
+	advanceIterator, err := stub.GetStateByPartialCompositeKey(ADVANCE, attributes)
+	if err != nil {
+		return nil, err
+	}
+	defer advanceIterator.Close()
+	for advanceIterator.HasNext() {
+		queryResponse, err := advanceIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		advance := Advance{}
+		err = jsonToObject(queryResponse.Value, &advance)
+		if err != nil {
+			return nil, err
+		}
+		advances = append(advances, advance)
+	}
+	err = foldAdvanceRecoupments(stub, attributes, advances)
+	if err != nil {
+		return nil, err
+	}
+	return advances, nil
+}
+
+/*
+* foldAdvanceRecoupments function sets the recouped amount and the balance of advances from their public
+* recoupments and from the recoupments of the private data collections of the caller.
+*
+* @param    {Array}  - partial key of the recoupments, a payee and optional payer and advance UUID
+* @param    {Array}  - advances under the partial key, updated in place
+* @return   {error}  - Error
+ */
+func foldAdvanceRecoupments(stub shim.ChaincodeStubInterface, attributes []string, advances []Advance) error {
+	if len(advances) == 0 {
+		return nil
+	}
+	collections, err := getCallerPrivateCollections(stub)
+	if err != nil {
+		return err
+	}
+	recouped := map[string]float64{}
+	for _, collection := range append([]string{""}, collections...) {
+		recoupmentIterator, err := getLedgerDataByPartialCompositeKey(stub, collection, ADVANCERECOUPMENT, attributes)
+		if err != nil {
+			return err
+		}
+		for recoupmentIterator.HasNext() {
+			queryResponse, err := recoupmentIterator.Next()
+			if err != nil {
+				recoupmentIterator.Close()
+				return err
+			}
+			recoupment := AdvanceRecoupment{}
+			err = jsonToObject(queryResponse.Value, &recoupment)
+			if err != nil {
+				recoupmentIterator.Close()
+				return err
+			}
+			recouped[recoupment.AdvanceUUID] += recoupment.Recouped
+		}
+		recoupmentIterator.Close()
+	}
+	for i := range advances {
+		advances[i].Recouped = toFixed(recouped[advances[i].AdvanceUUID], 2)
+		advances[i].Balance = toFixed(advances[i].Amount-advances[i].Recouped, 2)
+	}
+	return nil
+}
+
+//isAdvanceEligible - returns whether an advance is recouped from a royalty statement
+func isAdvanceEligible(advance Advance, royaltyStatement RoyaltyStatement) bool {
+	if advance.Balance <= 0 {
+		return false
+	}
+	if advance.Currency != "" && royaltyStatement.Currency != "" && advance.Currency != royaltyStatement.Currency {
+		return false
+	}
+	if advance.AdvanceDate != "" && royaltyStatement.ExploitationDate != "" && !isDateInEffect(advance.AdvanceDate, "", royaltyStatement.ExploitationDate) {
+		return false
+	}
+	if len(advance.IncomeTypes) == 0 {
+		return true
+	}
+	for _, incomeType := range advance.IncomeTypes {
+		if incomeType == royaltyStatement.RightCategory || incomeType == normalizeUsageType(royaltyStatement.UsageType) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+* recoupRoyaltyStatement function withholds the recoupable part of a royalty statement for the advances of its
+* payer to its payee from what is left after the tax withheld. The advances already recouped in the transaction are
+* taken from pendingAdvances, as the ledger does not return the writes of the running transaction.
+*
+* @param    {RoyaltyStatement} - royalty statement, its recouped amount, unrecouped balance and net payable are set
+* @param    {map}              - advances recouped earlier in the transaction by key
+* @return   {Array}            - the recoupments of the advances, to be recorded with putRecoupedAdvances
+* @return   {error}            - Error
+ */
+func recoupRoyaltyStatement(stub shim.ChaincodeStubInterface, royaltyStatement *RoyaltyStatement, pendingAdvances map[string]Advance) ([]advanceRecoupment, error) {
+	royaltyStatement.RecoupedAmount = 0
+	royaltyStatement.UnrecoupedBalance = 0
+	royaltyStatement.AdvanceUUIDs = nil
+	defer setNetPayable(royaltyStatement)
+
+	payer, payee, amount := getRoyaltyStatementParties(*royaltyStatement)
+	if payer == "" || payee == "" || royaltyStatement.PaymentState == PAID {
+		return nil, nil
+	}
+	advances, err := getAdvancesFromLedger(stub, []string{payee, payer})
+	if err != nil {
+		return nil, err
+	}
+	for i := range advances {
+		if pendingAdvance, ok := pendingAdvances[advances[i].AdvanceUUID]; ok {
+			advances[i] = pendingAdvance
+		}
+	}
+	sort.SliceStable(advances, func(i, j int) bool {
+		if advances[i].AdvanceDate != advances[j].AdvanceDate {
+			return advances[i].AdvanceDate < advances[j].AdvanceDate
+		}
+		return advances[i].AdvanceUUID < advances[j].AdvanceUUID
+	})
+
+	// advances are recouped from what is left to the payee after tax
+	recoupable := toFixed(amount-royaltyStatement.TaxWithheld, 2)
+	recoupments := []advanceRecoupment{}
+	remaining := recoupable
+	for _, advance := range advances {
+		if !isAdvanceEligible(advance, *royaltyStatement) {
+			continue
+		}
+		recouped := toFixed(math.Min(math.Min(recoupable*advance.RecoupmentRate*0.01, remaining), advance.Balance), 2)
+		if recouped > 0 {
+			remaining = toFixed(remaining-recouped, 2)
+			advance.Recouped = toFixed(advance.Recouped+recouped, 2)
+			advance.Balance = toFixed(advance.Amount-advance.Recouped, 2)
+			royaltyStatement.RecoupedAmount = toFixed(royaltyStatement.RecoupedAmount+recouped, 2)
+			royaltyStatement.AdvanceUUIDs = append(royaltyStatement.AdvanceUUIDs, advance.AdvanceUUID)
+			recoupments = append(recoupments, advanceRecoupment{advance: advance, recouped: recouped})
+		}
+		royaltyStatement.UnrecoupedBalance = toFixed(royaltyStatement.UnrecoupedBalance+advance.Balance, 2)
+	}
+	return recoupments, nil
+}
+
+//advanceRecoupment - an amount recouped from a royalty statement and the advance after the recoupment
+type advanceRecoupment struct {
+	advance  Advance
+	recouped float64
+}
+
+//putRecoupedAdvances - records the recoupments of a royalty statement and keeps the advances for the rest of the transaction
+func putRecoupedAdvances(stub shim.ChaincodeStubInterface, royaltyStatement RoyaltyStatement, recoupments []advanceRecoupment, pendingAdvances map[string]Advance) error {
+	if len(recoupments) == 0 {
+		return nil
+	}
+	// the recouped amounts of a private statement are private too
+	collection, err := getRoyaltyStatementCollection(stub, royaltyStatement)
+	if err != nil {
+		return err
+	}
+	for _, recoupment := range recoupments {
+		advance := recoupment.advance
+		recoupmentKey, err := stub.CreateCompositeKey(ADVANCERECOUPMENT, []string{advance.Payee, advance.Payer, advance.AdvanceUUID, royaltyStatement.RoyaltyStatementUUID, stub.GetTxID()})
+		if err != nil {
+			return err
+		}
+		recoupmentBytes, err := objectToJSON(AdvanceRecoupment{DocType: ADVANCERECOUPMENT, AdvanceUUID: advance.AdvanceUUID, RoyaltyStatementUUID: royaltyStatement.RoyaltyStatementUUID, Recouped: recoupment.recouped})
+		if err != nil {
+			return err
+		}
+		err = putLedgerData(stub, collection, recoupmentKey, recoupmentBytes)
+		if err != nil {
+			return err
+		}
+		pendingAdvances[advance.AdvanceUUID] = advance
+	}
+	return nil
+}
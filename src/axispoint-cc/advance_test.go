@@ -0,0 +1,192 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"axispoint-cc/memstub"
+)
+
+var advances_in = `[{"advanceUUID":"adv1","payee":"Writer-IPI","payer":"spotify-IPI","amount":12,"recoupmentRate":50,"incomeTypes":["stream"],"advanceDate":"2018-01-01"}]`
+
+var recoupedRoyaltyStatements_in = `[` +
+	`{"royaltyStatementUUID":"rs1","source":"spotify-IPI","isrc":"QZAB11800001","exploitationDate":"2018-12-30","amount":10,"rightType":"OWNERSHIP","usageType":"STREAM","rightHolder":"Writer-IPI"},` +
+	`{"royaltyStatementUUID":"rs2","source":"spotify-IPI","isrc":"QZAB11800001","exploitationDate":"2018-12-30","amount":10,"rightType":"OWNERSHIP","usageType":"DOWNLOAD","rightHolder":"Writer-IPI"},` +
+	`{"royaltyStatementUUID":"rs3","source":"spotify-IPI","isrc":"QZAB11800001","exploitationDate":"2018-12-31","amount":10,"rightType":"OWNERSHIP","usageType":"STREAM","rightHolder":"Writer-IPI"}]`
+
+func MockGetAdvanceResponse(functionName string) []byte {
+	switch functionName {
+	case "Test_AddAdvances":
+		return []byte(`{"successCount":0,"failureCount":4,"advanceResponses":[` +
+			`{"advanceUUID":"adv1","message":"Advance 'adv1' already exists!","success":false},` +
+			`{"advanceUUID":"adv2","message":"Advance 'adv2' needs a payee and a payer","success":false},` +
+			`{"advanceUUID":"adv3","message":"Recoupment rate 150 of advance 'adv3' is not a percentage","success":false},` +
+			`{"advanceUUID":"adv4","message":"Amount -5 of advance 'adv4' is not positive","success":false}]}`)
+	case "Test_GetAdvances":
+		return []byte(`[{"docType":"ADVANCE","advanceUUID":"adv1","payee":"Writer-IPI","payer":"spotify-IPI","amount":12,"recoupmentRate":50,"incomeTypes":["STREAM"],"advanceDate":"2018-01-01","recouped":10,"balance":2}]`)
+	default:
+		return []byte("[]")
+	}
+}
+
+func Test_AddAdvances(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addAdvances"), []byte(advances_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("addAdvances"), []byte(`[` +
+		`{"advanceUUID":"adv1","payee":"Writer-IPI","payer":"spotify-IPI","amount":12},` +
+		`{"advanceUUID":"adv2","payee":"Writer-IPI","amount":12},` +
+		`{"advanceUUID":"adv3","payee":"Writer-IPI","payer":"spotify-IPI","amount":12,"recoupmentRate":150},` +
+		`{"advanceUUID":"adv4","payee":"Writer-IPI","payer":"spotify-IPI","amount":-5}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := MockGetAdvanceResponse("Test_AddAdvances")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_AddRoyaltyStatements_Recoupment(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addAdvances"), []byte(advances_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	// half of the streams is withheld until the advance is recouped, the downloads are not eligible
+	_, err = checkInvoke(t, stub, [][]byte{[]byte("addRoyaltyStatements"), []byte(recoupedRoyaltyStatements_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	actualRecoupments := [][]float64{}
+	for _, royaltyStatementUUID := range []string{"rs1", "rs2", "rs3"} {
+		royaltyStatement := RoyaltyStatement{}
+		err = jsonToObject(stub.State[royaltyStatementUUID], &royaltyStatement)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		actualRecoupments = append(actualRecoupments, []float64{royaltyStatement.RecoupedAmount, royaltyStatement.UnrecoupedBalance})
+	}
+	expectedRecoupments := [][]float64{{5, 7}, {0, 0}, {5, 2}}
+	if !reflect.DeepEqual(expectedRecoupments, actualRecoupments) {
+		t.Fatalf("Actual response is not equal to expected response: %v", actualRecoupments)
+	}
+
+	// the recouped amounts are not outstanding
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getBalances"), []byte(`{"ipi":"Writer-IPI"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	balances := []Balance{}
+	err = jsonToObject(actual, &balances)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(balances) != 1 || balances[0].Receivable != 20 {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+}
+
+func Test_GetAdvances(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addAdvances"), []byte(advances_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, err = checkInvoke(t, stub, [][]byte{[]byte("addRoyaltyStatements"), []byte(recoupedRoyaltyStatements_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getAdvances"), []byte("Writer-IPI"), []byte("spotify-IPI")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := MockGetAdvanceResponse("Test_GetAdvances")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+
+	// the terms change but the recouped amount is kept
+	_, err = checkInvoke(t, stub, [][]byte{[]byte("updateAdvances"), []byte(`[{"advanceUUID":"adv1","payee":"Writer-IPI","payer":"spotify-IPI","amount":15}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("getAdvances"), []byte("Writer-IPI")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected = []byte(`[{"docType":"ADVANCE","advanceUUID":"adv1","payee":"Writer-IPI","payer":"spotify-IPI","amount":15,"recoupmentRate":100,"recouped":10,"balance":5}]`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+}
+
+func Test_AddRoyaltyStatements_PrivateRecoupment(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("updateIpiOrg"), []byte(`{"ipi":"spotify-IPI","org":"Org1MSP"}`), []byte(`{"ipi":"Writer-IPI","org":"Org2MSP"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, err = checkInvoke(t, stub, [][]byte{[]byte("addAdvances"), []byte(advances_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	advanceKey, _ := stub.CreateCompositeKey(ADVANCE, []string{"Writer-IPI", "spotify-IPI", "adv1"})
+	advanceBytes := string(stub.State[advanceKey])
+	if advanceBytes == "" {
+		t.Fatalf("Advance '%s' is not recorded", advanceKey)
+	}
+
+	_, err = checkInvokeTransient(t, stub, [][]byte{[]byte("addRoyaltyStatements")}, getRoyaltyStatementsTransient(recoupedRoyaltyStatements_in))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// recoupment does not rewrite the terms of the advance nor writes public recoupments
+	if string(stub.State[advanceKey]) != advanceBytes {
+		t.Fatalf("Advance was rewritten by recoupment: %s", stub.State[advanceKey])
+	}
+	recoupmentPrefix, _ := stub.CreateCompositeKey(ADVANCERECOUPMENT, []string{})
+	for key := range stub.State {
+		if strings.HasPrefix(key, recoupmentPrefix) {
+			t.Fatalf("Recoupment of a private royalty statement is public: %q", key)
+		}
+	}
+
+	// the orgs of the statements fold the recoupments, other orgs do not see them
+	getCallerMspID = MockGetCallerOrg2MSP
+	defer func() { getCallerMspID = getCreatorMspID }()
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getAdvances"), []byte("Writer-IPI"), []byte("spotify-IPI")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := MockGetAdvanceResponse("Test_GetAdvances")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+
+	getCallerMspID = MockGetCallerOrg3MSP
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("getAdvances"), []byte("Writer-IPI"), []byte("spotify-IPI")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !strings.Contains(string(actual), `"recouped":0,"balance":12`) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+}
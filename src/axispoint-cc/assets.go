@@ -8,9 +8,16 @@ type Response struct {
 }
 
 /////////////////////////////////////////////////////
-// RoyaltyStatementCreation event name
+// RoyaltyStatementCreation event name and payload version
 /////////////////////////////////////////////////////
 const EventRoyaltyStatementCreation string = "RoyaltyStatementCreation"
+const EventRoyaltyStatementCreationVersion string = "2.0"
+
+/////////////////////////////////////////////////////
+// ExploitationReportClassification event name and payload version
+/////////////////////////////////////////////////////
+const EventExploitationReportClassification string = "ExploitationReportClassification"
+const EventExploitationReportClassificationVersion string = "1.0"
 
 /////////////////////////////////////////////////////
 // Constant for table names
@@ -23,8 +30,48 @@ const (
 	ROYALTYSTATEMENT         string = "ROYALTYSTATEMENT"
 	COLLECTIONRIGHTREPORT    string = "COLLECTIONRIGHTREPORT" //change this to collectionRight
 	IPIORGMAP                string = "IPIORGMAP"
+	IPIORGHISTORY            string = "IPIORGHISTORY"
+	DISPUTE                  string = "DISPUTE"
+	OPENDISPUTE              string = "OPENDISPUTE"
+	BALANCE                  string = "BALANCE"
+	PERIODSTATEMENT          string = "PERIODSTATEMENT"
+	MUSICALWORK              string = "MUSICALWORK"
+	RECORDINGWORK            string = "RECORDINGWORK"
+	ROYALTYPRIVATEDETAILS    string = "ROYALTYPRIVATEDETAILS"
+	TERRITORYGROUP           string = "TERRITORYGROUP"
+	USAGETYPECATEGORY        string = "USAGETYPECATEGORY"
+	ADVANCE                  string = "ADVANCE"
+	TAXRULE                  string = "TAXRULE"
+	TAXRESIDENCY             string = "TAXRESIDENCY"
+	PRIVATECOLLECTION        string = "PRIVATECOLLECTION"
+	PERIODPRIVATETOTALS      string = "PERIODPRIVATETOTALS"
+	ADVANCERECOUPMENT        string = "ADVANCERECOUPMENT"
+	COLLECTEDSTATEMENT       string = "COLLECTEDSTATEMENT"
 )
 
+/////////////////////////////////////////////////////
+// Prefix of the private data collections shared by the orgs of royalty statements
+/////////////////////////////////////////////////////
+const ROYALTY_COLLECTION_PREFIX string = "royalties"
+
+/////////////////////////////////////////////////////
+// Keys of the transient map carrying the private inputs of a transaction
+/////////////////////////////////////////////////////
+const (
+	TRANSIENT_ROYALTY_STATEMENTS string = "royaltyStatements"
+	TRANSIENT_SALT               string = "salt"
+)
+
+/////////////////////////////////////////////////////
+// Minimum number of random bytes of the salt of private details
+/////////////////////////////////////////////////////
+const MIN_SALT_LENGTH int = 16
+
+/////////////////////////////////////////////////////
+// Maximum number of hops resolved in a collection chain
+/////////////////////////////////////////////////////
+const MAX_COLLECTION_CHAIN_HOPS int = 10
+
 /////////////////////////////////////////////////////
 // Constant for the Exploitation Report State field values
 /////////////////////////////////////////////////////
@@ -38,6 +85,8 @@ const (
 	MISSING_REPRESENTATIVE       string = "MISSING_REPRESENTATIVE"
 	MISSING_AFFILIATE            string = "MISSING_AFFILIATE"
 	UNKOWN_ISRC                  string = "UNKOWN_ISRC"
+	INVALID_ISRC                 string = "INVALID_ISRC"
+	INVALID_TERRITORY            string = "INVALID_TERRITORY"
 )
 
 /////////////////////////////////////////////////////
@@ -46,6 +95,64 @@ const (
 const (
 	OWNERSHIP  string = "OWNERSHIP"
 	COLLECTION string = "COLLECTION"
+	FEE        string = "FEE"
+)
+
+/////////////////////////////////////////////////////
+// Constant for the Right Categories of right holder shares
+/////////////////////////////////////////////////////
+const (
+	MECHANICAL  string = "MECHANICAL"
+	PERFORMANCE string = "PERFORMANCE"
+	SYNC        string = "SYNC"
+	PRINT       string = "PRINT"
+)
+
+/////////////////////////////////////////////////////
+// Constant for the Collection Right Fee Type field values
+/////////////////////////////////////////////////////
+const (
+	PERCENT_FEE string = "PERCENT"
+	FLAT_FEE    string = "FLAT"
+	CAPPED_FEE  string = "CAPPED"
+)
+
+/////////////////////////////////////////////////////
+// Constant for the Royalty Statement Payment State
+/////////////////////////////////////////////////////
+const (
+	PAID string = "PAID"
+)
+
+/////////////////////////////////////////////////////
+// Constant for the Balance levels and the currency used when none is reported
+/////////////////////////////////////////////////////
+const (
+	BALANCE_IPI          string = "IPI"
+	BALANCE_ORG          string = "ORG"
+	UNSPECIFIED_CURRENCY string = "UNSPECIFIED"
+)
+
+/////////////////////////////////////////////////////
+// Constant for the Dispute State and Resolution Outcome field values
+/////////////////////////////////////////////////////
+const (
+	DISPUTE_OPEN     string = "OPEN"
+	DISPUTE_RESOLVED string = "RESOLVED"
+	ACCEPTED         string = "ACCEPTED"
+	REJECTED         string = "REJECTED"
+	WITHDRAWN        string = "WITHDRAWN"
+)
+
+/////////////////////////////////////////////////////
+// Constant for the Dispute Reason Code field values
+/////////////////////////////////////////////////////
+const (
+	WRONG_AMOUNT       string = "WRONG_AMOUNT"
+	WRONG_SPLIT        string = "WRONG_SPLIT"
+	WRONG_RIGHT_HOLDER string = "WRONG_RIGHT_HOLDER"
+	DUPLICATE_USAGE    string = "DUPLICATE_USAGE"
+	OTHER              string = "OTHER"
 )
 
 //ExploitationReport : struct defining data model for Exploitation Reports
@@ -62,47 +169,184 @@ type ExploitationReport struct {
 	ExploitationReportUUID string  `json:"exploitationReportUUID"`
 	Territory              string  `json:"territory"`
 	State                  string  `json:"state"`
+	Currency               string  `json:"currency,omitempty"`
 }
 
 //RoyaltyStatement : struct defining data model for Royalty Reports
 type RoyaltyStatement struct {
+	DocType                 string   `json:"docType"`
+	RoyaltyStatementUUID    string   `json:"royaltyStatementUUID"`
+	ExploitationReportUUID  string   `json:"exploitationReportUUID"`
+	Source                  string   `json:"source"`
+	Isrc                    string   `json:"isrc"`
+	SongTitle               string   `json:"songTitle"`
+	WriterName              string   `json:"writerName"`
+	Units                   int      `json:"units"`
+	ExploitationDate        string   `json:"exploitationDate"`
+	Amount                  float64  `json:"amount"`
+	RightType               string   `json:"rightType"`
+	Territory               string   `json:"territory"`
+	UsageType               string   `json:"usageType"`
+	RightHolder             string   `json:"rightHolder"`
+	Administrator           string   `json:"administrator"`
+	Collector               string   `json:"collector"`
+	State                   string   `json:"state"`
+	CollectionRight         float64  `json:"collectionRight,omitempty"`
+	CollectionRightPercent  float64  `json:"collectionRightPercent,omitempty"`
+	CopyrightDataReportUUID string   `json:"copyrightDataReportUUID,omitempty"`
+	PaymentState            string   `json:"paymentState,omitempty"`
+	PaymentDate             string   `json:"paymentDate,omitempty"`
+	Currency                string   `json:"currency,omitempty"`
+	PeriodStatementUUID     string   `json:"periodStatementUUID,omitempty"`
+	Iswc                    string   `json:"iswc,omitempty"`
+	PrivateCollection       string   `json:"privateCollection,omitempty"`
+	PrivateOrgs             []string `json:"privateOrgs,omitempty"`
+	PrivateDataHash         string   `json:"privateDataHash,omitempty"`
+	CollectionRightUUID     string   `json:"collectionRightUUID,omitempty"`
+	GrossAmount             float64  `json:"grossAmount,omitempty"`
+	FeeAmount               float64  `json:"feeAmount,omitempty"`
+	NetAmount               float64  `json:"netAmount,omitempty"`
+	ParentStatementUUID     string   `json:"parentStatementUUID,omitempty"`
+	Lineage                 []string `json:"lineage,omitempty"`
+	RightCategory           string   `json:"rightCategory,omitempty"`
+	RightHolderRole         string   `json:"rightHolderRole,omitempty"`
+	RecoupedAmount          float64  `json:"recoupedAmount,omitempty"`
+	UnrecoupedBalance       float64  `json:"unrecoupedBalance,omitempty"`
+	AdvanceUUIDs            []string `json:"advanceUUIDs,omitempty"`
+	TaxRuleUUID             string   `json:"taxRuleUUID,omitempty"`
+	TaxRate                 float64  `json:"taxRate,omitempty"`
+	TaxableAmount           float64  `json:"taxableAmount,omitempty"`
+	TaxWithheld             float64  `json:"taxWithheld,omitempty"`
+	NetPayable              float64  `json:"netPayable,omitempty"`
+}
+
+//RoyaltyStatementPrivateDetails : amounts of a royalty statement kept in the private data collection of its orgs
+type RoyaltyStatementPrivateDetails struct {
 	DocType                string  `json:"docType"`
 	RoyaltyStatementUUID   string  `json:"royaltyStatementUUID"`
-	ExploitationReportUUID string  `json:"exploitationReportUUID"`
-	Source                 string  `json:"source"`
-	Isrc                   string  `json:"isrc"`
-	SongTitle              string  `json:"songTitle"`
-	WriterName             string  `json:"writerName"`
-	Units                  int     `json:"units"`
-	ExploitationDate       string  `json:"exploitationDate"`
 	Amount                 float64 `json:"amount"`
-	RightType              string  `json:"rightType"`
-	Territory              string  `json:"territory"`
-	UsageType              string  `json:"usageType"`
-	RightHolder            string  `json:"rightHolder"`
-	Administrator          string  `json:"administrator"`
-	Collector              string  `json:"collector"`
-	State                  string  `json:"state"`
-	CollectionRight        float64 `json:"collectionRight,omitempty"`
-	CollectionRightPercent float64 `json:"collectionRightPercent,omitempty"`
+	CollectionRight        float64 `json:"collectionRight"`
+	CollectionRightPercent float64 `json:"collectionRightPercent"`
+	GrossAmount            float64 `json:"grossAmount,omitempty"`
+	FeeAmount              float64 `json:"feeAmount,omitempty"`
+	NetAmount              float64 `json:"netAmount,omitempty"`
+	RecoupedAmount         float64 `json:"recoupedAmount,omitempty"`
+	UnrecoupedBalance      float64 `json:"unrecoupedBalance,omitempty"`
+	TaxableAmount          float64 `json:"taxableAmount,omitempty"`
+	TaxWithheld            float64 `json:"taxWithheld,omitempty"`
+	NetPayable             float64 `json:"netPayable,omitempty"`
+	Salt                   string  `json:"salt"`
+}
+
+//PrivateCollection : struct definition for the registration of a private data collection shared by orgs
+type PrivateCollection struct {
+	DocType           string   `json:"docType"`
+	PrivateCollection string   `json:"privateCollection"`
+	PrivateOrgs       []string `json:"privateOrgs"`
+}
+
+//PrivateDataVerification : result of checking private details against the hash of their royalty statement
+type PrivateDataVerification struct {
+	RoyaltyStatementUUID string `json:"royaltyStatementUUID"`
+	PrivateCollection    string `json:"privateCollection"`
+	PrivateDataHash      string `json:"privateDataHash"`
+	ComputedHash         string `json:"computedHash"`
+	Verified             bool   `json:"verified"`
 }
 
 //CopyrightDataReport : struct definition
 type CopyrightDataReport struct {
-	DocType           string        `json:"docType"`
-	CopyrightDataUUID string        `json:"copyrightDataReportUUID"`
-	Isrc              string        `json:"isrc"`
-	SongTitle         string        `json:"songTitle"`
-	StartDate         string        `json:"startDate"`
-	EndDate           string        `json:"endDate"`
-	RightHolders      []RightHolder `json:"rightHolders"`
+	DocType             string        `json:"docType"`
+	CopyrightDataUUID   string        `json:"copyrightDataReportUUID"`
+	Isrc                string        `json:"isrc"`
+	SongTitle           string        `json:"songTitle"`
+	StartDate           string        `json:"startDate"`
+	EndDate             string        `json:"endDate"`
+	Territories         []string      `json:"territories,omitempty"`
+	ExcludedTerritories []string      `json:"excludedTerritories,omitempty"`
+	RightHolders        []RightHolder `json:"rightHolders"`
 }
 
 //RightHolder : struct definition for copyright data report
 type RightHolder struct {
-	Selector string  `json:"selector"`
-	IPI      string  `json:"ipi"`
-	Percent  float64 `json:"percent"`
+	Selector string             `json:"selector"`
+	IPI      string             `json:"ipi"`
+	Percent  float64            `json:"percent"`
+	Shares   map[string]float64 `json:"shares,omitempty"`
+	Role     string             `json:"role,omitempty"`
+}
+
+//Advance : struct definition of an advance paid by a payer to a payee, recouped from the royalties the payer owes the payee
+type Advance struct {
+	DocType        string   `json:"docType"`
+	AdvanceUUID    string   `json:"advanceUUID"`
+	Payee          string   `json:"payee"`
+	Payer          string   `json:"payer"`
+	Amount         float64  `json:"amount"`
+	RecoupmentRate float64  `json:"recoupmentRate"`
+	IncomeTypes    []string `json:"incomeTypes,omitempty"`
+	Currency       string   `json:"currency,omitempty"`
+	AdvanceDate    string   `json:"advanceDate,omitempty"`
+	Recouped       float64  `json:"recouped"`
+	Balance        float64  `json:"balance"`
+}
+
+//AdvanceRecoupment : struct definition of the part of an advance recouped from a royalty statement
+type AdvanceRecoupment struct {
+	DocType              string  `json:"docType"`
+	AdvanceUUID          string  `json:"advanceUUID"`
+	RoyaltyStatementUUID string  `json:"royaltyStatementUUID"`
+	Recouped             float64 `json:"recouped"`
+}
+
+//TaxRule : struct definition of the withholding tax of a source territory for payees resident in a country, or for all foreign payees without residency
+type TaxRule struct {
+	DocType           string   `json:"docType"`
+	TaxRuleUUID       string   `json:"taxRuleUUID"`
+	SourceTerritory   string   `json:"sourceTerritory"`
+	Residency         string   `json:"residency,omitempty"`
+	Rate              float64  `json:"rate"`
+	TreatyRate        *float64 `json:"treatyRate,omitempty"`
+	ExemptIncomeTypes []string `json:"exemptIncomeTypes,omitempty"`
+	StartDate         string   `json:"startDate,omitempty"`
+	EndDate           string   `json:"endDate,omitempty"`
+}
+
+//TaxResidency : struct definition of the tax residency of a payee
+type TaxResidency struct {
+	DocType       string `json:"docType"`
+	Ipi           string `json:"ipi"`
+	Country       string `json:"country"`
+	TreatyClaimed bool   `json:"treatyClaimed"`
+	Exempt        bool   `json:"exempt"`
+}
+
+//UsageTypeCategory : struct definition of the right category an exploitation usage type falls under
+type UsageTypeCategory struct {
+	DocType       string `json:"docType"`
+	UsageType     string `json:"usageType"`
+	RightCategory string `json:"rightCategory"`
+}
+
+//TerritoryGroup : struct definition of a named group of territories, Countries is only set when the group is read
+type TerritoryGroup struct {
+	DocType   string   `json:"docType"`
+	Code      string   `json:"code"`
+	Name      string   `json:"name"`
+	Includes  []string `json:"includes"`
+	Excludes  []string `json:"excludes,omitempty"`
+	Countries []string `json:"countries,omitempty"`
+}
+
+//MusicalWork : struct definition of the musical work underlying recordings, its split applies to all its recordings
+type MusicalWork struct {
+	DocType      string        `json:"docType"`
+	Iswc         string        `json:"iswc"`
+	Title        string        `json:"title"`
+	StartDate    string        `json:"startDate,omitempty"`
+	EndDate      string        `json:"endDate,omitempty"`
+	RightHolders []RightHolder `json:"rightHolders"`
+	Isrcs        []string      `json:"isrcs"`
 }
 
 //CollectionRights : struct definition
@@ -113,9 +357,49 @@ type CollectionRight struct {
 	FromName            string        `json:"fromName"`
 	StartDate           string        `json:"startDate"`
 	EndDate             string        `json:"endDate"`
+	Territories         []string      `json:"territories,omitempty"`
+	ExcludedTerritories []string      `json:"excludedTerritories,omitempty"`
+	Priority            int           `json:"priority,omitempty"`
+	Fee                 *FeeTerms     `json:"fee,omitempty"`
 	RightHolders        []RightHolder `json:"rightHolders"`
 }
 
+//FeeTerms : struct definition of the fee a collector keeps from what it collects under a collection right.
+// A PERCENT fee keeps percent of the gross, a FLAT fee keeps amount and a CAPPED fee keeps percent of the gross up to amount.
+type FeeTerms struct {
+	Type    string  `json:"type"`
+	Percent float64 `json:"percent,omitempty"`
+	Amount  float64 `json:"amount,omitempty"`
+}
+
+//CollectionChain : royalty statements of every hop collecting the amount of a royalty statement
+type CollectionChain struct {
+	RoyaltyStatementUUID string             `json:"royaltyStatementUUID"`
+	Persisted            bool               `json:"persisted"`
+	RoyaltyStatements    []RoyaltyStatement `json:"royaltyStatements"`
+}
+
+//CollectionGraph : collection rights around an IPI, downstream to the IPIs collecting for it and upstream from the IPIs it collects for
+type CollectionGraph struct {
+	Ipi        string                `json:"ipi"`
+	AsOfDate   string                `json:"asOfDate,omitempty"`
+	Upstream   []CollectionGraphEdge `json:"upstream"`
+	Downstream []CollectionGraphEdge `json:"downstream"`
+}
+
+//CollectionGraphEdge : struct definition of a right holder collecting for an IPI under a collection right
+type CollectionGraphEdge struct {
+	CollectionRightUUID string    `json:"collectionRightUUID"`
+	From                string    `json:"from"`
+	To                  string    `json:"to"`
+	Percent             float64   `json:"percent"`
+	Selector            string    `json:"selector"`
+	StartDate           string    `json:"startDate"`
+	EndDate             string    `json:"endDate"`
+	Territories         []string  `json:"territories,omitempty"`
+	Fee                 *FeeTerms `json:"fee,omitempty"`
+}
+
 //RoyaltyStatementCreationEventPayload payload to passed as part of the event.
 type RoyaltyStatementCreationEventPayload struct {
 	Type                 string `json:"type"`
@@ -125,9 +409,134 @@ type RoyaltyStatementCreationEventPayload struct {
 	IsDSP                bool   `json:"isDSP"`
 }
 
+//RoyaltyStatementCreationEvent : versioned envelope of the royalty statement creation event of a transaction
+type RoyaltyStatementCreationEvent struct {
+	Version string                                `json:"version"`
+	Targets []RoyaltyStatementCreationEventTarget `json:"targets"`
+}
+
+//RoyaltyStatementCreationEventTarget : royalty statements of a transaction sharing the same target org and IPI
+type RoyaltyStatementCreationEventTarget struct {
+	Type                  string   `json:"type"`
+	TargetOrg             string   `json:"targetOrg"`
+	TargetIPI             string   `json:"targetIPI"`
+	IsDSP                 bool     `json:"isDSP"`
+	RoyaltyStatementUUIDs []string `json:"royaltyStatementUUIDs"`
+}
+
+//ExploitationReportClassificationEvent : versioned envelope of the exploitation report classification event of a transaction
+type ExploitationReportClassificationEvent struct {
+	Version         string                             `json:"version"`
+	Classifications []ExploitationReportClassification `json:"classifications"`
+}
+
+//ExploitationReportClassification : exploitation reports of a transaction sharing the same state, ISRC and source
+type ExploitationReportClassification struct {
+	Type                    string   `json:"type"`
+	Isrc                    string   `json:"isrc"`
+	Source                  string   `json:"source"`
+	RightHolders            []string `json:"rightHolders"`
+	Orgs                    []string `json:"orgs"`
+	ExploitationReportUUIDs []string `json:"exploitationReportUUIDs"`
+}
+
 //IpiOrgMap : struct defining data model for IPI-Org mapping
 type IpiOrgMap struct {
-	DocType string `json:"docType"`
-	Ipi     string `json:"ipi"`
-	Org     string `json:"org"`
+	DocType   string `json:"docType"`
+	Ipi       string `json:"ipi"`
+	Org       string `json:"org"`
+	StartDate string `json:"startDate,omitempty"`
+	EndDate   string `json:"endDate,omitempty"`
+}
+
+//Dispute : struct defining data model for disputes raised against a Royalty Statement, Exploitation Report or Copyright Data Report
+type Dispute struct {
+	DocType        string             `json:"docType"`
+	DisputeUUID    string             `json:"disputeUUID"`
+	TargetUUID     string             `json:"targetUUID"`
+	TargetType     string             `json:"targetType"`
+	RaisedBy       string             `json:"raisedBy"`
+	ReasonCode     string             `json:"reasonCode"`
+	ClaimedAmount  float64            `json:"claimedAmount,omitempty"`
+	ClaimedSplit   []RightHolder      `json:"claimedSplit,omitempty"`
+	EvidenceHashes []string           `json:"evidenceHashes,omitempty"`
+	Comments       []DisputeComment   `json:"comments"`
+	State          string             `json:"state"`
+	CreatedDate    string             `json:"createdDate"`
+	Resolution     *DisputeResolution `json:"resolution,omitempty"`
+}
+
+//DisputeComment : struct definition for a comment in the dispute thread
+type DisputeComment struct {
+	Author      string `json:"author"`
+	Comment     string `json:"comment"`
+	CreatedDate string `json:"createdDate"`
+}
+
+//DisputeResolution : struct definition for the outcome of a dispute
+type DisputeResolution struct {
+	Outcome      string `json:"outcome"`
+	ResolvedBy   string `json:"resolvedBy"`
+	Comment      string `json:"comment"`
+	Recompute    bool   `json:"recompute"`
+	ResolvedDate string `json:"resolvedDate"`
+}
+
+//BalanceDelta : struct definition for a change to the balance between a party and a counterparty
+type BalanceDelta struct {
+	Payable    float64 `json:"payable"`
+	Receivable float64 `json:"receivable"`
+}
+
+//Balance : struct definition for the outstanding balance between a party and a counterparty in a currency
+type Balance struct {
+	Level        string  `json:"level"`
+	Party        string  `json:"party"`
+	Counterparty string  `json:"counterparty"`
+	Currency     string  `json:"currency"`
+	Payable      float64 `json:"payable"`
+	Receivable   float64 `json:"receivable"`
+	Net          float64 `json:"net"`
+}
+
+//BalanceQuery : struct definition for the getBalances filter
+type BalanceQuery struct {
+	Ipi          string `json:"ipi"`
+	Org          string `json:"org"`
+	Counterparty string `json:"counterparty"`
+	Currency     string `json:"currency"`
+}
+
+//PeriodStatement : struct defining data model for the roll-up of the royalty statements of a payee over a period
+type PeriodStatement struct {
+	DocType               string                  `json:"docType"`
+	PeriodStatementUUID   string                  `json:"periodStatementUUID"`
+	Ipi                   string                  `json:"ipi"`
+	PeriodStart           string                  `json:"periodStart"`
+	PeriodEnd             string                  `json:"periodEnd"`
+	ClosedDate            string                  `json:"closedDate"`
+	StatementCount        int                     `json:"statementCount"`
+	Totals                map[string]PeriodTotals `json:"totals"`
+	RoyaltyStatementUUIDs []string                `json:"royaltyStatementUUIDs"`
+	PrivateCollections    []string                `json:"privateCollections,omitempty"`
+}
+
+//PeriodPrivateTotals : struct definition for the totals of the private royalty statements of a period statement in one collection
+type PeriodPrivateTotals struct {
+	DocType             string                  `json:"docType"`
+	PeriodStatementUUID string                  `json:"periodStatementUUID"`
+	PrivateCollection   string                  `json:"privateCollection"`
+	StatementCount      int                     `json:"statementCount"`
+	Totals              map[string]PeriodTotals `json:"totals"`
+}
+
+//PeriodTotals : struct definition for the totals of a period statement in one currency
+type PeriodTotals struct {
+	Amount           float64            `json:"amount"`
+	OwnershipAmount  float64            `json:"ownershipAmount"`
+	CollectionAmount float64            `json:"collectionAmount"`
+	ByIsrc           map[string]float64 `json:"byIsrc"`
+	ByTerritory      map[string]float64 `json:"byTerritory"`
+	ByUsageType      map[string]float64 `json:"byUsageType"`
+	BySource         map[string]float64 `json:"bySource"`
 }
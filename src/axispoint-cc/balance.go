@@ -0,0 +1,424 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"axispoint-cc/royalty"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+/*
+* Balances are kept as delta records instead of a single balance record per party so that
+* concurrent transactions writing statements for the same party never read-modify-write the same key.
+* Every delta is a composite key BALANCE~level~party~counterparty~currency~royaltyStatementUUID~txID
+* and getBalances sums the deltas of a party on read. The deltas of a royalty statement between orgs
+* are written to the private data collection of the statement, so that only the orgs of the statement
+* can sum them.
+ */
+
+/*
+* getBalances function returns the outstanding payable and receivable balances of an IPI or an org,
+* per counterparty and per currency, from the public deltas and the deltas of the collections of the caller
+*
+* @params   {Array} args
+* @property {string} 0       - stringified JSON balance query, e.g. {"ipi":"..."} or {"org":"...","currency":"EUR"}
+* @return   {pb.Response}    - peer Response
+ */
+func getBalances(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "getBalances"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) != 1 {
+		return getErrorResponse("Missing arguments: Balance query object is required")
+	}
+
+	balanceQuery := BalanceQuery{}
+	err := jsonToObject([]byte(args[0]), &balanceQuery)
+	if err == nil {
+		err = normalizeBalanceQuery(&balanceQuery)
+	}
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	level, party := BALANCE_IPI, balanceQuery.Ipi
+	if party == "" {
+		level, party = BALANCE_ORG, balanceQuery.Org
+	}
+	if party == "" {
+		return getErrorResponse(fmt.Sprintf("%s - Balance query requires an 'ipi' or an 'org'.", methodName))
+	}
+
+	balances, err := sumBalanceDeltas(stub, level, party, balanceQuery.Counterparty, balanceQuery.Currency)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	objBytes, err := objectToJSON(balances)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	logger.Info("EXITING <", methodName, len(balances))
+	return shim.Success(objBytes)
+}
+
+//normalizeBalanceQuery - normalizes the IPIs of a balance query, the counterparty of an org is an org
+func normalizeBalanceQuery(balanceQuery *BalanceQuery) error {
+	var err error
+	balanceQuery.Ipi, err = normalizeIpi(balanceQuery.Ipi)
+	if err != nil || balanceQuery.Ipi == "" {
+		return err
+	}
+	balanceQuery.Counterparty, err = normalizeIpi(balanceQuery.Counterparty)
+	return err
+}
+
+/*
+* compactBalances function replaces the balance deltas of an IPI or an org with one delta per counterparty
+* and currency, in the public state and in the collections of the caller. It should be run when the party is not
+* receiving statements, as it reads every delta of the party. It returns the public balances, getBalances returns
+* the balances of the collections as well.
+*
+* @params   {Array} args
+* @property {string} 0       - stringified JSON balance query, e.g. {"ipi":"..."} or {"org":"..."}
+* @return   {pb.Response}    - peer Response
+ */
+func compactBalances(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "compactBalances"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) != 1 {
+		return getErrorResponse("Missing arguments: Balance query object is required")
+	}
+
+	balanceQuery := BalanceQuery{}
+	err := jsonToObject([]byte(args[0]), &balanceQuery)
+	if err == nil {
+		err = normalizeBalanceQuery(&balanceQuery)
+	}
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	level, party := BALANCE_IPI, balanceQuery.Ipi
+	if party == "" {
+		level, party = BALANCE_ORG, balanceQuery.Org
+	}
+	if party == "" {
+		return getErrorResponse(fmt.Sprintf("%s - Balance query requires an 'ipi' or an 'org'.", methodName))
+	}
+
+	collections, err := getCallerPrivateCollections(stub)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	// the deltas are compacted in the public state and in each collection of the caller
+	balances := []Balance{}
+	for _, collection := range append([]string{""}, collections...) {
+		collectionBalances, deltaKeys, err := sumCollectionBalanceDeltas(stub, collection, level, party, "", "")
+		if err != nil {
+			return getErrorResponse(err.Error())
+		}
+
+		// delete all the deltas of the party
+		for _, deltaKey := range deltaKeys {
+			err = delLedgerData(stub, collection, deltaKey)
+			if err != nil {
+				return getErrorResponse(err.Error())
+			}
+		}
+
+		// write the compacted deltas
+		for _, balance := range collectionBalances {
+			deltaKey, err := stub.CreateCompositeKey(BALANCE, []string{level, party, balance.Counterparty, balance.Currency, "", stub.GetTxID()})
+			if err != nil {
+				return getErrorResponse(err.Error())
+			}
+			deltaBytes, _ := objectToJSON(BalanceDelta{Payable: balance.Payable, Receivable: balance.Receivable})
+			err = putLedgerData(stub, collection, deltaKey, deltaBytes)
+			if err != nil {
+				return getErrorResponse(err.Error())
+			}
+		}
+
+		// the response is recorded on the ledger, it only returns the public balances
+		if collection == "" {
+			balances = collectionBalances
+		}
+	}
+
+	objBytes, _ := objectToJSON(balances)
+	logger.Info("EXITING <", methodName, len(balances))
+	return shim.Success(objBytes)
+}
+
+//sumBalanceDeltas - sums the public balance deltas of a party and the deltas of the collections of the caller per counterparty and currency
+func sumBalanceDeltas(stub shim.ChaincodeStubInterface, level string, party string, counterparty string, currency string) ([]Balance, error) {
+	collections, err := getCallerPrivateCollections(stub)
+	if err != nil {
+		return nil, err
+	}
+
+	balancesByKey := map[string]*Balance{}
+	keys := []string{}
+	for _, collection := range append([]string{""}, collections...) {
+		collectionBalances, _, err := sumCollectionBalanceDeltas(stub, collection, level, party, counterparty, currency)
+		if err != nil {
+			return nil, err
+		}
+		for _, collectionBalance := range collectionBalances {
+			key := collectionBalance.Counterparty + "~" + collectionBalance.Currency
+			balance, ok := balancesByKey[key]
+			if !ok {
+				balance = &Balance{Level: level, Party: party, Counterparty: collectionBalance.Counterparty, Currency: collectionBalance.Currency}
+				balancesByKey[key] = balance
+				keys = append(keys, key)
+			}
+			balance.Payable += collectionBalance.Payable
+			balance.Receivable += collectionBalance.Receivable
+		}
+	}
+
+	sort.Strings(keys)
+	balances := []Balance{}
+	for _, key := range keys {
+		balance := balancesByKey[key]
+		balance.Payable = toFixed(balance.Payable, 6)
+		balance.Receivable = toFixed(balance.Receivable, 6)
+		balance.Net = toFixed(balance.Receivable-balance.Payable, 6)
+		balances = append(balances, *balance)
+	}
+	return balances, nil
+}
+
+/*
+* sumCollectionBalanceDeltas function sums the balance deltas of a party per counterparty and currency in the
+* public state, for an empty collection, or in a private data collection
+*
+* @param    {string} - private data collection or an empty string for the public state
+* @return   {Array}  - balances sorted by counterparty and currency
+* @return   {Array}  - keys of the summed deltas
+* @return   {error}  - Error
+ */
+func sumCollectionBalanceDeltas(stub shim.ChaincodeStubInterface, collection string, level string, party string, counterparty string, currency string) ([]Balance, []string, error) {
+	attributes := []string{level, party}
+	if counterparty != "" {
+		attributes = append(attributes, counterparty)
+		if currency != "" {
+			attributes = append(attributes, currency)
+		}
+	}
+
+	deltaIterator, err := getLedgerDataByPartialCompositeKey(stub, collection, BALANCE, attributes)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer deltaIterator.Close()
+
+	balancesByKey := map[string]*Balance{}
+	keys := []string{}
+	deltaKeys := []string{}
+	for deltaIterator.HasNext() {
+		delta, err := deltaIterator.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		_, keyParts, err := stub.SplitCompositeKey(delta.Key)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(keyParts) < 4 || (currency != "" && keyParts[3] != currency) {
+			continue
+		}
+
+		balanceDelta := BalanceDelta{}
+		err = jsonToObject(delta.Value, &balanceDelta)
+		if err != nil {
+			return nil, nil, err
+		}
+		deltaKeys = append(deltaKeys, delta.Key)
+
+		key := keyParts[2] + "~" + keyParts[3]
+		balance, ok := balancesByKey[key]
+		if !ok {
+			balance = &Balance{Level: level, Party: party, Counterparty: keyParts[2], Currency: keyParts[3]}
+			balancesByKey[key] = balance
+			keys = append(keys, key)
+		}
+		balance.Payable += balanceDelta.Payable
+		balance.Receivable += balanceDelta.Receivable
+	}
+
+	sort.Strings(keys)
+	balances := []Balance{}
+	for _, key := range keys {
+		balance := balancesByKey[key]
+		balance.Payable = toFixed(balance.Payable, 6)
+		balance.Receivable = toFixed(balance.Receivable, 6)
+		balance.Net = toFixed(balance.Receivable-balance.Payable, 6)
+		balances = append(balances, *balance)
+	}
+	return balances, deltaKeys, nil
+}
+
+//balanceEntry : balance delta of a party towards a counterparty at IPI or org level
+type balanceEntry struct {
+	level        string
+	party        string
+	counterparty string
+	delta        BalanceDelta
+}
+
+//getRoyaltyStatementParties - returns who owes the amount of a royalty statement to whom
+func getRoyaltyStatementParties(royaltyStatement RoyaltyStatement) (string, string, float64) {
+	return royalty.Parties(royalty.Statement{
+		RightType:       royaltyStatement.RightType,
+		Source:          royaltyStatement.Source,
+		RightHolder:     royaltyStatement.RightHolder,
+		Administrator:   royaltyStatement.Administrator,
+		Collector:       royaltyStatement.Collector,
+		Amount:          royaltyStatement.Amount,
+		CollectionRight: royaltyStatement.CollectionRight,
+	})
+}
+
+//getOutstandingAmount - returns the amount of a royalty statement that is still to be paid, the tax withheld and the amount recouped for advances are not paid
+func getOutstandingAmount(royaltyStatement *RoyaltyStatement) float64 {
+	if royaltyStatement == nil || royaltyStatement.PaymentState == PAID {
+		return 0
+	}
+	_, _, amount := getRoyaltyStatementParties(*royaltyStatement)
+	return toFixed(amount-royaltyStatement.TaxWithheld-royaltyStatement.RecoupedAmount, 2)
+}
+
+/*
+* updateRoyaltyStatementBalances function records the balance deltas for a royalty statement that changed
+* from previous to current. A nil previous statement means the statement is new and a paid current statement
+* no longer counts towards the outstanding balances. The deltas of a statement between orgs are written to
+* its private data collection.
+*
+* @param    {RoyaltyStatement} - the statement as it was on the ledger, or nil
+* @param    {RoyaltyStatement} - the statement as it is written to the ledger
+* @return   {error}            - Error
+ */
+func updateRoyaltyStatementBalances(stub shim.ChaincodeStubInterface, previous *RoyaltyStatement, current *RoyaltyStatement) error {
+	deltas := map[string]BalanceDelta{}
+	deltaCollections := map[string]string{}
+	keys := []string{}
+
+	// the previous statement keeps the collection it was written to, the current one is written to the collection of its orgs
+	currentCollection, err := getRoyaltyStatementCollection(stub, *current)
+	if err != nil {
+		return err
+	}
+	previousCollection := ""
+	if previous != nil {
+		previousCollection = previous.PrivateCollection
+	}
+
+	for _, change := range []struct {
+		royaltyStatement *RoyaltyStatement
+		sign             float64
+		collection       string
+	}{{previous, -1, previousCollection}, {current, 1, currentCollection}} {
+		amount := getOutstandingAmount(change.royaltyStatement) * change.sign
+		if amount == 0 {
+			continue
+		}
+		payer, payee, _ := getRoyaltyStatementParties(*change.royaltyStatement)
+		if payer == "" || payee == "" {
+			continue
+		}
+		currency := change.royaltyStatement.Currency
+		if currency == "" {
+			currency = UNSPECIFIED_CURRENCY
+		}
+
+		// the orgs are the ones the parties belonged to on the exploitation date
+		payerOrg, err := getOrgForIpiOnDate(stub, payer, change.royaltyStatement.ExploitationDate)
+		if err != nil {
+			return err
+		}
+		payeeOrg, err := getOrgForIpiOnDate(stub, payee, change.royaltyStatement.ExploitationDate)
+		if err != nil {
+			return err
+		}
+
+		entries := []balanceEntry{
+			{BALANCE_IPI, payer, payee, BalanceDelta{Payable: amount}},
+			{BALANCE_IPI, payee, payer, BalanceDelta{Receivable: amount}},
+		}
+		if payerOrg != "" && payeeOrg != "" {
+			entries = append(entries,
+				balanceEntry{BALANCE_ORG, payerOrg, payeeOrg, BalanceDelta{Payable: amount}},
+				balanceEntry{BALANCE_ORG, payeeOrg, payerOrg, BalanceDelta{Receivable: amount}})
+		}
+
+		for _, entry := range entries {
+			deltaKey, err := stub.CreateCompositeKey(BALANCE, []string{entry.level, entry.party, entry.counterparty, currency, change.royaltyStatement.RoyaltyStatementUUID, stub.GetTxID()})
+			if err != nil {
+				return err
+			}
+			// the same key in two collections is two deltas
+			deltaID := change.collection + "~" + deltaKey
+			delta, ok := deltas[deltaID]
+			if !ok {
+				// the key is unique to the transaction, reading it does not cause MVCC conflicts
+				deltaBytes, err := getLedgerData(stub, change.collection, deltaKey)
+				if err != nil {
+					return err
+				}
+				if deltaBytes != nil {
+					err = jsonToObject(deltaBytes, &delta)
+					if err != nil {
+						return err
+					}
+				}
+				keys = append(keys, deltaID)
+				deltaCollections[deltaID] = change.collection
+			}
+			delta.Payable += entry.delta.Payable
+			delta.Receivable += entry.delta.Receivable
+			deltas[deltaID] = delta
+		}
+	}
+
+	for _, deltaID := range keys {
+		delta := deltas[deltaID]
+		collection := deltaCollections[deltaID]
+		deltaKey := deltaID[len(collection)+1:]
+		if delta.Payable == 0 && delta.Receivable == 0 {
+			err := delLedgerData(stub, collection, deltaKey)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		deltaBytes, err := objectToJSON(delta)
+		if err != nil {
+			return err
+		}
+		err = putLedgerData(stub, collection, deltaKey, deltaBytes)
+		if err != nil {
+			return errors.New("Failed to record balance for royalty statement " + current.RoyaltyStatementUUID + ": " + err.Error())
+		}
+	}
+	return nil
+}
+
+//getOrgForIpi - returns the org the IPI is mapped to on the transaction date or an empty string when the IPI is not mapped
+func getOrgForIpi(stub shim.ChaincodeStubInterface, ipi string) (string, error) {
+	txDate, err := getTxDate(stub)
+	if err != nil {
+		return "", err
+	}
+	ipiOrg, err := getIpiOrgInEffect(stub, ipi, txDate)
+	if err != nil || ipiOrg == nil {
+		return "", err
+	}
+	return ipiOrg.Org, nil
+}
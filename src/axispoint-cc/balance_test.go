@@ -0,0 +1,155 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"axispoint-cc/memstub"
+)
+
+var balanceRoyaltyStatements_in = `[{"royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":200,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":"MISSING_AFFILIATE","currency":"EUR"},{"royaltyStatementUUID":"5bbbda3a-6335-4248-9d10-019a73f59dfc","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":300,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"ACME-Music-Corp-IPI","collector":"","state":"MISSING_AFFILIATE","currency":"EUR"},{"royaltyStatementUUID":"7f384cbf-0d0d-3698-9714-841b8ecb73f9","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":500,"rightType":"COLLECTION","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":"","collectionRight":50,"collectionRightPercent":0.1,"currency":"EUR"}]`
+var balanceRoyaltyStatementUpdate_in = `[{"royaltyStatementUUID":"5bbbda3a-6335-4248-9d10-019a73f59dfc","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":250,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"ACME-Music-Corp-IPI","collector":"","state":"MISSING_AFFILIATE","currency":"EUR"}]`
+
+func MockGetBalanceResponse(functionName string) []byte {
+	switch functionName {
+	case "Test_GetBalances_Ipi":
+		return []byte(`[{"level":"IPI","party":"Ned-IPI","counterparty":"Swedish-Publishing-IPI","currency":"EUR","payable":50,"receivable":0,"net":-50},{"level":"IPI","party":"Ned-IPI","counterparty":"spotify-IPI","currency":"EUR","payable":0,"receivable":500,"net":500}]`)
+	case "Test_GetBalances_Org":
+		return []byte(`[{"level":"ORG","party":"Org1MSP","counterparty":"Org2MSP","currency":"EUR","payable":500,"receivable":0,"net":-500}]`)
+	case "Test_GetBalances_Paid":
+		return []byte(`[{"level":"IPI","party":"Ned-IPI","counterparty":"spotify-IPI","currency":"EUR","payable":0,"receivable":250,"net":250}]`)
+	case "Test_GetBalances_Compacted":
+		return []byte(`[{"level":"IPI","party":"Ned-IPI","counterparty":"Swedish-Publishing-IPI","currency":"EUR","payable":50,"receivable":0,"net":-50},{"level":"IPI","party":"Ned-IPI","counterparty":"spotify-IPI","currency":"EUR","payable":0,"receivable":250,"net":250}]`)
+	default:
+		return []byte("[]")
+	}
+}
+
+func addBalanceRoyaltyStatements(t *testing.T) *memstub.Stub {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+
+	// Init
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("updateIpiOrg"), []byte(`{"ipi":"spotify-IPI","org":"Org1MSP"}`), []byte(`{"ipi":"Ned-IPI","org":"Org2MSP"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// the statements of spotify to Ned are private to their orgs
+	_, err = checkInvokeTransient(t, stub, [][]byte{[]byte("addRoyaltyStatements")}, getRoyaltyStatementsTransient(balanceRoyaltyStatements_in))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	return stub
+}
+
+func Test_GetBalances_Ipi(t *testing.T) {
+	stub := addBalanceRoyaltyStatements(t)
+
+	getCallerMspID = MockGetCallerOrg2MSP
+	defer func() { getCallerMspID = getCreatorMspID }()
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getBalances"), []byte(`{"ipi":"Ned-IPI"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected := MockGetBalanceResponse("Test_GetBalances_Ipi")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_GetBalances_Org(t *testing.T) {
+	stub := addBalanceRoyaltyStatements(t)
+
+	getCallerMspID = MockGetCallerOrg2MSP
+	defer func() { getCallerMspID = getCreatorMspID }()
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getBalances"), []byte(`{"org":"Org1MSP","currency":"EUR"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected := MockGetBalanceResponse("Test_GetBalances_Org")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_GetBalances_UpdatedAndPaid(t *testing.T) {
+	stub := addBalanceRoyaltyStatements(t)
+
+	getCallerMspID = MockGetCallerOrg2MSP
+	defer func() { getCallerMspID = getCreatorMspID }()
+
+	_, err := checkInvokeTransient(t, stub, [][]byte{[]byte("updateRoyaltyStatements")}, map[string][]byte{TRANSIENT_ROYALTY_STATEMENTS: []byte(balanceRoyaltyStatementUpdate_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	_, err = checkInvoke(t, stub, [][]byte{[]byte("payRoyaltyStatements"), []byte("0daccfc9-9e3a-43f1-8e60-d0d0916a82e3")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getBalances"), []byte(`{"ipi":"Ned-IPI","counterparty":"spotify-IPI"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected := MockGetBalanceResponse("Test_GetBalances_Paid")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+
+	_, err = checkInvoke(t, stub, [][]byte{[]byte("compactBalances"), []byte(`{"ipi":"Ned-IPI"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("getBalances"), []byte(`{"ipi":"Ned-IPI"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected = MockGetBalanceResponse("Test_GetBalances_Compacted")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_GetBalances_NonMember(t *testing.T) {
+	stub := addBalanceRoyaltyStatements(t)
+
+	getCallerMspID = MockGetCallerOrg3MSP
+	defer func() { getCallerMspID = getCreatorMspID }()
+
+	// an org outside of the statements between spotify and Ned does not sum their deltas
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getBalances"), []byte(`{"ipi":"Ned-IPI","counterparty":"spotify-IPI"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if string(actual) != "[]" {
+		t.Fatalf("Expected no balance for a non member org, got %s", actual)
+	}
+
+	// nor finds them in the public state
+	balancePrefix, _ := stub.CreateCompositeKey(BALANCE, []string{})
+	for key := range stub.State {
+		if !strings.HasPrefix(key, balancePrefix) {
+			continue
+		}
+		for _, royaltyStatementUUID := range []string{"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3", "5bbbda3a-6335-4248-9d10-019a73f59dfc"} {
+			if strings.Contains(key, royaltyStatementUUID) {
+				t.Fatalf("Balance delta of private royalty statement '%s' is public: %q", royaltyStatementUUID, key)
+			}
+		}
+		if strings.Contains(key, "Org1MSP") || strings.Contains(key, "spotify-IPI") {
+			t.Fatalf("Balance delta between spotify and Ned is public: %q", key)
+		}
+	}
+}
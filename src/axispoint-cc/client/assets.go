@@ -0,0 +1,537 @@
+package client
+
+import (
+	"axispoint-cc/royalty"
+)
+
+// The assets of the ledger and the outputs of the chaincode functions, as the chaincode marshals them
+
+// ExploitationReport - a sale or usage of a recording reported by a DSP
+type ExploitationReport struct {
+	DocType                string  `json:"docType"`
+	Source                 string  `json:"source"`
+	SongTitle              string  `json:"songTitle"`
+	WriterName             string  `json:"writerName"`
+	Isrc                   string  `json:"isrc"`
+	Units                  int     `json:"units"`
+	ExploitationDate       string  `json:"exploitationDate"`
+	Amount                 float64 `json:"amount"`
+	UsageType              string  `json:"usageType"`
+	ExploitationReportUUID string  `json:"exploitationReportUUID"`
+	Territory              string  `json:"territory"`
+	State                  string  `json:"state"`
+	Currency               string  `json:"currency,omitempty"`
+}
+
+// RoyaltyStatement - the amount a payer owes a payee for an exploitation report
+type RoyaltyStatement struct {
+	DocType                 string   `json:"docType"`
+	RoyaltyStatementUUID    string   `json:"royaltyStatementUUID"`
+	ExploitationReportUUID  string   `json:"exploitationReportUUID"`
+	Source                  string   `json:"source"`
+	Isrc                    string   `json:"isrc"`
+	SongTitle               string   `json:"songTitle"`
+	WriterName              string   `json:"writerName"`
+	Units                   int      `json:"units"`
+	ExploitationDate        string   `json:"exploitationDate"`
+	Amount                  float64  `json:"amount"`
+	RightType               string   `json:"rightType"`
+	Territory               string   `json:"territory"`
+	UsageType               string   `json:"usageType"`
+	RightHolder             string   `json:"rightHolder"`
+	Administrator           string   `json:"administrator"`
+	Collector               string   `json:"collector"`
+	State                   string   `json:"state"`
+	CollectionRight         float64  `json:"collectionRight,omitempty"`
+	CollectionRightPercent  float64  `json:"collectionRightPercent,omitempty"`
+	CopyrightDataReportUUID string   `json:"copyrightDataReportUUID,omitempty"`
+	PaymentState            string   `json:"paymentState,omitempty"`
+	PaymentDate             string   `json:"paymentDate,omitempty"`
+	Currency                string   `json:"currency,omitempty"`
+	PeriodStatementUUID     string   `json:"periodStatementUUID,omitempty"`
+	Iswc                    string   `json:"iswc,omitempty"`
+	PrivateCollection       string   `json:"privateCollection,omitempty"`
+	PrivateOrgs             []string `json:"privateOrgs,omitempty"`
+	PrivateDataHash         string   `json:"privateDataHash,omitempty"`
+	CollectionRightUUID     string   `json:"collectionRightUUID,omitempty"`
+	GrossAmount             float64  `json:"grossAmount,omitempty"`
+	FeeAmount               float64  `json:"feeAmount,omitempty"`
+	NetAmount               float64  `json:"netAmount,omitempty"`
+	ParentStatementUUID     string   `json:"parentStatementUUID,omitempty"`
+	Lineage                 []string `json:"lineage,omitempty"`
+	RightCategory           string   `json:"rightCategory,omitempty"`
+	RightHolderRole         string   `json:"rightHolderRole,omitempty"`
+	RecoupedAmount          float64  `json:"recoupedAmount,omitempty"`
+	UnrecoupedBalance       float64  `json:"unrecoupedBalance,omitempty"`
+	AdvanceUUIDs            []string `json:"advanceUUIDs,omitempty"`
+	TaxRuleUUID             string   `json:"taxRuleUUID,omitempty"`
+	TaxRate                 float64  `json:"taxRate,omitempty"`
+	TaxableAmount           float64  `json:"taxableAmount,omitempty"`
+	TaxWithheld             float64  `json:"taxWithheld,omitempty"`
+	NetPayable              float64  `json:"netPayable,omitempty"`
+}
+
+// Parties returns the payer, the payee and the amount of a royalty statement as the chaincode books them
+func (royaltyStatement RoyaltyStatement) Parties() (string, string, float64) {
+	return royalty.Parties(royalty.Statement{
+		RightType:       royaltyStatement.RightType,
+		Source:          royaltyStatement.Source,
+		RightHolder:     royaltyStatement.RightHolder,
+		Administrator:   royaltyStatement.Administrator,
+		Collector:       royaltyStatement.Collector,
+		Amount:          royaltyStatement.Amount,
+		CollectionRight: royaltyStatement.CollectionRight,
+	})
+}
+
+// RoyaltyStatementPrivateDetails - amounts of a royalty statement kept in the private data collection of its orgs
+type RoyaltyStatementPrivateDetails struct {
+	DocType                string  `json:"docType"`
+	RoyaltyStatementUUID   string  `json:"royaltyStatementUUID"`
+	Amount                 float64 `json:"amount"`
+	CollectionRight        float64 `json:"collectionRight"`
+	CollectionRightPercent float64 `json:"collectionRightPercent"`
+	GrossAmount            float64 `json:"grossAmount,omitempty"`
+	FeeAmount              float64 `json:"feeAmount,omitempty"`
+	NetAmount              float64 `json:"netAmount,omitempty"`
+	RecoupedAmount         float64 `json:"recoupedAmount,omitempty"`
+	UnrecoupedBalance      float64 `json:"unrecoupedBalance,omitempty"`
+	TaxableAmount          float64 `json:"taxableAmount,omitempty"`
+	TaxWithheld            float64 `json:"taxWithheld,omitempty"`
+	NetPayable             float64 `json:"netPayable,omitempty"`
+	Salt                   string  `json:"salt"`
+}
+
+// PrivateDataVerification - result of checking private details against the hash of their royalty statement
+type PrivateDataVerification struct {
+	RoyaltyStatementUUID string `json:"royaltyStatementUUID"`
+	PrivateCollection    string `json:"privateCollection"`
+	PrivateDataHash      string `json:"privateDataHash"`
+	ComputedHash         string `json:"computedHash"`
+	Verified             bool   `json:"verified"`
+}
+
+// CopyrightDataReport - the split of the rights of a recording between its right holders
+type CopyrightDataReport struct {
+	DocType             string        `json:"docType"`
+	CopyrightDataUUID   string        `json:"copyrightDataReportUUID"`
+	Isrc                string        `json:"isrc"`
+	SongTitle           string        `json:"songTitle"`
+	StartDate           string        `json:"startDate"`
+	EndDate             string        `json:"endDate"`
+	Territories         []string      `json:"territories,omitempty"`
+	ExcludedTerritories []string      `json:"excludedTerritories,omitempty"`
+	RightHolders        []RightHolder `json:"rightHolders"`
+}
+
+// RightHolder - a right holder of a copyright data report, musical work or collection right
+type RightHolder struct {
+	Selector string             `json:"selector"`
+	IPI      string             `json:"ipi"`
+	Percent  float64            `json:"percent"`
+	Shares   map[string]float64 `json:"shares,omitempty"`
+	Role     string             `json:"role,omitempty"`
+}
+
+// Advance - an advance paid by a payer to a payee, recouped from the royalties the payer owes the payee
+type Advance struct {
+	DocType        string   `json:"docType"`
+	AdvanceUUID    string   `json:"advanceUUID"`
+	Payee          string   `json:"payee"`
+	Payer          string   `json:"payer"`
+	Amount         float64  `json:"amount"`
+	RecoupmentRate float64  `json:"recoupmentRate"`
+	IncomeTypes    []string `json:"incomeTypes,omitempty"`
+	Currency       string   `json:"currency,omitempty"`
+	AdvanceDate    string   `json:"advanceDate,omitempty"`
+	Recouped       float64  `json:"recouped"`
+	Balance        float64  `json:"balance"`
+}
+
+// TaxRule - the withholding tax of a source territory for payees resident in a country, or for all foreign payees without residency
+type TaxRule struct {
+	DocType           string   `json:"docType"`
+	TaxRuleUUID       string   `json:"taxRuleUUID"`
+	SourceTerritory   string   `json:"sourceTerritory"`
+	Residency         string   `json:"residency,omitempty"`
+	Rate              float64  `json:"rate"`
+	TreatyRate        *float64 `json:"treatyRate,omitempty"`
+	ExemptIncomeTypes []string `json:"exemptIncomeTypes,omitempty"`
+	StartDate         string   `json:"startDate,omitempty"`
+	EndDate           string   `json:"endDate,omitempty"`
+}
+
+// TaxResidency - the tax residency of a payee
+type TaxResidency struct {
+	DocType       string `json:"docType"`
+	Ipi           string `json:"ipi"`
+	Country       string `json:"country"`
+	TreatyClaimed bool   `json:"treatyClaimed"`
+	Exempt        bool   `json:"exempt"`
+}
+
+// UsageTypeCategory - the right category an exploitation usage type falls under
+type UsageTypeCategory struct {
+	DocType       string `json:"docType"`
+	UsageType     string `json:"usageType"`
+	RightCategory string `json:"rightCategory"`
+}
+
+// TerritoryGroup - a named group of territories, Countries is only set when the group is read
+type TerritoryGroup struct {
+	DocType   string   `json:"docType"`
+	Code      string   `json:"code"`
+	Name      string   `json:"name"`
+	Includes  []string `json:"includes"`
+	Excludes  []string `json:"excludes,omitempty"`
+	Countries []string `json:"countries,omitempty"`
+}
+
+// MusicalWork - the musical work underlying recordings, its split applies to all its recordings
+type MusicalWork struct {
+	DocType      string        `json:"docType"`
+	Iswc         string        `json:"iswc"`
+	Title        string        `json:"title"`
+	StartDate    string        `json:"startDate,omitempty"`
+	EndDate      string        `json:"endDate,omitempty"`
+	RightHolders []RightHolder `json:"rightHolders"`
+	Isrcs        []string      `json:"isrcs"`
+}
+
+// CollectionRight - the right holders collecting for an IPI
+type CollectionRight struct {
+	DocType             string        `json:"docType"`
+	CollectionRightUUID string        `json:"collectionRightUUID"`
+	From                string        `json:"from"`
+	FromName            string        `json:"fromName"`
+	StartDate           string        `json:"startDate"`
+	EndDate             string        `json:"endDate"`
+	Territories         []string      `json:"territories,omitempty"`
+	ExcludedTerritories []string      `json:"excludedTerritories,omitempty"`
+	Priority            int           `json:"priority,omitempty"`
+	Fee                 *FeeTerms     `json:"fee,omitempty"`
+	RightHolders        []RightHolder `json:"rightHolders"`
+}
+
+// FeeTerms - the fee a collector keeps from what it collects under a collection right.
+// A PERCENT fee keeps percent of the gross, a FLAT fee keeps amount and a CAPPED fee keeps percent of the gross up to amount.
+type FeeTerms struct {
+	Type    string  `json:"type"`
+	Percent float64 `json:"percent,omitempty"`
+	Amount  float64 `json:"amount,omitempty"`
+}
+
+// CollectionChain - royalty statements of every hop collecting the amount of a royalty statement
+type CollectionChain struct {
+	RoyaltyStatementUUID string             `json:"royaltyStatementUUID"`
+	Persisted            bool               `json:"persisted"`
+	RoyaltyStatements    []RoyaltyStatement `json:"royaltyStatements"`
+}
+
+// CollectionGraph - collection rights around an IPI, downstream to the IPIs collecting for it and upstream from the IPIs it collects for
+type CollectionGraph struct {
+	Ipi        string                `json:"ipi"`
+	AsOfDate   string                `json:"asOfDate,omitempty"`
+	Upstream   []CollectionGraphEdge `json:"upstream"`
+	Downstream []CollectionGraphEdge `json:"downstream"`
+}
+
+// CollectionGraphEdge - a right holder collecting for an IPI under a collection right
+type CollectionGraphEdge struct {
+	CollectionRightUUID string    `json:"collectionRightUUID"`
+	From                string    `json:"from"`
+	To                  string    `json:"to"`
+	Percent             float64   `json:"percent"`
+	Selector            string    `json:"selector"`
+	StartDate           string    `json:"startDate"`
+	EndDate             string    `json:"endDate"`
+	Territories         []string  `json:"territories,omitempty"`
+	Fee                 *FeeTerms `json:"fee,omitempty"`
+}
+
+// IpiOrgMap - the org an IPI is mapped to
+type IpiOrgMap struct {
+	DocType   string `json:"docType"`
+	Ipi       string `json:"ipi"`
+	Org       string `json:"org"`
+	StartDate string `json:"startDate,omitempty"`
+	EndDate   string `json:"endDate,omitempty"`
+}
+
+// Dispute - a dispute raised against a Royalty Statement, Exploitation Report or Copyright Data Report
+type Dispute struct {
+	DocType        string             `json:"docType"`
+	DisputeUUID    string             `json:"disputeUUID"`
+	TargetUUID     string             `json:"targetUUID"`
+	TargetType     string             `json:"targetType"`
+	RaisedBy       string             `json:"raisedBy"`
+	ReasonCode     string             `json:"reasonCode"`
+	ClaimedAmount  float64            `json:"claimedAmount,omitempty"`
+	ClaimedSplit   []RightHolder      `json:"claimedSplit,omitempty"`
+	EvidenceHashes []string           `json:"evidenceHashes,omitempty"`
+	Comments       []DisputeComment   `json:"comments"`
+	State          string             `json:"state"`
+	CreatedDate    string             `json:"createdDate"`
+	Resolution     *DisputeResolution `json:"resolution,omitempty"`
+}
+
+// DisputeComment - a comment in the dispute thread
+type DisputeComment struct {
+	Author      string `json:"author"`
+	Comment     string `json:"comment"`
+	CreatedDate string `json:"createdDate"`
+}
+
+// DisputeResolution - the outcome of a dispute
+type DisputeResolution struct {
+	Outcome      string `json:"outcome"`
+	ResolvedBy   string `json:"resolvedBy"`
+	Comment      string `json:"comment"`
+	Recompute    bool   `json:"recompute"`
+	ResolvedDate string `json:"resolvedDate"`
+}
+
+// Balance - the outstanding balance between a party and a counterparty in a currency
+type Balance struct {
+	Level        string  `json:"level"`
+	Party        string  `json:"party"`
+	Counterparty string  `json:"counterparty"`
+	Currency     string  `json:"currency"`
+	Payable      float64 `json:"payable"`
+	Receivable   float64 `json:"receivable"`
+	Net          float64 `json:"net"`
+}
+
+// BalanceQuery - the filter of getBalances and compactBalances
+type BalanceQuery struct {
+	Ipi          string `json:"ipi"`
+	Org          string `json:"org"`
+	Counterparty string `json:"counterparty"`
+	Currency     string `json:"currency"`
+}
+
+// PeriodStatement - the roll-up of the royalty statements of a payee over a period
+type PeriodStatement struct {
+	DocType               string                  `json:"docType"`
+	PeriodStatementUUID   string                  `json:"periodStatementUUID"`
+	Ipi                   string                  `json:"ipi"`
+	PeriodStart           string                  `json:"periodStart"`
+	PeriodEnd             string                  `json:"periodEnd"`
+	ClosedDate            string                  `json:"closedDate"`
+	StatementCount        int                     `json:"statementCount"`
+	Totals                map[string]PeriodTotals `json:"totals"`
+	RoyaltyStatementUUIDs []string                `json:"royaltyStatementUUIDs"`
+	PrivateCollections    []string                `json:"privateCollections,omitempty"`
+}
+
+// PeriodTotals - the totals of a period statement in one currency
+type PeriodTotals struct {
+	Amount           float64            `json:"amount"`
+	OwnershipAmount  float64            `json:"ownershipAmount"`
+	CollectionAmount float64            `json:"collectionAmount"`
+	ByIsrc           map[string]float64 `json:"byIsrc"`
+	ByTerritory      map[string]float64 `json:"byTerritory"`
+	ByUsageType      map[string]float64 `json:"byUsageType"`
+	BySource         map[string]float64 `json:"bySource"`
+}
+
+// EarningsReportQuery - the query of getEarningsReport
+type EarningsReportQuery struct {
+	Ipi       string   `json:"ipi"`
+	StartDate string   `json:"startDate"`
+	EndDate   string   `json:"endDate"`
+	GroupBy   []string `json:"groupBy"`
+	PageSize  int      `json:"pageSize"`
+	Bookmark  string   `json:"bookmark"`
+}
+
+// EarningsGroup - the earnings of an IPI for a combination of dimension values in a currency
+type EarningsGroup struct {
+	Dimensions map[string]string `json:"dimensions"`
+	Currency   string            `json:"currency"`
+	Amount     float64           `json:"amount"`
+	Units      int               `json:"units"`
+	Count      int               `json:"count"`
+}
+
+// EarningsReport - a page of the earnings of an IPI grouped by dimensions
+type EarningsReport struct {
+	Ipi         string          `json:"ipi"`
+	StartDate   string          `json:"startDate"`
+	EndDate     string          `json:"endDate"`
+	GroupBy     []string        `json:"groupBy"`
+	TotalGroups int             `json:"totalGroups"`
+	Groups      []EarningsGroup `json:"groups"`
+	Bookmark    string          `json:"bookmark"`
+}
+
+// ExploitationReportResponse - the outcome of writing an exploitation report
+type ExploitationReportResponse struct {
+	ExploitationReportUUID string `json:"exploitationReportUUID"`
+	Message                string `json:"message"`
+	Success                bool   `json:"success"`
+}
+
+// GenerateExploitationReportsOutput - the output of generateExploitationReports, the royalty statements generated for
+// the exploitation reports and the failed exploitation reports
+type GenerateExploitationReportsOutput struct {
+	SuccessCount                int                          `json:"successCount"`
+	FailureCount                int                          `json:"failureCount"`
+	ExploitationReportResponses []ExploitationReportResponse `json:"exploitationReportResponses"`
+	RoyaltyStatements           []RoyaltyStatement           `json:"royaltyStatements"`
+	ExploitationReports         []ExploitationReport         `json:"exploitationReports"`
+}
+
+// ExploitationReportOutput - the output of updateExploitationReports and insertExploitationReports
+type ExploitationReportOutput struct {
+	SuccessCount        int                          `json:"successCount"`
+	FailureCount        int                          `json:"failureCount"`
+	ExploitationReports []ExploitationReportResponse `json:"exploitationReports"`
+}
+
+// RoyaltyStatementResponse - the outcome of writing or paying a royalty statement
+type RoyaltyStatementResponse struct {
+	RoyaltyStatementUUID string `json:"royaltyStatementUUID"`
+	Message              string `json:"message"`
+	Success              bool   `json:"success"`
+}
+
+// RoyaltyStatementOutput - the output of the functions writing or paying royalty statements
+type RoyaltyStatementOutput struct {
+	SuccessCount      int                        `json:"successCount"`
+	FailureCount      int                        `json:"failureCount"`
+	RoyaltyStatements []RoyaltyStatementResponse `json:"royaltyStatements"`
+}
+
+// CopyrightDataReportResponse - the outcome of writing a copyright data report
+type CopyrightDataReportResponse struct {
+	CopyrightDataReportUUID string `json:"copyrightDataReportUUID"`
+	Message                 string `json:"message"`
+	Success                 bool   `json:"success"`
+}
+
+// CopyrightDataReportOutput - the output of addCopyrightDataReports and updateCopyrightDataReports
+type CopyrightDataReportOutput struct {
+	SuccessCount         int                           `json:"successCount"`
+	FailureCount         int                           `json:"failureCount"`
+	CopyrightDataReports []CopyrightDataReportResponse `json:"copyrightDataReports"`
+}
+
+// CollectionRightsResponse - the outcome of writing a collection right
+type CollectionRightsResponse struct {
+	CollectionRightUUID string `json:"collectionRightUUID"`
+	Message             string `json:"message"`
+	Success             bool   `json:"success"`
+}
+
+// CollectionRightsOutput - the output of addCollectionRights and updateCollectionRights
+type CollectionRightsOutput struct {
+	SuccessCount              int                        `json:"successCount"`
+	FailureCount              int                        `json:"failureCount"`
+	CollectionRightsResponses []CollectionRightsResponse `json:"collectionRightsResponses"`
+}
+
+// DisputeResponse - the outcome of raising a dispute
+type DisputeResponse struct {
+	DisputeUUID string `json:"disputeUUID"`
+	Message     string `json:"message"`
+	Success     bool   `json:"success"`
+}
+
+// DisputeOutput - the output of addDisputes
+type DisputeOutput struct {
+	SuccessCount     int               `json:"successCount"`
+	FailureCount     int               `json:"failureCount"`
+	DisputeResponses []DisputeResponse `json:"disputeResponses"`
+}
+
+// DisputeResolutionOutput - the output of resolveDispute, the statements and reports recomputed by the resolution
+type DisputeResolutionOutput struct {
+	Dispute             Dispute              `json:"dispute"`
+	RoyaltyStatements   []RoyaltyStatement   `json:"royaltyStatements"`
+	ExploitationReports []ExploitationReport `json:"exploitationReports"`
+}
+
+// TerritoryGroupResponse - the outcome of writing a territory group
+type TerritoryGroupResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Success bool   `json:"success"`
+}
+
+// TerritoryGroupOutput - the output of addTerritoryGroups and updateTerritoryGroups
+type TerritoryGroupOutput struct {
+	SuccessCount            int                      `json:"successCount"`
+	FailureCount            int                      `json:"failureCount"`
+	TerritoryGroupResponses []TerritoryGroupResponse `json:"territoryGroupResponses"`
+}
+
+// UsageTypeCategoryResponse - the outcome of writing a usage type category
+type UsageTypeCategoryResponse struct {
+	UsageType string `json:"usageType"`
+	Message   string `json:"message"`
+	Success   bool   `json:"success"`
+}
+
+// UsageTypeCategoryOutput - the output of addUsageTypeCategories and updateUsageTypeCategories
+type UsageTypeCategoryOutput struct {
+	SuccessCount               int                         `json:"successCount"`
+	FailureCount               int                         `json:"failureCount"`
+	UsageTypeCategoryResponses []UsageTypeCategoryResponse `json:"usageTypeCategoryResponses"`
+}
+
+// AdvanceResponse - the outcome of writing an advance
+type AdvanceResponse struct {
+	AdvanceUUID string `json:"advanceUUID"`
+	Message     string `json:"message"`
+	Success     bool   `json:"success"`
+}
+
+// AdvanceOutput - the output of addAdvances and updateAdvances
+type AdvanceOutput struct {
+	SuccessCount     int               `json:"successCount"`
+	FailureCount     int               `json:"failureCount"`
+	AdvanceResponses []AdvanceResponse `json:"advanceResponses"`
+}
+
+// TaxRuleResponse - the outcome of writing a tax rule
+type TaxRuleResponse struct {
+	TaxRuleUUID string `json:"taxRuleUUID"`
+	Message     string `json:"message"`
+	Success     bool   `json:"success"`
+}
+
+// TaxRuleOutput - the output of addTaxRules and updateTaxRules
+type TaxRuleOutput struct {
+	SuccessCount     int               `json:"successCount"`
+	FailureCount     int               `json:"failureCount"`
+	TaxRuleResponses []TaxRuleResponse `json:"taxRuleResponses"`
+}
+
+// TaxResidencyResponse - the outcome of writing a tax residency
+type TaxResidencyResponse struct {
+	Ipi     string `json:"ipi"`
+	Message string `json:"message"`
+	Success bool   `json:"success"`
+}
+
+// TaxResidencyOutput - the output of addTaxResidencies and updateTaxResidencies
+type TaxResidencyOutput struct {
+	SuccessCount          int                    `json:"successCount"`
+	FailureCount          int                    `json:"failureCount"`
+	TaxResidencyResponses []TaxResidencyResponse `json:"taxResidencyResponses"`
+}
+
+// MusicalWorkResponse - the outcome of writing a musical work
+type MusicalWorkResponse struct {
+	Iswc    string `json:"iswc"`
+	Message string `json:"message"`
+	Success bool   `json:"success"`
+}
+
+// MusicalWorkOutput - the output of addMusicalWorks and updateMusicalWorks
+type MusicalWorkOutput struct {
+	SuccessCount         int                   `json:"successCount"`
+	FailureCount         int                   `json:"failureCount"`
+	MusicalWorkResponses []MusicalWorkResponse `json:"musicalWorkResponses"`
+}
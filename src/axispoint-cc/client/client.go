@@ -0,0 +1,245 @@
+/*
+Package client calls the functions of the axispoint chaincode with typed requests and responses.
+
+Each function of the chaincode has a method of the same name on Client, taking the assets or the parameters of
+the function instead of its string arguments and returning its output decoded. The functions writing to the
+ledger are submitted, the others evaluated. The chaincode reports its errors either as a peer error or as a
+status envelope {"status":"500","message":"..."} in the payload of a successful response; both are returned as
+an *Error.
+
+The transport carries the invocations: the gateway package wraps the contract of a fabric-sdk-go gateway
+connection, the clienttest package runs the chaincode in process on a MockStub answering rich queries for tests.
+
+	axispoint := client.New(gateway.NewTransport(network.GetContract("axispoint-cc")))
+	copyrightDataReports, err := axispoint.SearchForCopyrightDataReportWithParameters(client.CopyrightDataReportSearch{Isrc: "USRC17607839"})
+*/
+package client
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"axispoint-cc/royalty"
+)
+
+// The doc types of the assets of the ledger, the selector of a query of an asset type holds its doc type
+const (
+	ExploitationReportDocType  = "EXPLOITATIONREPORT"
+	CopyrightDataReportDocType = "COPYRIGHTDATAREPORT"
+	RoyaltyStatementDocType    = "ROYALTYSTATEMENT"
+	CollectionRightDocType     = "COLLECTIONRIGHTREPORT"
+	IpiOrgMapDocType           = "IPIORGMAP"
+	DisputeDocType             = "DISPUTE"
+	PeriodStatementDocType     = "PERIODSTATEMENT"
+	MusicalWorkDocType         = "MUSICALWORK"
+)
+
+// Keys of the transient map of the functions recording royalty statements, which pass the statements between
+// orgs with a random salt of their private details
+const (
+	TransientRoyaltyStatements = "royaltyStatements"
+	TransientSalt              = "salt"
+)
+
+// Right types of royalty statements, the collection type of GenerateCollectionStatement
+const (
+	Ownership  = royalty.Ownership
+	Collection = royalty.Collection
+	Fee        = royalty.Fee
+)
+
+// Client - the functions of the chaincode over a transport
+type Client struct {
+	transport Transport
+}
+
+// New returns the client of the chaincode reached through a transport
+func New(transport Transport) *Client {
+	return &Client{transport: transport}
+}
+
+// Error - an error returned by a chaincode function
+type Error struct {
+	Function string
+	Status   int
+	Message  string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("%s: %s", err.Function, err.Message)
+}
+
+// Response - the status envelope of the chaincode
+type Response struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// Query - a CouchDB rich query of the assets of a type. The doc type of the assets is added to its selector
+// when the selector has none.
+type Query struct {
+	Selector map[string]interface{} `json:"selector"`
+	Sort     []map[string]string    `json:"sort,omitempty"`
+	Limit    int                    `json:"limit,omitempty"`
+	Skip     int                    `json:"skip,omitempty"`
+}
+
+// Ping checks that the chaincode answers
+func (client *Client) Ping() (string, error) {
+	message := ""
+	return message, client.evaluate("ping", nil, &message)
+}
+
+// ResetLedger deletes all the assets of the ledger, it returns the message of the chaincode with the number of
+// deleted records
+func (client *Client) ResetLedger() (string, error) {
+	message := ""
+	return message, client.submit("resetLedger", nil, &message)
+}
+
+// DeleteAsset deletes all the assets of doc types
+func (client *Client) DeleteAsset(docTypes ...string) (string, error) {
+	message := ""
+	return message, client.submit("deleteAsset", docTypes, &message)
+}
+
+// DeleteAssetByUUID deletes assets by their keys
+func (client *Client) DeleteAssetByUUID(uuids ...string) (string, error) {
+	message := ""
+	return message, client.submit("deleteAssetByUUID", uuids, &message)
+}
+
+// GetAssetByUUID returns the asset of a key as the ledger holds it
+func (client *Client) GetAssetByUUID(uuid string) (json.RawMessage, error) {
+	asset := json.RawMessage{}
+	return asset, client.evaluate("getAssetByUUID", []string{uuid}, &asset)
+}
+
+//submit - submits a function and decodes its output
+func (client *Client) submit(function string, args []string, output interface{}) error {
+	payload, err := client.transport.Submit(function, args...)
+	if err != nil {
+		return err
+	}
+	return decode(function, payload, output)
+}
+
+//evaluate - evaluates a function and decodes its output
+func (client *Client) evaluate(function string, args []string, output interface{}) error {
+	payload, err := client.transport.Evaluate(function, args...)
+	if err != nil {
+		return err
+	}
+	return decode(function, payload, output)
+}
+
+//submitJSON - submits a function taking the JSON of an input as its argument
+func (client *Client) submitJSON(function string, input interface{}, output interface{}) error {
+	args, err := getJSONArgs(input)
+	if err != nil {
+		return err
+	}
+	return client.submit(function, args, output)
+}
+
+//submitRoyaltyStatements - submits a function recording royalty statements, the statements are passed in the
+// transient map with a random salt so that their amounts are not recorded on the ledger
+func (client *Client) submitRoyaltyStatements(function string, royaltyStatements []RoyaltyStatement, output interface{}) error {
+	royaltyStatementsBytes, err := json.Marshal(royaltyStatements)
+	if err != nil {
+		return err
+	}
+	salt := make([]byte, 32)
+	_, err = rand.Read(salt)
+	if err != nil {
+		return err
+	}
+	payload, err := client.transport.SubmitTransient(function, map[string][]byte{TransientRoyaltyStatements: royaltyStatementsBytes, TransientSalt: salt})
+	if err != nil {
+		return err
+	}
+	return decode(function, payload, output)
+}
+
+//evaluateJSON - evaluates a function taking the JSON of an input as its argument
+func (client *Client) evaluateJSON(function string, input interface{}, output interface{}) error {
+	args, err := getJSONArgs(input)
+	if err != nil {
+		return err
+	}
+	return client.evaluate(function, args, output)
+}
+
+//evaluateQuery - evaluates a function taking an optional rich query of the assets of a doc type
+func (client *Client) evaluateQuery(function string, query *Query, docType string, output interface{}) error {
+	args, err := getQueryArgs(query, docType)
+	if err != nil {
+		return err
+	}
+	return client.evaluate(function, args, output)
+}
+
+//decode - decodes the payload of a function into its output. A status envelope is an error unless its status is
+// 200, the message of a successful envelope is decoded into a string output.
+func decode(function string, payload []byte, output interface{}) error {
+	response, isEnvelope := getEnvelope(payload)
+	if isEnvelope {
+		if response.Status != "200" {
+			status, _ := strconv.Atoi(response.Status)
+			return &Error{Function: function, Status: status, Message: response.Message}
+		}
+		if message, ok := output.(*string); ok {
+			*message = response.Message
+		}
+		return nil
+	}
+	if output == nil || len(payload) == 0 {
+		return nil
+	}
+	err := json.Unmarshal(payload, output)
+	if err != nil {
+		return fmt.Errorf("%s: failed to decode the response: %s", function, err.Error())
+	}
+	return nil
+}
+
+//getEnvelope - returns the status envelope of a payload, a payload is an envelope when it only has a status and
+// a message
+func getEnvelope(payload []byte) (Response, bool) {
+	response := Response{}
+	fields := map[string]json.RawMessage{}
+	if json.Unmarshal(payload, &fields) != nil || len(fields) != 2 || fields["status"] == nil || fields["message"] == nil {
+		return response, false
+	}
+	return response, json.Unmarshal(payload, &response) == nil
+}
+
+//getQueryArgs - returns the arguments of a query function, no argument for a nil query so that the chaincode
+// queries all the assets of its type
+func getQueryArgs(query *Query, docType string) ([]string, error) {
+	if query == nil {
+		return nil, nil
+	}
+	selector := map[string]interface{}{"docType": docType}
+	for field, condition := range query.Selector {
+		selector[field] = condition
+	}
+	docTypeQuery := *query
+	docTypeQuery.Selector = selector
+	queryBytes, err := json.Marshal(docTypeQuery)
+	if err != nil {
+		return nil, err
+	}
+	return []string{string(queryBytes)}, nil
+}
+
+//getJSONArgs - returns the JSON of an input as the single argument of a function
+func getJSONArgs(input interface{}) ([]string, error) {
+	inputBytes, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	return []string{string(inputBytes)}, nil
+}
@@ -0,0 +1,159 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// fakeTransport - answers every function with the payload of its name and records the arguments and the
+// transient maps of the invocations
+type fakeTransport struct {
+	payloads    map[string]string
+	invocations [][]string
+	transients  []map[string][]byte
+}
+
+func (transport *fakeTransport) Submit(function string, args ...string) ([]byte, error) {
+	return transport.SubmitTransient(function, nil, args...)
+}
+
+func (transport *fakeTransport) SubmitTransient(function string, transient map[string][]byte, args ...string) ([]byte, error) {
+	transport.invocations = append(transport.invocations, append([]string{function}, args...))
+	transport.transients = append(transport.transients, transient)
+	payload, ok := transport.payloads[function]
+	if !ok {
+		return nil, &Error{Function: function, Status: 500, Message: "Invalid function " + function}
+	}
+	return []byte(payload), nil
+}
+
+func (transport *fakeTransport) Evaluate(function string, args ...string) ([]byte, error) {
+	return transport.SubmitTransient(function, nil, args...)
+}
+
+func newFakeClient(t *testing.T, payloads map[string]string) (*Client, *fakeTransport) {
+	transport := &fakeTransport{payloads: payloads}
+	return New(transport), transport
+}
+
+func Test_Decode(t *testing.T) {
+	axispoint, _ := newFakeClient(t, map[string]string{
+		"ping":                 `{"status":"200","message":"Ping OK"}`,
+		"getTaxResidency":      `{"status":"500","message":"Tax Residency of '00014107338' does not exist!"}`,
+		"getIpiOrgByUUID":      `{"docType":"IPIORGMAP","ipi":"00014107338","org":"Org1"}`,
+		"addIpiOrg":            `{"message": "IPI-Org mapping created successfully"}`,
+		"getRoyaltyStatements": `null`,
+	})
+
+	message, err := axispoint.Ping()
+	if err != nil || message != "Ping OK" {
+		t.Fatalf("Actual ping is not equal to expected ping: %s %v", message, err)
+	}
+
+	// an error envelope in a successful response is an error
+	_, err = axispoint.GetTaxResidency("00014107338")
+	expectedError := &Error{Function: "getTaxResidency", Status: 500, Message: "Tax Residency of '00014107338' does not exist!"}
+	if !reflect.DeepEqual(expectedError, err) {
+		t.Fatalf("Actual error is not equal to expected error: %v", err)
+	}
+
+	// the errors of the transport are returned as they are
+	_, err = axispoint.GetTerritory("EU")
+	expectedError = &Error{Function: "getTerritory", Status: 500, Message: "Invalid function getTerritory"}
+	if !reflect.DeepEqual(expectedError, err) {
+		t.Fatalf("Actual error is not equal to expected error: %v", err)
+	}
+
+	ipiOrg, err := axispoint.GetIpiOrgByUUID("00014107338")
+	if err != nil || !reflect.DeepEqual(&IpiOrgMap{DocType: IpiOrgMapDocType, Ipi: "00014107338", Org: "Org1"}, ipiOrg) {
+		t.Fatalf("Actual IPI-Org mapping is not equal to expected IPI-Org mapping: %+v %v", ipiOrg, err)
+	}
+	message, err = axispoint.AddIpiOrg(IpiOrgMap{Ipi: "00014107338", Org: "Org1"})
+	if err != nil || message != "IPI-Org mapping created successfully" {
+		t.Fatalf("Actual message is not equal to expected message: %s %v", message, err)
+	}
+	royaltyStatements, err := axispoint.GetRoyaltyStatements(nil)
+	if err != nil || len(royaltyStatements) != 0 {
+		t.Fatalf("Expected no royalty statement: %+v %v", royaltyStatements, err)
+	}
+}
+
+func Test_Args(t *testing.T) {
+	axispoint, transport := newFakeClient(t, map[string]string{
+		"searchForCopyrightDataReportWithParameters": `[]`,
+		"getRoyaltyStatements":                       `[]`,
+		"getAdvances":                                `[]`,
+		"resolveCollectionChain":                     `{"royaltyStatementUUID":"rs1","persisted":true,"royaltyStatements":[]}`,
+		"addDisputeComment":                          `{"disputeUUID":"d1"}`,
+		"getBalances":                                `[]`,
+		"addRoyaltyStatements":                       `{"successCount":1,"failureCount":0,"royaltyStatements":[]}`,
+	})
+
+	_, err := axispoint.SearchForCopyrightDataReportWithParameters(CopyrightDataReportSearch{Isrc: "USRC17607839", SongTitle: "Hello World"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, err = axispoint.GetRoyaltyStatements(&Query{Selector: map[string]interface{}{"isrc": "USRC17607839"}, Limit: 10})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, err = axispoint.GetRoyaltyStatements(nil)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, err = axispoint.GetAdvances("00014107338", "")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	collectionChain, err := axispoint.ResolveCollectionChain("rs1", true)
+	if err != nil || !collectionChain.Persisted {
+		t.Fatalf("Actual collection chain is not equal to expected collection chain: %+v %v", collectionChain, err)
+	}
+	_, err = axispoint.AddDisputeComment("d1", DisputeComment{Author: "00014107338", Comment: "see the DSR"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, err = axispoint.GetBalances(BalanceQuery{Ipi: "00014107338"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, err = axispoint.AddRoyaltyStatements([]RoyaltyStatement{{RoyaltyStatementUUID: "rs1", Amount: 100}})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expectedInvocations := [][]string{
+		{"searchForCopyrightDataReportWithParameters", "USRC17607839", "Hello World"},
+		{"getRoyaltyStatements", `{"selector":{"docType":"ROYALTYSTATEMENT","isrc":"USRC17607839"},"limit":10}`},
+		{"getRoyaltyStatements"},
+		{"getAdvances", "00014107338"},
+		{"resolveCollectionChain", "rs1", "true"},
+		{"addDisputeComment", "d1", `{"author":"00014107338","comment":"see the DSR","createdDate":""}`},
+		{"getBalances", `{"ipi":"00014107338","org":"","counterparty":"","currency":""}`},
+		{"addRoyaltyStatements"},
+	}
+	if !reflect.DeepEqual(expectedInvocations, transport.invocations) {
+		t.Fatalf("Actual invocations are not equal to expected invocations: %q", transport.invocations)
+	}
+
+	// royalty statements are passed in the transient map with a random salt
+	transient := transport.transients[len(transport.transients)-1]
+	royaltyStatements := []RoyaltyStatement{}
+	err = json.Unmarshal(transient[TransientRoyaltyStatements], &royaltyStatements)
+	if err != nil || len(royaltyStatements) != 1 || royaltyStatements[0].Amount != 100 || len(transient[TransientSalt]) != 32 {
+		t.Fatalf("Unexpected transient map: %q %v", transient, err)
+	}
+	if transport.transients[0] != nil {
+		t.Fatalf("Expected no transient map for the other functions: %q", transport.transients[0])
+	}
+
+	// a search parameter requires the parameters before it
+	_, err = axispoint.SearchForCopyrightDataReportWithParameters(CopyrightDataReportSearch{Isrc: "USRC17607839", StartDate: "2018-01-01"})
+	if err == nil || err.Error() != "copyright data report search by start date requires the song title" {
+		t.Fatalf("Expected a search without song title to be rejected: %v", err)
+	}
+	if len(transport.invocations) != len(expectedInvocations) {
+		t.Fatalf("Expected a rejected search not to be invoked")
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Package clienttest runs the chaincode in process behind the client of the chaincode, for tests.
+
+	transport, err := clienttest.NewMockStubTransport("axispoint-cc", new(AxispointChaincode))
+	axispoint := client.New(transport)
+*/
+package clienttest
+
+import (
+	"strconv"
+	"sync"
+
+	"axispoint-cc/client"
+	"axispoint-cc/memstub"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// MockStubTransport - the transport invoking a chaincode in process on a MockStub answering rich queries, for
+// tests. Each invocation is a transaction of its own; the MockStub has no endorsement, evaluated transactions
+// write to its state too.
+type MockStubTransport struct {
+	Stub  *memstub.Stub
+	mutex sync.Mutex
+	txs   int
+}
+
+// NewMockStubTransport returns the transport of a chaincode initialized on a new MockStub
+func NewMockStubTransport(name string, chaincode shim.Chaincode) (*MockStubTransport, error) {
+	transport := &MockStubTransport{Stub: memstub.NewStub(name, chaincode)}
+	response := transport.Stub.MockInit(transport.nextTxID(), [][]byte{[]byte("init")})
+	if response.Status != shim.OK {
+		return nil, &client.Error{Function: "init", Status: int(response.Status), Message: response.Message}
+	}
+	return transport, nil
+}
+
+// Submit invokes a chaincode function on the MockStub
+func (transport *MockStubTransport) Submit(function string, args ...string) ([]byte, error) {
+	return transport.invoke(function, args)
+}
+
+// SubmitTransient invokes a chaincode function on the MockStub with a transient map
+func (transport *MockStubTransport) SubmitTransient(function string, transient map[string][]byte, args ...string) ([]byte, error) {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	transport.Stub.Transient = transient
+	defer func() { transport.Stub.Transient = nil }()
+	return transport.invokeLocked(function, args)
+}
+
+// Evaluate invokes a chaincode function on the MockStub
+func (transport *MockStubTransport) Evaluate(function string, args ...string) ([]byte, error) {
+	return transport.invoke(function, args)
+}
+
+//invoke - invokes a chaincode function in a transaction of its own, a peer error is returned as a client Error
+func (transport *MockStubTransport) invoke(function string, args []string) ([]byte, error) {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	return transport.invokeLocked(function, args)
+}
+
+//invokeLocked - invokes a chaincode function while the transport is locked
+func (transport *MockStubTransport) invokeLocked(function string, args []string) ([]byte, error) {
+	invokeArgs := [][]byte{[]byte(function)}
+	for _, arg := range args {
+		invokeArgs = append(invokeArgs, []byte(arg))
+	}
+	response := transport.Stub.MockInvoke(transport.nextTxID(), invokeArgs)
+	if response.Status != shim.OK {
+		return nil, &client.Error{Function: function, Status: int(response.Status), Message: response.Message}
+	}
+	return response.Payload, nil
+}
+
+//nextTxID - returns the id of the next transaction, the chaincode keys the deltas of balances by transaction
+func (transport *MockStubTransport) nextTxID() string {
+	transport.txs++
+	return "tx" + strconv.Itoa(transport.txs)
+}
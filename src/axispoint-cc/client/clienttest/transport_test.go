@@ -0,0 +1,63 @@
+package clienttest
+
+import (
+	"reflect"
+	"testing"
+
+	"axispoint-cc/client"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// fakeChaincode - answers ping and records the transient maps of the invocations
+type fakeChaincode struct {
+	transients []map[string][]byte
+}
+
+func (chaincode *fakeChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (chaincode *fakeChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	function, _ := stub.GetFunctionAndParameters()
+	transient, _ := stub.GetTransient()
+	chaincode.transients = append(chaincode.transients, transient)
+	if function != "ping" && function != "addRoyaltyStatements" {
+		return shim.Error("Invalid function " + function)
+	}
+	return shim.Success([]byte(`{"status":"200","message":"Ping OK"}`))
+}
+
+func Test_MockStubTransport(t *testing.T) {
+	chaincode := &fakeChaincode{}
+	transport, err := NewMockStubTransport("fake", chaincode)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	axispoint := client.New(transport)
+
+	message, err := axispoint.Ping()
+	if err != nil || message != "Ping OK" {
+		t.Fatalf("Actual ping is not equal to expected ping: %s %v", message, err)
+	}
+
+	// a peer error is an error of the client
+	_, err = axispoint.GetTerritory("EU")
+	expectedError := &client.Error{Function: "getTerritory", Status: shim.ERROR, Message: "Invalid function getTerritory"}
+	if !reflect.DeepEqual(expectedError, err) {
+		t.Fatalf("Actual error is not equal to expected error: %v", err)
+	}
+
+	// the transient map is only passed to the transaction submitting it
+	_, err = axispoint.AddRoyaltyStatements([]client.RoyaltyStatement{{RoyaltyStatementUUID: "rs1", Amount: 100}})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, err = axispoint.Ping()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if chaincode.transients[0] != nil || chaincode.transients[2][client.TransientRoyaltyStatements] == nil || chaincode.transients[3] != nil {
+		t.Fatalf("Unexpected transient maps: %q", chaincode.transients)
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Package gateway carries the invocations of the client of the chaincode to a Fabric network through a fabric-sdk-go
+gateway connection. It is kept apart from the client package, whose tests run the chaincode in process, because
+the protos of fabric-sdk-go cannot be linked next to those of the Fabric 1.4 shim.
+
+	wallet, err := gateway.NewFileSystemWallet("wallet")
+	gw, err := gateway.Connect(gateway.WithConfig(config.FromFile("connection.yaml")), gateway.WithIdentity(wallet, "admin"))
+	defer gw.Close()
+	network, err := gw.GetNetwork("mychannel")
+	axispoint := client.New(axispointgateway.NewTransport(network.GetContract("axispoint-cc")))
+*/
+package gateway
+
+import (
+	fabricgateway "github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+//gatewayContract - the transactions of a gateway contract the transport uses, implemented by gateway.Contract of fabric-sdk-go
+type gatewayContract interface {
+	SubmitTransaction(name string, args ...string) ([]byte, error)
+	EvaluateTransaction(name string, args ...string) ([]byte, error)
+	CreateTransaction(name string, opts ...fabricgateway.TransactionOption) (*fabricgateway.Transaction, error)
+}
+
+// Transport - the client transport invoking the chaincode of a Fabric network through the contract of a
+// fabric-sdk-go gateway connection, the identity of the connection is the caller of the chaincode
+type Transport struct {
+	contract gatewayContract
+}
+
+// NewTransport returns the transport of a gateway contract, e.g. network.GetContract("axispoint-cc")
+func NewTransport(contract *fabricgateway.Contract) *Transport {
+	return &Transport{contract: contract}
+}
+
+// Submit submits a transaction to the peers of the gateway and waits for its commit
+func (transport *Transport) Submit(function string, args ...string) ([]byte, error) {
+	return transport.contract.SubmitTransaction(function, args...)
+}
+
+// SubmitTransient submits a transaction with a transient map to the peers of the gateway and waits for its commit
+func (transport *Transport) SubmitTransient(function string, transient map[string][]byte, args ...string) ([]byte, error) {
+	transaction, err := transport.contract.CreateTransaction(function, fabricgateway.WithTransient(transient))
+	if err != nil {
+		return nil, err
+	}
+	return transaction.Submit(args...)
+}
+
+// Evaluate evaluates a transaction on a peer of the gateway without committing it
+func (transport *Transport) Evaluate(function string, args ...string) ([]byte, error) {
+	return transport.contract.EvaluateTransaction(function, args...)
+}
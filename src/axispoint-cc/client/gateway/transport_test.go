@@ -0,0 +1,57 @@
+package gateway
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"axispoint-cc/client"
+	fabricgateway "github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+)
+
+// fakeContract - a gateway contract answering every transaction with its name and whether it was submitted
+type fakeContract struct {
+	err error
+}
+
+func (contract fakeContract) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	return []byte(`{"status":"200","message":"submitted ` + name + `"}`), contract.err
+}
+
+func (contract fakeContract) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	return []byte(`{"status":"200","message":"evaluated ` + name + `"}`), contract.err
+}
+
+func (contract fakeContract) CreateTransaction(name string, opts ...fabricgateway.TransactionOption) (*fabricgateway.Transaction, error) {
+	return nil, contract.err
+}
+
+func Test_Transport(t *testing.T) {
+	axispoint := client.New(&Transport{contract: fakeContract{}})
+	actual := []string{}
+	message, err := axispoint.Ping()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actual = append(actual, message)
+	message, err = axispoint.ResetLedger()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actual = append(actual, message)
+	if !reflect.DeepEqual([]string{"evaluated ping", "submitted resetLedger"}, actual) {
+		t.Fatalf("Actual messages are not equal to expected messages: %v", actual)
+	}
+
+	// the errors of the gateway are returned as they are
+	gatewayError := errors.New("endorsement failure")
+	axispoint = client.New(&Transport{contract: fakeContract{err: gatewayError}})
+	_, err = axispoint.Ping()
+	if err != gatewayError {
+		t.Fatalf("Actual error is not equal to expected error: %v", err)
+	}
+	_, err = axispoint.AddRoyaltyStatements([]client.RoyaltyStatement{{RoyaltyStatementUUID: "rs1", Amount: 100}})
+	if err != gatewayError {
+		t.Fatalf("Actual error is not equal to expected error: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package client
+
+//messageOutput - the output of the functions answering with a message only
+type messageOutput struct {
+	Message string `json:"message"`
+}
+
+// AddIpiOrg maps an IPI to an org
+func (client *Client) AddIpiOrg(ipiOrg IpiOrgMap) (string, error) {
+	output := messageOutput{}
+	return output.Message, client.submitJSON("addIpiOrg", ipiOrg, &output)
+}
+
+// UpdateIpiOrg updates the mapping of an IPI to an org
+func (client *Client) UpdateIpiOrg(ipiOrg IpiOrgMap) (string, error) {
+	output := messageOutput{}
+	return output.Message, client.submitJSON("updateIpiOrg", ipiOrg, &output)
+}
+
+// GetIpiOrgByUUID returns the mapping of an IPI to an org
+func (client *Client) GetIpiOrgByUUID(ipi string) (*IpiOrgMap, error) {
+	ipiOrg := &IpiOrgMap{}
+	return ipiOrg, client.evaluate("getIpiOrgByUUID", []string{ipi}, ipiOrg)
+}
+
+// GetAllIpiOrgs returns all the mappings of IPIs to orgs
+func (client *Client) GetAllIpiOrgs() ([]IpiOrgMap, error) {
+	ipiOrgs := []IpiOrgMap{}
+	return ipiOrgs, client.evaluate("getAllIpiOrgs", nil, &ipiOrgs)
+}
+
+// DeleteIpiOrgByUUID deletes the mappings of IPIs to orgs and their history
+func (client *Client) DeleteIpiOrgByUUID(ipis ...string) (string, error) {
+	message := ""
+	return message, client.submit("deleteIpiOrgByUUID", ipis, &message)
+}
+
+// TransferIpi moves an IPI to another org from an effective date
+func (client *Client) TransferIpi(ipi string, org string, effectiveDate string) (string, error) {
+	output := messageOutput{}
+	return output.Message, client.submit("transferIpi", []string{ipi, org, effectiveDate}, &output)
+}
+
+// GetIpiOrgHistory returns the org mappings of an IPI sorted by start date
+func (client *Client) GetIpiOrgHistory(ipi string) ([]IpiOrgMap, error) {
+	ipiOrgs := []IpiOrgMap{}
+	return ipiOrgs, client.evaluate("getIpiOrgHistory", []string{ipi}, &ipiOrgs)
+}
+
+// AddAdvances records advances
+func (client *Client) AddAdvances(advances []Advance) (*AdvanceOutput, error) {
+	output := &AdvanceOutput{}
+	return output, client.submitJSON("addAdvances", advances, output)
+}
+
+// UpdateAdvances updates advances
+func (client *Client) UpdateAdvances(advances []Advance) (*AdvanceOutput, error) {
+	output := &AdvanceOutput{}
+	return output, client.submitJSON("updateAdvances", advances, output)
+}
+
+// GetAdvances returns the advances paid to a payee, by a payer unless the payer is empty
+func (client *Client) GetAdvances(payee string, payer string) ([]Advance, error) {
+	args := []string{payee}
+	if payer != "" {
+		args = append(args, payer)
+	}
+	advances := []Advance{}
+	return advances, client.evaluate("getAdvances", args, &advances)
+}
+
+// AddTaxRules records withholding tax rules
+func (client *Client) AddTaxRules(taxRules []TaxRule) (*TaxRuleOutput, error) {
+	output := &TaxRuleOutput{}
+	return output, client.submitJSON("addTaxRules", taxRules, output)
+}
+
+// UpdateTaxRules updates withholding tax rules
+func (client *Client) UpdateTaxRules(taxRules []TaxRule) (*TaxRuleOutput, error) {
+	output := &TaxRuleOutput{}
+	return output, client.submitJSON("updateTaxRules", taxRules, output)
+}
+
+// GetTaxRules returns the tax rules of a source territory, for a residency unless the residency is empty
+func (client *Client) GetTaxRules(sourceTerritory string, residency string) ([]TaxRule, error) {
+	args := []string{sourceTerritory}
+	if residency != "" {
+		args = append(args, residency)
+	}
+	taxRules := []TaxRule{}
+	return taxRules, client.evaluate("getTaxRules", args, &taxRules)
+}
+
+// AddTaxResidencies records the tax residencies of payees
+func (client *Client) AddTaxResidencies(taxResidencies []TaxResidency) (*TaxResidencyOutput, error) {
+	output := &TaxResidencyOutput{}
+	return output, client.submitJSON("addTaxResidencies", taxResidencies, output)
+}
+
+// UpdateTaxResidencies updates the tax residencies of payees
+func (client *Client) UpdateTaxResidencies(taxResidencies []TaxResidency) (*TaxResidencyOutput, error) {
+	output := &TaxResidencyOutput{}
+	return output, client.submitJSON("updateTaxResidencies", taxResidencies, output)
+}
+
+// GetTaxResidency returns the tax residency of a payee
+func (client *Client) GetTaxResidency(ipi string) (*TaxResidency, error) {
+	taxResidency := &TaxResidency{}
+	return taxResidency, client.evaluate("getTaxResidency", []string{ipi}, taxResidency)
+}
+
+// GetBalances returns the outstanding balances of an IPI or an org
+func (client *Client) GetBalances(balanceQuery BalanceQuery) ([]Balance, error) {
+	balances := []Balance{}
+	return balances, client.evaluateJSON("getBalances", balanceQuery, &balances)
+}
+
+// CompactBalances replaces the balance deltas of an IPI or an org by a delta per counterparty and currency, and
+// returns the public balances, the balances of the private data collections of the caller are only returned by
+// GetBalances
+func (client *Client) CompactBalances(balanceQuery BalanceQuery) ([]Balance, error) {
+	balances := []Balance{}
+	return balances, client.submitJSON("compactBalances", balanceQuery, &balances)
+}
+
+// CloseStatementPeriod rolls up the royalty statements of a payee over a period into a period statement, which
+// only has the totals of the public royalty statements
+func (client *Client) CloseStatementPeriod(ipi string, periodStart string, periodEnd string) (*PeriodStatement, error) {
+	periodStatement := &PeriodStatement{}
+	return periodStatement, client.submit("closeStatementPeriod", []string{ipi, periodStart, periodEnd}, periodStatement)
+}
+
+// GetPeriodStatement returns a period statement with the totals of its public royalty statements and of the
+// private royalty statements of the collections of the caller
+func (client *Client) GetPeriodStatement(periodStatementUUID string) (*PeriodStatement, error) {
+	periodStatement := &PeriodStatement{}
+	return periodStatement, client.evaluate("getPeriodStatement", []string{periodStatementUUID}, periodStatement)
+}
+
+// GetEarningsReport returns a page of the earnings of an IPI grouped by dimensions
+func (client *Client) GetEarningsReport(earningsReportQuery EarningsReportQuery) (*EarningsReport, error) {
+	earningsReport := &EarningsReport{}
+	return earningsReport, client.evaluateJSON("getEarningsReport", earningsReportQuery, earningsReport)
+}
@@ -0,0 +1,41 @@
+package client
+
+// AddTerritoryGroups records territory groups
+func (client *Client) AddTerritoryGroups(territoryGroups []TerritoryGroup) (*TerritoryGroupOutput, error) {
+	output := &TerritoryGroupOutput{}
+	return output, client.submitJSON("addTerritoryGroups", territoryGroups, output)
+}
+
+// UpdateTerritoryGroups updates territory groups
+func (client *Client) UpdateTerritoryGroups(territoryGroups []TerritoryGroup) (*TerritoryGroupOutput, error) {
+	output := &TerritoryGroupOutput{}
+	return output, client.submitJSON("updateTerritoryGroups", territoryGroups, output)
+}
+
+// GetTerritory returns a country or a territory group with the countries it resolves to
+func (client *Client) GetTerritory(code string) (*TerritoryGroup, error) {
+	territoryGroup := &TerritoryGroup{}
+	return territoryGroup, client.evaluate("getTerritory", []string{code}, territoryGroup)
+}
+
+// AddUsageTypeCategories maps usage types to right categories
+func (client *Client) AddUsageTypeCategories(usageTypeCategories []UsageTypeCategory) (*UsageTypeCategoryOutput, error) {
+	output := &UsageTypeCategoryOutput{}
+	return output, client.submitJSON("addUsageTypeCategories", usageTypeCategories, output)
+}
+
+// UpdateUsageTypeCategories updates the right categories of usage types
+func (client *Client) UpdateUsageTypeCategories(usageTypeCategories []UsageTypeCategory) (*UsageTypeCategoryOutput, error) {
+	output := &UsageTypeCategoryOutput{}
+	return output, client.submitJSON("updateUsageTypeCategories", usageTypeCategories, output)
+}
+
+// GetUsageTypeCategories returns the right category of a usage type, of all the usage types when it is empty
+func (client *Client) GetUsageTypeCategories(usageType string) ([]UsageTypeCategory, error) {
+	args := []string{}
+	if usageType != "" {
+		args = append(args, usageType)
+	}
+	usageTypeCategories := []UsageTypeCategory{}
+	return usageTypeCategories, client.evaluate("getUsageTypeCategories", args, &usageTypeCategories)
+}
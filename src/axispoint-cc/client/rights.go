@@ -0,0 +1,150 @@
+package client
+
+import "fmt"
+
+// CopyrightDataReportSearch - the parameters of searchForCopyrightDataReportWithParameters, a parameter requires
+// the parameters before it
+type CopyrightDataReportSearch struct {
+	Isrc      string
+	SongTitle string
+	StartDate string
+	EndDate   string
+}
+
+// AddCopyrightDataReports records copyright data reports
+func (client *Client) AddCopyrightDataReports(copyrightDataReports []CopyrightDataReport) (*CopyrightDataReportOutput, error) {
+	output := &CopyrightDataReportOutput{}
+	return output, client.submitJSON("addCopyrightDataReports", copyrightDataReports, output)
+}
+
+// UpdateCopyrightDataReports updates copyright data reports
+func (client *Client) UpdateCopyrightDataReports(copyrightDataReports []CopyrightDataReport) (*CopyrightDataReportOutput, error) {
+	output := &CopyrightDataReportOutput{}
+	return output, client.submitJSON("updateCopyrightDataReports", copyrightDataReports, output)
+}
+
+// GetCopyrightDataReportByID returns a copyright data report by UUID
+func (client *Client) GetCopyrightDataReportByID(copyrightDataReportUUID string) (*CopyrightDataReport, error) {
+	copyrightDataReport := &CopyrightDataReport{}
+	return copyrightDataReport, client.evaluate("getCopyrightDataReportByID", []string{copyrightDataReportUUID}, copyrightDataReport)
+}
+
+// DeleteCopyrightDataReportByIDs deletes copyright data reports by UUID
+func (client *Client) DeleteCopyrightDataReportByIDs(copyrightDataReportUUIDs ...string) (string, error) {
+	message := ""
+	return message, client.submit("deleteCopyrightDataReportByIDs", copyrightDataReportUUIDs, &message)
+}
+
+// SearchForCopyrightDataReportWithParameters returns the copyright data reports of an ISRC matching the song
+// title, start date and end date of a search
+func (client *Client) SearchForCopyrightDataReportWithParameters(search CopyrightDataReportSearch) ([]CopyrightDataReport, error) {
+	args, err := search.args()
+	if err != nil {
+		return nil, err
+	}
+	copyrightDataReports := []CopyrightDataReport{}
+	return copyrightDataReports, client.evaluate("searchForCopyrightDataReportWithParameters", args, &copyrightDataReports)
+}
+
+// GetAllCopyrightDataReports returns the copyright data reports of a query, all of them for a nil query
+func (client *Client) GetAllCopyrightDataReports(query *Query) ([]CopyrightDataReport, error) {
+	copyrightDataReports := []CopyrightDataReport{}
+	return copyrightDataReports, client.evaluateQuery("getAllCopyrightDataReports", query, CopyrightDataReportDocType, &copyrightDataReports)
+}
+
+// AddCollectionRights records collection rights
+func (client *Client) AddCollectionRights(collectionRights []CollectionRight) (*CollectionRightsOutput, error) {
+	output := &CollectionRightsOutput{}
+	return output, client.submitJSON("addCollectionRights", collectionRights, output)
+}
+
+// UpdateCollectionRights updates collection rights
+func (client *Client) UpdateCollectionRights(collectionRights []CollectionRight) (*CollectionRightsOutput, error) {
+	output := &CollectionRightsOutput{}
+	return output, client.submitJSON("updateCollectionRights", collectionRights, output)
+}
+
+// GetCollectionRights returns the collection rights of a query, all of them for a nil query
+func (client *Client) GetCollectionRights(query *Query) ([]CollectionRight, error) {
+	collectionRights := []CollectionRight{}
+	return collectionRights, client.evaluateQuery("getCollectionRights", query, CollectionRightDocType, &collectionRights)
+}
+
+// GetCollectionGraph returns the collection rights around an IPI, in effect on a date unless the date is empty
+func (client *Client) GetCollectionGraph(ipi string, asOfDate string) (*CollectionGraph, error) {
+	args := []string{ipi}
+	if asOfDate != "" {
+		args = append(args, asOfDate)
+	}
+	collectionGraph := &CollectionGraph{}
+	return collectionGraph, client.evaluate("getCollectionGraph", args, collectionGraph)
+}
+
+// AddMusicalWorks records musical works
+func (client *Client) AddMusicalWorks(musicalWorks []MusicalWork) (*MusicalWorkOutput, error) {
+	output := &MusicalWorkOutput{}
+	return output, client.submitJSON("addMusicalWorks", musicalWorks, output)
+}
+
+// UpdateMusicalWorks updates musical works
+func (client *Client) UpdateMusicalWorks(musicalWorks []MusicalWork) (*MusicalWorkOutput, error) {
+	output := &MusicalWorkOutput{}
+	return output, client.submitJSON("updateMusicalWorks", musicalWorks, output)
+}
+
+// GetMusicalWork returns the musical work of an ISWC, or of a recording by ISRC
+func (client *Client) GetMusicalWork(iswcOrIsrc string) (*MusicalWork, error) {
+	musicalWork := &MusicalWork{}
+	return musicalWork, client.evaluate("getMusicalWork", []string{iswcOrIsrc}, musicalWork)
+}
+
+// AddDisputes raises disputes
+func (client *Client) AddDisputes(disputes []Dispute) (*DisputeOutput, error) {
+	output := &DisputeOutput{}
+	return output, client.submitJSON("addDisputes", disputes, output)
+}
+
+// GetDisputes returns the disputes of a query, all of them for a nil query
+func (client *Client) GetDisputes(query *Query) ([]Dispute, error) {
+	disputes := []Dispute{}
+	return disputes, client.evaluateQuery("getDisputes", query, DisputeDocType, &disputes)
+}
+
+// AddDisputeComment adds a comment to the thread of an open dispute and returns the dispute
+func (client *Client) AddDisputeComment(disputeUUID string, disputeComment DisputeComment) (*Dispute, error) {
+	args, err := getJSONArgs(disputeComment)
+	if err != nil {
+		return nil, err
+	}
+	dispute := &Dispute{}
+	return dispute, client.submit("addDisputeComment", append([]string{disputeUUID}, args...), dispute)
+}
+
+// ResolveDispute resolves an open dispute, recomputing the disputed statements when the resolution asks for it
+func (client *Client) ResolveDispute(disputeUUID string, disputeResolution DisputeResolution) (*DisputeResolutionOutput, error) {
+	args, err := getJSONArgs(disputeResolution)
+	if err != nil {
+		return nil, err
+	}
+	output := &DisputeResolutionOutput{}
+	return output, client.submit("resolveDispute", append([]string{disputeUUID}, args...), output)
+}
+
+//args - returns the positional arguments of a search, up to its last parameter
+func (search CopyrightDataReportSearch) args() ([]string, error) {
+	names := []string{"ISRC", "song title", "start date", "end date"}
+	values := []string{search.Isrc, search.SongTitle, search.StartDate, search.EndDate}
+	last := len(values) - 1
+	for last >= 0 && values[last] == "" {
+		last--
+	}
+	if last < 0 {
+		return nil, fmt.Errorf("copyright data report search requires an ISRC")
+	}
+	for i := 0; i < last; i++ {
+		if values[i] == "" {
+			return nil, fmt.Errorf("copyright data report search by %s requires the %s", names[last], names[i])
+		}
+	}
+	return values[:last+1], nil
+}
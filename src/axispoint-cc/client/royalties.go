@@ -0,0 +1,96 @@
+package client
+
+// GenerateExploitationReports records exploitation reports and generates the royalty statements of their right
+// holders
+func (client *Client) GenerateExploitationReports(exploitationReports []ExploitationReport) (*GenerateExploitationReportsOutput, error) {
+	output := &GenerateExploitationReportsOutput{}
+	return output, client.submitJSON("generateExploitationReports", exploitationReports, output)
+}
+
+// UpdateExploitationReports updates exploitation reports
+func (client *Client) UpdateExploitationReports(exploitationReports []ExploitationReport) (*ExploitationReportOutput, error) {
+	output := &ExploitationReportOutput{}
+	return output, client.submitJSON("updateExploitationReports", exploitationReports, output)
+}
+
+// InsertExploitationReports records exploitation reports without generating royalty statements
+func (client *Client) InsertExploitationReports(exploitationReports []ExploitationReport) (*ExploitationReportOutput, error) {
+	output := &ExploitationReportOutput{}
+	return output, client.submitJSON("insertExploitationReports", exploitationReports, output)
+}
+
+// GetExploitationReports returns the exploitation reports of a query, all of them for a nil query
+func (client *Client) GetExploitationReports(query *Query) ([]ExploitationReport, error) {
+	exploitationReports := []ExploitationReport{}
+	return exploitationReports, client.evaluateQuery("getExploitationReports", query, ExploitationReportDocType, &exploitationReports)
+}
+
+// AddRoyaltyStatements records royalty statements, passed in the transient map so that the amounts of the
+// statements between orgs stay off the ledger
+func (client *Client) AddRoyaltyStatements(royaltyStatements []RoyaltyStatement) (*RoyaltyStatementOutput, error) {
+	output := &RoyaltyStatementOutput{}
+	return output, client.submitRoyaltyStatements("addRoyaltyStatements", royaltyStatements, output)
+}
+
+// AddRoyaltyStatementAndEvent records royalty statements and sets the creation event of their ownership
+// statements, the statements are passed in the transient map like AddRoyaltyStatements
+func (client *Client) AddRoyaltyStatementAndEvent(royaltyStatements []RoyaltyStatement) (*RoyaltyStatementOutput, error) {
+	output := &RoyaltyStatementOutput{}
+	return output, client.submitRoyaltyStatements("addRoyaltyStatementAndEvent", royaltyStatements, output)
+}
+
+// GetRoyaltyStatements returns the royalty statements of a query, all of them for a nil query
+func (client *Client) GetRoyaltyStatements(query *Query) ([]RoyaltyStatement, error) {
+	royaltyStatements := []RoyaltyStatement{}
+	return royaltyStatements, client.evaluateQuery("getRoyaltyStatements", query, RoyaltyStatementDocType, &royaltyStatements)
+}
+
+// GetRoyaltyStatementsByUUIDs returns royalty statements by UUID
+func (client *Client) GetRoyaltyStatementsByUUIDs(royaltyStatementUUIDs ...string) ([]RoyaltyStatement, error) {
+	royaltyStatements := []RoyaltyStatement{}
+	return royaltyStatements, client.evaluate("getRoyaltyStatementsByUUIDs", royaltyStatementUUIDs, &royaltyStatements)
+}
+
+// UpdateRoyaltyStatements updates royalty statements, passed in the transient map like AddRoyaltyStatements
+func (client *Client) UpdateRoyaltyStatements(royaltyStatements []RoyaltyStatement) (*RoyaltyStatementOutput, error) {
+	output := &RoyaltyStatementOutput{}
+	return output, client.submitRoyaltyStatements("updateRoyaltyStatements", royaltyStatements, output)
+}
+
+// PayRoyaltyStatements marks royalty statements as paid
+func (client *Client) PayRoyaltyStatements(royaltyStatementUUIDs ...string) (*RoyaltyStatementOutput, error) {
+	output := &RoyaltyStatementOutput{}
+	return output, client.submit("payRoyaltyStatements", royaltyStatementUUIDs, output)
+}
+
+// GenerateCollectionStatement returns the royalty statement of the collection by an IPI of the amount of a royalty
+// statement, without recording it
+func (client *Client) GenerateCollectionStatement(royaltyStatementUUID string, targetIpi string, collectionType string) (*RoyaltyStatement, error) {
+	royaltyStatement := &RoyaltyStatement{}
+	return royaltyStatement, client.evaluate("generateCollectionStatement", []string{royaltyStatementUUID, targetIpi, collectionType}, royaltyStatement)
+}
+
+// ResolveCollectionChain returns the royalty statements of every hop collecting the amount of a royalty statement,
+// and records them when persist is set
+func (client *Client) ResolveCollectionChain(royaltyStatementUUID string, persist bool) (*CollectionChain, error) {
+	collectionChain := &CollectionChain{}
+	if persist {
+		return collectionChain, client.submit("resolveCollectionChain", []string{royaltyStatementUUID, "true"}, collectionChain)
+	}
+	return collectionChain, client.evaluate("resolveCollectionChain", []string{royaltyStatementUUID}, collectionChain)
+}
+
+// VerifyRoyaltyStatementPrivateData checks the private details of a royalty statement against its hash, the
+// details read from its private data collection when none are given
+func (client *Client) VerifyRoyaltyStatementPrivateData(royaltyStatementUUID string, privateDetails *RoyaltyStatementPrivateDetails) (*PrivateDataVerification, error) {
+	args := []string{royaltyStatementUUID}
+	if privateDetails != nil {
+		detailsArgs, err := getJSONArgs(privateDetails)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, detailsArgs...)
+	}
+	privateDataVerification := &PrivateDataVerification{}
+	return privateDataVerification, client.evaluate("verifyRoyaltyStatementPrivateData", args, privateDataVerification)
+}
@@ -0,0 +1,10 @@
+package client
+
+// Transport - carries the invocations of the chaincode functions to a peer. Submit endorses and commits a
+// transaction, Evaluate only queries the ledger. SubmitTransient submits a transaction with private inputs in
+// its transient map, which is passed to the chaincode but not recorded on the ledger.
+type Transport interface {
+	Submit(function string, args ...string) ([]byte, error)
+	SubmitTransient(function string, transient map[string][]byte, args ...string) ([]byte, error)
+	Evaluate(function string, args ...string) ([]byte, error)
+}
@@ -0,0 +1,64 @@
+/*
+Command cwrimport maps the works of a CISAC CWR v2.x file to addCopyrightDataReports and addCollectionRights
+chaincode payloads.
+
+	cwrimport -batch 100 -out payloads/ -report report.json CW190001PUB_000.V21
+
+The payloads are written one per line to the standard output, or to batch-NNNN.json files of the -out
+directory, and the validation report to the standard error or the -report file. The command exits with
+status 2 when the report has errors, unless -allow-errors is set, in which case only the works without error
+are emitted.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"axispoint-cc/cwr"
+	"axispoint-cc/payload"
+)
+
+func main() {
+	batchSize := flag.Int("batch", cwr.DefaultBatchSize, "number of copyright data reports or collection rights per payload")
+	out := flag.String("out", "", "directory the payloads are written to, the standard output by default")
+	reportPath := flag.String("report", "", "file the validation report is written to, the standard error by default")
+	allowErrors := flag.Bool("allow-errors", false, "emit the works without error of a file with errors")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags] <cwr file>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	file, err := os.Open(flag.Arg(0))
+	if err != nil {
+		exit(err)
+	}
+	defer file.Close()
+
+	cwrImport, err := cwr.ImportFile(file, cwr.Options{BatchSize: *batchSize})
+	if err != nil {
+		exit(err)
+	}
+	err = payload.WriteReport(cwrImport.Report, *reportPath)
+	if err != nil {
+		exit(err)
+	}
+	if !cwrImport.Report.Valid && !*allowErrors {
+		os.Exit(2)
+	}
+	err = payload.Write(cwrImport.Payloads, *out)
+	if err != nil {
+		exit(err)
+	}
+}
+
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, err.Error())
+	os.Exit(1)
+}
@@ -0,0 +1,63 @@
+/*
+Command dsrimport maps a DDEX DSR sales report flat file to generateExploitationReports chaincode payloads.
+
+	dsrimport -source spotify-IPI -batch 100 -out payloads/ -report report.json sales.tsv
+
+The payloads are written one per line to the standard output, or to batch-NNNN.json files of the -out
+directory, and the validation report to the standard error or the -report file. The command exits with
+status 2 when the report has errors, unless -allow-errors is set, in which case only valid sales are emitted.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"axispoint-cc/dsr"
+	"axispoint-cc/payload"
+)
+
+func main() {
+	source := flag.String("source", "", "IPI of the DSP on the ledger, the sender party id of the file by default")
+	batchSize := flag.Int("batch", dsr.DefaultBatchSize, "number of exploitation reports per payload")
+	out := flag.String("out", "", "directory the payloads are written to, the standard output by default")
+	reportPath := flag.String("report", "", "file the validation report is written to, the standard error by default")
+	allowErrors := flag.Bool("allow-errors", false, "emit the valid sales of a file with errors")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags] <dsr file>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	file, err := os.Open(flag.Arg(0))
+	if err != nil {
+		exit(err)
+	}
+	defer file.Close()
+
+	dsrImport, err := dsr.ImportFile(file, dsr.Options{Source: *source, BatchSize: *batchSize})
+	if err != nil {
+		exit(err)
+	}
+	err = payload.WriteReport(dsrImport.Report, *reportPath)
+	if err != nil {
+		exit(err)
+	}
+	if !dsrImport.Report.Valid && !*allowErrors {
+		os.Exit(2)
+	}
+	err = payload.Write(dsrImport.Payloads, *out)
+	if err != nil {
+		exit(err)
+	}
+}
+
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, err.Error())
+	os.Exit(1)
+}
@@ -0,0 +1,170 @@
+/*
+Command statementexport exports royalty statements to a CSV layout or to a DDEX Claim Detail (CRD) message.
+
+	statementexport -statements statements.json -period period.json -format crd -out claims.crd
+	statementexport -statements statements.json -columns "ISRC=isrc,Title=songTitle,Net=payable" -delimiter ";"
+	statementexport -period period.json
+
+The -statements file holds the output of getRoyaltyStatements, the -period file the output of
+getPeriodStatement, which adds the totals of the private statements of the caller. With both, only the
+statements of the period are exported and reconciled with its totals; with only -period, its totals are
+exported. The content is written to the standard output or the -out file, the manifest holding the totals and
+the content hash to the standard error or the -manifest file. The command exits with status 2 when the
+statements do not reconcile with the period, unless -allow-discrepancies is set.
+*/
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"axispoint-cc/export"
+)
+
+func main() {
+	statementsPath := flag.String("statements", "", "file of the royalty statements, - for the standard input")
+	periodPath := flag.String("period", "", "file of the period statement, - for the standard input")
+	format := flag.String("format", export.CSV, "format of the export, csv or crd")
+	layoutPath := flag.String("layout", "", "JSON file of the CSV layout, the default layout by default")
+	columns := flag.String("columns", "", "columns of the CSV layout, e.g. ISRC=isrc,Title=songTitle,payable")
+	delimiter := flag.String("delimiter", "", "delimiter of the CSV layout")
+	decimals := flag.Int("decimals", 0, "number of decimals of the amounts")
+	sender := flag.String("sender", "", "party id of the sender of a CRD message")
+	messageID := flag.String("message-id", "", "id of a CRD message, derived from its statements by default")
+	out := flag.String("out", "", "file the export is written to, the standard output by default")
+	manifestPath := flag.String("manifest", "", "file the manifest is written to, the standard error by default")
+	allowDiscrepancies := flag.Bool("allow-discrepancies", false, "export statements that do not reconcile with the period")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 0 || (*statementsPath == "" && *periodPath == "") || (*statementsPath == "-" && *periodPath == "-") {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	layout, err := getLayout(*layoutPath, *columns, *delimiter, *decimals)
+	if err != nil {
+		exit(err)
+	}
+	options := export.Options{Format: *format, Layout: layout, Decimals: *decimals, Sender: *sender, MessageID: *messageID}
+
+	var royaltyStatements []export.RoyaltyStatement
+	if *statementsPath != "" {
+		err = readFile(*statementsPath, func(reader io.Reader) error {
+			royaltyStatements, err = export.ReadStatements(reader)
+			return err
+		})
+		if err != nil {
+			exit(err)
+		}
+	}
+	var periodStatement *export.PeriodStatement
+	if *periodPath != "" {
+		err = readFile(*periodPath, func(reader io.Reader) error {
+			periodStatement, err = export.ReadPeriodStatement(reader)
+			return err
+		})
+		if err != nil {
+			exit(err)
+		}
+	}
+
+	var statementExport *export.Export
+	switch {
+	case periodStatement == nil:
+		statementExport, err = export.Statements(royaltyStatements, options)
+	case royaltyStatements == nil:
+		statementExport, err = export.PeriodSummary(*periodStatement, options)
+	default:
+		statementExport, err = export.Period(*periodStatement, royaltyStatements, options)
+	}
+	if err != nil {
+		exit(err)
+	}
+
+	err = writeManifest(statementExport.Manifest, *manifestPath)
+	if err != nil {
+		exit(err)
+	}
+	if len(statementExport.Manifest.Discrepancies) > 0 && !*allowDiscrepancies {
+		os.Exit(2)
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(statementExport.Content)
+	} else {
+		err = ioutil.WriteFile(*out, statementExport.Content, 0644)
+	}
+	if err != nil {
+		exit(err)
+	}
+}
+
+//getLayout - returns the CSV layout of a layout file, or of the default layout, with the columns, the delimiter
+// and the decimals of the flags
+func getLayout(layoutPath string, columns string, delimiter string, decimals int) (*export.Layout, error) {
+	layout := export.DefaultLayout
+	if layoutPath != "" {
+		err := readFile(layoutPath, func(reader io.Reader) error {
+			layoutFile, err := export.ReadLayout(reader)
+			if err == nil {
+				layout = *layoutFile
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if columns != "" {
+		parsedColumns, err := export.ParseColumns(columns)
+		if err != nil {
+			return nil, err
+		}
+		layout.Name = "custom"
+		layout.Columns = parsedColumns
+	}
+	if delimiter != "" {
+		layout.Delimiter = delimiter
+	}
+	if decimals > 0 {
+		layout.Decimals = decimals
+	}
+	return &layout, layout.Validate()
+}
+
+//readFile - reads a file, or the standard input for -
+func readFile(path string, read func(io.Reader) error) error {
+	if path == "-" {
+		return read(os.Stdin)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return read(file)
+}
+
+//writeManifest - writes the manifest to a file or to the standard error
+func writeManifest(manifest export.Manifest, manifestPath string) error {
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if manifestPath == "" {
+		_, err = fmt.Fprintln(os.Stderr, string(manifestBytes))
+		return err
+	}
+	return ioutil.WriteFile(manifestPath, append(manifestBytes, '\n'), 0644)
+}
+
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, err.Error())
+	os.Exit(1)
+}
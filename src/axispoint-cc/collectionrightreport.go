@@ -2,9 +2,11 @@ package main
 
 import (
 	"fmt"
-	"reflect"
+	"math"
+	"sort"
 	"strings"
 
+	"github.com/Knetic/govaluate"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
@@ -42,6 +44,8 @@ func addCollectionRights(stub shim.ChaincodeStubInterface, args []string) pb.Res
 	collectionRightsOutput := CollectionRightsOutput{}
 	collectionRights := &[]CollectionRight{}
 	collectionRightsResponses := []CollectionRightsResponse{}
+	// rights written by this transaction are not returned by rich queries yet
+	pendingCollectionRights := map[string]CollectionRight{}
 
 	// Unmarshal the args input to an array of royalty statement records
 	err := jsonToObject([]byte(args[0]), collectionRights)
@@ -56,6 +60,25 @@ func addCollectionRights(stub shim.ChaincodeStubInterface, args []string) pb.Res
 		collectionRightsResponse.CollectionRightUUID = collectionRight.CollectionRightUUID
 		collectionRightsResponse.Success = true
 
+		// reject the collection right when its IPIs, territories or fee terms are invalid or when it would create a collection loop
+		err = normalizeCollectionRightIpis(&collectionRight)
+		if err == nil {
+			err = validateTerritories(stub, collectionRight.Territories, collectionRight.ExcludedTerritories)
+		}
+		if err == nil {
+			err = validateFeeTerms(collectionRight.Fee)
+		}
+		if err == nil {
+			err = checkCollectionRightCycle(stub, collectionRight, pendingCollectionRights)
+		}
+		if err != nil {
+			collectionRightsResponse.Success = false
+			collectionRightsResponse.Message = err.Error()
+			collectionRightsResponses = append(collectionRightsResponses, collectionRightsResponse)
+			collectionRightsOutput.FailureCount++
+			continue
+		}
+
 		// check if royalty statement already exists
 		collectionRightExistingBytes, err := stub.GetState(collectionRight.CollectionRightUUID)
 		if collectionRightExistingBytes != nil {
@@ -84,6 +107,7 @@ func addCollectionRights(stub shim.ChaincodeStubInterface, args []string) pb.Res
 		}
 
 		if collectionRightsResponse.Success {
+			pendingCollectionRights[collectionRight.CollectionRightUUID] = collectionRight
 			collectionRightsOutput.SuccessCount++
 		} else {
 			collectionRightsResponses = append(collectionRightsResponses, collectionRightsResponse)
@@ -157,6 +181,8 @@ func updateCollectionRights(stub shim.ChaincodeStubInterface, args []string) pb.
 	collectionRightsOutput := CollectionRightsOutput{}
 	collectionRights := &[]CollectionRight{}
 	collectionRightsResponses := []CollectionRightsResponse{}
+	// rights written by this transaction are not returned by rich queries yet
+	pendingCollectionRights := map[string]CollectionRight{}
 
 	// Unmarshal the args input to an array of collection rights
 	err := jsonToObject([]byte(args[0]), collectionRights)
@@ -171,6 +197,25 @@ func updateCollectionRights(stub shim.ChaincodeStubInterface, args []string) pb.
 		collectionRightsResponse.CollectionRightUUID = collectionRight.CollectionRightUUID
 		collectionRightsResponse.Success = true
 
+		// reject the collection right when its IPIs, territories or fee terms are invalid or when it would create a collection loop
+		err = normalizeCollectionRightIpis(&collectionRight)
+		if err == nil {
+			err = validateTerritories(stub, collectionRight.Territories, collectionRight.ExcludedTerritories)
+		}
+		if err == nil {
+			err = validateFeeTerms(collectionRight.Fee)
+		}
+		if err == nil {
+			err = checkCollectionRightCycle(stub, collectionRight, pendingCollectionRights)
+		}
+		if err != nil {
+			collectionRightsResponse.Success = false
+			collectionRightsResponse.Message = err.Error()
+			collectionRightsResponses = append(collectionRightsResponses, collectionRightsResponse)
+			collectionRightsOutput.FailureCount++
+			continue
+		}
+
 		// check if collectionRights already exists
 		collectionRightExistingBytes, err := stub.GetState(collectionRight.CollectionRightUUID)
 		if collectionRightExistingBytes == nil {
@@ -199,6 +244,7 @@ func updateCollectionRights(stub shim.ChaincodeStubInterface, args []string) pb.
 		}
 
 		if collectionRightsResponse.Success {
+			pendingCollectionRights[collectionRight.CollectionRightUUID] = collectionRight
 			collectionRightsOutput.SuccessCount++
 		} else {
 			collectionRightsResponses = append(collectionRightsResponses, collectionRightsResponse)
@@ -229,7 +275,10 @@ func generateCollectionStatement(stub shim.ChaincodeStubInterface, args []string
 	exploitationReport := ExploitationReport{}
 	previousRoyaltyStatement := RoyaltyStatement{}
 	royaltyStatementUUID := args[0]
-	targetIPI := args[1]
+	targetIPI, err := normalizeIpi(args[1])
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
 	collectionType := args[2]
 	royaltyStatement := RoyaltyStatement{}
 
@@ -241,6 +290,9 @@ func generateCollectionStatement(stub shim.ChaincodeStubInterface, args []string
 		return getErrorResponse(errMessage)
 	}
 	err = jsonToObject(royaltyStatementExistingBytes, &previousRoyaltyStatement)
+	if err == nil {
+		err = mergeRoyaltyStatementPrivateDetails(stub, &previousRoyaltyStatement)
+	}
 	if err != nil {
 		errMessage = fmt.Sprintf("%s - Failed to convert royalty statement with uuid '%s'.  Error: %s", methodName, royaltyStatementUUID, err.Error())
 		logger.Error(errMessage)
@@ -265,6 +317,7 @@ func generateCollectionStatement(stub shim.ChaincodeStubInterface, args []string
 
 	// create exploitation report parameters to evaluate the selector expressions
 	exploitationReportParameters, _ := getEvaluableParameters(&exploitationReport)
+	exploitationReportParameters["Role"] = previousRoyaltyStatement.RightHolderRole
 
 	//1. setup the base royalty statement
 	royaltyStatement.DocType = ROYALTYSTATEMENT
@@ -277,59 +330,54 @@ func generateCollectionStatement(stub shim.ChaincodeStubInterface, args []string
 	royaltyStatement.Units = exploitationReport.Units
 	royaltyStatement.Territory = exploitationReport.Territory
 	royaltyStatement.UsageType = exploitationReport.UsageType
+	royaltyStatement.Currency = exploitationReport.Currency
 	royaltyStatement.Administrator = ""
 	royaltyStatement.Collector = ""
 	royaltyStatement.Amount = previousRoyaltyStatement.Amount
+	royaltyStatement.RightHolderRole = previousRoyaltyStatement.RightHolderRole
 	logger.Infof("%s - struct value : %+v\n", methodName, royaltyStatement)
 
-	//2. Get all potential collectionrights  that we have based on the 'From' field matching the target IPI.
-	collectionRights, err := getCollectionRightsMatchingIpi(stub, targetIPI)
+	//2. Get the collectionrights in effect for the exploitation whose 'From' field matches the target IPI, by priority.
+	collectionRights, err := getCollectionRightsMatchingIpi(stub, targetIPI, exploitationReport)
 	if err != nil {
 		errMessage = fmt.Sprintf("%s - Failed to get collection rights matching target IPI '%s'.  Error: %s", methodName, targetIPI, err.Error())
 		logger.Error(errMessage)
 		return getErrorResponse(errMessage)
 	}
 
-	isSelectorValid := false
-
 	//3.  evaluate every single selector within [collectionRights.rightHolder] until we find the 'FIRST' one that works
-	//we should idealy only get one match
-collectionRightsLoop:
-	for _, collectionRight := range collectionRights {
-		//evaluate the rule
-		for _, rightHolder := range collectionRight.RightHolders {
-
-			if rightHolder.Selector == "" {
-				isSelectorValid = true
-			} else {
-				isSelectorValidResult, err := evaluate(rightHolder.Selector, exploitationReportParameters)
-				if err != nil {
-					logger.Errorf("%s - Failed to get a valid evaluator for right holder ipi %s, selector %s. Error: %s", methodName, rightHolder.IPI, rightHolder.Selector, err.Error())
-				}
-				//to prevent a crash when test cases are run.
-				if reflect.ValueOf(isSelectorValidResult).IsValid() {
-					isSelectorValid = isSelectorValidResult.(bool)
-				}
-			}
-			if isSelectorValid == true {
-				if collectionType == OWNERSHIP {
-					royaltyStatement.RightHolder = targetIPI
-					royaltyStatement.Administrator = rightHolder.IPI
-					royaltyStatement.RightType = COLLECTION
-					royaltyStatement.CollectionRightPercent = rightHolder.Percent / 100
-					royaltyStatement.CollectionRight = royaltyStatement.Amount * royaltyStatement.CollectionRightPercent
-				}
-				if collectionType == COLLECTION {
-					royaltyStatement.Administrator = targetIPI
-					royaltyStatement.Collector = rightHolder.IPI
-					royaltyStatement.RightType = COLLECTION
-					royaltyStatement.CollectionRightPercent = rightHolder.Percent / 100
-					royaltyStatement.CollectionRight = royaltyStatement.Amount * royaltyStatement.CollectionRightPercent
-					royaltyStatement.RightHolder = previousRoyaltyStatement.RightHolder
-				}
+	collectionRight, rightHolder, isSelectorValid := getMatchingCollectionRight(collectionRights, exploitationReportParameters, getSelectorFunctions(stub))
+	if isSelectorValid == true {
+		if collectionType == OWNERSHIP {
+			royaltyStatement.RightHolder = targetIPI
+			royaltyStatement.Administrator = rightHolder.IPI
+			royaltyStatement.RightType = COLLECTION
+			royaltyStatement.CollectionRightPercent = rightHolder.Percent / 100
+			royaltyStatement.CollectionRight = royaltyStatement.Amount * royaltyStatement.CollectionRightPercent
+		}
+		if collectionType == COLLECTION {
+			royaltyStatement.Administrator = targetIPI
+			royaltyStatement.Collector = rightHolder.IPI
+			royaltyStatement.RightType = COLLECTION
+			royaltyStatement.CollectionRightPercent = rightHolder.Percent / 100
+			royaltyStatement.CollectionRight = royaltyStatement.Amount * royaltyStatement.CollectionRightPercent
+			royaltyStatement.RightHolder = previousRoyaltyStatement.RightHolder
+		}
+		// the collector keeps its fee from the gross and the statement is for the net
+		applyCollectionFee(&royaltyStatement, collectionRight.Fee)
+		royaltyStatement.CollectionRightUUID = collectionRight.CollectionRightUUID
+		royaltyStatement.ParentStatementUUID = previousRoyaltyStatement.RoyaltyStatementUUID
+		royaltyStatement.Lineage = append(append([]string{}, previousRoyaltyStatement.Lineage...), previousRoyaltyStatement.RoyaltyStatementUUID)
 
-				break collectionRightsLoop
-			}
+		// the hop may already be booked by resolveCollectionChain
+		collectedUUID, err := getCollectedStatement(stub, royaltyStatement.ParentStatementUUID, royaltyStatement.CollectionRightUUID)
+		if err != nil {
+			return getErrorResponse(err.Error())
+		}
+		if collectedUUID != "" {
+			errMessage = fmt.Sprintf("%s - Royalty statement '%s' is already collected with collection right '%s' by royalty statement '%s'", methodName, royaltyStatementUUID, royaltyStatement.CollectionRightUUID, collectedUUID)
+			logger.Error(errMessage)
+			return getErrorResponse(errMessage)
 		}
 	}
 	//we found atleast 1 matching rule above so return the royaltyStatement from above step.
@@ -341,6 +389,13 @@ collectionRightsLoop:
 		royaltyStatement.RightType = COLLECTION
 		royaltyStatement.RightHolder = previousRoyaltyStatement.RightHolder
 	}
+	// the tax of the territory of the exploitation is withheld from the payee
+	err = applyWithholdingTax(stub, &royaltyStatement)
+	if err != nil {
+		errMessage = fmt.Sprintf("%s - Failed to apply the withholding tax to royalty statement '%s'.  Error: %s", methodName, royaltyStatement.RoyaltyStatementUUID, err.Error())
+		logger.Error(errMessage)
+		return getErrorResponse(errMessage)
+	}
 	//return the royaltyStatement
 	objResultBytes, err := objectToJSON(royaltyStatement)
 	if err != nil {
@@ -352,8 +407,245 @@ collectionRightsLoop:
 	return shim.Success(objResultBytes)
 }
 
-//getCollectionRightsMatchingIpi Get all potential collectionrights  that we have based on the 'From' field matching the target IPI.
-func getCollectionRightsMatchingIpi(stub shim.ChaincodeStubInterface, targetIPI string) ([]CollectionRight, error) {
+/* resolveCollectionChain function contains business logic to resolve every hop collecting the amount
+of a royalty statement in one call, e.g. writer -> publisher -> sub-publisher -> local society. Each hop
+matches the first collection right in effect on the exploitation date whose selector holds for the
+exploitation report, and collects its percent of the amount collected by the previous hop. A statement is
+collected once with each collection right, so hops already recorded, e.g. from generateCollectionStatement, are left
+out of the chain and the next hop collects from the recorded statement.
+* @params   {Array} args
+* @property {string} 0       - uuid of the royalty statement to collect.
+* @property {string} 1       - optional, "true" to record the royalty statements of the chain on the ledger.
+* @return   {pb.Response}    - peer Response
+*/
+func resolveCollectionChain(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "resolveCollectionChain"
+	logger.Info("ENTERING >", methodName, args)
+	var errMessage string
+
+	if len(args) < 1 {
+		errMessage = fmt.Sprintf("%s - Incorrect number of parameters provided '%d'.  Operation cannot continue", methodName, len(args))
+		logger.Error(errMessage)
+		return getErrorResponse(errMessage)
+	}
+	royaltyStatementUUID := args[0]
+	persist := len(args) > 1 && args[1] == "true"
+
+	rootRoyaltyStatement := RoyaltyStatement{}
+	royaltyStatementExistingBytes, err := stub.GetState(royaltyStatementUUID)
+	if err != nil || royaltyStatementExistingBytes == nil {
+		errMessage = fmt.Sprintf("%s - Royalty statement '%s' does not exist!", methodName, royaltyStatementUUID)
+		logger.Error(errMessage)
+		return getErrorResponse(errMessage)
+	}
+	err = jsonToObject(royaltyStatementExistingBytes, &rootRoyaltyStatement)
+	if err == nil {
+		err = mergeRoyaltyStatementPrivateDetails(stub, &rootRoyaltyStatement)
+	}
+	if err != nil {
+		errMessage = fmt.Sprintf("%s - Failed to convert royalty statement with uuid '%s'.  Error: %s", methodName, royaltyStatementUUID, err.Error())
+		logger.Error(errMessage)
+		return getErrorResponse(errMessage)
+	}
+
+	exploitationReport := ExploitationReport{}
+	exploitationReportExistingBytes, err := stub.GetState(rootRoyaltyStatement.ExploitationReportUUID)
+	if err == nil {
+		err = jsonToObject(exploitationReportExistingBytes, &exploitationReport)
+	}
+	if err != nil {
+		errMessage = fmt.Sprintf("%s - Failed to get exploitation report with uuid '%s' from the ledger.  Error: %s", methodName, rootRoyaltyStatement.ExploitationReportUUID, err.Error())
+		logger.Error(errMessage)
+		return getErrorResponse(errMessage)
+	}
+
+	collectionChain := CollectionChain{RoyaltyStatementUUID: royaltyStatementUUID, Persisted: persist}
+	collectionChain.RoyaltyStatements, err = getCollectionChain(stub, rootRoyaltyStatement, exploitationReport)
+	if err != nil {
+		errMessage = fmt.Sprintf("%s - Failed to resolve the collection chain of royalty statement '%s'.  Error: %s", methodName, royaltyStatementUUID, err.Error())
+		logger.Error(errMessage)
+		return getErrorResponse(errMessage)
+	}
+
+	if persist {
+		pendingAdvances := map[string]Advance{}
+		for i := range collectionChain.RoyaltyStatements {
+			royaltyStatement := &collectionChain.RoyaltyStatements[i]
+			royaltyStatementExistingBytes, _ := stub.GetState(royaltyStatement.RoyaltyStatementUUID)
+			if royaltyStatementExistingBytes != nil {
+				return getErrorResponse(fmt.Sprintf("%s - Royalty statement '%s' of the collection chain already exists!", methodName, royaltyStatement.RoyaltyStatementUUID))
+			}
+			// the advances of the parties of a hop are recouped from it
+			recoupments, err := recoupRoyaltyStatement(stub, royaltyStatement, pendingAdvances)
+			if err == nil {
+				err = putRoyaltyStatement(stub, *royaltyStatement)
+			}
+			if err == nil {
+				err = updateRoyaltyStatementBalances(stub, nil, royaltyStatement)
+			}
+			if err == nil {
+				err = putRecoupedAdvances(stub, *royaltyStatement, recoupments, pendingAdvances)
+			}
+			if err != nil {
+				return getErrorResponse(fmt.Sprintf("%s - Failed to record royalty statement '%s'.  Error: %s", methodName, royaltyStatement.RoyaltyStatementUUID, err.Error()))
+			}
+		}
+	}
+
+	objBytes, err := objectToJSON(collectionChain)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	logger.Info("EXITING <", methodName, royaltyStatementUUID, len(collectionChain.RoyaltyStatements))
+	return shim.Success(objBytes)
+}
+
+//getCollectionChain - walks the collection rights from the payee of a royalty statement and returns a royalty statement per hop,
+//followed by the statement of the fee kept at the hop if any. Only the net of a hop passes to the next one. A hop already collected
+//by a recorded statement is left out and the walk continues from that statement. The walk stops when no collection right matches, when an IPI would collect twice or after MAX_COLLECTION_CHAIN_HOPS hops.
+func getCollectionChain(stub shim.ChaincodeStubInterface, rootRoyaltyStatement RoyaltyStatement, exploitationReport ExploitationReport) ([]RoyaltyStatement, error) {
+	var methodName = "getCollectionChain"
+	royaltyStatements := []RoyaltyStatement{}
+
+	exploitationReportParameters, _ := getEvaluableParameters(&exploitationReport)
+	exploitationReportParameters["Role"] = rootRoyaltyStatement.RightHolderRole
+	selectorFunctions := getSelectorFunctions(stub)
+	payer, payee, amount := getRoyaltyStatementParties(rootRoyaltyStatement)
+	if payee == "" {
+		return royaltyStatements, nil
+	}
+	collectingIpis := map[string]bool{payer: true, payee: true}
+	lineage := append(append([]string{}, rootRoyaltyStatement.Lineage...), rootRoyaltyStatement.RoyaltyStatementUUID)
+	previousRoyaltyStatement := rootRoyaltyStatement
+
+	for hop := 1; hop <= MAX_COLLECTION_CHAIN_HOPS; hop++ {
+		collectionRights, err := getCollectionRightsMatchingIpi(stub, payee, exploitationReport)
+		if err != nil {
+			return nil, err
+		}
+		collectionRight, rightHolder, found := getMatchingCollectionRight(collectionRights, exploitationReportParameters, selectorFunctions)
+		if !found {
+			break
+		}
+		if collectingIpis[rightHolder.IPI] {
+			logger.Warningf("%s - '%s' already collects in the chain of royalty statement '%s', the chain stops at '%s'", methodName, rightHolder.IPI, rootRoyaltyStatement.RoyaltyStatementUUID, payee)
+			break
+		}
+		collectingIpis[rightHolder.IPI] = true
+
+		// a hop already booked from generateCollectionStatement is not booked again, the chain continues from it
+		collectedUUID, err := getCollectedStatement(stub, previousRoyaltyStatement.RoyaltyStatementUUID, collectionRight.CollectionRightUUID)
+		if err != nil {
+			return nil, err
+		}
+		if collectedUUID != "" {
+			collectedRoyaltyStatement := RoyaltyStatement{}
+			collectedRoyaltyStatementBytes, err := stub.GetState(collectedUUID)
+			if err == nil && collectedRoyaltyStatementBytes == nil {
+				err = fmt.Errorf("Royalty statement '%s' collecting '%s' does not exist", collectedUUID, previousRoyaltyStatement.RoyaltyStatementUUID)
+			}
+			if err == nil {
+				err = jsonToObject(collectedRoyaltyStatementBytes, &collectedRoyaltyStatement)
+			}
+			if err == nil {
+				err = mergeRoyaltyStatementPrivateDetails(stub, &collectedRoyaltyStatement)
+			}
+			if err != nil {
+				return nil, err
+			}
+			lineage = append(lineage, collectedUUID)
+			previousRoyaltyStatement = collectedRoyaltyStatement
+			payee = rightHolder.IPI
+			amount = collectedRoyaltyStatement.CollectionRight
+			continue
+		}
+
+		royaltyStatement := RoyaltyStatement{}
+		royaltyStatement.DocType = ROYALTYSTATEMENT
+		royaltyStatement.RoyaltyStatementUUID = fmt.Sprintf("%s-%d", rootRoyaltyStatement.RoyaltyStatementUUID, hop)
+		royaltyStatement.ExploitationReportUUID = rootRoyaltyStatement.ExploitationReportUUID
+		royaltyStatement.Source = rootRoyaltyStatement.Source
+		royaltyStatement.Isrc = rootRoyaltyStatement.Isrc
+		royaltyStatement.Iswc = rootRoyaltyStatement.Iswc
+		royaltyStatement.SongTitle = rootRoyaltyStatement.SongTitle
+		royaltyStatement.WriterName = rootRoyaltyStatement.WriterName
+		royaltyStatement.Units = rootRoyaltyStatement.Units
+		royaltyStatement.ExploitationDate = rootRoyaltyStatement.ExploitationDate
+		royaltyStatement.Territory = rootRoyaltyStatement.Territory
+		royaltyStatement.UsageType = rootRoyaltyStatement.UsageType
+		royaltyStatement.Currency = rootRoyaltyStatement.Currency
+		royaltyStatement.RightType = COLLECTION
+		royaltyStatement.RightHolder = rootRoyaltyStatement.RightHolder
+		royaltyStatement.RightHolderRole = rootRoyaltyStatement.RightHolderRole
+		if previousRoyaltyStatement.Administrator == "" {
+			// the right holder is collected by its administrator
+			royaltyStatement.Administrator = rightHolder.IPI
+		} else {
+			// the administrator is collected by the next collector
+			royaltyStatement.Administrator = payee
+			royaltyStatement.Collector = rightHolder.IPI
+		}
+		royaltyStatement.Amount = amount
+		royaltyStatement.CollectionRightPercent = rightHolder.Percent / 100
+		royaltyStatement.CollectionRight = royaltyStatement.Amount * royaltyStatement.CollectionRightPercent
+		royaltyStatement.CollectionRightUUID = collectionRight.CollectionRightUUID
+		royaltyStatement.ParentStatementUUID = previousRoyaltyStatement.RoyaltyStatementUUID
+		royaltyStatement.Lineage = append([]string{}, lineage...)
+		applyCollectionFee(&royaltyStatement, collectionRight.Fee)
+		err = applyWithholdingTax(stub, &royaltyStatement)
+		if err != nil {
+			return nil, err
+		}
+		royaltyStatements = append(royaltyStatements, royaltyStatement)
+		if royaltyStatement.FeeAmount > 0 {
+			feeStatement := getCollectionFeeStatement(royaltyStatement)
+			err = applyWithholdingTax(stub, &feeStatement)
+			if err != nil {
+				return nil, err
+			}
+			royaltyStatements = append(royaltyStatements, feeStatement)
+		}
+
+		lineage = append(lineage, royaltyStatement.RoyaltyStatementUUID)
+		previousRoyaltyStatement = royaltyStatement
+		payee = rightHolder.IPI
+		amount = royaltyStatement.CollectionRight
+	}
+	return royaltyStatements, nil
+}
+
+//getMatchingCollectionRight - returns the first collection right with a right holder whose selector holds and that right holder
+func getMatchingCollectionRight(collectionRights []CollectionRight, parameters map[string]interface{}, functions map[string]govaluate.ExpressionFunction) (CollectionRight, RightHolder, bool) {
+	for _, collectionRight := range collectionRights {
+		for _, rightHolder := range collectionRight.RightHolders {
+			if rightHolder.Selector == "" {
+				return collectionRight, rightHolder, true
+			}
+			isSelectorValidResult, err := evaluateWithFunctions(rightHolder.Selector, parameters, functions)
+			if err != nil {
+				logger.Errorf("Failed to get a valid evaluator for right holder ipi %s, selector %s. Error: %s", rightHolder.IPI, rightHolder.Selector, err.Error())
+				continue
+			}
+			if isSelectorValid, ok := isSelectorValidResult.(bool); ok && isSelectorValid {
+				return collectionRight, rightHolder, true
+			}
+		}
+	}
+	return CollectionRight{}, RightHolder{}, false
+}
+
+/*
+* getCollectionRightsMatchingIpi function returns the collection rights whose 'From' field matches the target IPI
+* and that are in effect for the exploitation, i.e. on its date and in its territory, ordered by priority.
+* Collection right dates are not always zero padded, e.g. 2010-12-1, so they are compared once parsed
+* rather than in the rich query selector.
+*
+* @param    {string}             - target IPI
+* @param    {ExploitationReport} - exploitation report being collected
+* @return   {[]CollectionRight}  - collection rights by priority
+* @return   {error}              - Error
+ */
+func getCollectionRightsMatchingIpi(stub shim.ChaincodeStubInterface, targetIPI string, exploitationReport ExploitationReport) ([]CollectionRight, error) {
 
 	var methodName = "getCollectionRightsMatchingIpi"
 	logger.Infof("%s - Begin Execution ", methodName)
@@ -375,7 +667,432 @@ func getCollectionRightsMatchingIpi(stub shim.ChaincodeStubInterface, targetIPI
 	if err != nil {
 		return nil, fmt.Errorf("%s - Failed to convert bytes to collection rights object for target IPI '%s' .  Error: %s", methodName, targetIPI, err.Error())
 	}
-	logger.Infof("%s - retrieved %d collection right reports matching target IPI '%s'.", methodName, len(resultCollectionRights), targetIPI)
-	return resultCollectionRights, nil
 
+	collectionRights := []CollectionRight{}
+	for _, collectionRight := range resultCollectionRights {
+		if isCollectionRightInEffect(stub, collectionRight, exploitationReport.ExploitationDate, exploitationReport.Territory) {
+			collectionRights = append(collectionRights, collectionRight)
+		}
+	}
+	sortCollectionRightsByPriority(collectionRights)
+
+	logger.Infof("%s - retrieved %d collection right reports matching target IPI '%s', %d in effect.", methodName, len(resultCollectionRights), targetIPI, len(collectionRights))
+	return collectionRights, nil
+
+}
+
+//isCollectionRightInEffect - returns whether a collection right applies on the exploitation date and in the exploitation territory
+func isCollectionRightInEffect(stub shim.ChaincodeStubInterface, collectionRight CollectionRight, exploitationDate string, exploitationTerritory string) bool {
+	if !isDateInEffect(collectionRight.StartDate, collectionRight.EndDate, exploitationDate) {
+		return false
+	}
+	if !isTerritorialCollectionRight(collectionRight) {
+		return true
+	}
+	return isTerritoryInArea(stub, exploitationTerritory, collectionRight.Territories, collectionRight.ExcludedTerritories)
+}
+
+//isTerritorialCollectionRight - returns whether a collection right is limited to territories rather than worldwide
+func isTerritorialCollectionRight(collectionRight CollectionRight) bool {
+	return len(collectionRight.Territories) > 0 || len(collectionRight.ExcludedTerritories) > 0
+}
+
+/*
+* sortCollectionRightsByPriority function orders the collection rights in effect for an exploitation so the first
+* one with a matching right holder collects:
+*   1. the lowest explicit priority, rights without a priority come after all prioritized rights
+*   2. rights limited to territories before worldwide rights
+*   3. the latest start date, i.e. the most recent deal
+*   4. the collection right uuid, so the order never depends on the order of the query results
+ */
+func sortCollectionRightsByPriority(collectionRights []CollectionRight) {
+	sort.SliceStable(collectionRights, func(i, j int) bool {
+		a, b := collectionRights[i], collectionRights[j]
+		if a.Priority != b.Priority {
+			if a.Priority == 0 || b.Priority == 0 {
+				return b.Priority == 0
+			}
+			return a.Priority < b.Priority
+		}
+		aTerritory, bTerritory := isTerritorialCollectionRight(a), isTerritorialCollectionRight(b)
+		if aTerritory != bTerritory {
+			return aTerritory
+		}
+		aStartDate, _ := parseDate(a.StartDate, false)
+		bStartDate, _ := parseDate(b.StartDate, false)
+		if !aStartDate.Equal(bStartDate) {
+			return aStartDate.After(bStartDate)
+		}
+		return a.CollectionRightUUID < b.CollectionRightUUID
+	})
+}
+
+/* getCollectionGraph function contains business logic to get the network of who collects for whom around
+an IPI. Downstream edges go from the IPI to the IPIs collecting for it, and on to the IPIs collecting for
+them, upstream edges lead to the IPI from the IPIs it collects for.
+* @params   {Array} args
+* @property {string} 0       - IPI
+* @property {string} 1       - optional, only the collection rights in effect on this date.
+* @return   {pb.Response}    - peer Response
+*/
+func getCollectionGraph(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "getCollectionGraph"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) < 1 || len(args) > 2 {
+		return getErrorResponse(fmt.Sprintf("%s - Incorrect number of parameters provided '%d'. Needed IPI and optional as of date", methodName, len(args)))
+	}
+	collectionGraph := CollectionGraph{Ipi: args[0]}
+	if len(args) == 2 {
+		collectionGraph.AsOfDate = args[1]
+		_, err := parseDate(collectionGraph.AsOfDate, false)
+		if err != nil {
+			return getErrorResponse(fmt.Sprintf("%s - %s", methodName, err.Error()))
+		}
+	}
+
+	var err error
+	collectionGraph.Downstream, err = getCollectionGraphEdges(stub, collectionGraph.Ipi, collectionGraph.AsOfDate, false)
+	if err == nil {
+		collectionGraph.Upstream, err = getCollectionGraphEdges(stub, collectionGraph.Ipi, collectionGraph.AsOfDate, true)
+	}
+	if err != nil {
+		return getErrorResponse(fmt.Sprintf("%s - Failed to get the collection graph of '%s'.  Error: %s", methodName, collectionGraph.Ipi, err.Error()))
+	}
+
+	objBytes, err := objectToJSON(collectionGraph)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	logger.Info("EXITING <", methodName, len(collectionGraph.Downstream), len(collectionGraph.Upstream))
+	return shim.Success(objBytes)
+}
+
+//getCollectionGraphEdges - walks the collection rights from an IPI breadth first, each IPI is only walked once so loops end
+func getCollectionGraphEdges(stub shim.ChaincodeStubInterface, ipi string, asOfDate string, upstream bool) ([]CollectionGraphEdge, error) {
+	edges := []CollectionGraphEdge{}
+	walked := map[string]bool{ipi: true}
+	ipis := []string{ipi}
+	for len(ipis) > 0 {
+		currentIpi := ipis[0]
+		ipis = ipis[1:]
+		collectionRights, err := getCollectionRightsForIpi(stub, currentIpi, upstream, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, collectionRight := range collectionRights {
+			if asOfDate != "" && !isDateInEffect(collectionRight.StartDate, collectionRight.EndDate, asOfDate) {
+				continue
+			}
+			for _, rightHolder := range collectionRight.RightHolders {
+				if upstream && rightHolder.IPI != currentIpi {
+					continue
+				}
+				edges = append(edges, getCollectionGraphEdge(collectionRight, rightHolder))
+				nextIpi := rightHolder.IPI
+				if upstream {
+					nextIpi = collectionRight.From
+				}
+				if !walked[nextIpi] {
+					walked[nextIpi] = true
+					ipis = append(ipis, nextIpi)
+				}
+			}
+		}
+	}
+	return edges, nil
+}
+
+//getCollectionGraphEdge - returns the edge of a right holder of a collection right
+func getCollectionGraphEdge(collectionRight CollectionRight, rightHolder RightHolder) CollectionGraphEdge {
+	edge := CollectionGraphEdge{}
+	edge.CollectionRightUUID = collectionRight.CollectionRightUUID
+	edge.From = collectionRight.From
+	edge.To = rightHolder.IPI
+	edge.Percent = rightHolder.Percent
+	edge.Selector = rightHolder.Selector
+	edge.StartDate = collectionRight.StartDate
+	edge.EndDate = collectionRight.EndDate
+	edge.Territories = collectionRight.Territories
+	edge.Fee = collectionRight.Fee
+	return edge
+}
+
+/*
+* getCollectionRightsForIpi function returns the collection rights from an IPI, or upstream the collection rights
+* with the IPI as a right holder. Rights written earlier in the transaction are not returned by rich queries
+* so the pending rights are used in place of their version on the ledger.
+*
+* @param    {string}                     - IPI
+* @param    {bool}                       - rights with the IPI as a right holder rather than from the IPI
+* @param    {map[string]CollectionRight} - rights written by the transaction by uuid, may be nil
+* @return   {[]CollectionRight}          - collection rights
+* @return   {error}                      - Error
+ */
+func getCollectionRightsForIpi(stub shim.ChaincodeStubInterface, ipi string, upstream bool, pendingCollectionRights map[string]CollectionRight) ([]CollectionRight, error) {
+	queryString := fmt.Sprintf(`{"selector":{"docType":"%s","from":"%s"}}`, COLLECTIONRIGHTREPORT, ipi)
+	if upstream {
+		queryString = fmt.Sprintf(`{"selector":{"docType":"%s","rightHolders":{"$elemMatch":{"ipi":"%s"}}}}`, COLLECTIONRIGHTREPORT, ipi)
+	}
+	queryResult, err := getCollectionRightsForQueryString(stub, queryString)
+	if err != nil {
+		return nil, err
+	}
+	var resultCollectionRights []CollectionRight
+	err = sliceToStruct(queryResult, &resultCollectionRights)
+	if err != nil {
+		return nil, err
+	}
+
+	collectionRights := []CollectionRight{}
+	for _, collectionRight := range resultCollectionRights {
+		if _, isPending := pendingCollectionRights[collectionRight.CollectionRightUUID]; !isPending && isCollectionRightOfIpi(collectionRight, ipi, upstream) {
+			collectionRights = append(collectionRights, collectionRight)
+		}
+	}
+	pendingCollectionRightUUIDs := []string{}
+	for collectionRightUUID := range pendingCollectionRights {
+		pendingCollectionRightUUIDs = append(pendingCollectionRightUUIDs, collectionRightUUID)
+	}
+	sort.Strings(pendingCollectionRightUUIDs)
+	for _, collectionRightUUID := range pendingCollectionRightUUIDs {
+		if isCollectionRightOfIpi(pendingCollectionRights[collectionRightUUID], ipi, upstream) {
+			collectionRights = append(collectionRights, pendingCollectionRights[collectionRightUUID])
+		}
+	}
+	return collectionRights, nil
+}
+
+//isCollectionRightOfIpi - returns whether a collection right is from an IPI, or upstream has the IPI as a right holder
+func isCollectionRightOfIpi(collectionRight CollectionRight, ipi string, upstream bool) bool {
+	if !upstream {
+		return collectionRight.From == ipi
+	}
+	for _, rightHolder := range collectionRight.RightHolders {
+		if rightHolder.IPI == ipi {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+* checkCollectionRightCycle function rejects a collection right that would create a collection loop, i.e. one of
+* its right holders already collects, directly or through other collectors, for the IPI the right is from. Only
+* rights whose dates overlap the dates of the new right are followed, a deal can revert once the other has ended.
+*
+* @param    {CollectionRight}            - collection right to add or update
+* @param    {map[string]CollectionRight} - rights written earlier in the transaction by uuid
+* @return   {error}                      - Error describing the loop
+ */
+func checkCollectionRightCycle(stub shim.ChaincodeStubInterface, collectionRight CollectionRight, pendingCollectionRights map[string]CollectionRight) error {
+	collectionRights := map[string]CollectionRight{collectionRight.CollectionRightUUID: collectionRight}
+	for collectionRightUUID, pendingCollectionRight := range pendingCollectionRights {
+		if collectionRightUUID != collectionRight.CollectionRightUUID {
+			collectionRights[collectionRightUUID] = pendingCollectionRight
+		}
+	}
+
+	visited := map[string]bool{}
+	for _, rightHolder := range collectionRight.RightHolders {
+		path, err := findCollectionPath(stub, rightHolder.IPI, collectionRight.From, collectionRight, collectionRights, visited)
+		if err != nil {
+			return fmt.Errorf("Failed to check collection right '%s' for collection loops.  Error: %s", collectionRight.CollectionRightUUID, err.Error())
+		}
+		if path != nil {
+			return fmt.Errorf("Collection right '%s' would create a collection loop: %s", collectionRight.CollectionRightUUID, strings.Join(append([]string{collectionRight.From}, path...), ", "))
+		}
+	}
+	return nil
+}
+
+//findCollectionPath - returns the IPIs collecting from one IPI to another over rights overlapping the dates of a collection right, or nil
+func findCollectionPath(stub shim.ChaincodeStubInterface, from string, to string, collectionRight CollectionRight, collectionRights map[string]CollectionRight, visited map[string]bool) ([]string, error) {
+	if from == to {
+		return []string{to}, nil
+	}
+	if visited[from] {
+		return nil, nil
+	}
+	visited[from] = true
+
+	nextCollectionRights, err := getCollectionRightsForIpi(stub, from, false, collectionRights)
+	if err != nil {
+		return nil, err
+	}
+	for _, nextCollectionRight := range nextCollectionRights {
+		if !isDateRangeOverlapping(nextCollectionRight.StartDate, nextCollectionRight.EndDate, collectionRight.StartDate, collectionRight.EndDate) {
+			continue
+		}
+		for _, rightHolder := range nextCollectionRight.RightHolders {
+			path, err := findCollectionPath(stub, rightHolder.IPI, to, collectionRight, collectionRights, visited)
+			if err != nil || path != nil {
+				return append([]string{from}, path...), err
+			}
+		}
+	}
+	return nil, nil
+}
+
+//validateFeeTerms - checks the fee terms of a collection right
+func validateFeeTerms(fee *FeeTerms) error {
+	if fee == nil {
+		return nil
+	}
+	switch fee.Type {
+	case PERCENT_FEE, FLAT_FEE, CAPPED_FEE:
+	default:
+		return fmt.Errorf("Invalid fee type '%s', expected one of %s, %s or %s", fee.Type, PERCENT_FEE, FLAT_FEE, CAPPED_FEE)
+	}
+	if fee.Percent < 0 || fee.Percent > 100 {
+		return fmt.Errorf("Invalid fee percent '%v', expected a value between 0 and 100", fee.Percent)
+	}
+	if fee.Amount < 0 {
+		return fmt.Errorf("Invalid fee amount '%v', expected a positive value", fee.Amount)
+	}
+	return nil
+}
+
+//getCollectionFee - returns the fee kept from a gross amount, never more than the gross
+func getCollectionFee(fee *FeeTerms, gross float64) float64 {
+	if fee == nil || gross <= 0 {
+		return 0
+	}
+	var feeAmount float64
+	switch fee.Type {
+	case PERCENT_FEE:
+		feeAmount = gross * fee.Percent / 100
+	case FLAT_FEE:
+		feeAmount = fee.Amount
+	case CAPPED_FEE:
+		feeAmount = math.Min(gross*fee.Percent/100, fee.Amount)
+	}
+	return math.Min(feeAmount, gross)
+}
+
+//applyCollectionFee - splits the collection right of a statement in gross, fee and net, the statement is then for the net
+func applyCollectionFee(royaltyStatement *RoyaltyStatement, fee *FeeTerms) {
+	if fee == nil {
+		return
+	}
+	royaltyStatement.GrossAmount = royaltyStatement.CollectionRight
+	royaltyStatement.FeeAmount = getCollectionFee(fee, royaltyStatement.GrossAmount)
+	royaltyStatement.NetAmount = royaltyStatement.GrossAmount - royaltyStatement.FeeAmount
+	royaltyStatement.CollectionRight = royaltyStatement.NetAmount
+}
+
+//getCollectedStatement - returns the UUID of the statement collecting a royalty statement with a collection right or an empty string
+func getCollectedStatement(stub shim.ChaincodeStubInterface, parentStatementUUID string, collectionRightUUID string) (string, error) {
+	collectedStatementKey, err := stub.CreateCompositeKey(COLLECTEDSTATEMENT, []string{parentStatementUUID, collectionRightUUID})
+	if err != nil {
+		return "", err
+	}
+	collectedStatementBytes, err := stub.GetState(collectedStatementKey)
+	if err != nil {
+		return "", err
+	}
+	return string(collectedStatementBytes), nil
+}
+
+//checkCollectedStatement - returns an error when another statement already collects the parent statement of a
+//collection statement with its collection right
+func checkCollectedStatement(stub shim.ChaincodeStubInterface, royaltyStatement RoyaltyStatement) error {
+	if royaltyStatement.RightType != COLLECTION || royaltyStatement.ParentStatementUUID == "" || royaltyStatement.CollectionRightUUID == "" {
+		return nil
+	}
+	collectedUUID, err := getCollectedStatement(stub, royaltyStatement.ParentStatementUUID, royaltyStatement.CollectionRightUUID)
+	if err != nil {
+		return err
+	}
+	if collectedUUID != "" && collectedUUID != royaltyStatement.RoyaltyStatementUUID {
+		return fmt.Errorf("Royalty statement '%s' is already collected with collection right '%s' by royalty statement '%s'", royaltyStatement.ParentStatementUUID, royaltyStatement.CollectionRightUUID, collectedUUID)
+	}
+	return nil
+}
+
+//putCollectedStatement - records that a collection statement collects its parent statement with its collection right,
+//unless another statement already does
+func putCollectedStatement(stub shim.ChaincodeStubInterface, royaltyStatement RoyaltyStatement) error {
+	if royaltyStatement.RightType != COLLECTION || royaltyStatement.ParentStatementUUID == "" || royaltyStatement.CollectionRightUUID == "" {
+		return nil
+	}
+	err := checkCollectedStatement(stub, royaltyStatement)
+	if err != nil {
+		return err
+	}
+	collectedStatementKey, err := stub.CreateCompositeKey(COLLECTEDSTATEMENT, []string{royaltyStatement.ParentStatementUUID, royaltyStatement.CollectionRightUUID})
+	if err != nil {
+		return err
+	}
+	return stub.PutState(collectedStatementKey, []byte(royaltyStatement.RoyaltyStatementUUID))
+}
+
+//getCollectionFeeStatement - returns the statement of the fee kept by the collecting party of a collection statement
+func getCollectionFeeStatement(royaltyStatement RoyaltyStatement) RoyaltyStatement {
+	feeStatement := royaltyStatement
+	feeStatement.RoyaltyStatementUUID = royaltyStatement.RoyaltyStatementUUID + "-fee"
+	feeStatement.RightType = FEE
+	feeStatement.CollectionRight = royaltyStatement.FeeAmount
+	feeStatement.CollectionRightPercent = 0
+	feeStatement.GrossAmount = 0
+	feeStatement.NetAmount = 0
+	feeStatement.RecoupedAmount = 0
+	feeStatement.UnrecoupedBalance = 0
+	feeStatement.AdvanceUUIDs = nil
+	feeStatement.TaxRuleUUID = ""
+	feeStatement.TaxRate = 0
+	feeStatement.TaxableAmount = 0
+	feeStatement.TaxWithheld = 0
+	feeStatement.NetPayable = 0
+	feeStatement.State = ""
+	feeStatement.PaymentState = ""
+	feeStatement.PaymentDate = ""
+	feeStatement.PeriodStatementUUID = ""
+	feeStatement.ParentStatementUUID = royaltyStatement.RoyaltyStatementUUID
+	feeStatement.Lineage = append(append([]string{}, royaltyStatement.Lineage...), royaltyStatement.RoyaltyStatementUUID)
+	return feeStatement
+}
+
+//checkCollectionFeeStatement - checks that the statement of the fee kept on a collection statement can be recorded
+func checkCollectionFeeStatement(stub shim.ChaincodeStubInterface, royaltyStatement RoyaltyStatement) error {
+	if royaltyStatement.RightType != COLLECTION || royaltyStatement.FeeAmount <= 0 {
+		return nil
+	}
+	feeStatement := getCollectionFeeStatement(royaltyStatement)
+	err := applyWithholdingTax(stub, &feeStatement)
+	if err != nil {
+		return err
+	}
+	return checkRoyaltyStatementWrite(stub, feeStatement)
+}
+
+//putCollectionFeeStatement - records the statement of the fee kept on a collection statement unless it is already recorded
+func putCollectionFeeStatement(stub shim.ChaincodeStubInterface, royaltyStatement RoyaltyStatement) error {
+	if royaltyStatement.RightType != COLLECTION || royaltyStatement.FeeAmount <= 0 {
+		return nil
+	}
+	feeStatement := getCollectionFeeStatement(royaltyStatement)
+	feeStatementExistingBytes, err := stub.GetState(feeStatement.RoyaltyStatementUUID)
+	if err != nil || feeStatementExistingBytes != nil {
+		return err
+	}
+	err = applyWithholdingTax(stub, &feeStatement)
+	if err != nil {
+		return err
+	}
+	err = putRoyaltyStatement(stub, feeStatement)
+	if err != nil {
+		return err
+	}
+	return updateRoyaltyStatementBalances(stub, nil, &feeStatement)
+}
+
+//normalizeCollectionRightIpis - normalizes the IPIs of a collection right
+func normalizeCollectionRightIpis(collectionRight *CollectionRight) error {
+	from, err := normalizeIpi(collectionRight.From)
+	if err != nil {
+		return err
+	}
+	collectionRight.From = from
+	return normalizeRightHolderIpis(collectionRight.RightHolders)
 }
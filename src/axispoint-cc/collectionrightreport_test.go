@@ -1,9 +1,12 @@
 package main
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
+	"axispoint-cc/memstub"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
 
@@ -11,7 +14,7 @@ var collectionRightReportUUID = "15094dbb-9853-4737-aaa6-544ed27e0ac1"
 var collectionRightReportSingleInput = `[{"docType":"COLLECTIONRIGHTREPORT","collectionRightUUID":"15094dbb-9853-4737-aaa6-544ed27e0ac1","from":"PU200004","fromName":"MARS FORCE MUSIC","startDate":"2010-12-1","endDate":"2030-12-1","rightHolders":[{"selector":"Territory=\"GER\"","ipi":"PG100001","percent":100},{"selector":"Territory=\"USA\"","ipi":"PU200001","percent":100},{"selector":"Territory=\"AUS\"","ipi":"PA300001","percent":100}]}]`
 var collectionRightReportMultipleInput = `[{"docType":"COLLECTIONRIGHTREPORT","collectionRightUUID":"04240be9-73d3-4227-88a1-31c52d4db3bc","from":"PA300002","fromName":"URBAN SONGS","startDate":"2010-12-1","endDate":"2030-12-1","rightHolders":[{"selector":"Territory=\"GER\"","ipi":"PG100001","percent":100},{"selector":"Territory=\"USA\"","ipi":"PU200001","percent":100},{"selector":"Territory=\"AUS\"","ipi":"PA300001","percent":100}]},{"docType":"COLLECTIONRIGHTREPORT","collectionRightUUID":"15094dbb-9853-4737-aaa6-544ed27e0ac1","from":"PU200004","fromName":"MARS FORCE MUSIC","startDate":"2010-12-1","endDate":"2030-12-1","rightHolders":[{"selector":"Territory=\"GER\"","ipi":"PG100001","percent":100},{"selector":"Territory=\"USA\"","ipi":"PU200001","percent":100},{"selector":"Territory=\"AUS\"","ipi":"PA300001","percent":100}]}]`
 var collectionRightReportSingleOutput1 = `{"docType":"COLLECTIONRIGHTREPORT","collectionRightUUID":"15094dbb-9853-4737-aaa6-544ed27e0ac1","from":"PU200004","fromName":"MARS FORCE MUSIC","startDate":"2010-12-1","endDate":"2030-12-1","rightHolders":[{"selector":"Territory=\"GER\"","ipi":"PG100001","percent":100},{"selector":"Territory=\"USA\"","ipi":"PU200001","percent":100},{"selector":"Territory=\"AUS\"","ipi":"PA300001","percent":100}]}`
-var collectionRightReportSingleOutput2 = `{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"2cfbdb47-cca7-3eca-b73e-0d6c478a6abc","isrc":"123Src","songTitle":"NY NY","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}`
+var collectionRightReportSingleOutput2 = `{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"2cfbdb47-cca7-3eca-b73e-0d6c478a6abc","isrc":"QZAB11800123","songTitle":"NY NY","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}`
 var updatedCollectionRightReportSingleInput = `[{"docType":"COLLECTIONRIGHTREPORT","collectionRightUUID":"15094dbb-9853-4737-aaa6-544ed27e0ac1","from":"PU200004","fromName":"MARS FORCE MUSIC - updated","startDate":"2010-12-1","endDate":"2030-12-1","rightHolders":[{"selector":"Territory=\"GER\"","ipi":"PG100001","percent":100},{"selector":"Territory=\"USA\"","ipi":"PU200001","percent":100},{"selector":"Territory=\"AUS\"","ipi":"PA300001","percent":100}]}]`
 var updatedCollectionRightReportSingleOutput = `{"docType":"COLLECTIONRIGHTREPORT","collectionRightUUID":"15094dbb-9853-4737-aaa6-544ed27e0ac1","from":"PU200004","fromName":"MARS FORCE MUSIC - updated","startDate":"2010-12-1","endDate":"2030-12-1","rightHolders":[{"selector":"Territory=\"GER\"","ipi":"PG100001","percent":100},{"selector":"Territory=\"USA\"","ipi":"PU200001","percent":100},{"selector":"Territory=\"AUS\"","ipi":"PA300001","percent":100}]}`
 
@@ -29,21 +32,17 @@ func MockGetCollectionRightReportResponse(functionName string) []byte {
 	}
 }
 
-func MockGetCollectionRightReport(stub shim.ChaincodeStubInterface, queryString string) ([]string, error) {
-	return []string{`{"docType":"COLLECTIONRIGHTREPORT","collectionRightUUID":"15094dbb-9853-4737-aaa6-544ed27e0ac1","from":"PU200004","fromName":"MARS FORCE MUSIC","startDate":"2010-12-1","endDate":"2030-12-1","rightHolders":[{"selector":"Territory=\"GER\"","ipi":"PG100001","percent":100},{"selector":"Territory=\"USA\"","ipi":"PU200001","percent":100},{"selector":"Territory=\"AUS\"","ipi":"PA300001","percent":100}]}`}, nil
-}
 func MockGetUpdatedCollectionRightReport(stub shim.ChaincodeStubInterface, queryString string) ([]string, error) {
-	return []string{`{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"1cfbdb47-cca7-3eca-b73e-0d6c478a5abc","isrc":"1234567Src","songTitle":"modified","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}`}, nil
+	return []string{`{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"1cfbdb47-cca7-3eca-b73e-0d6c478a5abc","isrc":"QZAB11804567","songTitle":"modified","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}`}, nil
 }
 
 func Test_AddCollectionRightReports_Single(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
 
-	getCollectionRightsForQueryString = MockGetCollectionRightReport
 	actual, err := checkInvoke(t, stub, [][]byte{[]byte("addCollectionRights"), []byte(collectionRightReportSingleInput)})
 	if err != nil {
 		t.Fatalf(err.Error())
@@ -65,7 +64,7 @@ func Test_AddCollectionRightReports_Single(t *testing.T) {
 
 func Test_AddCollectionRightReport_Multiple(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -92,7 +91,7 @@ func Test_AddCollectionRightReport_Multiple(t *testing.T) {
 func Test_GetCollectionRightReportByID(t *testing.T) {
 
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -108,12 +107,7 @@ func Test_GetCollectionRightReportByID(t *testing.T) {
 		t.Fatalf(err.Error())
 	}
 
-	expectedReports, err := MockGetCollectionRightReport(stub, "")
-	if err != nil {
-		t.Fatalf(err.Error())
-	}
-	//normalize []string to string
-	if !reflect.DeepEqual(expectedReports[0], string(actualReport)) {
+	if !reflect.DeepEqual(collectionRightReportSingleOutput1, string(actualReport)) {
 		t.Fatalf("Actual response is not equal to expected response")
 	}
 }
@@ -122,12 +116,11 @@ func Test_GetCollectionRightReportByID(t *testing.T) {
 func Test_DeleteCollectionRightReportByIDs(t *testing.T) {
 
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
 
-	getCollectionRightsForQueryString = MockGetCollectionRightReport
 	_, err := checkInvoke(t, stub, [][]byte{[]byte("addCollectionRights"), []byte(collectionRightReportSingleInput)})
 	if err != nil {
 		t.Fatalf(err.Error())
@@ -148,7 +141,7 @@ func Test_DeleteCollectionRightReportByIDs(t *testing.T) {
 
 func Test_updateCollectionRightReports_Single(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -176,7 +169,7 @@ func Test_updateCollectionRightReports_Single(t *testing.T) {
 //Test the Edge cases
 func Test_AddCollectionRightReports_Empty1(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -189,7 +182,7 @@ func Test_AddCollectionRightReports_Empty1(t *testing.T) {
 
 func Test_AddCollectionRightReports_Empty2(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -199,3 +192,331 @@ func Test_AddCollectionRightReports_Empty2(t *testing.T) {
 		t.Fatalf(err.Error())
 	}
 }
+
+var collectionChainExploitationReport = `{"docType":"EXPLOITATIONREPORT","exploitationReportUUID":"er1","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"NY NY","writerName":"","units":10,"exploitationDate":"2018-12-30","amount":100,"usageType":"","territory":"USA","state":"MATCHED","currency":"EUR"}`
+var collectionChainRoyaltyStatement = `[{"royaltyStatementUUID":"rs1","exploitationReportUUID":"er1","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"NY NY","units":10,"exploitationDate":"2018-12-30","amount":100,"rightType":"OWNERSHIP","territory":"USA","rightHolder":"W-IPI","currency":"EUR"}]`
+var collectionChainRights = `[{"collectionRightUUID":"cr1","from":"W-IPI","fromName":"WRITER","startDate":"2010-12-1","endDate":"2030-12-1","rightHolders":[{"selector":"Territory == 'GER'","ipi":"PG-IPI","percent":100},{"selector":"Territory == 'USA'","ipi":"P-IPI","percent":50}]},` +
+	`{"collectionRightUUID":"cr2","from":"P-IPI","fromName":"PUBLISHER","startDate":"2010-12-1","endDate":"2030-12-1","rightHolders":[{"selector":"","ipi":"SP-IPI","percent":20}]},` +
+	`{"collectionRightUUID":"cr3","from":"SP-IPI","fromName":"SUB-PUBLISHER","startDate":"2019-1-1","endDate":"2030-12-1","rightHolders":[{"selector":"","ipi":"SOC-IPI","percent":10}]}]`
+var collectionChainHop1 = `{"docType":"ROYALTYSTATEMENT","royaltyStatementUUID":"rs1-1","exploitationReportUUID":"er1","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"NY NY","writerName":"","units":10,"exploitationDate":"2018-12-30","amount":100,"rightType":"COLLECTION","territory":"USA","usageType":"","rightHolder":"W-IPI","administrator":"P-IPI","collector":"","state":"","collectionRight":50,"collectionRightPercent":0.5,"currency":"EUR","collectionRightUUID":"cr1","parentStatementUUID":"rs1","lineage":["rs1"],"netPayable":50}`
+var collectionChainHop2 = `{"docType":"ROYALTYSTATEMENT","royaltyStatementUUID":"rs1-2","exploitationReportUUID":"er1","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"NY NY","writerName":"","units":10,"exploitationDate":"2018-12-30","amount":50,"rightType":"COLLECTION","territory":"USA","usageType":"","rightHolder":"W-IPI","administrator":"P-IPI","collector":"SP-IPI","state":"","collectionRight":10,"collectionRightPercent":0.2,"currency":"EUR","collectionRightUUID":"cr2","parentStatementUUID":"rs1-1","lineage":["rs1","rs1-1"],"netPayable":10}`
+var collectionChainGeneratedHop2 = `{"docType":"ROYALTYSTATEMENT","royaltyStatementUUID":"rs1-2","exploitationReportUUID":"er1","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"NY NY","writerName":"","units":10,"exploitationDate":"2018-12-30","amount":50,"rightType":"COLLECTION","territory":"USA","usageType":"","rightHolder":"W-IPI","administrator":"P-IPI","collector":"SP-IPI","state":"","collectionRight":10,"collectionRightPercent":0.2,"currency":"EUR","collectionRightUUID":"cr2","parentStatementUUID":"rs2","lineage":["rs1","rs2"],"netPayable":10}`
+
+// setupCollectionChain - records the chain writer -> publisher -> sub-publisher
+func setupCollectionChain(t *testing.T, stub *memstub.Stub) {
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+	stub.MockTransactionStart("er1")
+	stub.PutState("er1", []byte(collectionChainExploitationReport))
+	stub.MockTransactionEnd("er1")
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addRoyaltyStatements"), []byte(collectionChainRoyaltyStatement)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, err = checkInvoke(t, stub, [][]byte{[]byte("addCollectionRights"), []byte(collectionChainRights)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+}
+
+func Test_ResolveCollectionChain(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	setupCollectionChain(t, stub)
+
+	// the sub-publisher's right to the society is not in effect yet
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("resolveCollectionChain"), []byte("rs1")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := []byte(`{"royaltyStatementUUID":"rs1","persisted":false,"royaltyStatements":[` + collectionChainHop1 + `,` + collectionChainHop2 + `]}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+	royaltyStatementBytes, _ := stub.GetState("rs1-1")
+	if royaltyStatementBytes != nil {
+		t.Fatalf("Royalty statements of the chain were recorded without being asked")
+	}
+}
+
+func Test_ResolveCollectionChain_Persist(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	setupCollectionChain(t, stub)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("resolveCollectionChain"), []byte("rs1"), []byte("true")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	checkState(t, stub, "rs1-1", collectionChainHop1)
+	checkState(t, stub, "rs1-2", collectionChainHop2)
+
+	// a chain is only recorded once
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("resolveCollectionChain"), []byte("rs1"), []byte("true")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := []byte(`{"royaltyStatementUUID":"rs1","persisted":true,"royaltyStatements":[]}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+}
+
+func Test_ResolveCollectionChain_GeneratedHop(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	setupCollectionChain(t, stub)
+
+	// the first hop is generated and recorded by the event flow
+	generated, err := checkInvoke(t, stub, [][]byte{[]byte("generateCollectionStatement"), []byte("rs1"), []byte("W-IPI"), []byte(OWNERSHIP)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	royaltyStatement := RoyaltyStatement{}
+	err = jsonToObject(generated, &royaltyStatement)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	royaltyStatement.RoyaltyStatementUUID = "rs2"
+	royaltyStatementsBytes, _ := objectToJSON([]RoyaltyStatement{royaltyStatement})
+	_, err = checkInvoke(t, stub, [][]byte{[]byte("addRoyaltyStatements"), royaltyStatementsBytes})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// the chain does not book the hop again and continues from it
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("resolveCollectionChain"), []byte("rs1"), []byte("true")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := []byte(`{"royaltyStatementUUID":"rs1","persisted":true,"royaltyStatements":[` + collectionChainGeneratedHop2 + `]}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+	royaltyStatementBytes, _ := stub.GetState("rs1-1")
+	if royaltyStatementBytes != nil {
+		t.Fatalf("The hop recorded by the event flow was booked again")
+	}
+	checkState(t, stub, "rs1-2", collectionChainGeneratedHop2)
+
+	// nor can the event flow record it twice
+	royaltyStatement.RoyaltyStatementUUID = "rs3"
+	royaltyStatementsBytes, _ = objectToJSON([]RoyaltyStatement{royaltyStatement})
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("addRoyaltyStatements"), royaltyStatementsBytes})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !strings.Contains(string(actual), `"failureCount":1`) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+}
+
+func Test_GenerateCollectionStatement_ResolvedChain(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	setupCollectionChain(t, stub)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("resolveCollectionChain"), []byte("rs1"), []byte("true")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// the hops of a resolved chain are not generated again
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("generateCollectionStatement"), []byte("rs1"), []byte("W-IPI"), []byte(OWNERSHIP)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := []byte(`{"status":"500","message":"generateCollectionStatement - Royalty statement 'rs1' is already collected with collection right 'cr1' by royalty statement 'rs1-1'"}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+}
+
+var prioritizedCollectionRights = []string{
+	`{"docType":"COLLECTIONRIGHTREPORT","collectionRightUUID":"a","from":"P-IPI","startDate":"2010-12-1","endDate":"2030-12-1","rightHolders":[{"selector":"","ipi":"A-IPI","percent":10}]}`,
+	`{"docType":"COLLECTIONRIGHTREPORT","collectionRightUUID":"b","from":"P-IPI","startDate":"2010-12-1","endDate":"2030-12-1","territories":["usa"],"rightHolders":[{"selector":"","ipi":"B-IPI","percent":10}]}`,
+	`{"docType":"COLLECTIONRIGHTREPORT","collectionRightUUID":"c","from":"P-IPI","startDate":"2010-12-1","endDate":"2030-12-1","priority":2,"rightHolders":[{"selector":"","ipi":"C-IPI","percent":10}]}`,
+	`{"docType":"COLLECTIONRIGHTREPORT","collectionRightUUID":"d","from":"P-IPI","startDate":"2010-12-1","endDate":"2030-12-1","priority":1,"territories":["GER"],"rightHolders":[{"selector":"","ipi":"D-IPI","percent":10}]}`,
+	`{"docType":"COLLECTIONRIGHTREPORT","collectionRightUUID":"e","from":"P-IPI","startDate":"","endDate":"","priority":1,"rightHolders":[{"selector":"","ipi":"E-IPI","percent":10}]}`,
+	`{"docType":"COLLECTIONRIGHTREPORT","collectionRightUUID":"f","from":"P-IPI","startDate":"2010-12-1","endDate":"2015-12-1","priority":1,"rightHolders":[{"selector":"","ipi":"F-IPI","percent":10}]}`,
+	`{"docType":"COLLECTIONRIGHTREPORT","collectionRightUUID":"g","from":"P-IPI","startDate":"2016-1-1","endDate":"2030-12-1","rightHolders":[{"selector":"","ipi":"G-IPI","percent":10}]}`,
+}
+
+func Test_GetCollectionRightsMatchingIpi_Priority(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+	putDocuments(t, stub, "collectionRightUUID", prioritizedCollectionRights)
+
+	// the expired and the german rights do not apply, the others are ordered by priority, territory and start date
+	collectionRights, err := getCollectionRightsMatchingIpi(stub, "P-IPI", ExploitationReport{ExploitationDate: "2018-12-30", Territory: "USA"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actual := []string{}
+	for _, collectionRight := range collectionRights {
+		actual = append(actual, collectionRight.CollectionRightUUID)
+	}
+	expected := []string{"e", "c", "b", "g", "a"}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %v", actual)
+	}
+}
+
+func Test_GenerateCollectionStatement_ExpiredCollectionRight(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	setupCollectionChain(t, stub)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addCollectionRights"), []byte(`[{"collectionRightUUID":"cr4","from":"SP-IPI","fromName":"SUB-PUBLISHER","startDate":"","endDate":"","rightHolders":[{"selector":"","ipi":"LOC-IPI","percent":10}]}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// the sub-publisher's deal with the society only starts in 2019
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("generateCollectionStatement"), []byte("rs1"), []byte("SP-IPI"), []byte(COLLECTION)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	royaltyStatement := RoyaltyStatement{}
+	err = jsonToObject(actual, &royaltyStatement)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if royaltyStatement.Administrator != "SP-IPI" || royaltyStatement.Collector != "LOC-IPI" || royaltyStatement.CollectionRight != 10 {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+}
+
+func Test_GetCollectionFee(t *testing.T) {
+	actual := []float64{
+		getCollectionFee(nil, 100),
+		getCollectionFee(&FeeTerms{Type: PERCENT_FEE, Percent: 15}, 100),
+		getCollectionFee(&FeeTerms{Type: FLAT_FEE, Amount: 5}, 100),
+		getCollectionFee(&FeeTerms{Type: FLAT_FEE, Amount: 5}, 2),
+		getCollectionFee(&FeeTerms{Type: CAPPED_FEE, Percent: 15, Amount: 10}, 50),
+		getCollectionFee(&FeeTerms{Type: CAPPED_FEE, Percent: 15, Amount: 10}, 100),
+	}
+	expected := []float64{0, 15, 5, 2, 7.5, 10}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %v", actual)
+	}
+}
+
+func Test_AddCollectionRights_InvalidFee(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("addCollectionRights"), []byte(`[{"collectionRightUUID":"cr1","from":"P-IPI","fee":{"type":"SHARE","percent":15},"rightHolders":[]}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := []byte(`{"successCount":0,"failureCount":1,"collectionRightsResponses":[{"collectionRightUUID":"cr1","message":"Invalid fee type 'SHARE', expected one of PERCENT, FLAT or CAPPED","success":false}]}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_ResolveCollectionChain_Fee(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	setupCollectionChain(t, stub)
+
+	// the publisher keeps a commission of 15% of what it collects, capped at 5
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("updateCollectionRights"), []byte(`[{"collectionRightUUID":"cr1","from":"W-IPI","fromName":"WRITER","startDate":"2010-12-1","endDate":"2030-12-1","fee":{"type":"CAPPED","percent":15,"amount":5},"rightHolders":[{"selector":"Territory == 'USA'","ipi":"P-IPI","percent":50}]}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("resolveCollectionChain"), []byte("rs1"), []byte("true")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	collectionChain := CollectionChain{}
+	err = jsonToObject(actual, &collectionChain)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actualStatements := []string{}
+	for _, royaltyStatement := range collectionChain.RoyaltyStatements {
+		actualStatements = append(actualStatements, fmt.Sprintf("%s %s %v %v %v %v", royaltyStatement.RoyaltyStatementUUID, royaltyStatement.RightType, royaltyStatement.GrossAmount, royaltyStatement.FeeAmount, royaltyStatement.NetAmount, royaltyStatement.CollectionRight))
+	}
+	// only the net of the publisher passes to the sub-publisher
+	expectedStatements := []string{"rs1-1 COLLECTION 50 5 45 45", "rs1-1-fee FEE 0 5 0 5", "rs1-2 COLLECTION 0 0 0 9"}
+	if !reflect.DeepEqual(expectedStatements, actualStatements) {
+		t.Fatalf("Actual response is not equal to expected response: %v", actualStatements)
+	}
+
+	// the writer owes the gross to the publisher, as the net statement and the fee statement
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("getBalances"), []byte(`{"ipi":"P-IPI","counterparty":"W-IPI"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	balances := []Balance{}
+	err = jsonToObject(actual, &balances)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(balances) != 1 || balances[0].Receivable != 50 {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+}
+
+func Test_AddCollectionRights_Cycle(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	setupCollectionChain(t, stub)
+
+	// the sub-publisher would collect for the writer it collects from, the society's right ends before the writer's deal starts,
+	// and the two rights of the second batch collect for each other
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("addCollectionRights"), []byte(`[{"collectionRightUUID":"cr4","from":"SP-IPI","startDate":"","endDate":"","rightHolders":[{"selector":"","ipi":"W-IPI","percent":10}]},` +
+		`{"collectionRightUUID":"cr5","from":"SOC-IPI","startDate":"2000-1-1","endDate":"2009-12-31","rightHolders":[{"selector":"","ipi":"W-IPI","percent":10}]},` +
+		`{"collectionRightUUID":"cr6","from":"X-IPI","startDate":"","endDate":"","rightHolders":[{"selector":"","ipi":"X-IPI","percent":10}]}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := []byte(`{"successCount":1,"failureCount":2,"collectionRightsResponses":[` +
+		`{"collectionRightUUID":"cr4","message":"Collection right 'cr4' would create a collection loop: SP-IPI, W-IPI, P-IPI, SP-IPI","success":false},` +
+		`{"collectionRightUUID":"cr6","message":"Collection right 'cr6' would create a collection loop: X-IPI, X-IPI","success":false}]}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("addCollectionRights"), []byte(`[{"collectionRightUUID":"cr7","from":"A-IPI","startDate":"","endDate":"","rightHolders":[{"selector":"","ipi":"B-IPI","percent":10}]},` +
+		`{"collectionRightUUID":"cr8","from":"B-IPI","startDate":"","endDate":"","rightHolders":[{"selector":"","ipi":"A-IPI","percent":10}]}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected = []byte(`{"successCount":1,"failureCount":1,"collectionRightsResponses":[{"collectionRightUUID":"cr8","message":"Collection right 'cr8' would create a collection loop: B-IPI, A-IPI, B-IPI","success":false}]}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+}
+
+func Test_GetCollectionGraph(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	setupCollectionChain(t, stub)
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getCollectionGraph"), []byte("P-IPI")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := []byte(`{"ipi":"P-IPI","upstream":[{"collectionRightUUID":"cr1","from":"W-IPI","to":"P-IPI","percent":50,"selector":"Territory == 'USA'","startDate":"2010-12-1","endDate":"2030-12-1"}],` +
+		`"downstream":[{"collectionRightUUID":"cr2","from":"P-IPI","to":"SP-IPI","percent":20,"selector":"","startDate":"2010-12-1","endDate":"2030-12-1"},` +
+		`{"collectionRightUUID":"cr3","from":"SP-IPI","to":"SOC-IPI","percent":10,"selector":"","startDate":"2019-1-1","endDate":"2030-12-1"}]}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+
+	// the society does not collect for the sub-publisher yet
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("getCollectionGraph"), []byte("P-IPI"), []byte("2018-12-30")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected = []byte(`{"ipi":"P-IPI","asOfDate":"2018-12-30","upstream":[{"collectionRightUUID":"cr1","from":"W-IPI","to":"P-IPI","percent":50,"selector":"Territory == 'USA'","startDate":"2010-12-1","endDate":"2030-12-1"}],` +
+		`"downstream":[{"collectionRightUUID":"cr2","from":"P-IPI","to":"SP-IPI","percent":20,"selector":"","startDate":"2010-12-1","endDate":"2030-12-1"}]}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+}
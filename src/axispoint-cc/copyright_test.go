@@ -2,25 +2,29 @@ package main
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
-	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"axispoint-cc/memstub"
 )
 
 var copyrightDataReportUUID = "1cfbdb47-cca7-3eca-b73e-0d6c478a5abc"
-var copyrightDataReportSingleInput = `[{"copyrightDataReportUUID":"1cfbdb47-cca7-3eca-b73e-0d6c478a5abc","docType":"COPYRIGHTDATAREPORT","isrc":"123Src","songTitle":"NY NY","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}]`
-var copyrightDataReportMultipleInput = `[{"copyrightDataReportUUID":"1cfbdb47-cca7-3eca-b73e-0d6c478a5abc","docType":"COPYRIGHTDATAREPORT","isrc":"123Src","songTitle":"NY NY","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]},{"copyrightDataReportUUID":"2cfbdb47-cca7-3eca-b73e-0d6c478a6abc","docType":"COPYRIGHTDATAREPORT","isrc":"123Src","songTitle":"NY NY","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}]`
-var copyrightDataReportSingleOutput1 = `{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"1cfbdb47-cca7-3eca-b73e-0d6c478a5abc","isrc":"123Src","songTitle":"NY NY","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}`
-var copyrightDataReportSingleOutput2 = `{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"2cfbdb47-cca7-3eca-b73e-0d6c478a6abc","isrc":"123Src","songTitle":"NY NY","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}`
-var updatedCopyrightDateReportSingleInput = `[{"copyrightDataReportUUID":"1cfbdb47-cca7-3eca-b73e-0d6c478a5abc","docType":"COPYRIGHTDATAREPORT","isrc":"1234567Src","songTitle":"modified","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector": "slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}]`
+var copyrightDataReportSingleInput = `[{"copyrightDataReportUUID":"1cfbdb47-cca7-3eca-b73e-0d6c478a5abc","docType":"COPYRIGHTDATAREPORT","isrc":"QZAB11800123","songTitle":"NY NY","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}]`
+var copyrightDataReportMultipleInput = `[{"copyrightDataReportUUID":"1cfbdb47-cca7-3eca-b73e-0d6c478a5abc","docType":"COPYRIGHTDATAREPORT","isrc":"QZAB11800123","songTitle":"NY NY","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]},{"copyrightDataReportUUID":"2cfbdb47-cca7-3eca-b73e-0d6c478a6abc","docType":"COPYRIGHTDATAREPORT","isrc":"QZAB11800123","songTitle":"NY NY","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}]`
+var copyrightDataReportSingleOutput1 = `{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"1cfbdb47-cca7-3eca-b73e-0d6c478a5abc","isrc":"QZAB11800123","songTitle":"NY NY","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}`
+var copyrightDataReportSingleOutput2 = `{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"2cfbdb47-cca7-3eca-b73e-0d6c478a6abc","isrc":"QZAB11800123","songTitle":"NY NY","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}`
+var updatedCopyrightDateReportSingleInput = `[{"copyrightDataReportUUID":"1cfbdb47-cca7-3eca-b73e-0d6c478a5abc","docType":"COPYRIGHTDATAREPORT","isrc":"QZAB11804567","songTitle":"modified","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector": "slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}]`
+var updatedCopyrightDataReportSingleOutput = `{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"1cfbdb47-cca7-3eca-b73e-0d6c478a5abc","isrc":"QZAB11804567","songTitle":"modified","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}`
 
-var copyrightDataReportMultipleOutput1 = `{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"1cfbdb47-cca7-3eca-b73e-0d6c478a5abc","isrc":"123Src","songTitle":"NY NY","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}`
-var copyrightDataReportMultipleOutput2 = `{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"2cfbdb47-cca7-3eca-b73e-0d6c478a6abc","isrc":"123Src","songTitle":"NY NY","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}`
+var copyrightDataReportMultipleOutput1 = `{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"1cfbdb47-cca7-3eca-b73e-0d6c478a5abc","isrc":"QZAB11800123","songTitle":"NY NY","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}`
+var copyrightDataReportMultipleOutput2 = `{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"2cfbdb47-cca7-3eca-b73e-0d6c478a6abc","isrc":"QZAB11800123","songTitle":"NY NY","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}`
 
 func MockGetCopyrightDataReportResponse(functionName string) []byte {
 	switch functionName {
 	case "Test_AddCopyrightDataReports_Single":
 		return []byte(`{"successCount":1,"failureCount":0,"copyrightDataReports":[]}`)
+	case "Test_AddCopyrightDataReports_InvalidIdentifiers":
+		return []byte(`{"successCount":1,"failureCount":2,"copyrightDataReports":[{"copyrightDataReportUUID":"cdr-invalid-isrc","message":"invalid ISRC '123Src'","success":false},{"copyrightDataReportUUID":"cdr-invalid-ipi","message":"invalid IPI name number '00014107339': check digits do not match","success":false}]}`)
 	case "Test_AddCopyrightDataReports_Multiple":
 		return []byte(`{"successCount":2,"failureCount":0,"copyrightDataReports":[]}`)
 	default:
@@ -28,20 +32,13 @@ func MockGetCopyrightDataReportResponse(functionName string) []byte {
 	}
 }
 
-func MockGetCopyrightDataReport(stub shim.ChaincodeStubInterface, queryString string) ([]string, error) {
-	return []string{`{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"1cfbdb47-cca7-3eca-b73e-0d6c478a5abc","isrc":"123Src","songTitle":"NY NY","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}`}, nil
-}
-func MockGetUpdatedCopyrightDataReport(stub shim.ChaincodeStubInterface, queryString string) ([]string, error) {
-	return []string{`{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"1cfbdb47-cca7-3eca-b73e-0d6c478a5abc","isrc":"1234567Src","songTitle":"modified","startDate":"2018-01-01T21:17:34.371Z","endDate":"2018-11-15T22:27:34.111Z","rightHolders":[{"selector":"slct1","ipi":"ipi1","percent":42},{"selector":"slct2","ipi":"ipi2","percent":33}]}`}, nil
-}
 func Test_AddCopyrightDataReports_Single(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
 
-	getCopyrightDataReportForQueryString = MockGetCopyrightDataReport
 	actual, err := checkInvoke(t, stub, [][]byte{[]byte("addCopyrightDataReports"), []byte(copyrightDataReportSingleInput)})
 	if err != nil {
 		t.Fatalf(err.Error())
@@ -64,29 +61,22 @@ func Test_AddCopyrightDataReports_Single(t *testing.T) {
 func Test_GetCopyrightDataReportByID(t *testing.T) {
 
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
 
-	getCopyrightDataReportForQueryString = MockGetCopyrightDataReport
 	_, err := checkInvoke(t, stub, [][]byte{[]byte("addCopyrightDataReports"), []byte(copyrightDataReportSingleInput)})
 	if err != nil {
 		t.Fatalf(err.Error())
 	}
 
-	getCopyrightDataReportForQueryString = MockGetCopyrightDataReport
 	actualReport, err := checkInvoke(t, stub, [][]byte{[]byte("getCopyrightDataReportByID"), []byte(copyrightDataReportUUID)})
 	if err != nil {
 		t.Fatalf(err.Error())
 	}
 
-	expectedReports, err := MockGetCopyrightDataReport(stub, "")
-	if err != nil {
-		t.Fatalf(err.Error())
-	}
-	//normalize []string to string
-	if !reflect.DeepEqual(expectedReports[0], string(actualReport)) {
+	if !reflect.DeepEqual(copyrightDataReportSingleOutput1, string(actualReport)) {
 		t.Fatalf("Actual response is not equal to expected response")
 	}
 }
@@ -95,12 +85,11 @@ func Test_GetCopyrightDataReportByID(t *testing.T) {
 func Test_DeleteCopyrightDataReportByIDs(t *testing.T) {
 
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
 
-	getCopyrightDataReportForQueryString = MockGetCopyrightDataReport
 	_, err := checkInvoke(t, stub, [][]byte{[]byte("addCopyrightDataReports"), []byte(copyrightDataReportSingleInput)})
 	if err != nil {
 		t.Fatalf(err.Error())
@@ -123,12 +112,11 @@ func Test_DeleteCopyrightDataReportByIDs(t *testing.T) {
 func Test_updateCopyrightDataReportByIDs(t *testing.T) {
 
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
 
-	getCopyrightDataReportForQueryString = MockGetCopyrightDataReport
 	_, err := checkInvoke(t, stub, [][]byte{[]byte("addCopyrightDataReports"), []byte(copyrightDataReportSingleInput)})
 	if err != nil {
 		t.Fatalf(err.Error())
@@ -139,25 +127,19 @@ func Test_updateCopyrightDataReportByIDs(t *testing.T) {
 		t.Fatalf(err.Error())
 	}
 
-	getCopyrightDataReportForQueryString = MockGetUpdatedCopyrightDataReport
 	actualReport, err := checkInvoke(t, stub, [][]byte{[]byte("getCopyrightDataReportByID"), []byte(copyrightDataReportUUID)})
 	if err != nil {
 		t.Fatalf(err.Error())
 	}
 
-	expectedReports, err := MockGetUpdatedCopyrightDataReport(stub, "")
-	if err != nil {
-		t.Fatalf(err.Error())
-	}
-	//normalize []string to string
-	if !reflect.DeepEqual(expectedReports[0], string(actualReport)) {
+	if !reflect.DeepEqual(updatedCopyrightDataReportSingleOutput, string(actualReport)) {
 		t.Fatalf("Actual response is not equal to expected response")
 	}
 }
 
 func Test_updateCopyrightDataReports_Single(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -184,7 +166,7 @@ func Test_updateCopyrightDataReports_Single(t *testing.T) {
 //Test the Edge cases
 func Test_AddCopyrightDataReports_Empty1(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -197,7 +179,7 @@ func Test_AddCopyrightDataReports_Empty1(t *testing.T) {
 
 func Test_AddCopyrightDataReports_Empty2(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -210,7 +192,7 @@ func Test_AddCopyrightDataReports_Empty2(t *testing.T) {
 
 func Test_AddCopyrightDataReport_Multiple(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -234,3 +216,35 @@ func Test_AddCopyrightDataReport_Multiple(t *testing.T) {
 		t.Fatalf("Actual response is not equal to expected response")
 	}
 }
+
+func Test_AddCopyrightDataReports_InvalidIdentifiers(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+
+	// Init
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	input := `[{"copyrightDataReportUUID":"cdr-normalized","isrc":"qz-ab1-18-00123","startDate":"2018-01-01","endDate":"2018-12-31","rightHolders":[{"ipi":"IPI 141.073.38","percent":50},{"ipi":"ipi2","percent":50}]},` +
+		`{"copyrightDataReportUUID":"cdr-invalid-isrc","isrc":"123Src","startDate":"2018-01-01","endDate":"2018-12-31","rightHolders":[{"ipi":"ipi1","percent":100}]},` +
+		`{"copyrightDataReportUUID":"cdr-invalid-ipi","isrc":"QZAB11800123","startDate":"2018-01-01","endDate":"2018-12-31","rightHolders":[{"ipi":"00014107339","percent":100}]}]`
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("addCopyrightDataReports"), []byte(input)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// identifiers are stored in their canonical form
+	checkState(t, stub, "cdr-normalized", `{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"cdr-normalized","isrc":"QZAB11800123","songTitle":"","startDate":"2018-01-01","endDate":"2018-12-31","rightHolders":[{"selector":"","ipi":"00014107338","percent":50},{"selector":"","ipi":"ipi2","percent":50}]}`)
+
+	expected := MockGetCopyrightDataReportResponse("Test_AddCopyrightDataReports_InvalidIdentifiers")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+	// and are found in any of their forms
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("searchForCopyrightDataReportWithParameters"), []byte("isrc qz-ab1-18-00123")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !strings.Contains(string(actual), `"copyrightDataReportUUID":"cdr-normalized"`) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+}
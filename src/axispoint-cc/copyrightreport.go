@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"axispoint-cc/identifier"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
@@ -50,6 +51,19 @@ func addCopyrightDataReports(stub shim.ChaincodeStubInterface, args []string) pb
 		copyrightDataReportResponse.CopyrightDataReportUUID = copyrightDataReport.CopyrightDataUUID
 		copyrightDataReportResponse.Success = true
 
+		// reject the report when its identifiers or territories are invalid
+		err = normalizeCopyrightDataReportIdentifiers(&copyrightDataReport)
+		if err == nil {
+			err = validateTerritories(stub, copyrightDataReport.Territories, copyrightDataReport.ExcludedTerritories)
+		}
+		if err != nil {
+			copyrightDataReportResponse.Success = false
+			copyrightDataReportResponse.Message = err.Error()
+			copyrightDataReportResponses = append(copyrightDataReportResponses, copyrightDataReportResponse)
+			copyrightDataReportOutput.FailureCount++
+			continue
+		}
+
 		//Record royaltyReport on ledger
 		copyrightDataReporBytes, err := objectToJSON(copyrightDataReport)
 		if err != nil {
@@ -175,6 +189,13 @@ func searchForCopyrightDataReportWithParameters(stub shim.ChaincodeStubInterface
 		logger.Error(message)
 		return getErrorResponse(message)
 	}
+	// the ISRC is stored normalized
+	isrc, err := identifier.NormalizeIsrc(args[0])
+	if err != nil {
+		return getErrorResponse(fmt.Sprintf("%s - %s", methodName, err.Error()))
+	}
+	args = append([]string{isrc}, args[1:]...)
+
 	var queryString string
 	//expected arguments
 	switch len(args) {
@@ -311,6 +332,17 @@ func updateCopyrightDataReports(stub shim.ChaincodeStubInterface, args []string)
 		copyrightDataReportResponse.Success = true
 
 		//Record copyrightDataReport on ledger
+		err = normalizeCopyrightDataReportIdentifiers(&copyrightDataReport)
+		if err == nil {
+			err = validateTerritories(stub, copyrightDataReport.Territories, copyrightDataReport.ExcludedTerritories)
+		}
+		if err != nil {
+			copyrightDataReportResponse.Success = false
+			copyrightDataReportResponse.Message = err.Error()
+			copyrightDataReportResponses = append(copyrightDataReportResponses, copyrightDataReportResponse)
+			copyrightDataReportOutput.FailureCount++
+			continue
+		}
 		copyrightDataReportBytes, err := objectToJSON(copyrightDataReport)
 		if err == nil {
 			existingReportBytes, err = stub.GetState(copyrightDataReport.CopyrightDataUUID)
@@ -353,3 +385,21 @@ func updateCopyrightDataReports(stub shim.ChaincodeStubInterface, args []string)
 	logger.Infof("%s - updated copyright reports output: %s.", methodName, copyrightDataReportOutput)
 	return shim.Success(objBytes)
 }
+
+//normalizeCopyrightDataReportIdentifiers - normalizes the ISRC and the right holder IPIs of a copyright data report
+func normalizeCopyrightDataReportIdentifiers(copyrightDataReport *CopyrightDataReport) error {
+	isrc, err := identifier.NormalizeIsrc(copyrightDataReport.Isrc)
+	if err != nil {
+		return err
+	}
+	copyrightDataReport.Isrc = isrc
+	err = normalizeRightHolderIpis(copyrightDataReport.RightHolders)
+	if err != nil {
+		return err
+	}
+	err = normalizeRightHolderShares(copyrightDataReport.RightHolders)
+	if err != nil {
+		return err
+	}
+	return normalizeRightHolderRoles(copyrightDataReport.RightHolders)
+}
@@ -0,0 +1,544 @@
+/*
+Package cwr parses CISAC CWR v2.x registration files and maps their works to the copyright data reports and
+collection rights of the chaincode.
+
+A CWR file has fixed width records, one per line, the first three characters of a line are its record type:
+
+	HDR       the header of the file: its sender and creation date
+	GRH, GRT  the header and trailer of a group of transactions of one transaction type
+	NWR, REV  a work registration or revision transaction: its title, submitter work number and ISWC
+	SPU, OPU  a controlled or other publisher of the work: its chain, IPI name number, type and ownership shares
+	SPT, OPT  a territory of the publisher before it: included or excluded TIS territory and collection shares
+	SWR, OWR  a controlled or other writer of the work: its IPI name number, designation and ownership shares
+	SWT, OWT  a territory of the writer before it: included or excluded TIS territory and collection shares
+	PWR       the original publisher of a controlled writer
+	REC       a recording of the work: its ISRC
+	TRL       the trailer of the file: the number of groups, transactions and records of the file
+
+Records of a transaction follow its NWR or REV record. ISW and EXC transactions are read like works, AGR and
+ACK transactions and other record types, e.g. the ALT alternate titles, are ignored. Shares are percentages
+with two decimals, e.g. 05000 is 50%.
+*/
+package cwr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Record types
+const (
+	HeaderRecord                  = "HDR"
+	GroupHeaderRecord             = "GRH"
+	GroupTrailerRecord            = "GRT"
+	TrailerRecord                 = "TRL"
+	PublisherRecord               = "SPU"
+	OtherPublisherRecord          = "OPU"
+	PublisherTerritoryRecord      = "SPT"
+	OtherPublisherTerritoryRecord = "OPT"
+	WriterRecord                  = "SWR"
+	OtherWriterRecord             = "OWR"
+	WriterTerritoryRecord         = "SWT"
+	OtherWriterTerritoryRecord    = "OWT"
+	PublisherForWriterRecord      = "PWR"
+	RecordingRecord               = "REC"
+)
+
+// Issue severities
+const (
+	Error   = "ERROR"
+	Warning = "WARNING"
+)
+
+//workTransactions - the transaction types whose header record starts a work
+var workTransactions = map[string]bool{"NWR": true, "REV": true, "ISW": true, "EXC": true}
+
+//otherTransactions - the transaction types that are not read, their records are ignored
+var otherTransactions = map[string]bool{"AGR": true, "ACK": true}
+
+// Issue - a problem found in a CWR file, errors keep the work they concern from being imported
+type Issue struct {
+	Line     int    `json:"line,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Header - the HDR record of a CWR file
+type Header struct {
+	SenderType   string `json:"senderType"`
+	SenderID     string `json:"senderId"`
+	SenderName   string `json:"senderName"`
+	Version      string `json:"version"`
+	CreationDate string `json:"creationDate"`
+}
+
+// Shares - the performance, mechanical and synchronization shares of an interested party in percent
+type Shares struct {
+	Performance float64 `json:"performance"`
+	Mechanical  float64 `json:"mechanical"`
+	Sync        float64 `json:"sync"`
+}
+
+// TerritoryShare - a territory record of an interested party, the collection shares of an included territory
+type TerritoryShare struct {
+	Line     int    `json:"line"`
+	Include  bool   `json:"include"`
+	TisCode  string `json:"tisCode"`
+	Sequence string `json:"sequence"`
+	Shares   Shares `json:"shares"`
+}
+
+// Publisher - a publisher of a work, the publishers of a chain share a chain sequence and its first publisher is the original publisher
+type Publisher struct {
+	Line                  int              `json:"line"`
+	Controlled            bool             `json:"controlled"`
+	Chain                 string           `json:"chain"`
+	InterestedPartyNumber string           `json:"interestedPartyNumber"`
+	Name                  string           `json:"name"`
+	Type                  string           `json:"type"`
+	IpiNameNumber         string           `json:"ipiNameNumber"`
+	Shares                Shares           `json:"shares"`
+	Territories           []TerritoryShare `json:"territories"`
+}
+
+// Writer - a writer of a work and the interested party numbers of its original publishers
+type Writer struct {
+	Line                  int              `json:"line"`
+	Controlled            bool             `json:"controlled"`
+	InterestedPartyNumber string           `json:"interestedPartyNumber"`
+	LastName              string           `json:"lastName"`
+	FirstName             string           `json:"firstName"`
+	Designation           string           `json:"designation"`
+	IpiNameNumber         string           `json:"ipiNameNumber"`
+	Shares                Shares           `json:"shares"`
+	Territories           []TerritoryShare `json:"territories"`
+	Publishers            []string         `json:"publishers"`
+	PublisherLines        []int            `json:"publisherLines"`
+}
+
+// Recording - a recording of a work
+type Recording struct {
+	Line int    `json:"line"`
+	Isrc string `json:"isrc"`
+}
+
+// Work - a work transaction of a CWR file and the number of its records that could not be read
+type Work struct {
+	Line                int         `json:"line"`
+	TransactionType     string      `json:"transactionType"`
+	Title               string      `json:"title"`
+	SubmitterWorkNumber string      `json:"submitterWorkNumber"`
+	Iswc                string      `json:"iswc"`
+	Publishers          []Publisher `json:"publishers"`
+	Writers             []Writer    `json:"writers"`
+	Recordings          []Recording `json:"recordings"`
+	Errors              int         `json:"errors"`
+}
+
+// Trailer - the TRL record of a CWR file
+type Trailer struct {
+	Line         int `json:"line"`
+	Groups       int `json:"groups"`
+	Transactions int `json:"transactions"`
+	Records      int `json:"records"`
+}
+
+// File - a parsed CWR file with the issues found while parsing it
+type File struct {
+	Header       Header   `json:"header"`
+	Works        []Work   `json:"works"`
+	Trailer      *Trailer `json:"trailer,omitempty"`
+	Lines        int      `json:"lines"`
+	Records      int      `json:"records"`
+	Groups       int      `json:"groups"`
+	Transactions int      `json:"transactions"`
+	Issues       []Issue  `json:"issues"`
+}
+
+//record - a line of a CWR file
+type record struct {
+	line       int
+	recordType string
+	text       string
+}
+
+//get - returns the field of a record at a 1 based position, an empty field when the line is shorter
+func (r record) get(start int, length int) string {
+	if start-1 >= len(r.text) {
+		return ""
+	}
+	end := start - 1 + length
+	if end > len(r.text) {
+		end = len(r.text)
+	}
+	return strings.TrimSpace(r.text[start-1 : end])
+}
+
+//getInt - returns an integer field, an empty field is 0
+func (r record) getInt(name string, start int, length int) (int, error) {
+	value := r.get(start, length)
+	if value == "" {
+		return 0, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s '%s' is not a number", name, value)
+	}
+	return number, nil
+}
+
+//getShare - returns a share field of three integer and two decimal digits, an empty field is 0
+func (r record) getShare(name string, start int, length int) (float64, error) {
+	number, err := r.getInt(name, start, length)
+	if err != nil {
+		return 0, err
+	}
+	share := float64(number) / 100
+	if share > 100 {
+		return 0, fmt.Errorf("%s %v is not a percentage", name, share)
+	}
+	return share, nil
+}
+
+//getShares - returns the performance, mechanical and sync shares of a record at their positions
+func (r record) getShares(performance int, mechanical int, sync int) (Shares, error) {
+	shares := Shares{}
+	var err error
+	if shares.Performance, err = r.getShare("PR share", performance, 5); err != nil {
+		return shares, err
+	}
+	if shares.Mechanical, err = r.getShare("MR share", mechanical, 5); err != nil {
+		return shares, err
+	}
+	if shares.Sync, err = r.getShare("SR share", sync, 5); err != nil {
+		return shares, err
+	}
+	return shares, nil
+}
+
+//parser - the state of the parsing of a CWR file
+type parser struct {
+	file              *File
+	work              *Work
+	groupLine         int
+	groupTransactions int
+	groupRecords      int
+	inGroup           bool
+}
+
+// Parse reads a CWR file. It fails when the file cannot be read or has no header, other problems are reported
+// as issues of the file.
+func Parse(reader io.Reader) (*File, error) {
+	p := &parser{file: &File{Works: []Work{}, Issues: []Issue{}}}
+	hasHeader := false
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		p.file.Lines++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		rec := record{line: p.file.Lines, text: text}
+		rec.recordType = rec.get(1, 3)
+		p.file.Records++
+		if p.inGroup {
+			p.groupRecords++
+		}
+
+		var err error
+		switch {
+		case rec.recordType == HeaderRecord:
+			hasHeader = true
+			p.file.Header = parseHeader(rec)
+		case rec.recordType == GroupHeaderRecord:
+			p.startGroup(rec)
+		case rec.recordType == GroupTrailerRecord:
+			err = p.endGroup(rec)
+		case rec.recordType == TrailerRecord:
+			err = p.addTrailer(rec)
+		case workTransactions[rec.recordType]:
+			p.addWork(rec)
+		case rec.recordType == PublisherRecord || rec.recordType == OtherPublisherRecord:
+			err = p.addPublisher(rec)
+		case rec.recordType == PublisherTerritoryRecord || rec.recordType == OtherPublisherTerritoryRecord:
+			err = p.addPublisherTerritory(rec)
+		case rec.recordType == WriterRecord || rec.recordType == OtherWriterRecord:
+			err = p.addWriter(rec)
+		case rec.recordType == WriterTerritoryRecord || rec.recordType == OtherWriterTerritoryRecord:
+			err = p.addWriterTerritory(rec)
+		case rec.recordType == PublisherForWriterRecord:
+			err = p.addPublisherForWriter(rec)
+		case rec.recordType == RecordingRecord:
+			err = p.addRecording(rec)
+		case otherTransactions[rec.recordType]:
+			p.work = nil
+			p.file.Transactions++
+			p.groupTransactions++
+		}
+		if err != nil {
+			p.file.Issues = append(p.file.Issues, Issue{Line: rec.line, Severity: Error, Message: fmt.Sprintf("%s record: %s", rec.recordType, err.Error())})
+			if p.work != nil {
+				p.work.Errors++
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !hasHeader {
+		return nil, fmt.Errorf("CWR file has no %s record", HeaderRecord)
+	}
+	if p.inGroup {
+		p.file.Issues = append(p.file.Issues, Issue{Line: p.groupLine, Severity: Error, Message: fmt.Sprintf("group has no %s record", GroupTrailerRecord)})
+	}
+	if p.file.Trailer == nil {
+		p.file.Issues = append(p.file.Issues, Issue{Severity: Warning, Message: fmt.Sprintf("CWR file has no %s record", TrailerRecord)})
+	}
+	return p.file, nil
+}
+
+//parseHeader - reads the HDR record, the CWR version of a 2.1 file is not in its header
+func parseHeader(rec record) Header {
+	header := Header{
+		SenderType:   rec.get(4, 2),
+		SenderID:     rec.get(6, 9),
+		SenderName:   rec.get(15, 45),
+		Version:      rec.get(102, 3),
+		CreationDate: rec.get(65, 8),
+	}
+	if header.Version == "" {
+		header.Version = "2.1"
+	}
+	if len(header.CreationDate) == 8 {
+		header.CreationDate = header.CreationDate[:4] + "-" + header.CreationDate[4:6] + "-" + header.CreationDate[6:]
+	}
+	return header
+}
+
+func (p *parser) startGroup(rec record) {
+	p.work = nil
+	p.inGroup = true
+	p.groupLine = rec.line
+	p.groupTransactions = 0
+	p.groupRecords = 1
+	p.file.Groups++
+}
+
+//endGroup - compares the transaction and record counts of a GRT record to the records of its group
+func (p *parser) endGroup(rec record) error {
+	p.work = nil
+	if !p.inGroup {
+		return fmt.Errorf("group has no %s record", GroupHeaderRecord)
+	}
+	p.inGroup = false
+	transactions, err := rec.getInt("transaction count", 9, 8)
+	if err != nil {
+		return err
+	}
+	records, err := rec.getInt("record count", 17, 8)
+	if err != nil {
+		return err
+	}
+	if transactions != p.groupTransactions {
+		return fmt.Errorf("group reports %d transactions, it has %d", transactions, p.groupTransactions)
+	}
+	if records != p.groupRecords {
+		return fmt.Errorf("group reports %d records, it has %d", records, p.groupRecords)
+	}
+	return nil
+}
+
+//addTrailer - compares the counts of the TRL record to the file
+func (p *parser) addTrailer(rec record) error {
+	p.work = nil
+	trailer := Trailer{Line: rec.line}
+	var err error
+	if trailer.Groups, err = rec.getInt("group count", 4, 5); err != nil {
+		return err
+	}
+	if trailer.Transactions, err = rec.getInt("transaction count", 9, 8); err != nil {
+		return err
+	}
+	if trailer.Records, err = rec.getInt("record count", 17, 8); err != nil {
+		return err
+	}
+	p.file.Trailer = &trailer
+	if trailer.Groups != p.file.Groups {
+		return fmt.Errorf("trailer reports %d groups, the file has %d", trailer.Groups, p.file.Groups)
+	}
+	if trailer.Transactions != p.file.Transactions {
+		return fmt.Errorf("trailer reports %d transactions, the file has %d", trailer.Transactions, p.file.Transactions)
+	}
+	if trailer.Records != p.file.Records {
+		return fmt.Errorf("trailer reports %d records, the file has %d", trailer.Records, p.file.Records)
+	}
+	return nil
+}
+
+func (p *parser) addWork(rec record) {
+	p.file.Transactions++
+	p.groupTransactions++
+	p.file.Works = append(p.file.Works, Work{
+		Line:                rec.line,
+		TransactionType:     rec.recordType,
+		Title:               rec.get(20, 60),
+		SubmitterWorkNumber: rec.get(82, 14),
+		Iswc:                rec.get(96, 11),
+		Publishers:          []Publisher{},
+		Writers:             []Writer{},
+		Recordings:          []Recording{},
+	})
+	p.work = &p.file.Works[len(p.file.Works)-1]
+}
+
+//getWork - returns the work of a detail record
+func (p *parser) getWork() (*Work, error) {
+	if p.work == nil {
+		return nil, fmt.Errorf("record is not part of a work transaction")
+	}
+	return p.work, nil
+}
+
+func (p *parser) addPublisher(rec record) error {
+	work, err := p.getWork()
+	if err != nil {
+		return err
+	}
+	shares, err := rec.getShares(116, 124, 132)
+	if err != nil {
+		return err
+	}
+	work.Publishers = append(work.Publishers, Publisher{
+		Line:                  rec.line,
+		Controlled:            rec.recordType == PublisherRecord,
+		Chain:                 rec.get(20, 2),
+		InterestedPartyNumber: rec.get(22, 9),
+		Name:                  rec.get(31, 45),
+		Type:                  rec.get(77, 2),
+		IpiNameNumber:         rec.get(88, 11),
+		Shares:                shares,
+		Territories:           []TerritoryShare{},
+	})
+	return nil
+}
+
+func (p *parser) addWriter(rec record) error {
+	work, err := p.getWork()
+	if err != nil {
+		return err
+	}
+	shares, err := rec.getShares(130, 138, 146)
+	if err != nil {
+		return err
+	}
+	work.Writers = append(work.Writers, Writer{
+		Line:                  rec.line,
+		Controlled:            rec.recordType == WriterRecord,
+		InterestedPartyNumber: rec.get(20, 9),
+		LastName:              rec.get(29, 45),
+		FirstName:             rec.get(74, 30),
+		Designation:           rec.get(105, 2),
+		IpiNameNumber:         rec.get(116, 11),
+		Shares:                shares,
+		Territories:           []TerritoryShare{},
+		Publishers:            []string{},
+		PublisherLines:        []int{},
+	})
+	return nil
+}
+
+//parseTerritoryShare - reads a SPT or SWT record whose collection shares start at a position
+func parseTerritoryShare(rec record, sharesStart int) (TerritoryShare, error) {
+	territoryShare := TerritoryShare{Line: rec.line}
+	shares, err := rec.getShares(sharesStart, sharesStart+5, sharesStart+10)
+	if err != nil {
+		return territoryShare, err
+	}
+	territoryShare.Shares = shares
+	switch rec.get(sharesStart+15, 1) {
+	case "I":
+		territoryShare.Include = true
+	case "E":
+		territoryShare.Include = false
+	default:
+		return territoryShare, fmt.Errorf("inclusion/exclusion indicator '%s' is not I or E", rec.get(sharesStart+15, 1))
+	}
+	territoryShare.TisCode = rec.get(sharesStart+16, 4)
+	territoryShare.Sequence = rec.get(sharesStart+21, 3)
+	return territoryShare, nil
+}
+
+func (p *parser) addPublisherTerritory(rec record) error {
+	work, err := p.getWork()
+	if err != nil {
+		return err
+	}
+	territoryShare, err := parseTerritoryShare(rec, 35)
+	if err != nil {
+		return err
+	}
+	interestedPartyNumber := rec.get(20, 9)
+	for i := len(work.Publishers) - 1; i >= 0; i-- {
+		if work.Publishers[i].InterestedPartyNumber == interestedPartyNumber {
+			work.Publishers[i].Territories = append(work.Publishers[i].Territories, territoryShare)
+			return nil
+		}
+	}
+	return fmt.Errorf("publisher '%s' is not a publisher of the work", interestedPartyNumber)
+}
+
+func (p *parser) addWriterTerritory(rec record) error {
+	work, err := p.getWork()
+	if err != nil {
+		return err
+	}
+	territoryShare, err := parseTerritoryShare(rec, 29)
+	if err != nil {
+		return err
+	}
+	interestedPartyNumber := rec.get(20, 9)
+	for i := len(work.Writers) - 1; i >= 0; i-- {
+		if work.Writers[i].InterestedPartyNumber == interestedPartyNumber {
+			work.Writers[i].Territories = append(work.Writers[i].Territories, territoryShare)
+			return nil
+		}
+	}
+	return fmt.Errorf("writer '%s' is not a writer of the work", interestedPartyNumber)
+}
+
+//addPublisherForWriter - links a writer to its original publisher, a PWR record without writer is the one of the writer before it
+func (p *parser) addPublisherForWriter(rec record) error {
+	work, err := p.getWork()
+	if err != nil {
+		return err
+	}
+	publisherNumber := rec.get(20, 9)
+	writerNumber := rec.get(102, 9)
+	for i := len(work.Writers) - 1; i >= 0; i-- {
+		if writerNumber == "" || work.Writers[i].InterestedPartyNumber == writerNumber {
+			work.Writers[i].Publishers = append(work.Writers[i].Publishers, publisherNumber)
+			work.Writers[i].PublisherLines = append(work.Writers[i].PublisherLines, rec.line)
+			return nil
+		}
+	}
+	return fmt.Errorf("writer '%s' is not a writer of the work", writerNumber)
+}
+
+func (p *parser) addRecording(rec record) error {
+	work, err := p.getWork()
+	if err != nil {
+		return err
+	}
+	work.Recordings = append(work.Recordings, Recording{Line: rec.line, Isrc: rec.get(250, 12)})
+	return nil
+}
+
+// Name returns the name of a writer as first name followed by last name
+func (writer Writer) Name() string {
+	return strings.TrimSpace(writer.FirstName + " " + writer.LastName)
+}
@@ -0,0 +1,158 @@
+package cwr
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"axispoint-cc/territory"
+)
+
+func importSample(t *testing.T, options Options) *Import {
+	file, err := os.Open("testdata/sample.cwr")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer file.Close()
+	cwrImport, err := ImportFile(file, options)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	return cwrImport
+}
+
+func Test_Parse(t *testing.T) {
+	file, err := Parse(strings.NewReader("HDRPB123456789PUBLISHER\n" +
+		"GRHNWR00001\n" +
+		"SWR0000000000000001W1\n" +
+		"NWR0000000000000000TITLE" + strings.Repeat(" ", 55) + "EN" + "W001\n" +
+		"SPT0000000000000001P1             050000500005000X2136N001\n" +
+		"GRT000010000000100000005\n" +
+		"TRL000010000000200000007\n"))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expectedHeader := Header{SenderType: "PB", SenderID: "123456789", SenderName: "PUBLISHER", Version: "2.1"}
+	if !reflect.DeepEqual(expectedHeader, file.Header) || len(file.Works) != 1 || file.Works[0].SubmitterWorkNumber != "W001" || file.Works[0].Errors != 1 {
+		t.Fatalf("Actual file is not equal to expected file: %+v", file)
+	}
+	actualIssues := []string{}
+	for _, issue := range file.Issues {
+		actualIssues = append(actualIssues, issue.Message)
+	}
+	expectedIssues := []string{
+		"SWR record: record is not part of a work transaction",
+		"SPT record: inclusion/exclusion indicator 'X' is not I or E",
+		"TRL record: trailer reports 2 transactions, the file has 1",
+	}
+	if !reflect.DeepEqual(expectedIssues, actualIssues) {
+		t.Fatalf("Actual issues are not equal to expected issues: %+v", file.Issues)
+	}
+
+	_, err = Parse(strings.NewReader("NWR0000000000000000TITLE\n"))
+	if err == nil || err.Error() != "CWR file has no HDR record" {
+		t.Fatalf("Expected a file without header to be rejected: %v", err)
+	}
+}
+
+func Test_ImportFile(t *testing.T) {
+	cwrImport := importSample(t, Options{})
+
+	rightHolders := []RightHolder{
+		{IPI: "12345678993", Percent: 50, Shares: map[string]float64{Performance: 50, Mechanical: 50, Sync: 50}, Role: "E"},
+		{IPI: "34567891230", Percent: 50, Shares: map[string]float64{Performance: 50, Mechanical: 50, Sync: 50}, Role: "CA"},
+	}
+	expectedCopyrightDataReports := []CopyrightDataReport{
+		{CopyrightDataUUID: "123456789-W001-USRC17607839", Isrc: "USRC17607839", SongTitle: "HELLO WORLD", RightHolders: rightHolders},
+		{CopyrightDataUUID: "123456789-W001-QZAB11800001", Isrc: "QZAB11800001", SongTitle: "HELLO WORLD", RightHolders: rightHolders},
+	}
+	if !reflect.DeepEqual(expectedCopyrightDataReports, cwrImport.CopyrightDataReports) {
+		t.Fatalf("Actual copyright data reports are not equal to expected copyright data reports: %+v", cwrImport.CopyrightDataReports)
+	}
+
+	// the administrator collects its collection share of the share owned by its chain, the original
+	// publisher the part of the writer share the writer does not collect
+	expectedCollectionRights := []CollectionRight{
+		{CollectionRightUUID: "123456789-W001-P1-P2-001", From: "12345678993", FromName: "ORIGINAL PUBLISHING", Territories: []string{"DE"}, RightHolders: []RightHolder{{IPI: "23456789157", Percent: 100, Role: "AM"}}},
+		{CollectionRightUUID: "123456789-W001-P1-P2-002", From: "12345678993", FromName: "ORIGINAL PUBLISHING", Territories: []string{"AT"}, RightHolders: []RightHolder{{IPI: "23456789157", Percent: 50, Role: "AM"}}},
+		{CollectionRightUUID: "123456789-W001-W1-P1-001", From: "34567891230", FromName: "JOHN LENNON", Territories: []string{territory.World}, ExcludedTerritories: []string{"US"}, RightHolders: []RightHolder{{IPI: "12345678993", Percent: 50, Role: "E"}}},
+	}
+	if !reflect.DeepEqual(expectedCollectionRights, cwrImport.CollectionRights) {
+		t.Fatalf("Actual collection rights are not equal to expected collection rights: %+v", cwrImport.CollectionRights)
+	}
+
+	report := cwrImport.Report
+	if report.Valid || report.Version != "2.1" || report.CreationDate != "2019-01-15" || report.Records != 28 || report.Works != 4 || report.RejectedWorks != 2 || report.Batches != 2 {
+		t.Fatalf("Actual report is not equal to expected report: %+v", report)
+	}
+	actualIssues := []string{}
+	for _, issue := range report.Issues {
+		actualIssues = append(actualIssues, issue.Severity, issue.Message)
+	}
+	expectedIssues := []string{
+		Error, "publisher 'UNKNOWN PUBLISHING' has no IPI name number",
+		Error, "TIS territory '9999' is not supported",
+		Error, "publisher 'P9' of writer 'ANN SMITH' is not a publisher of the work",
+		Warning, "SYNC ownership shares of work 'W004' total 90%",
+		Warning, "work 'W004' has no recording with an ISRC, it has no copyright data report",
+	}
+	if !reflect.DeepEqual(expectedIssues, actualIssues) {
+		t.Fatalf("Actual issues are not equal to expected issues: %+v", report.Issues)
+	}
+}
+
+func Test_Territory(t *testing.T) {
+	countries := map[string]bool{}
+	for tisCode := range tisCountries {
+		country, err := Territory(tisCode)
+		if err != nil || !territory.IsCountry(country) || countries[country] {
+			t.Fatalf("TIS code '%s' is not the code of a country: %s %v", tisCode, country, err)
+		}
+		countries[country] = true
+	}
+	if len(countries) != len(territory.Countries()) {
+		t.Fatalf("Expected a TIS code per country, found %d", len(countries))
+	}
+
+	actual := []string{}
+	for _, tisCode := range []string{"2136", "840", "0276"} {
+		code, err := Territory(tisCode)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		actual = append(actual, code)
+	}
+	if !reflect.DeepEqual([]string{territory.World, "US", "DE"}, actual) {
+		t.Fatalf("Actual territories are not equal to expected territories: %v", actual)
+	}
+	_, err := Territory("2120")
+	if err == nil {
+		t.Fatalf("Expected a TIS territory group to be rejected")
+	}
+}
+
+func Test_Payloads(t *testing.T) {
+	cwrImport := importSample(t, Options{BatchSize: 2})
+
+	functions := []string{}
+	sizes := []int{}
+	for _, payload := range cwrImport.Payloads {
+		batch := []json.RawMessage{}
+		err := json.Unmarshal([]byte(payload.Args[0]), &batch)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		functions = append(functions, payload.Function)
+		sizes = append(sizes, len(batch))
+	}
+	if !reflect.DeepEqual([]string{CopyrightDataReportFunction, CollectionRightFunction, CollectionRightFunction}, functions) || !reflect.DeepEqual([]int{2, 2, 1}, sizes) {
+		t.Fatalf("Actual payloads are not equal to expected payloads: %v %v", functions, sizes)
+	}
+
+	_, err := Payloads(cwrImport.CopyrightDataReports, cwrImport.CollectionRights, 0)
+	if err == nil {
+		t.Fatalf("Expected a batch size of 0 to be rejected")
+	}
+}
@@ -0,0 +1,417 @@
+package cwr
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"axispoint-cc/client"
+	"axispoint-cc/identifier"
+	"axispoint-cc/payload"
+)
+
+// Chaincode functions the copyright data reports and collection rights are submitted to
+const (
+	CopyrightDataReportFunction = "addCopyrightDataReports"
+	CollectionRightFunction     = "addCollectionRights"
+)
+
+// DefaultBatchSize - the number of assets of a chaincode payload when no batch size is given
+const DefaultBatchSize = 100
+
+// Right categories of the shares of right holders
+const (
+	Mechanical  = "MECHANICAL"
+	Performance = "PERFORMANCE"
+	Sync        = "SYNC"
+)
+
+// The assets of the chaincode an import maps the works to, as its client encodes them
+type (
+	CopyrightDataReport = client.CopyrightDataReport
+	RightHolder         = client.RightHolder
+	CollectionRight     = client.CollectionRight
+)
+
+// ValidationReport - the outcome of the import of a CWR file
+type ValidationReport struct {
+	Sender               string  `json:"sender"`
+	SenderName           string  `json:"senderName"`
+	Version              string  `json:"version"`
+	CreationDate         string  `json:"creationDate"`
+	Lines                int     `json:"lines"`
+	Records              int     `json:"records"`
+	Transactions         int     `json:"transactions"`
+	Works                int     `json:"works"`
+	RejectedWorks        int     `json:"rejectedWorks"`
+	CopyrightDataReports int     `json:"copyrightDataReports"`
+	CollectionRights     int     `json:"collectionRights"`
+	Batches              int     `json:"batches"`
+	Issues               []Issue `json:"issues"`
+	Valid                bool    `json:"valid"`
+}
+
+// Options - the options of an import
+type Options struct {
+	// BatchSize is the number of assets per chaincode payload, DefaultBatchSize by default
+	BatchSize int
+}
+
+// Import - the copyright data reports and collection rights of a CWR file, their chaincode payloads and the validation report
+type Import struct {
+	CopyrightDataReports []CopyrightDataReport `json:"copyrightDataReports"`
+	CollectionRights     []CollectionRight     `json:"collectionRights"`
+	Payloads             []payload.Payload     `json:"payloads"`
+	Report               ValidationReport      `json:"report"`
+}
+
+// ImportFile parses a CWR file and maps its works to copyright data reports and collection rights batched in chaincode payloads
+func ImportFile(reader io.Reader, options Options) (*Import, error) {
+	file, err := Parse(reader)
+	if err != nil {
+		return nil, err
+	}
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	copyrightDataReports, collectionRights, rejectedWorks, issues := file.Rights()
+	payloads, err := Payloads(copyrightDataReports, collectionRights, batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	report := ValidationReport{
+		Sender:               file.Header.SenderID,
+		SenderName:           file.Header.SenderName,
+		Version:              file.Header.Version,
+		CreationDate:         file.Header.CreationDate,
+		Lines:                file.Lines,
+		Records:              file.Records,
+		Transactions:         file.Transactions,
+		Works:                len(file.Works),
+		RejectedWorks:        rejectedWorks,
+		CopyrightDataReports: len(copyrightDataReports),
+		CollectionRights:     len(collectionRights),
+		Batches:              len(payloads),
+		Issues:               append(append([]Issue{}, file.Issues...), issues...),
+	}
+	sort.SliceStable(report.Issues, func(i, j int) bool { return report.Issues[i].Line < report.Issues[j].Line })
+	report.Valid = true
+	for _, issue := range report.Issues {
+		if issue.Severity == Error {
+			report.Valid = false
+		}
+	}
+
+	return &Import{CopyrightDataReports: copyrightDataReports, CollectionRights: collectionRights, Payloads: payloads, Report: report}, nil
+}
+
+// Rights maps the works of a CWR file to copyright data reports and collection rights. A work with an error,
+// e.g. a right holder without IPI name number or an unknown territory, is rejected as a whole rather than
+// recorded with a partial split.
+func (file *File) Rights() ([]CopyrightDataReport, []CollectionRight, int, []Issue) {
+	copyrightDataReports := []CopyrightDataReport{}
+	collectionRights := []CollectionRight{}
+	rejectedWorks := 0
+	issues := []Issue{}
+	for _, work := range file.Works {
+		workCopyrightDataReports, workCollectionRights, workIssues := file.getWorkRights(work)
+		issues = append(issues, workIssues...)
+		if hasError(workIssues) || work.Errors > 0 {
+			rejectedWorks++
+			continue
+		}
+		copyrightDataReports = append(copyrightDataReports, workCopyrightDataReports...)
+		collectionRights = append(collectionRights, workCollectionRights...)
+	}
+	return copyrightDataReports, collectionRights, rejectedWorks, issues
+}
+
+//getWorkRights - maps a work to a copyright data report per recording and the collection rights of its publishers and writers
+func (file *File) getWorkRights(work Work) ([]CopyrightDataReport, []CollectionRight, []Issue) {
+	issues := []Issue{}
+	if work.SubmitterWorkNumber == "" {
+		issues = append(issues, Issue{Line: work.Line, Severity: Error, Message: fmt.Sprintf("work '%s' has no submitter work number", work.Title)})
+		return nil, nil, issues
+	}
+	workID := fmt.Sprintf("%s-%s", file.Header.SenderID, work.SubmitterWorkNumber)
+
+	rightHolders, rightHolderIssues := getRightHolders(work)
+	issues = append(issues, rightHolderIssues...)
+
+	copyrightDataReports := []CopyrightDataReport{}
+	for _, recording := range work.Recordings {
+		if recording.Isrc == "" {
+			continue
+		}
+		isrc, err := identifier.NormalizeIsrc(recording.Isrc)
+		if err != nil {
+			issues = append(issues, Issue{Line: recording.Line, Severity: Error, Message: err.Error()})
+			continue
+		}
+		copyrightDataReports = append(copyrightDataReports, CopyrightDataReport{
+			CopyrightDataUUID: fmt.Sprintf("%s-%s", workID, isrc),
+			Isrc:              isrc,
+			SongTitle:         work.Title,
+			RightHolders:      rightHolders,
+		})
+	}
+	if len(copyrightDataReports) == 0 {
+		issues = append(issues, Issue{Line: work.Line, Severity: Warning, Message: fmt.Sprintf("work '%s' has no recording with an ISRC, it has no copyright data report", work.SubmitterWorkNumber)})
+	}
+
+	collectionRights, collectionRightIssues := getPublisherCollectionRights(work, workID)
+	issues = append(issues, collectionRightIssues...)
+	writerCollectionRights, collectionRightIssues := getWriterCollectionRights(work, workID)
+	issues = append(issues, collectionRightIssues...)
+	collectionRights = append(collectionRights, writerCollectionRights...)
+
+	return copyrightDataReports, collectionRights, issues
+}
+
+//getRightHolders - returns the publishers and writers owning a share of a work and warns when their shares do not total 100%
+func getRightHolders(work Work) ([]RightHolder, []Issue) {
+	rightHolders := []RightHolder{}
+	issues := []Issue{}
+	total := Shares{}
+	addRightHolder := func(line int, name string, ipiNameNumber string, role string, shares Shares) {
+		if shares == (Shares{}) {
+			return
+		}
+		ipi, err := normalizeIpi(name, ipiNameNumber)
+		if err != nil {
+			issues = append(issues, Issue{Line: line, Severity: Error, Message: err.Error()})
+			return
+		}
+		total.Performance += shares.Performance
+		total.Mechanical += shares.Mechanical
+		total.Sync += shares.Sync
+		rightHolders = append(rightHolders, RightHolder{
+			IPI:     ipi,
+			Percent: shares.Performance,
+			Shares:  map[string]float64{Performance: shares.Performance, Mechanical: shares.Mechanical, Sync: shares.Sync},
+			Role:    role,
+		})
+	}
+	for _, publisher := range work.Publishers {
+		addRightHolder(publisher.Line, fmt.Sprintf("publisher '%s'", publisher.Name), publisher.IpiNameNumber, publisher.Type, publisher.Shares)
+	}
+	for _, writer := range work.Writers {
+		addRightHolder(writer.Line, fmt.Sprintf("writer '%s'", writer.Name()), writer.IpiNameNumber, writer.Designation, writer.Shares)
+	}
+
+	if len(issues) > 0 {
+		return rightHolders, issues
+	}
+	for category, share := range map[string]float64{Performance: total.Performance, Mechanical: total.Mechanical, Sync: total.Sync} {
+		if share != 0 && math.Abs(share-100) >= 0.01 {
+			issues = append(issues, Issue{Line: work.Line, Severity: Warning, Message: fmt.Sprintf("%s ownership shares of work '%s' total %v%%", category, work.SubmitterWorkNumber, round(share))})
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Message < issues[j].Message })
+	return rightHolders, issues
+}
+
+// getPublisherCollectionRights returns the collection rights of the administrators and sub-publishers of the
+// publisher chains of a work. The later publishers of a chain collect, in each territory they include, the
+// percentage of the share owned by the chain that is their collection share.
+func getPublisherCollectionRights(work Work, workID string) ([]CollectionRight, []Issue) {
+	collectionRights := []CollectionRight{}
+	issues := []Issue{}
+
+	chains := map[string][]Publisher{}
+	chainOrder := []string{}
+	for _, publisher := range work.Publishers {
+		if !publisher.Controlled {
+			continue
+		}
+		if _, ok := chains[publisher.Chain]; !ok {
+			chainOrder = append(chainOrder, publisher.Chain)
+		}
+		chains[publisher.Chain] = append(chains[publisher.Chain], publisher)
+	}
+
+	for _, chain := range chainOrder {
+		publishers := chains[chain]
+		if len(publishers) < 2 {
+			continue
+		}
+		originalPublisher := publishers[0]
+		owned := 0.0
+		for _, publisher := range publishers {
+			owned += publisher.Shares.Performance
+		}
+		if owned == 0 {
+			issues = append(issues, Issue{Line: originalPublisher.Line, Severity: Warning, Message: fmt.Sprintf("publisher chain %s of work '%s' owns no performance share, it has no collection right", chain, work.SubmitterWorkNumber)})
+			continue
+		}
+		from, err := normalizeIpi(fmt.Sprintf("publisher '%s'", originalPublisher.Name), originalPublisher.IpiNameNumber)
+		if err != nil {
+			issues = append(issues, Issue{Line: originalPublisher.Line, Severity: Error, Message: err.Error()})
+			continue
+		}
+		for _, publisher := range publishers[1:] {
+			collector, err := normalizeIpi(fmt.Sprintf("publisher '%s'", publisher.Name), publisher.IpiNameNumber)
+			if err != nil {
+				issues = append(issues, Issue{Line: publisher.Line, Severity: Error, Message: err.Error()})
+				continue
+			}
+			includes, excludedTerritories, territoryIssues := getTerritories(publisher.Territories)
+			issues = append(issues, territoryIssues...)
+			for _, include := range includes {
+				percent := math.Min(round(include.share.Performance/owned*100), 100)
+				if percent <= 0 {
+					continue
+				}
+				collectionRights = append(collectionRights, CollectionRight{
+					CollectionRightUUID: fmt.Sprintf("%s-%s-%s-%s", workID, originalPublisher.InterestedPartyNumber, publisher.InterestedPartyNumber, include.sequence),
+					From:                from,
+					FromName:            originalPublisher.Name,
+					Territories:         []string{include.territory},
+					ExcludedTerritories: excludedTerritories,
+					RightHolders:        []RightHolder{{IPI: collector, Percent: percent, Role: publisher.Type}},
+				})
+			}
+		}
+	}
+	return collectionRights, issues
+}
+
+// getWriterCollectionRights returns the collection rights of the original publishers of the controlled writers
+// of a work. In each territory the writer includes, its publisher collects the part of the owned share the
+// writer does not collect itself. A writer without territory is collected by its publisher worldwide.
+func getWriterCollectionRights(work Work, workID string) ([]CollectionRight, []Issue) {
+	collectionRights := []CollectionRight{}
+	issues := []Issue{}
+	for _, writer := range work.Writers {
+		if !writer.Controlled || len(writer.Publishers) == 0 || writer.Shares.Performance == 0 {
+			continue
+		}
+		if len(writer.Publishers) > 1 {
+			issues = append(issues, Issue{Line: writer.PublisherLines[1], Severity: Warning, Message: fmt.Sprintf("writer '%s' has several original publishers, only the first one collects", writer.Name())})
+		}
+		includes, excludedTerritories, territoryIssues := getTerritories(writer.Territories)
+		issues = append(issues, territoryIssues...)
+		if len(writer.Territories) == 0 {
+			includes = []territoryInclude{{sequence: "0"}}
+		}
+		var publisher *Publisher
+		for i := range work.Publishers {
+			if work.Publishers[i].InterestedPartyNumber == writer.Publishers[0] {
+				publisher = &work.Publishers[i]
+				break
+			}
+		}
+		if publisher == nil {
+			issues = append(issues, Issue{Line: writer.PublisherLines[0], Severity: Error, Message: fmt.Sprintf("publisher '%s' of writer '%s' is not a publisher of the work", writer.Publishers[0], writer.Name())})
+			continue
+		}
+		from, err := normalizeIpi(fmt.Sprintf("writer '%s'", writer.Name()), writer.IpiNameNumber)
+		if err != nil {
+			// reported with the right holders of the work
+			continue
+		}
+		collector, err := normalizeIpi(fmt.Sprintf("publisher '%s'", publisher.Name), publisher.IpiNameNumber)
+		if err != nil {
+			issues = append(issues, Issue{Line: writer.PublisherLines[0], Severity: Error, Message: err.Error()})
+			continue
+		}
+
+		for _, include := range includes {
+			percent := round((writer.Shares.Performance - include.share.Performance) / writer.Shares.Performance * 100)
+			if percent <= 0 {
+				continue
+			}
+			collectionRight := CollectionRight{
+				CollectionRightUUID: fmt.Sprintf("%s-%s-%s-%s", workID, writer.InterestedPartyNumber, publisher.InterestedPartyNumber, include.sequence),
+				From:                from,
+				FromName:            writer.Name(),
+				ExcludedTerritories: excludedTerritories,
+				RightHolders:        []RightHolder{{IPI: collector, Percent: percent, Role: publisher.Type}},
+			}
+			if include.territory != "" {
+				collectionRight.Territories = []string{include.territory}
+			}
+			collectionRights = append(collectionRights, collectionRight)
+		}
+	}
+	return collectionRights, issues
+}
+
+//territoryInclude - an included territory of an interested party and its collection shares
+type territoryInclude struct {
+	territory string
+	sequence  string
+	share     Shares
+}
+
+//getTerritories - returns the included territories of an interested party and the territories it excludes from all of them
+func getTerritories(territoryShares []TerritoryShare) ([]territoryInclude, []string, []Issue) {
+	includes := []territoryInclude{}
+	var excludedTerritories []string
+	issues := []Issue{}
+	for _, territoryShare := range territoryShares {
+		code, err := Territory(territoryShare.TisCode)
+		if err != nil {
+			issues = append(issues, Issue{Line: territoryShare.Line, Severity: Error, Message: err.Error()})
+			continue
+		}
+		if !territoryShare.Include {
+			excludedTerritories = append(excludedTerritories, code)
+			continue
+		}
+		sequence := territoryShare.Sequence
+		if sequence == "" {
+			sequence = fmt.Sprintf("L%d", territoryShare.Line)
+		}
+		includes = append(includes, territoryInclude{territory: code, sequence: sequence, share: territoryShare.Shares})
+	}
+	return includes, excludedTerritories, issues
+}
+
+//normalizeIpi - returns the IPI name number of an interested party or an error naming the party
+func normalizeIpi(party string, ipiNameNumber string) (string, error) {
+	if ipiNameNumber == "" {
+		return "", fmt.Errorf("%s has no IPI name number", party)
+	}
+	ipi, err := identifier.NormalizeIpi(ipiNameNumber)
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", party, err.Error())
+	}
+	return ipi, nil
+}
+
+//hasError - returns whether issues have an error
+func hasError(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Payloads batches copyright data reports and collection rights into addCopyrightDataReports and
+// addCollectionRights invocations of at most batchSize assets
+func Payloads(copyrightDataReports []CopyrightDataReport, collectionRights []CollectionRight, batchSize int) ([]payload.Payload, error) {
+	payloads, err := payload.Batches(CopyrightDataReportFunction, len(copyrightDataReports), batchSize, func(start int, end int) interface{} {
+		return copyrightDataReports[start:end]
+	})
+	if err != nil {
+		return nil, err
+	}
+	collectionRightPayloads, err := payload.Batches(CollectionRightFunction, len(collectionRights), batchSize, func(start int, end int) interface{} {
+		return collectionRights[start:end]
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(payloads, collectionRightPayloads...), nil
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
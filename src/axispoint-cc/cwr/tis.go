@@ -0,0 +1,66 @@
+package cwr
+
+import (
+	"fmt"
+	"strings"
+
+	"axispoint-cc/territory"
+)
+
+// TisWorld - the TIS code of the world
+const TisWorld = "2136"
+
+//tisCountries - ISO 3166-1 alpha-2 codes by TIS numeric code, the TIS code of a country is its ISO 3166-1 numeric code
+var tisCountries = map[string]string{
+	"0004": "AF", "0008": "AL", "0010": "AQ", "0012": "DZ", "0016": "AS", "0020": "AD", "0024": "AO", "0028": "AG",
+	"0031": "AZ", "0032": "AR", "0036": "AU", "0040": "AT", "0044": "BS", "0048": "BH", "0050": "BD", "0051": "AM",
+	"0052": "BB", "0056": "BE", "0060": "BM", "0064": "BT", "0068": "BO", "0070": "BA", "0072": "BW", "0074": "BV",
+	"0076": "BR", "0084": "BZ", "0086": "IO", "0090": "SB", "0092": "VG", "0096": "BN", "0100": "BG", "0104": "MM",
+	"0108": "BI", "0112": "BY", "0116": "KH", "0120": "CM", "0124": "CA", "0132": "CV", "0136": "KY", "0140": "CF",
+	"0144": "LK", "0148": "TD", "0152": "CL", "0156": "CN", "0158": "TW", "0162": "CX", "0166": "CC", "0170": "CO",
+	"0174": "KM", "0175": "YT", "0178": "CG", "0180": "CD", "0184": "CK", "0188": "CR", "0191": "HR", "0192": "CU",
+	"0196": "CY", "0203": "CZ", "0204": "BJ", "0208": "DK", "0212": "DM", "0214": "DO", "0218": "EC", "0222": "SV",
+	"0226": "GQ", "0231": "ET", "0232": "ER", "0233": "EE", "0234": "FO", "0238": "FK", "0239": "GS", "0242": "FJ",
+	"0246": "FI", "0248": "AX", "0250": "FR", "0254": "GF", "0258": "PF", "0260": "TF", "0262": "DJ", "0266": "GA",
+	"0268": "GE", "0270": "GM", "0275": "PS", "0276": "DE", "0288": "GH", "0292": "GI", "0296": "KI", "0300": "GR",
+	"0304": "GL", "0308": "GD", "0312": "GP", "0316": "GU", "0320": "GT", "0324": "GN", "0328": "GY", "0332": "HT",
+	"0334": "HM", "0336": "VA", "0340": "HN", "0344": "HK", "0348": "HU", "0352": "IS", "0356": "IN", "0360": "ID",
+	"0364": "IR", "0368": "IQ", "0372": "IE", "0376": "IL", "0380": "IT", "0384": "CI", "0388": "JM", "0392": "JP",
+	"0398": "KZ", "0400": "JO", "0404": "KE", "0408": "KP", "0410": "KR", "0414": "KW", "0417": "KG", "0418": "LA",
+	"0422": "LB", "0426": "LS", "0428": "LV", "0430": "LR", "0434": "LY", "0438": "LI", "0440": "LT", "0442": "LU",
+	"0446": "MO", "0450": "MG", "0454": "MW", "0458": "MY", "0462": "MV", "0466": "ML", "0470": "MT", "0474": "MQ",
+	"0478": "MR", "0480": "MU", "0484": "MX", "0492": "MC", "0496": "MN", "0498": "MD", "0499": "ME", "0500": "MS",
+	"0504": "MA", "0508": "MZ", "0512": "OM", "0516": "NA", "0520": "NR", "0524": "NP", "0528": "NL", "0531": "CW",
+	"0533": "AW", "0534": "SX", "0535": "BQ", "0540": "NC", "0548": "VU", "0554": "NZ", "0558": "NI", "0562": "NE",
+	"0566": "NG", "0570": "NU", "0574": "NF", "0578": "NO", "0580": "MP", "0581": "UM", "0583": "FM", "0584": "MH",
+	"0585": "PW", "0586": "PK", "0591": "PA", "0598": "PG", "0600": "PY", "0604": "PE", "0608": "PH", "0612": "PN",
+	"0616": "PL", "0620": "PT", "0624": "GW", "0626": "TL", "0630": "PR", "0634": "QA", "0638": "RE", "0642": "RO",
+	"0643": "RU", "0646": "RW", "0652": "BL", "0654": "SH", "0659": "KN", "0660": "AI", "0662": "LC", "0663": "MF",
+	"0666": "PM", "0670": "VC", "0674": "SM", "0678": "ST", "0682": "SA", "0686": "SN", "0688": "RS", "0690": "SC",
+	"0694": "SL", "0702": "SG", "0703": "SK", "0704": "VN", "0705": "SI", "0706": "SO", "0710": "ZA", "0716": "ZW",
+	"0724": "ES", "0728": "SS", "0729": "SD", "0732": "EH", "0740": "SR", "0744": "SJ", "0748": "SZ", "0752": "SE",
+	"0756": "CH", "0760": "SY", "0762": "TJ", "0764": "TH", "0768": "TG", "0772": "TK", "0776": "TO", "0780": "TT",
+	"0784": "AE", "0788": "TN", "0792": "TR", "0795": "TM", "0796": "TC", "0798": "TV", "0800": "UG", "0804": "UA",
+	"0807": "MK", "0818": "EG", "0826": "GB", "0831": "GG", "0832": "JE", "0833": "IM", "0834": "TZ", "0840": "US",
+	"0850": "VI", "0854": "BF", "0858": "UY", "0860": "UZ", "0862": "VE", "0876": "WF", "0882": "WS", "0887": "YE",
+	"0894": "ZM",
+}
+
+// Territory returns the territory code of the chaincode for a TIS numeric code: the alpha-2 code of a country
+// or WORLD. Other TIS territory groups are not supported.
+func Territory(tisCode string) (string, error) {
+	code := strings.TrimSpace(tisCode)
+	if code == "" {
+		return "", fmt.Errorf("TIS code is required")
+	}
+	if len(code) < 4 {
+		code = strings.Repeat("0", 4-len(code)) + code
+	}
+	if code == TisWorld {
+		return territory.World, nil
+	}
+	if country, ok := tisCountries[code]; ok {
+		return country, nil
+	}
+	return "", fmt.Errorf("TIS territory '%s' is not supported", tisCode)
+}
@@ -0,0 +1,499 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+var getDisputesForQueryString = getObjectByQueryFromLedger
+
+// DisputeResponse : defines response data from blockchain request
+type DisputeResponse struct {
+	DisputeUUID string `json:"disputeUUID"`
+	Message     string `json:"message"`
+	Success     bool   `json:"success"`
+}
+
+// DisputeOutput : defines accumulated output of blockchain requests
+type DisputeOutput struct {
+	SuccessCount     int               `json:"successCount"`
+	FailureCount     int               `json:"failureCount"`
+	DisputeResponses []DisputeResponse `json:"disputeResponses"`
+}
+
+// DisputeResolutionOutput : defines the output of a dispute resolution
+type DisputeResolutionOutput struct {
+	Dispute             Dispute              `json:"dispute"`
+	RoyaltyStatements   []RoyaltyStatement   `json:"royaltyStatements"`
+	ExploitationReports []ExploitationReport `json:"exploitationReports"`
+}
+
+/* addDisputes function contains business logic to open new
+Disputes against Royalty Statements, Exploitation Reports or Copyright Data Reports
+* @params   {Array} args
+* @property {string} 0       - stringified JSON array of disputes.
+* @return   {pb.Response}    - peer Response
+*/
+func addDisputes(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "addDisputes"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) != 1 {
+		return getErrorResponse("Missing arguments: Needed Dispute object to Create")
+	}
+
+	createdDate, err := getTxTimestamp(stub)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	disputeOutput := DisputeOutput{}
+	disputes := &[]Dispute{}
+	disputeResponses := []DisputeResponse{}
+
+	// Unmarshal the args input to an array of dispute records
+	err = jsonToObject([]byte(args[0]), disputes)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	// a transaction does not read its own writes on a peer, the disputes opened earlier in the batch are kept here
+	batchDisputes := map[string]bool{}
+	batchOpenDisputes := map[string]string{}
+
+	// iterate over disputes
+	for _, dispute := range *disputes {
+		dispute.DocType = DISPUTE
+		dispute.State = DISPUTE_OPEN
+		dispute.CreatedDate = createdDate
+		dispute.Resolution = nil
+		if dispute.Comments == nil {
+			dispute.Comments = []DisputeComment{}
+		}
+		for i := range dispute.Comments {
+			dispute.Comments[i].CreatedDate = createdDate
+		}
+		disputeResponse := DisputeResponse{}
+		disputeResponse.DisputeUUID = dispute.DisputeUUID
+		disputeResponse.Success = true
+
+		if dispute.DisputeUUID == "" {
+			disputeResponse.Success = false
+			disputeResponse.Message = "Dispute UUID is required"
+			disputeResponses = append(disputeResponses, disputeResponse)
+			disputeOutput.FailureCount++
+			continue
+		}
+
+		// check if dispute already exists
+		disputeExistingBytes, err := stub.GetState(dispute.DisputeUUID)
+		if disputeExistingBytes != nil || batchDisputes[dispute.DisputeUUID] {
+			disputeResponse.Success = false
+			disputeResponse.Message = "Dispute already exists!"
+			disputeResponses = append(disputeResponses, disputeResponse)
+			disputeOutput.FailureCount++
+			continue
+		}
+
+		// check the dispute against its target
+		err = validateDispute(stub, dispute)
+		if openDisputeUUID, ok := batchOpenDisputes[dispute.TargetUUID]; err == nil && ok {
+			err = fmt.Errorf("Dispute target '%s' is already under open dispute '%s'", dispute.TargetUUID, openDisputeUUID)
+		}
+		if err != nil {
+			disputeResponse.Success = false
+			disputeResponse.Message = err.Error()
+			disputeResponses = append(disputeResponses, disputeResponse)
+			disputeOutput.FailureCount++
+			continue
+		}
+
+		// convert dispute to bytes
+		disputeBytes, err := objectToJSON(dispute)
+		if err != nil {
+			disputeResponse.Success = false
+			disputeResponse.Message = err.Error()
+			disputeResponses = append(disputeResponses, disputeResponse)
+			disputeOutput.FailureCount++
+			continue
+		}
+
+		// add dispute to the ledger and freeze the target
+		err = stub.PutState(dispute.DisputeUUID, disputeBytes)
+		if err == nil {
+			err = putOpenDispute(stub, dispute.TargetUUID, dispute.DisputeUUID)
+		}
+		if err != nil {
+			disputeResponse.Success = false
+			disputeResponse.Message = err.Error()
+		}
+
+		if disputeResponse.Success {
+			batchDisputes[dispute.DisputeUUID] = true
+			batchOpenDisputes[dispute.TargetUUID] = dispute.DisputeUUID
+			disputeOutput.SuccessCount++
+		} else {
+			disputeResponses = append(disputeResponses, disputeResponse)
+			disputeOutput.FailureCount++
+		}
+	}
+
+	disputeOutput.DisputeResponses = disputeResponses
+
+	objBytes, _ := objectToJSON(disputeOutput)
+	logger.Info("EXITING <", methodName, disputeOutput)
+	return shim.Success(objBytes)
+}
+
+/* getDisputes function contains business logic to get
+Disputes based on the rich query selector
+* @params   {Array} args
+* @property {string} 0       - rich query selector.
+* @return   {pb.Response}    - peer Response
+*/
+func getDisputes(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "getDisputes"
+	logger.Infof("%s - Begin Execution ", methodName)
+	logger.Infof("%s - parameters received : %s", methodName, strings.Join(args, ","))
+	defer logger.Infof("%s - End Execution ", methodName)
+
+	queryString := fmt.Sprintf("{\"selector\":{\"docType\":\"%s\"}}", DISPUTE)
+	if len(args) == 1 {
+		queryString = args[0]
+	}
+
+	logger.Infof("%s - executing rich query : %s.", methodName, queryString)
+
+	// get disputes based on the rich query selector
+	queryResult, err := getDisputesForQueryString(stub, queryString)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	var resultDisputes []Dispute
+	err = sliceToStruct(queryResult, &resultDisputes)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	queryResultBytes, err := objectToJSON(resultDisputes)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	logger.Infof("result(s) received from couch db: %s", string(queryResultBytes))
+
+	//return bytes as result
+	return shim.Success(queryResultBytes)
+}
+
+/* addDisputeComment function adds a comment to the thread of an open Dispute
+* @params   {Array} args
+* @property {string} 0       - dispute UUID.
+* @property {string} 1       - stringified JSON dispute comment.
+* @return   {pb.Response}    - peer Response
+*/
+func addDisputeComment(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "addDisputeComment"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) != 2 {
+		return getErrorResponse("Missing arguments: Needed Dispute UUID and Dispute Comment object")
+	}
+
+	dispute, err := getDispute(stub, args[0])
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	if dispute.State != DISPUTE_OPEN {
+		return getErrorResponse(fmt.Sprintf("%s - Dispute '%s' is not open.", methodName, dispute.DisputeUUID))
+	}
+
+	disputeComment := DisputeComment{}
+	err = jsonToObject([]byte(args[1]), &disputeComment)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	if disputeComment.Comment == "" {
+		return getErrorResponse(fmt.Sprintf("%s - Dispute comment cannot be empty.", methodName))
+	}
+
+	disputeComment.CreatedDate, err = getTxTimestamp(stub)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	dispute.Comments = append(dispute.Comments, disputeComment)
+
+	disputeBytes, err := objectToJSON(dispute)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	err = stub.PutState(dispute.DisputeUUID, disputeBytes)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	logger.Info("EXITING <", methodName, dispute.DisputeUUID)
+	return shim.Success(disputeBytes)
+}
+
+/* resolveDispute function closes an open Dispute with a resolution outcome and unfreezes its target.
+When the resolution asks for it, the royalty statements of the affected exploitation reports are
+recomputed against the current copyright data and returned.
+* @params   {Array} args
+* @property {string} 0       - dispute UUID.
+* @property {string} 1       - stringified JSON dispute resolution.
+* @return   {pb.Response}    - peer Response
+*/
+func resolveDispute(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "resolveDispute"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) != 2 {
+		return getErrorResponse("Missing arguments: Needed Dispute UUID and Dispute Resolution object")
+	}
+
+	dispute, err := getDispute(stub, args[0])
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	if dispute.State != DISPUTE_OPEN {
+		return getErrorResponse(fmt.Sprintf("%s - Dispute '%s' is not open.", methodName, dispute.DisputeUUID))
+	}
+
+	disputeResolution := DisputeResolution{}
+	err = jsonToObject([]byte(args[1]), &disputeResolution)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	if disputeResolution.Outcome != ACCEPTED && disputeResolution.Outcome != REJECTED && disputeResolution.Outcome != WITHDRAWN {
+		return getErrorResponse(fmt.Sprintf("%s - Invalid dispute resolution outcome '%s'.", methodName, disputeResolution.Outcome))
+	}
+
+	disputeResolution.ResolvedDate, err = getTxTimestamp(stub)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	dispute.State = DISPUTE_RESOLVED
+	dispute.Resolution = &disputeResolution
+
+	disputeBytes, err := objectToJSON(dispute)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	err = stub.PutState(dispute.DisputeUUID, disputeBytes)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	// unfreeze the target of the dispute
+	err = deleteOpenDispute(stub, dispute.TargetUUID)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	disputeResolutionOutput := DisputeResolutionOutput{}
+	disputeResolutionOutput.Dispute = dispute
+	disputeResolutionOutput.RoyaltyStatements = []RoyaltyStatement{}
+	disputeResolutionOutput.ExploitationReports = []ExploitationReport{}
+
+	if disputeResolution.Recompute {
+		exploitationReports, err := getDisputedExploitationReports(stub, dispute)
+		if err != nil {
+			return getErrorResponse(err.Error())
+		}
+
+		for _, exploitationReport := range exploitationReports {
+			royaltyStatements, err := generateRoyaltyStatements(stub, &exploitationReport)
+			if err != nil {
+				return getErrorResponse(fmt.Sprintf("%s - Failed to recompute exploitation report '%s'. Error: %s", methodName, exploitationReport.ExploitationReportUUID, err.Error()))
+			}
+			disputeResolutionOutput.RoyaltyStatements = append(disputeResolutionOutput.RoyaltyStatements, royaltyStatements...)
+			disputeResolutionOutput.ExploitationReports = append(disputeResolutionOutput.ExploitationReports, exploitationReport)
+		}
+		logger.Infof("%s - recomputed %d royalty statements for dispute '%s'.", methodName, len(disputeResolutionOutput.RoyaltyStatements), dispute.DisputeUUID)
+	}
+
+	objBytes, _ := objectToJSON(disputeResolutionOutput)
+	logger.Info("EXITING <", methodName, dispute.DisputeUUID)
+	return shim.Success(objBytes)
+}
+
+//validateDispute - checks the reason code of the dispute and that its target exists with the expected type
+func validateDispute(stub shim.ChaincodeStubInterface, dispute Dispute) error {
+	switch dispute.ReasonCode {
+	case WRONG_AMOUNT, WRONG_SPLIT, WRONG_RIGHT_HOLDER, DUPLICATE_USAGE, OTHER:
+	default:
+		return fmt.Errorf("Invalid dispute reason code '%s'", dispute.ReasonCode)
+	}
+
+	switch dispute.TargetType {
+	case ROYALTYSTATEMENT, EXPLOITATIONREPORT, COPYRIGHTDATAREPORT:
+	default:
+		return fmt.Errorf("Invalid dispute target type '%s'", dispute.TargetType)
+	}
+
+	targetBytes, err := stub.GetState(dispute.TargetUUID)
+	if err != nil {
+		return err
+	}
+	if targetBytes == nil {
+		return fmt.Errorf("Dispute target '%s' does not exist", dispute.TargetUUID)
+	}
+
+	target := struct {
+		DocType string `json:"docType"`
+	}{}
+	err = jsonToObject(targetBytes, &target)
+	if err != nil {
+		return err
+	}
+	if target.DocType != dispute.TargetType {
+		return fmt.Errorf("Dispute target '%s' is a %s, not a %s", dispute.TargetUUID, target.DocType, dispute.TargetType)
+	}
+
+	openDisputeUUID, err := getOpenDispute(stub, dispute.TargetUUID)
+	if err != nil {
+		return err
+	}
+	if openDisputeUUID != "" {
+		return fmt.Errorf("Dispute target '%s' is already under open dispute '%s'", dispute.TargetUUID, openDisputeUUID)
+	}
+
+	return nil
+}
+
+//getDispute - get a dispute from the ledger by UUID
+func getDispute(stub shim.ChaincodeStubInterface, disputeUUID string) (Dispute, error) {
+	dispute := Dispute{}
+
+	disputeBytes, err := stub.GetState(disputeUUID)
+	if err != nil {
+		return dispute, err
+	}
+	if disputeBytes == nil {
+		return dispute, fmt.Errorf("UUID: %s does not exist", disputeUUID)
+	}
+
+	err = jsonToObject(disputeBytes, &dispute)
+	if err != nil {
+		return dispute, err
+	}
+	if dispute.DocType != DISPUTE {
+		return dispute, fmt.Errorf("UUID: %s is not a dispute", disputeUUID)
+	}
+
+	return dispute, nil
+}
+
+//getDisputedExploitationReports - get the exploitation reports affected by the target of a dispute
+func getDisputedExploitationReports(stub shim.ChaincodeStubInterface, dispute Dispute) ([]ExploitationReport, error) {
+	var methodName = "getDisputedExploitationReports"
+	exploitationReports := []ExploitationReport{}
+	exploitationReportUUID := dispute.TargetUUID
+
+	switch dispute.TargetType {
+	case ROYALTYSTATEMENT:
+		royaltyStatement := RoyaltyStatement{}
+		royaltyStatementBytes, err := stub.GetState(dispute.TargetUUID)
+		if err != nil {
+			return nil, err
+		}
+		err = jsonToObject(royaltyStatementBytes, &royaltyStatement)
+		if err != nil {
+			return nil, err
+		}
+		exploitationReportUUID = royaltyStatement.ExploitationReportUUID
+	case COPYRIGHTDATAREPORT:
+		copyrightDataReport := CopyrightDataReport{}
+		copyrightDataReportBytes, err := stub.GetState(dispute.TargetUUID)
+		if err != nil {
+			return nil, err
+		}
+		err = jsonToObject(copyrightDataReportBytes, &copyrightDataReport)
+		if err != nil {
+			return nil, err
+		}
+
+		// all the exploitation reports of the isrc during the copyright data report period are affected
+		queryString := fmt.Sprintf("{\"selector\":{\"docType\":\"%s\",\"isrc\":\"%s\",\"exploitationDate\":{\"$gte\":\"%s\",\"$lte\":\"%s\"}}}", EXPLOITATIONREPORT, copyrightDataReport.Isrc, copyrightDataReport.StartDate, copyrightDataReport.EndDate)
+		logger.Infof("%s - executing rich query : %s.", methodName, queryString)
+		queryResult, err := getExploitationReportsForQueryString(stub, queryString)
+		if err != nil {
+			return nil, err
+		}
+		err = sliceToStruct(queryResult, &exploitationReports)
+		if err != nil {
+			return nil, err
+		}
+		return exploitationReports, nil
+	}
+
+	exploitationReportBytes, err := stub.GetState(exploitationReportUUID)
+	if err != nil {
+		return nil, err
+	}
+	if exploitationReportBytes == nil {
+		return nil, fmt.Errorf("%s - Exploitation Report '%s' does not exist", methodName, exploitationReportUUID)
+	}
+	exploitationReport := ExploitationReport{}
+	err = jsonToObject(exploitationReportBytes, &exploitationReport)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(exploitationReports, exploitationReport), nil
+}
+
+//getRoyaltyStatementOpenDispute - returns the UUID of the open dispute freezing a royalty statement, either directly
+//or through the exploitation report or copyright data report it was generated from
+func getRoyaltyStatementOpenDispute(stub shim.ChaincodeStubInterface, royaltyStatement RoyaltyStatement) (string, error) {
+	for _, targetUUID := range []string{royaltyStatement.RoyaltyStatementUUID, royaltyStatement.ExploitationReportUUID, royaltyStatement.CopyrightDataReportUUID} {
+		if targetUUID == "" {
+			continue
+		}
+		openDisputeUUID, err := getOpenDispute(stub, targetUUID)
+		if err != nil || openDisputeUUID != "" {
+			return openDisputeUUID, err
+		}
+	}
+	return "", nil
+}
+
+//getOpenDispute - returns the UUID of the open dispute on the target or an empty string
+func getOpenDispute(stub shim.ChaincodeStubInterface, targetUUID string) (string, error) {
+	openDisputeKey, err := stub.CreateCompositeKey(OPENDISPUTE, []string{targetUUID})
+	if err != nil {
+		return "", err
+	}
+	openDisputeBytes, err := stub.GetState(openDisputeKey)
+	if err != nil {
+		return "", err
+	}
+	return string(openDisputeBytes), nil
+}
+
+//putOpenDispute - records the open dispute on the target
+func putOpenDispute(stub shim.ChaincodeStubInterface, targetUUID string, disputeUUID string) error {
+	openDisputeKey, err := stub.CreateCompositeKey(OPENDISPUTE, []string{targetUUID})
+	if err != nil {
+		return err
+	}
+	return stub.PutState(openDisputeKey, []byte(disputeUUID))
+}
+
+//deleteOpenDispute - removes the open dispute record of the target
+func deleteOpenDispute(stub shim.ChaincodeStubInterface, targetUUID string) error {
+	openDisputeKey, err := stub.CreateCompositeKey(OPENDISPUTE, []string{targetUUID})
+	if err != nil {
+		return err
+	}
+	err = stub.DelState(openDisputeKey)
+	if err != nil {
+		return errors.New("Failed to unfreeze dispute target " + targetUUID + ": " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"axispoint-cc/memstub"
+)
+
+var disputeRoyaltyStatement_in = `[{"royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":200,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":"MISSING_AFFILIATE"}]`
+var disputeSingle1_in = `[{"disputeUUID":"d1f0a6c2-5b7e-4c1d-9a43-7f0e2b6c8d11","targetUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","targetType":"ROYALTYSTATEMENT","raisedBy":"Ned-IPI","reasonCode":"WRONG_AMOUNT","claimedAmount":250,"evidenceHashes":["9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"],"comments":[{"author":"Ned-IPI","comment":"amount does not match the units reported"}]}]`
+var disputeSingle2_in = `[{"disputeUUID":"b7c2e9d4-1a3f-4e5b-8c6d-2f9a0e1b3c22","targetUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","targetType":"ROYALTYSTATEMENT","raisedBy":"Ned-IPI","reasonCode":"WRONG_SPLIT"}]`
+var disputeSameBatch_in = `[{"disputeUUID":"d1f0a6c2-5b7e-4c1d-9a43-7f0e2b6c8d11","targetUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","targetType":"ROYALTYSTATEMENT","raisedBy":"Ned-IPI","reasonCode":"WRONG_AMOUNT"},{"disputeUUID":"b7c2e9d4-1a3f-4e5b-8c6d-2f9a0e1b3c22","targetUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","targetType":"ROYALTYSTATEMENT","raisedBy":"Ned-IPI","reasonCode":"WRONG_SPLIT"},{"disputeUUID":"d1f0a6c2-5b7e-4c1d-9a43-7f0e2b6c8d11","targetUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","targetType":"ROYALTYSTATEMENT","raisedBy":"Ned-IPI","reasonCode":"OTHER"},{"targetUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","targetType":"ROYALTYSTATEMENT","raisedBy":"Ned-IPI","reasonCode":"OTHER"}]`
+var disputeInvalidTarget_in = `[{"disputeUUID":"c3d4e5f6-7a8b-4c9d-0e1f-2a3b4c5d6e33","targetUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","targetType":"EXPLOITATIONREPORT","raisedBy":"Ned-IPI","reasonCode":"OTHER"},{"disputeUUID":"e5f6a7b8-9c0d-4e1f-2a3b-4c5d6e7f8a44","targetUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","targetType":"ROYALTYSTATEMENT","raisedBy":"Ned-IPI","reasonCode":"NOT_A_REASON"}]`
+
+func MockGetDisputeResponse(functionName string) []byte {
+	switch functionName {
+	case "Test_AddDisputes_Single":
+		return []byte(`{"successCount":1,"failureCount":0,"disputeResponses":[]}`)
+	case "Test_AddDisputes_AlreadyOpen":
+		return []byte(`{"successCount":0,"failureCount":1,"disputeResponses":[{"disputeUUID":"b7c2e9d4-1a3f-4e5b-8c6d-2f9a0e1b3c22","message":"Dispute target '0daccfc9-9e3a-43f1-8e60-d0d0916a82e3' is already under open dispute 'd1f0a6c2-5b7e-4c1d-9a43-7f0e2b6c8d11'","success":false}]}`)
+	case "Test_AddDisputes_SameBatch":
+		return []byte(`{"successCount":1,"failureCount":3,"disputeResponses":[{"disputeUUID":"b7c2e9d4-1a3f-4e5b-8c6d-2f9a0e1b3c22","message":"Dispute target '0daccfc9-9e3a-43f1-8e60-d0d0916a82e3' is already under open dispute 'd1f0a6c2-5b7e-4c1d-9a43-7f0e2b6c8d11'","success":false},{"disputeUUID":"d1f0a6c2-5b7e-4c1d-9a43-7f0e2b6c8d11","message":"Dispute already exists!","success":false},{"disputeUUID":"","message":"Dispute UUID is required","success":false}]}`)
+	case "Test_AddDisputes_InvalidTarget":
+		return []byte(`{"successCount":0,"failureCount":2,"disputeResponses":[{"disputeUUID":"c3d4e5f6-7a8b-4c9d-0e1f-2a3b4c5d6e33","message":"Dispute target '0daccfc9-9e3a-43f1-8e60-d0d0916a82e3' is a ROYALTYSTATEMENT, not a EXPLOITATIONREPORT","success":false},{"disputeUUID":"e5f6a7b8-9c0d-4e1f-2a3b-4c5d6e7f8a44","message":"Invalid dispute reason code 'NOT_A_REASON'","success":false}]}`)
+	case "Test_PayRoyaltyStatements_Frozen":
+		return []byte(`{"successCount":0,"failureCount":1,"royaltyStatements":[{"royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","message":"Royalty Statement is frozen by open dispute 'd1f0a6c2-5b7e-4c1d-9a43-7f0e2b6c8d11'","success":false}]}`)
+	case "Test_PayRoyaltyStatements_Resolved":
+		return []byte(`{"successCount":1,"failureCount":0,"royaltyStatements":[]}`)
+	case "Test_PayRoyaltyStatements_AlreadyPaid":
+		return []byte(`{"successCount":0,"failureCount":1,"royaltyStatements":[{"royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","message":"Royalty Statement is already paid!","success":false}]}`)
+	default:
+		return []byte("[]")
+	}
+}
+
+func addDisputedRoyaltyStatement(t *testing.T) *memstub.Stub {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+
+	// Init
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addRoyaltyStatements"), []byte(disputeRoyaltyStatement_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("addDisputes"), []byte(disputeSingle1_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected := MockGetDisputeResponse("Test_AddDisputes_Single")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+	return stub
+}
+
+func getDisputeFromState(t *testing.T, stub *memstub.Stub, disputeUUID string) Dispute {
+	dispute := Dispute{}
+	err := jsonToObject(stub.State[disputeUUID], &dispute)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	return dispute
+}
+
+func Test_AddDisputes_Single(t *testing.T) {
+	stub := addDisputedRoyaltyStatement(t)
+
+	dispute := getDisputeFromState(t, stub, "d1f0a6c2-5b7e-4c1d-9a43-7f0e2b6c8d11")
+	if dispute.DocType != DISPUTE || dispute.State != DISPUTE_OPEN || dispute.CreatedDate == "" {
+		t.Fatalf("Dispute was not opened: %v", dispute)
+	}
+	if dispute.ClaimedAmount != 250 || len(dispute.EvidenceHashes) != 1 || len(dispute.Comments) != 1 {
+		t.Fatalf("Dispute claim was not recorded: %v", dispute)
+	}
+}
+
+func Test_AddDisputes_AlreadyOpen(t *testing.T) {
+	stub := addDisputedRoyaltyStatement(t)
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("addDisputes"), []byte(disputeSingle2_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected := MockGetDisputeResponse("Test_AddDisputes_AlreadyOpen")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_AddDisputes_SameBatch(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+
+	// Init
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addRoyaltyStatements"), []byte(disputeRoyaltyStatement_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// a peer does not show the batch its own writes, the disputes opened earlier in the batch are rejected from memory
+	stub.ReadCommitted = true
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("addDisputes"), []byte(disputeSameBatch_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected := MockGetDisputeResponse("Test_AddDisputes_SameBatch")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+	dispute := getDisputeFromState(t, stub, "d1f0a6c2-5b7e-4c1d-9a43-7f0e2b6c8d11")
+	if dispute.ReasonCode != WRONG_AMOUNT {
+		t.Fatalf("Expected the first dispute of the batch to be kept, got %v", dispute)
+	}
+}
+
+func Test_AddDisputes_InvalidTarget(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+
+	// Init
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addRoyaltyStatements"), []byte(disputeRoyaltyStatement_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("addDisputes"), []byte(disputeInvalidTarget_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected := MockGetDisputeResponse("Test_AddDisputes_InvalidTarget")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_AddDisputeComment(t *testing.T) {
+	stub := addDisputedRoyaltyStatement(t)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addDisputeComment"), []byte("d1f0a6c2-5b7e-4c1d-9a43-7f0e2b6c8d11"), []byte(`{"author":"Swedish-Publishing-IPI","comment":"units were reported twice by the source"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	dispute := getDisputeFromState(t, stub, "d1f0a6c2-5b7e-4c1d-9a43-7f0e2b6c8d11")
+	if len(dispute.Comments) != 2 || dispute.Comments[1].Author != "Swedish-Publishing-IPI" || dispute.Comments[1].CreatedDate == "" {
+		t.Fatalf("Dispute comment was not added: %v", dispute.Comments)
+	}
+}
+
+func Test_PayRoyaltyStatements_Frozen(t *testing.T) {
+	stub := addDisputedRoyaltyStatement(t)
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("payRoyaltyStatements"), []byte("0daccfc9-9e3a-43f1-8e60-d0d0916a82e3")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected := MockGetDisputeResponse("Test_PayRoyaltyStatements_Frozen")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_ResolveDispute(t *testing.T) {
+	stub := addDisputedRoyaltyStatement(t)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("resolveDispute"), []byte("d1f0a6c2-5b7e-4c1d-9a43-7f0e2b6c8d11"), []byte(`{"outcome":"REJECTED","resolvedBy":"Swedish-Publishing-IPI","comment":"amount confirmed by the source"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	dispute := getDisputeFromState(t, stub, "d1f0a6c2-5b7e-4c1d-9a43-7f0e2b6c8d11")
+	if dispute.State != DISPUTE_RESOLVED || dispute.Resolution == nil || dispute.Resolution.Outcome != REJECTED {
+		t.Fatalf("Dispute was not resolved: %v", dispute)
+	}
+
+	// the royalty statement is no longer frozen
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("payRoyaltyStatements"), []byte("0daccfc9-9e3a-43f1-8e60-d0d0916a82e3")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected := MockGetDisputeResponse("Test_PayRoyaltyStatements_Resolved")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("payRoyaltyStatements"), []byte("0daccfc9-9e3a-43f1-8e60-d0d0916a82e3")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected = MockGetDisputeResponse("Test_PayRoyaltyStatements_AlreadyPaid")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_ResolveDispute_InvalidOutcome(t *testing.T) {
+	stub := addDisputedRoyaltyStatement(t)
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("resolveDispute"), []byte("d1f0a6c2-5b7e-4c1d-9a43-7f0e2b6c8d11"), []byte(`{"outcome":"MAYBE"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected := []byte(`{"status":"500","message":"resolveDispute - Invalid dispute resolution outcome 'MAYBE'."}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
@@ -0,0 +1,340 @@
+/*
+Package dsr parses DDEX DSR sales report flat files and maps them to the exploitation reports of the chaincode.
+
+A DSR flat file is tab separated, one record per line, the first field of a line is its record type:
+
+	HEAD  the header of the message: its id, sender, recipient and usage period
+	SYnn  a summary record: the commercial model, use type, territory, currency and totals of a group of sales
+	ASnn  the sound recording of a detail block: its ISRC, title and display artist
+	SUnn  a sales or usage record of a detail block: its summary record, number of usages and amount
+	FOOT  the footer of the message: the number of lines, summary records and blocks of the file
+
+Lines starting with # define the columns of a record type, e.g. "#SU02	BlockId	SummaryRecordId	...", and
+replace the default columns of the record type. Other record types, e.g. the RE release records, are ignored.
+*/
+package dsr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Record types and record type families
+const (
+	HeaderRecord   = "HEAD"
+	FooterRecord   = "FOOT"
+	SummaryFamily  = "SY"
+	ResourceFamily = "AS"
+	UsageFamily    = "SU"
+)
+
+// Issue severities
+const (
+	Error   = "ERROR"
+	Warning = "WARNING"
+)
+
+//defaultColumns - the columns of the record types, after their record type, when the file does not define them
+var defaultColumns = map[string][]string{
+	HeaderRecord:   {"MessageVersion", "Profile", "ProfileVersion", "MessageId", "MessageCreatedDateTime", "FileNumber", "NumberOfFiles", "UsageStartDate", "UsageEndDate", "SenderPartyId", "SenderName", "ServiceDescription", "RecipientPartyId", "RecipientName"},
+	SummaryFamily:  {"SummaryRecordId", "DistributionChannel", "DistributionChannelDPID", "CommercialModel", "UseType", "Territory", "ServiceDescription", "Usages", "Users", "Currency", "NetRevenue"},
+	ResourceFamily: {"BlockId", "ResourceReference", "DspResourceId", "ISRC", "Title", "SubTitle", "DisplayArtistName", "DisplayArtistPartyId", "Duration", "ResourceType"},
+	UsageFamily:    {"BlockId", "SummaryRecordId", "SalesTransactionId", "ResourceReference", "UseType", "Territory", "NumberOfUsages", "Currency", "Amount"},
+	FooterRecord:   {"NumberOfLinesInFile", "NumberOfLinesInReport", "NumberOfSummaryRecords", "NumberOfBlocksInReport"},
+}
+
+// Issue - a problem found in a DSR file, errors keep the sales they concern from being imported
+type Issue struct {
+	Line     int    `json:"line,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Header - the HEAD record of a DSR file
+type Header struct {
+	MessageID              string `json:"messageId"`
+	MessageCreatedDateTime string `json:"messageCreatedDateTime,omitempty"`
+	UsageStartDate         string `json:"usageStartDate"`
+	UsageEndDate           string `json:"usageEndDate"`
+	SenderPartyID          string `json:"senderPartyId"`
+	SenderName             string `json:"senderName,omitempty"`
+	RecipientPartyID       string `json:"recipientPartyId,omitempty"`
+	RecipientName          string `json:"recipientName,omitempty"`
+}
+
+// Summary - a summary record of a DSR file
+type Summary struct {
+	Line            int     `json:"line"`
+	SummaryRecordID string  `json:"summaryRecordId"`
+	CommercialModel string  `json:"commercialModel,omitempty"`
+	UseType         string  `json:"useType"`
+	Territory       string  `json:"territory"`
+	Currency        string  `json:"currency"`
+	Usages          int     `json:"usages"`
+	NetRevenue      float64 `json:"netRevenue"`
+}
+
+// Resource - a sound recording of a detail block
+type Resource struct {
+	Line              int    `json:"line"`
+	ResourceReference string `json:"resourceReference"`
+	DspResourceID     string `json:"dspResourceId,omitempty"`
+	Isrc              string `json:"isrc"`
+	Title             string `json:"title"`
+	DisplayArtistName string `json:"displayArtistName,omitempty"`
+}
+
+// Usage - a sales or usage record of a detail block, its empty use type, territory and currency are the ones of its summary record
+type Usage struct {
+	Line               int     `json:"line"`
+	SummaryRecordID    string  `json:"summaryRecordId"`
+	SalesTransactionID string  `json:"salesTransactionId,omitempty"`
+	ResourceReference  string  `json:"resourceReference,omitempty"`
+	UseType            string  `json:"useType,omitempty"`
+	Territory          string  `json:"territory,omitempty"`
+	Currency           string  `json:"currency,omitempty"`
+	Units              int     `json:"units"`
+	Amount             float64 `json:"amount"`
+}
+
+// Block - a detail block of a DSR file, the sales of its sound recordings
+type Block struct {
+	BlockID   string     `json:"blockId"`
+	Resources []Resource `json:"resources"`
+	Usages    []Usage    `json:"usages"`
+}
+
+// Footer - the FOOT record of a DSR file
+type Footer struct {
+	Line                   int `json:"line"`
+	NumberOfLinesInFile    int `json:"numberOfLinesInFile"`
+	NumberOfSummaryRecords int `json:"numberOfSummaryRecords"`
+	NumberOfBlocksInReport int `json:"numberOfBlocksInReport"`
+}
+
+// File - a parsed DSR file with the issues found while parsing it
+type File struct {
+	Header    Header             `json:"header"`
+	Summaries map[string]Summary `json:"summaries"`
+	Blocks    []Block            `json:"blocks"`
+	Footer    *Footer            `json:"footer,omitempty"`
+	Lines     int                `json:"lines"`
+	Issues    []Issue            `json:"issues"`
+}
+
+//record - a line of a DSR file with its fields by column
+type record struct {
+	line       int
+	recordType string
+	fields     map[string]string
+}
+
+func (r record) get(column string) string {
+	return strings.TrimSpace(r.fields[column])
+}
+
+//getInt - returns an integer field, an empty field is 0
+func (r record) getInt(column string) (int, error) {
+	value := r.get(column)
+	if value == "" {
+		return 0, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s '%s' is not a number", column, value)
+	}
+	return number, nil
+}
+
+//getFloat - returns a decimal field, an empty field is 0
+func (r record) getFloat(column string) (float64, error) {
+	value := r.get(column)
+	if value == "" {
+		return 0, nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s '%s' is not a decimal", column, value)
+	}
+	return number, nil
+}
+
+//getFamily - returns the family of a record type, e.g. SU for SU02
+func getFamily(recordType string) string {
+	if recordType == HeaderRecord || recordType == FooterRecord || len(recordType) < 2 {
+		return recordType
+	}
+	return recordType[:2]
+}
+
+// Parse reads a DSR flat file. It fails when the file cannot be read or has no header, other problems are
+// reported as issues of the file.
+func Parse(reader io.Reader) (*File, error) {
+	file := &File{Summaries: map[string]Summary{}, Blocks: []Block{}, Issues: []Issue{}}
+	columns := map[string][]string{}
+	blocks := map[string]int{}
+	hasHeader := false
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		file.Lines++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		cells := strings.Split(text, "\t")
+		recordType := strings.TrimSpace(cells[0])
+
+		// a definition line names the columns of its record type
+		if strings.HasPrefix(recordType, "#") {
+			columns[strings.TrimPrefix(recordType, "#")] = cells[1:]
+			continue
+		}
+
+		recordColumns, ok := columns[recordType]
+		if !ok {
+			recordColumns, ok = defaultColumns[getFamily(recordType)]
+		}
+		if !ok {
+			continue
+		}
+		rec := record{line: file.Lines, recordType: recordType, fields: map[string]string{}}
+		for i, column := range recordColumns {
+			if i+1 < len(cells) {
+				rec.fields[strings.TrimSpace(column)] = cells[i+1]
+			}
+		}
+
+		var err error
+		switch getFamily(recordType) {
+		case HeaderRecord:
+			hasHeader = true
+			file.Header = parseHeader(rec)
+		case SummaryFamily:
+			err = file.addSummary(rec)
+		case ResourceFamily:
+			file.addResource(rec, blocks)
+		case UsageFamily:
+			err = file.addUsage(rec, blocks)
+		case FooterRecord:
+			err = file.addFooter(rec)
+		}
+		if err != nil {
+			file.Issues = append(file.Issues, Issue{Line: rec.line, Severity: Error, Message: fmt.Sprintf("%s record: %s", recordType, err.Error())})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !hasHeader {
+		return nil, fmt.Errorf("DSR file has no %s record", HeaderRecord)
+	}
+	return file, nil
+}
+
+func parseHeader(rec record) Header {
+	return Header{
+		MessageID:              rec.get("MessageId"),
+		MessageCreatedDateTime: rec.get("MessageCreatedDateTime"),
+		UsageStartDate:         rec.get("UsageStartDate"),
+		UsageEndDate:           rec.get("UsageEndDate"),
+		SenderPartyID:          rec.get("SenderPartyId"),
+		SenderName:             rec.get("SenderName"),
+		RecipientPartyID:       rec.get("RecipientPartyId"),
+		RecipientName:          rec.get("RecipientName"),
+	}
+}
+
+func (file *File) addSummary(rec record) error {
+	summary := Summary{
+		Line:            rec.line,
+		SummaryRecordID: rec.get("SummaryRecordId"),
+		CommercialModel: rec.get("CommercialModel"),
+		UseType:         rec.get("UseType"),
+		Territory:       rec.get("Territory"),
+		Currency:        rec.get("Currency"),
+	}
+	var err error
+	if summary.Usages, err = rec.getInt("Usages"); err != nil {
+		return err
+	}
+	if summary.NetRevenue, err = rec.getFloat("NetRevenue"); err != nil {
+		return err
+	}
+	if summary.SummaryRecordID == "" {
+		return fmt.Errorf("SummaryRecordId is required")
+	}
+	if _, ok := file.Summaries[summary.SummaryRecordID]; ok {
+		return fmt.Errorf("summary record '%s' is defined twice", summary.SummaryRecordID)
+	}
+	file.Summaries[summary.SummaryRecordID] = summary
+	return nil
+}
+
+//getBlock - returns the block with an id, appending it to the blocks of the file when it is new
+func (file *File) getBlock(blockID string, blocks map[string]int) *Block {
+	index, ok := blocks[blockID]
+	if !ok {
+		index = len(file.Blocks)
+		blocks[blockID] = index
+		file.Blocks = append(file.Blocks, Block{BlockID: blockID, Resources: []Resource{}, Usages: []Usage{}})
+	}
+	return &file.Blocks[index]
+}
+
+func (file *File) addResource(rec record, blocks map[string]int) {
+	block := file.getBlock(rec.get("BlockId"), blocks)
+	block.Resources = append(block.Resources, Resource{
+		Line:              rec.line,
+		ResourceReference: rec.get("ResourceReference"),
+		DspResourceID:     rec.get("DspResourceId"),
+		Isrc:              rec.get("ISRC"),
+		Title:             rec.get("Title"),
+		DisplayArtistName: rec.get("DisplayArtistName"),
+	})
+}
+
+func (file *File) addUsage(rec record, blocks map[string]int) error {
+	usage := Usage{
+		Line:               rec.line,
+		SummaryRecordID:    rec.get("SummaryRecordId"),
+		SalesTransactionID: rec.get("SalesTransactionId"),
+		ResourceReference:  rec.get("ResourceReference"),
+		UseType:            rec.get("UseType"),
+		Territory:          rec.get("Territory"),
+		Currency:           rec.get("Currency"),
+	}
+	var err error
+	if usage.Units, err = rec.getInt("NumberOfUsages"); err != nil {
+		return err
+	}
+	if usage.Amount, err = rec.getFloat("Amount"); err != nil {
+		return err
+	}
+	if _, ok := blocks[rec.get("BlockId")]; !ok {
+		return fmt.Errorf("block '%s' has no sound recording", rec.get("BlockId"))
+	}
+	block := file.getBlock(rec.get("BlockId"), blocks)
+	block.Usages = append(block.Usages, usage)
+	return nil
+}
+
+func (file *File) addFooter(rec record) error {
+	footer := Footer{Line: rec.line}
+	var err error
+	if footer.NumberOfLinesInFile, err = rec.getInt("NumberOfLinesInFile"); err != nil {
+		return err
+	}
+	if footer.NumberOfSummaryRecords, err = rec.getInt("NumberOfSummaryRecords"); err != nil {
+		return err
+	}
+	if footer.NumberOfBlocksInReport, err = rec.getInt("NumberOfBlocksInReport"); err != nil {
+		return err
+	}
+	file.Footer = &footer
+	return nil
+}
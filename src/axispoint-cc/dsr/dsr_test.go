@@ -0,0 +1,111 @@
+package dsr
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func importSample(t *testing.T, options Options) *Import {
+	file, err := os.Open("testdata/sample.tsv")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer file.Close()
+	dsrImport, err := ImportFile(file, options)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	return dsrImport
+}
+
+func Test_Parse(t *testing.T) {
+	file, err := Parse(strings.NewReader("HEAD\t3.0\tBasicAudioProfile\t1.0\tM1\t\t1\t1\t2018-12-01\t2018-12-31\tDSP1\n" +
+		"SY01\tS1\tInternet\t\tSubscriptionModel\tOnDemandStream\tFR\t\t10\t\tEUR\t1.2\n" +
+		"RE01\tB1\tignored\n" +
+		"AS01\tB1\tR1\t\tFRZ039800212\tTitle\n" +
+		"SU01\tB1\tS1\tT1\tR1\t\t\t10\t\t1.2\n" +
+		"FOOT\t6\t6\t1\t1\n"))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expectedBlocks := []Block{{BlockID: "B1", Resources: []Resource{{Line: 4, ResourceReference: "R1", Isrc: "FRZ039800212", Title: "Title"}}, Usages: []Usage{{Line: 5, SummaryRecordID: "S1", SalesTransactionID: "T1", ResourceReference: "R1", Units: 10, Amount: 1.2}}}}
+	if !reflect.DeepEqual(expectedBlocks, file.Blocks) {
+		t.Fatalf("Actual blocks are not equal to expected blocks: %+v", file.Blocks)
+	}
+	expectedSummary := Summary{Line: 2, SummaryRecordID: "S1", CommercialModel: "SubscriptionModel", UseType: "OnDemandStream", Territory: "FR", Currency: "EUR", Usages: 10, NetRevenue: 1.2}
+	if !reflect.DeepEqual(expectedSummary, file.Summaries["S1"]) || file.Header.MessageID != "M1" || file.Footer.NumberOfBlocksInReport != 1 || len(file.Issues) != 0 {
+		t.Fatalf("Actual file is not equal to expected file: %+v", file)
+	}
+
+	_, err = Parse(strings.NewReader("SY01\tS1\n"))
+	if err == nil || err.Error() != "DSR file has no HEAD record" {
+		t.Fatalf("Expected a file without header to be rejected: %v", err)
+	}
+}
+
+func Test_ImportFile(t *testing.T) {
+	dsrImport := importSample(t, Options{Source: "spotify-IPI"})
+
+	expected := []ExploitationReport{
+		{Source: "spotify-IPI", SongTitle: "Hello World", Isrc: "USRC17607839", Units: 100, ExploitationDate: "2018-12-01", Amount: 3, UsageType: "OnDemandStream", ExploitationReportUUID: "DSR-2018-12-001-T1", Territory: "US", Currency: "USD"},
+		{Source: "spotify-IPI", SongTitle: "Hello World", Isrc: "USRC17607839", Units: 2, ExploitationDate: "2018-12-01", Amount: 1.98, UsageType: "PermanentDownload", ExploitationReportUUID: "DSR-2018-12-001-T2", Territory: "GB", Currency: "USD"},
+		{Source: "spotify-IPI", SongTitle: "Second Song", Isrc: "QZAB11800001", Units: 50, ExploitationDate: "2018-12-01", Amount: 1.5, UsageType: "OnDemandStream", ExploitationReportUUID: "DSR-2018-12-001-T3", Territory: "US", Currency: "USD"},
+	}
+	if !reflect.DeepEqual(expected, dsrImport.ExploitationReports) {
+		t.Fatalf("Actual exploitation reports are not equal to expected exploitation reports: %+v", dsrImport.ExploitationReports)
+	}
+
+	report := dsrImport.Report
+	if report.Valid || report.Lines != 14 || report.SalesRecords != 5 || report.ExploitationReports != 3 || report.Rejected != 2 || report.Batches != 1 {
+		t.Fatalf("Actual report is not equal to expected report: %+v", report)
+	}
+	expectedTotals := []CurrencyTotal{{Currency: "USD", Units: 158, Amount: 7.62, ImportedUnits: 152, ImportedAmount: 6.48}}
+	if !reflect.DeepEqual(expectedTotals, report.Totals) {
+		t.Fatalf("Actual totals are not equal to expected totals: %+v", report.Totals)
+	}
+	actualIssues := []string{}
+	for _, issue := range report.Issues {
+		actualIssues = append(actualIssues, issue.Severity)
+		if issue.Line == 2 || issue.Line == 13 || issue.Line == 14 {
+			actualIssues = append(actualIssues, issue.Message)
+		}
+	}
+	expectedIssues := []string{
+		Warning, "summary record 'SY1' reports 150 usages, its sales records 155",
+		Warning, "summary record 'SY1' reports a net revenue of 4.50, its sales records 4.65",
+		Error, Error,
+		Error, "SU02 record: block 'B9' has no sound recording",
+		Error, "footer reports 16 lines, the file has 14",
+	}
+	if !reflect.DeepEqual(expectedIssues, actualIssues) {
+		t.Fatalf("Actual issues are not equal to expected issues: %+v", report.Issues)
+	}
+}
+
+func Test_Payloads(t *testing.T) {
+	dsrImport := importSample(t, Options{BatchSize: 2})
+
+	if len(dsrImport.Payloads) != 2 || dsrImport.Payloads[0].Function != GenerateFunction || dsrImport.Report.Source != "PADPIDA2014120301K" {
+		t.Fatalf("Actual payloads are not equal to expected payloads: %+v", dsrImport.Payloads)
+	}
+	batches := [][]ExploitationReport{}
+	for _, payload := range dsrImport.Payloads {
+		batch := []ExploitationReport{}
+		err := json.Unmarshal([]byte(payload.Args[0]), &batch)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		batches = append(batches, batch)
+	}
+	if len(batches[0]) != 2 || len(batches[1]) != 1 || batches[1][0].ExploitationReportUUID != "DSR-2018-12-001-T3" || batches[0][0].Source != "PADPIDA2014120301K" {
+		t.Fatalf("Actual batches are not equal to expected batches: %+v", batches)
+	}
+
+	_, err := Payloads(dsrImport.ExploitationReports, 0)
+	if err == nil {
+		t.Fatalf("Expected a batch size of 0 to be rejected")
+	}
+}
@@ -0,0 +1,319 @@
+package dsr
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"axispoint-cc/client"
+	"axispoint-cc/identifier"
+	"axispoint-cc/payload"
+	"axispoint-cc/territory"
+)
+
+// GenerateFunction - the chaincode function the exploitation reports are submitted to
+const GenerateFunction = "generateExploitationReports"
+
+// DefaultBatchSize - the number of exploitation reports of a chaincode payload when no batch size is given
+const DefaultBatchSize = 100
+
+//worldwide - the territory DSPs report for sales that are not reported by country
+const worldwide = "WORLDWIDE"
+
+// ExploitationReport - the exploitation report of the chaincode, as its client encodes it
+type ExploitationReport = client.ExploitationReport
+
+// CurrencyTotal - the totals of the sales of a currency
+type CurrencyTotal struct {
+	Currency       string  `json:"currency"`
+	Units          int     `json:"units"`
+	Amount         float64 `json:"amount"`
+	ImportedUnits  int     `json:"importedUnits"`
+	ImportedAmount float64 `json:"importedAmount"`
+}
+
+// ValidationReport - the outcome of the import of a DSR file
+type ValidationReport struct {
+	MessageID           string          `json:"messageId"`
+	Sender              string          `json:"sender"`
+	Source              string          `json:"source"`
+	UsageStartDate      string          `json:"usageStartDate"`
+	UsageEndDate        string          `json:"usageEndDate"`
+	Lines               int             `json:"lines"`
+	SummaryRecords      int             `json:"summaryRecords"`
+	Blocks              int             `json:"blocks"`
+	SalesRecords        int             `json:"salesRecords"`
+	ExploitationReports int             `json:"exploitationReports"`
+	Rejected            int             `json:"rejected"`
+	Batches             int             `json:"batches"`
+	Totals              []CurrencyTotal `json:"totals"`
+	Issues              []Issue         `json:"issues"`
+	Valid               bool            `json:"valid"`
+}
+
+// Options - the options of an import
+type Options struct {
+	// Source is the IPI of the DSP on the ledger, the sender party id of the file by default
+	Source string
+	// BatchSize is the number of exploitation reports per chaincode payload, DefaultBatchSize by default
+	BatchSize int
+}
+
+// Import - the exploitation reports of a DSR file, their chaincode payloads and the validation report
+type Import struct {
+	ExploitationReports []ExploitationReport `json:"exploitationReports"`
+	Payloads            []payload.Payload    `json:"payloads"`
+	Report              ValidationReport     `json:"report"`
+}
+
+// ImportFile parses a DSR file and maps its sales to exploitation reports batched in chaincode payloads
+func ImportFile(reader io.Reader, options Options) (*Import, error) {
+	file, err := Parse(reader)
+	if err != nil {
+		return nil, err
+	}
+	source := options.Source
+	if source == "" {
+		source = file.Header.SenderPartyID
+	}
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	exploitationReports, issues := file.ExploitationReports(source)
+	payloads, err := Payloads(exploitationReports, batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	report := ValidationReport{
+		MessageID:           file.Header.MessageID,
+		Sender:              file.Header.SenderPartyID,
+		Source:              source,
+		UsageStartDate:      file.Header.UsageStartDate,
+		UsageEndDate:        file.Header.UsageEndDate,
+		Lines:               file.Lines,
+		SummaryRecords:      len(file.Summaries),
+		Blocks:              len(file.Blocks),
+		ExploitationReports: len(exploitationReports),
+		Batches:             len(payloads),
+		Issues:              append(append(append([]Issue{}, file.Issues...), issues...), file.checkTotals()...),
+	}
+	for _, block := range file.Blocks {
+		report.SalesRecords += len(block.Usages)
+	}
+	report.Rejected = report.SalesRecords - report.ExploitationReports
+	report.Totals = getCurrencyTotals(file, exploitationReports)
+	sort.SliceStable(report.Issues, func(i, j int) bool { return report.Issues[i].Line < report.Issues[j].Line })
+	report.Valid = true
+	for _, issue := range report.Issues {
+		if issue.Severity == Error {
+			report.Valid = false
+		}
+	}
+
+	return &Import{ExploitationReports: exploitationReports, Payloads: payloads, Report: report}, nil
+}
+
+// ExploitationReports maps the sales records of a DSR file to exploitation reports of a source. Sales whose
+// sound recording, ISRC, territory or date is invalid are rejected with an error issue.
+func (file *File) ExploitationReports(source string) ([]ExploitationReport, []Issue) {
+	exploitationReports := []ExploitationReport{}
+	issues := []Issue{}
+
+	exploitationDate, err := normalizeDate(file.Header.UsageStartDate)
+	if err != nil {
+		issues = append(issues, Issue{Severity: Error, Message: fmt.Sprintf("usage start date: %s", err.Error())})
+	}
+
+	for _, block := range file.Blocks {
+		for _, usage := range block.Usages {
+			exploitationReport, err := file.getExploitationReport(block, usage, source, exploitationDate)
+			if err != nil {
+				issues = append(issues, Issue{Line: usage.Line, Severity: Error, Message: err.Error()})
+				continue
+			}
+			if _, ok := file.Summaries[usage.SummaryRecordID]; !ok {
+				issues = append(issues, Issue{Line: usage.Line, Severity: Warning, Message: fmt.Sprintf("summary record '%s' does not exist", usage.SummaryRecordID)})
+			}
+			exploitationReports = append(exploitationReports, exploitationReport)
+		}
+	}
+	return exploitationReports, issues
+}
+
+//getExploitationReport - maps a sales record of a block to an exploitation report
+func (file *File) getExploitationReport(block Block, usage Usage, source string, exploitationDate string) (ExploitationReport, error) {
+	exploitationReport := ExploitationReport{}
+	if exploitationDate == "" {
+		return exploitationReport, fmt.Errorf("the usage period of the file is not a date")
+	}
+	resource, err := getResource(block, usage)
+	if err != nil {
+		return exploitationReport, err
+	}
+	isrc, err := identifier.NormalizeIsrc(resource.Isrc)
+	if err != nil {
+		return exploitationReport, fmt.Errorf("sound recording '%s': %s", resource.ResourceReference, err.Error())
+	}
+
+	summary := file.Summaries[usage.SummaryRecordID]
+	useType := firstOf(usage.UseType, summary.UseType)
+	currency := firstOf(usage.Currency, summary.Currency)
+	exploitationTerritory := firstOf(usage.Territory, summary.Territory)
+	if strings.EqualFold(exploitationTerritory, worldwide) {
+		exploitationTerritory = ""
+	}
+	if exploitationTerritory != "" {
+		exploitationTerritory, err = territory.NormalizeCountry(exploitationTerritory)
+		if err != nil {
+			return exploitationReport, err
+		}
+	}
+
+	exploitationReport.Source = source
+	exploitationReport.SongTitle = resource.Title
+	exploitationReport.Isrc = isrc
+	exploitationReport.Units = usage.Units
+	exploitationReport.ExploitationDate = exploitationDate
+	exploitationReport.Amount = usage.Amount
+	exploitationReport.UsageType = useType
+	exploitationReport.Territory = exploitationTerritory
+	exploitationReport.Currency = currency
+	exploitationReport.ExploitationReportUUID = getExploitationReportUUID(file.Header.MessageID, usage)
+	return exploitationReport, nil
+}
+
+//getResource - returns the sound recording of a sales record, the first of its block when it does not reference one
+func getResource(block Block, usage Usage) (Resource, error) {
+	if len(block.Resources) == 0 {
+		return Resource{}, fmt.Errorf("block '%s' has no sound recording", block.BlockID)
+	}
+	if usage.ResourceReference == "" {
+		return block.Resources[0], nil
+	}
+	for _, resource := range block.Resources {
+		if resource.ResourceReference == usage.ResourceReference {
+			return resource, nil
+		}
+	}
+	return Resource{}, fmt.Errorf("sound recording '%s' is not in block '%s'", usage.ResourceReference, block.BlockID)
+}
+
+//getExploitationReportUUID - returns the id of the exploitation report of a sales record, unique to the message
+func getExploitationReportUUID(messageID string, usage Usage) string {
+	if usage.SalesTransactionID != "" {
+		return fmt.Sprintf("%s-%s", messageID, usage.SalesTransactionID)
+	}
+	return fmt.Sprintf("%s-L%d", messageID, usage.Line)
+}
+
+//checkTotals - compares the summary records and the footer to the sales records of the file
+func (file *File) checkTotals() []Issue {
+	issues := []Issue{}
+	units := map[string]int{}
+	amounts := map[string]float64{}
+	for _, block := range file.Blocks {
+		for _, usage := range block.Usages {
+			units[usage.SummaryRecordID] += usage.Units
+			amounts[usage.SummaryRecordID] += usage.Amount
+		}
+	}
+
+	summaryRecordIDs := []string{}
+	for summaryRecordID := range file.Summaries {
+		summaryRecordIDs = append(summaryRecordIDs, summaryRecordID)
+	}
+	sort.Strings(summaryRecordIDs)
+	for _, summaryRecordID := range summaryRecordIDs {
+		summary := file.Summaries[summaryRecordID]
+		if summary.Usages != 0 && summary.Usages != units[summaryRecordID] {
+			issues = append(issues, Issue{Line: summary.Line, Severity: Warning, Message: fmt.Sprintf("summary record '%s' reports %d usages, its sales records %d", summaryRecordID, summary.Usages, units[summaryRecordID])})
+		}
+		if math.Abs(summary.NetRevenue-amounts[summaryRecordID]) >= 0.01 {
+			issues = append(issues, Issue{Line: summary.Line, Severity: Warning, Message: fmt.Sprintf("summary record '%s' reports a net revenue of %.2f, its sales records %.2f", summaryRecordID, summary.NetRevenue, amounts[summaryRecordID])})
+		}
+	}
+
+	if file.Footer == nil {
+		issues = append(issues, Issue{Severity: Warning, Message: fmt.Sprintf("DSR file has no %s record", FooterRecord)})
+		return issues
+	}
+	if file.Footer.NumberOfSummaryRecords != len(file.Summaries) {
+		issues = append(issues, Issue{Line: file.Footer.Line, Severity: Error, Message: fmt.Sprintf("footer reports %d summary records, the file has %d", file.Footer.NumberOfSummaryRecords, len(file.Summaries))})
+	}
+	if file.Footer.NumberOfBlocksInReport != len(file.Blocks) {
+		issues = append(issues, Issue{Line: file.Footer.Line, Severity: Error, Message: fmt.Sprintf("footer reports %d blocks, the file has %d", file.Footer.NumberOfBlocksInReport, len(file.Blocks))})
+	}
+	if file.Footer.NumberOfLinesInFile != 0 && file.Footer.NumberOfLinesInFile != file.Lines {
+		issues = append(issues, Issue{Line: file.Footer.Line, Severity: Error, Message: fmt.Sprintf("footer reports %d lines, the file has %d", file.Footer.NumberOfLinesInFile, file.Lines)})
+	}
+	return issues
+}
+
+//getCurrencyTotals - returns the totals of the sales records and of the imported exploitation reports by currency
+func getCurrencyTotals(file *File, exploitationReports []ExploitationReport) []CurrencyTotal {
+	totals := map[string]*CurrencyTotal{}
+	getTotal := func(currency string) *CurrencyTotal {
+		if _, ok := totals[currency]; !ok {
+			totals[currency] = &CurrencyTotal{Currency: currency}
+		}
+		return totals[currency]
+	}
+	for _, block := range file.Blocks {
+		for _, usage := range block.Usages {
+			total := getTotal(firstOf(usage.Currency, file.Summaries[usage.SummaryRecordID].Currency))
+			total.Units += usage.Units
+			total.Amount = round(total.Amount + usage.Amount)
+		}
+	}
+	for _, exploitationReport := range exploitationReports {
+		total := getTotal(exploitationReport.Currency)
+		total.ImportedUnits += exploitationReport.Units
+		total.ImportedAmount = round(total.ImportedAmount + exploitationReport.Amount)
+	}
+
+	currencyTotals := []CurrencyTotal{}
+	for _, total := range totals {
+		currencyTotals = append(currencyTotals, *total)
+	}
+	sort.Slice(currencyTotals, func(i, j int) bool { return currencyTotals[i].Currency < currencyTotals[j].Currency })
+	return currencyTotals
+}
+
+// Payloads batches exploitation reports into generateExploitationReports invocations of at most batchSize reports
+func Payloads(exploitationReports []ExploitationReport, batchSize int) ([]payload.Payload, error) {
+	return payload.Batches(GenerateFunction, len(exploitationReports), batchSize, func(start int, end int) interface{} {
+		return exploitationReports[start:end]
+	})
+}
+
+//normalizeDate - returns a DSR date, or the date of a DSR date time, as YYYY-MM-DD
+func normalizeDate(date string) (string, error) {
+	date = strings.TrimSpace(date)
+	if len(date) > 10 && date[10] == 'T' {
+		date = date[:10]
+	}
+	parsedDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return "", fmt.Errorf("'%s' is not a date", date)
+	}
+	return parsedDate.Format("2006-01-02"), nil
+}
+
+func firstOf(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// EarningsReportQuery : defines the parameters of an earnings report request
+type EarningsReportQuery struct {
+	Ipi       string   `json:"ipi"`
+	StartDate string   `json:"startDate"`
+	EndDate   string   `json:"endDate"`
+	GroupBy   []string `json:"groupBy"`
+	PageSize  int      `json:"pageSize"`
+	Bookmark  string   `json:"bookmark"`
+}
+
+// EarningsGroup : defines the aggregated earnings of one group of royalty statements
+type EarningsGroup struct {
+	Dimensions map[string]string `json:"dimensions"`
+	Currency   string            `json:"currency"`
+	Amount     float64           `json:"amount"`
+	Units      int               `json:"units"`
+	Count      int               `json:"count"`
+}
+
+// EarningsReport : defines one page of an earnings report
+type EarningsReport struct {
+	Ipi         string          `json:"ipi"`
+	StartDate   string          `json:"startDate"`
+	EndDate     string          `json:"endDate"`
+	GroupBy     []string        `json:"groupBy"`
+	TotalGroups int             `json:"totalGroups"`
+	Groups      []EarningsGroup `json:"groups"`
+	Bookmark    string          `json:"bookmark"`
+}
+
+// earningsDimensions - royalty statement fields an earnings report can be grouped by
+var earningsDimensions = map[string]func(RoyaltyStatement) string{
+	"isrc":      func(royaltyStatement RoyaltyStatement) string { return royaltyStatement.Isrc },
+	"territory": func(royaltyStatement RoyaltyStatement) string { return royaltyStatement.Territory },
+	"usageType": func(royaltyStatement RoyaltyStatement) string { return royaltyStatement.UsageType },
+	"source":    func(royaltyStatement RoyaltyStatement) string { return royaltyStatement.Source },
+	"rightType": func(royaltyStatement RoyaltyStatement) string { return royaltyStatement.RightType },
+}
+
+// defaultEarningsPageSize - number of groups returned when the query does not set a page size
+const defaultEarningsPageSize = 100
+
+/*
+* getEarningsReport function aggregates the amounts, units and counts of the royalty statements payable to an IPI
+* within a date range, grouped by the requested dimensions and by currency. Groups are sorted by their key and
+* returned a page at a time; the returned bookmark is passed back to get the next page.
+*
+* @params   {Array} args
+* @property {string} 0       - stringified JSON earnings report query,
+*                              e.g. {"ipi":"...","startDate":"2018-10-01","endDate":"2018-12-31","groupBy":["isrc","territory"]}
+* @return   {pb.Response}    - peer Response
+ */
+func getEarningsReport(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "getEarningsReport"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) != 1 {
+		return getErrorResponse("Missing arguments: Earnings report query object is required")
+	}
+
+	earningsReportQuery := EarningsReportQuery{}
+	err := jsonToObject([]byte(args[0]), &earningsReportQuery)
+	if err == nil {
+		earningsReportQuery.Ipi, err = normalizeIpi(earningsReportQuery.Ipi)
+	}
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	if earningsReportQuery.Ipi == "" || earningsReportQuery.StartDate == "" || earningsReportQuery.EndDate == "" {
+		return getErrorResponse(fmt.Sprintf("%s - Earnings report query requires an 'ipi', a 'startDate' and an 'endDate'.", methodName))
+	}
+	for _, dimension := range earningsReportQuery.GroupBy {
+		if _, ok := earningsDimensions[dimension]; !ok {
+			return getErrorResponse(fmt.Sprintf("%s - Invalid group by dimension '%s'.", methodName, dimension))
+		}
+	}
+	if earningsReportQuery.PageSize <= 0 {
+		earningsReportQuery.PageSize = defaultEarningsPageSize
+	}
+
+	royaltyStatements, err := getPayeeRoyaltyStatements(stub, earningsReportQuery.Ipi, earningsReportQuery.StartDate, earningsReportQuery.EndDate)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	// aggregate the royalty statements per group key
+	groups := map[string]*EarningsGroup{}
+	groupKeys := []string{}
+	for _, royaltyStatement := range royaltyStatements {
+		currency := royaltyStatement.Currency
+		if currency == "" {
+			currency = UNSPECIFIED_CURRENCY
+		}
+		dimensions := map[string]string{}
+		keyParts := []string{}
+		for _, dimension := range earningsReportQuery.GroupBy {
+			dimensions[dimension] = earningsDimensions[dimension](royaltyStatement)
+			keyParts = append(keyParts, dimensions[dimension])
+		}
+		keyParts = append(keyParts, currency)
+		groupKey := strings.Join(keyParts, "~")
+
+		group, ok := groups[groupKey]
+		if !ok {
+			group = &EarningsGroup{Dimensions: dimensions, Currency: currency}
+			groups[groupKey] = group
+			groupKeys = append(groupKeys, groupKey)
+		}
+		_, _, amount := getRoyaltyStatementParties(royaltyStatement)
+		group.Amount = toFixed(group.Amount+amount, 6)
+		group.Units += royaltyStatement.Units
+		group.Count++
+	}
+	sort.Strings(groupKeys)
+
+	earningsReport := EarningsReport{}
+	earningsReport.Ipi = earningsReportQuery.Ipi
+	earningsReport.StartDate = earningsReportQuery.StartDate
+	earningsReport.EndDate = earningsReportQuery.EndDate
+	earningsReport.GroupBy = earningsReportQuery.GroupBy
+	earningsReport.TotalGroups = len(groupKeys)
+	earningsReport.Groups = []EarningsGroup{}
+
+	// the bookmark is the key of the last group of the previous page
+	start := sort.SearchStrings(groupKeys, earningsReportQuery.Bookmark)
+	if earningsReportQuery.Bookmark != "" && start < len(groupKeys) && groupKeys[start] == earningsReportQuery.Bookmark {
+		start++
+	}
+	for i := start; i < len(groupKeys) && len(earningsReport.Groups) < earningsReportQuery.PageSize; i++ {
+		earningsReport.Groups = append(earningsReport.Groups, *groups[groupKeys[i]])
+		if i < len(groupKeys)-1 {
+			earningsReport.Bookmark = groupKeys[i]
+		} else {
+			earningsReport.Bookmark = ""
+		}
+	}
+
+	objBytes, err := objectToJSON(earningsReport)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	logger.Info("EXITING <", methodName, len(earningsReport.Groups))
+	return shim.Success(objBytes)
+}
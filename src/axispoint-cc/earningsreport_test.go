@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"axispoint-cc/memstub"
+)
+
+func MockGetEarningsReportResponse(functionName string) []byte {
+	switch functionName {
+	case "Test_GetEarningsReport_FirstPage":
+		return []byte(`{"ipi":"Ned-IPI","startDate":"2018-10-01","endDate":"2018-12-31","groupBy":["isrc"],"totalGroups":2,"groups":[{"dimensions":{"isrc":"QZAB11800001"},"currency":"UNSPECIFIED","amount":250,"units":20000,"count":2}],"bookmark":"QZAB11800001~UNSPECIFIED"}`)
+	case "Test_GetEarningsReport_LastPage":
+		return []byte(`{"ipi":"Ned-IPI","startDate":"2018-10-01","endDate":"2018-12-31","groupBy":["isrc"],"totalGroups":2,"groups":[{"dimensions":{"isrc":"QZAB11800002"},"currency":"UNSPECIFIED","amount":50.5,"units":500,"count":1}],"bookmark":""}`)
+	case "Test_GetEarningsReport_TerritoryUsageType":
+		return []byte(`{"ipi":"Ned-IPI","startDate":"2018-12-01","endDate":"2019-01-31","groupBy":["territory","usageType"],"totalGroups":1,"groups":[{"dimensions":{"territory":"FRA","usageType":"MECH"},"currency":"UNSPECIFIED","amount":252,"units":20100,"count":3}],"bookmark":""}`)
+	case "Test_GetEarningsReport_InvalidDimension":
+		return []byte(`{"status":"500","message":"getEarningsReport - Invalid group by dimension 'writerName'."}`)
+	default:
+		return []byte("[]")
+	}
+}
+
+func addEarningsRoyaltyStatements(t *testing.T) *memstub.Stub {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+
+	// Init
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addRoyaltyStatements"), []byte(periodRoyaltyStatements_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	return stub
+}
+
+func Test_GetEarningsReport_Pages(t *testing.T) {
+	stub := addEarningsRoyaltyStatements(t)
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getEarningsReport"), []byte(`{"ipi":"Ned-IPI","startDate":"2018-10-01","endDate":"2018-12-31","groupBy":["isrc"],"pageSize":1}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected := MockGetEarningsReportResponse("Test_GetEarningsReport_FirstPage")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("getEarningsReport"), []byte(`{"ipi":"Ned-IPI","startDate":"2018-10-01","endDate":"2018-12-31","groupBy":["isrc"],"pageSize":1,"bookmark":"QZAB11800001~UNSPECIFIED"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected = MockGetEarningsReportResponse("Test_GetEarningsReport_LastPage")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_GetEarningsReport_TerritoryUsageType(t *testing.T) {
+	stub := addEarningsRoyaltyStatements(t)
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getEarningsReport"), []byte(`{"ipi":"Ned-IPI","startDate":"2018-12-01","endDate":"2019-01-31","groupBy":["territory","usageType"]}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected := MockGetEarningsReportResponse("Test_GetEarningsReport_TerritoryUsageType")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_GetEarningsReport_InvalidDimension(t *testing.T) {
+	stub := addEarningsRoyaltyStatements(t)
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getEarningsReport"), []byte(`{"ipi":"Ned-IPI","startDate":"2018-10-01","endDate":"2018-12-31","groupBy":["writerName"]}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected := MockGetEarningsReportResponse("Test_GetEarningsReport_InvalidDimension")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
@@ -3,6 +3,9 @@ package main
 import (
 	"fmt"
 	"reflect"
+	"sort"
+
+	"axispoint-cc/identifier"
 
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	pb "github.com/hyperledger/fabric/protos/peer"
@@ -51,9 +54,12 @@ func generateExploitationReports(stub shim.ChaincodeStubInterface, args []string
 		return getErrorResponse(err.Error())
 	}
 
+	exploitationReportClassificationEvent := ExploitationReportClassificationEvent{}
+	exploitationReportClassificationEvent.Version = EventExploitationReportClassificationVersion
+	exploitationReportClassificationEvent.Classifications = []ExploitationReportClassification{}
+
 	// iterate over exploitation reports
 	for _, exploitationReport := range *exploitationReports {
-		royaltyStatements := []RoyaltyStatement{}
 		exploitationReport.DocType = EXPLOITATIONREPORT
 		exploitationReport.State = INITIAL
 		exploitationReportResponse := ExploitationReportResponse{}
@@ -70,127 +76,24 @@ func generateExploitationReports(stub shim.ChaincodeStubInterface, args []string
 			continue
 		}
 
-		// create exploitation report parameters to evaluate the selector expressions
-		exploitationReportParameters, _ := getEvaluableParameters(&exploitationReport)
-
-		// query copyright data reports
-		queryString := fmt.Sprintf("{\"selector\":{\"docType\":\"%s\",\"isrc\":\"%s\", \"startDate\": { \"$lte\": \"%s\" }, \"endDate\": { \"$gte\": \"%s\" }}}", COPYRIGHTDATAREPORT, exploitationReport.Isrc, exploitationReport.ExploitationDate, exploitationReport.ExploitationDate)
-		copyrightDataReports, _ := queryCopyrightDataReports(stub, queryString)
-
-		// set the percentage. used for calculating incomplete royalty statement splits
-		totalPercentage := 0.0
-
-		for _, copyrightDataReport := range copyrightDataReports {
-			// get all the right holders and evaluate againist right holder selector expression
-			for _, rightHolder := range copyrightDataReport.RightHolders {
-				isSelectorValid := false
-				// generate royalty statements for copy right holders with empty selector
-				if rightHolder.Selector == "" {
-					isSelectorValid = true
-				} else {
-					isSelectorValidResult, err := evaluate(rightHolder.Selector, exploitationReportParameters)
-					if err != nil {
-						logger.Errorf("%s - Failed to get a valid evaluator for right holder ipi %s, selector %s. Error: %s", methodName, rightHolder.IPI, rightHolder.Selector, err.Error())
-					}
-					//to prevent a crash when test cases are run.
-					if reflect.ValueOf(isSelectorValidResult).IsValid() {
-						isSelectorValid = isSelectorValidResult.(bool)
-					}
-				}
-				if isSelectorValid {
-					// generate royalty statment
-					royaltyStatement := RoyaltyStatement{}
-					// set the royalty statment right holder
-					royaltyStatement.RightHolder = rightHolder.IPI
-					// set the right type to OWNERSHIP as the royalty statement is between DSP and owner adminsitrator
-					royaltyStatement.RightType = OWNERSHIP
-					royaltyStatement.Amount = toFixed(exploitationReport.Amount*rightHolder.Percent*0.01, 2)
-					royaltyStatements = append(royaltyStatements, royaltyStatement)
-
-					totalPercentage += rightHolder.Percent
-				}
+		// evaluate the copyright splits and generate the royalty statements, reports with an invalid ISRC or territory are only flagged
+		if normalizeExploitationReportIsrc(&exploitationReport) && validateExploitationReportTerritory(&exploitationReport) {
+			royaltyStatements, err := generateRoyaltyStatements(stub, &exploitationReport)
+			if err != nil {
+				// a statement without its tax would be paid in full
+				exploitationReportResponse.Success = false
+				exploitationReportResponse.Message = err.Error()
+				exploitationReportResponses = append(exploitationReportResponses, exploitationReportResponse)
+				exploitationReportOutput.FailureCount++
+				continue
 			}
+			exploitationReportOutput.RoyaltyStatements = append(exploitationReportOutput.RoyaltyStatements, royaltyStatements...)
 		}
 
-		// check the total percentage
-		if totalPercentage > 100 {
-			// if totalPercentage > 100, do not generate royalty reports
-			exploitationReport.State = INCONSISTENT_COPYRIGHT_SPLIT
-		} else {
-			// if totalPercentage < 100, set the exploitation report state as incomplete
-			if len(royaltyStatements) == 0 {
-				exploitationReport.State = UNKOWN_ISRC
-			} else if totalPercentage == 0 { // if royal statements exisys and the total percentage is 0
-				exploitationReport.State = INCOMPLETE_COPYRIGHT_SPLIT
-			} else if totalPercentage < 100 { // totalPercentage < 100 if there are missing copyright holders
-				exploitationReport.State = MISSING_COPYRIGHT_HOLDER
-			}
-
-			// for all the royalty statments, find the owner administrator and affiliation
-			for _, royaltyStatement := range royaltyStatements {
-				royaltyStatement.DocType = ROYALTYSTATEMENT
-				royaltyStatement.Source = exploitationReport.Source
-				royaltyStatement.SongTitle = exploitationReport.SongTitle
-				royaltyStatement.Isrc = exploitationReport.Isrc
-				royaltyStatement.ExploitationReportUUID = exploitationReport.ExploitationReportUUID
-				royaltyStatement.ExploitationDate = exploitationReport.ExploitationDate
-				royaltyStatement.WriterName = exploitationReport.WriterName
-				royaltyStatement.Units = exploitationReport.Units
-				royaltyStatement.Territory = exploitationReport.Territory
-				royaltyStatement.UsageType = exploitationReport.UsageType
-				royaltyStatement.Administrator = ""
-				royaltyStatement.Collector = ""
-
-				//removing the following since owner administrations and affiliations are deprecated.
-				// query owner administrations
-				/*queryString := fmt.Sprintf("{\"selector\":{\"docType\":\"%s\",\"owner\":\"%s\", \"startDate\": { \"$lte\": \"%s\" }, \"endDate\": { \"$gte\": \"%s\" }}}", OWNERADMINISTRATION, royaltyStatement.RightHolder, exploitationReport.ExploitationDate, exploitationReport.ExploitationDate)
-				ownerAdministrations, _ := queryOwnerAdministrations(stub, queryString)
-				royaltyStatement.State = MISSING_REPRESENTATIVE
-
-				for _, ownerAdministration := range ownerAdministrations {
-					for _, representation := range ownerAdministration.Representations {
-						isSelectorValid := false
-						// set owner representation for a royalty statement with empty selector
-						if representation.Selector == "" {
-							isSelectorValid = true
-						} else {
-							isSelectorValidResult, _ := evaluate(representation.Selector, exploitationReportParameters)
-							isSelectorValid = isSelectorValidResult.(bool)
-						}
-						if isSelectorValid {
-							royaltyStatement.State = MISSING_AFFILIATE
-							// set the royalty statement administrator
-							royaltyStatement.Administrator = representation.Representative
-
-							// query owner administrations
-							queryString := fmt.Sprintf("{\"selector\":{\"docType\":\"%s\",\"administrator\":\"%s\", \"startDate\": { \"$lte\": \"%s\" }, \"endDate\": { \"$gte\": \"%s\" }}}", ADMINISTRATORAFFILIATION, representation.Representative, exploitationReport.ExploitationDate, exploitationReport.ExploitationDate)
-							administratorAffiliations, _ := queryAdministratorAffiliations(stub, queryString)
-
-							for _, administratorAffiliation := range administratorAffiliations {
-								for _, affiliation := range administratorAffiliation.Affiliations {
-									isSelectorValid = false
-									// set administrator affiliation for a royalty statement with empty selector
-									if affiliation.Selector == "" {
-										isSelectorValid = true
-									} else {
-										isSelectorValidResult, _ := evaluate(affiliation.Selector, exploitationReportParameters)
-										isSelectorValid = isSelectorValidResult.(bool)
-									}
-									if isSelectorValid {
-										royaltyStatement.State = INITIAL
-										// set the royalty statement afflliation
-										royaltyStatement.Collector = affiliation.Affiliate
-										break
-									}
-								}
-							}
-							break
-						}
-					}
-				}*/
-				// add the royalty statement to output
-				exploitationReportOutput.RoyaltyStatements = append(exploitationReportOutput.RoyaltyStatements, royaltyStatement)
-			}
+		// let the right holders know about usage that could not be matched to a complete split
+		err = addExploitationReportClassification(stub, &exploitationReportClassificationEvent, exploitationReport)
+		if err != nil {
+			logger.Errorf("%s - Failed to classify exploitation report '%s'. Error: %s", methodName, exploitationReport.ExploitationReportUUID, err.Error())
 		}
 
 		// record exploitation report on ledger
@@ -220,11 +123,318 @@ func generateExploitationReports(stub shim.ChaincodeStubInterface, args []string
 
 	exploitationReportOutput.ExploitationReportResponses = exploitationReportResponses
 
+	err = setExploitationReportClassificationEvent(stub, exploitationReportClassificationEvent)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
 	objBytes, _ := objectToJSON(exploitationReportOutput)
 	logger.Info("EXITING <", methodName, exploitationReportOutput)
 	return shim.Success(objBytes)
 }
 
+//normalizeExploitationReportIsrc - normalizes the ISRC of an exploitation report or flags the report with the INVALID_ISRC state
+func normalizeExploitationReportIsrc(exploitationReport *ExploitationReport) bool {
+	isrc, err := identifier.NormalizeIsrc(exploitationReport.Isrc)
+	if err != nil {
+		logger.Warningf("Exploitation report '%s' flagged: %s", exploitationReport.ExploitationReportUUID, err.Error())
+		exploitationReport.State = INVALID_ISRC
+		return false
+	}
+	exploitationReport.Isrc = isrc
+	return true
+}
+
+/*
+* addExploitationReportClassification function adds a classified exploitation report to the classification event
+* of the transaction. Reports are grouped by state, ISRC and source; reports in the INITIAL state are not added.
+*
+* @param    {ExploitationReportClassificationEvent} - event of the transaction
+* @param    {ExploitationReport}                    - classified exploitation report
+* @return   {error}                                 - Error
+ */
+func addExploitationReportClassification(stub shim.ChaincodeStubInterface, exploitationReportClassificationEvent *ExploitationReportClassificationEvent, exploitationReport ExploitationReport) error {
+	if exploitationReport.State == INITIAL || exploitationReport.State == "" {
+		return nil
+	}
+
+	for i, classification := range exploitationReportClassificationEvent.Classifications {
+		if classification.Type == exploitationReport.State && classification.Isrc == exploitationReport.Isrc && classification.Source == exploitationReport.Source {
+			exploitationReportClassificationEvent.Classifications[i].ExploitationReportUUIDs = append(classification.ExploitationReportUUIDs, exploitationReport.ExploitationReportUUID)
+			return nil
+		}
+	}
+
+	rightHolders, orgs, err := getIsrcRightHolders(stub, exploitationReport.Isrc)
+	if err != nil {
+		return err
+	}
+
+	classification := ExploitationReportClassification{}
+	classification.Type = exploitationReport.State
+	classification.Isrc = exploitationReport.Isrc
+	classification.Source = exploitationReport.Source
+	classification.RightHolders = rightHolders
+	classification.Orgs = orgs
+	classification.ExploitationReportUUIDs = []string{exploitationReport.ExploitationReportUUID}
+	exploitationReportClassificationEvent.Classifications = append(exploitationReportClassificationEvent.Classifications, classification)
+	return nil
+}
+
+//getIsrcRightHolders - returns the sorted right holder IPIs of all the copyright data reports of an ISRC, or of its
+//musical work when there are none, and their orgs
+func getIsrcRightHolders(stub shim.ChaincodeStubInterface, isrc string) ([]string, []string, error) {
+	// the splits of every period are considered as the exploitation date may not be covered by any of them
+	queryString := fmt.Sprintf("{\"selector\":{\"docType\":\"%s\",\"isrc\":\"%s\"}}", COPYRIGHTDATAREPORT, isrc)
+	copyrightDataReports, err := queryCopyrightDataReports(stub, queryString)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(copyrightDataReports) == 0 {
+		musicalWork, err := getMusicalWorkForRecording(stub, isrc)
+		if err != nil {
+			return nil, nil, err
+		}
+		if musicalWork != nil {
+			copyrightDataReports = []CopyrightDataReport{{Isrc: isrc, RightHolders: musicalWork.RightHolders}}
+		}
+	}
+
+	rightHolders := []string{}
+	orgs := []string{}
+	seenRightHolders := map[string]bool{}
+	seenOrgs := map[string]bool{}
+	for _, copyrightDataReport := range copyrightDataReports {
+		if copyrightDataReport.Isrc != isrc {
+			continue
+		}
+		for _, rightHolder := range copyrightDataReport.RightHolders {
+			if rightHolder.IPI == "" || seenRightHolders[rightHolder.IPI] {
+				continue
+			}
+			seenRightHolders[rightHolder.IPI] = true
+			rightHolders = append(rightHolders, rightHolder.IPI)
+
+			org, err := getOrgForIpi(stub, rightHolder.IPI)
+			if err != nil {
+				return nil, nil, err
+			}
+			if org != "" && !seenOrgs[org] {
+				seenOrgs[org] = true
+				orgs = append(orgs, org)
+			}
+		}
+	}
+	sort.Strings(rightHolders)
+	sort.Strings(orgs)
+	return rightHolders, orgs, nil
+}
+
+//setExploitationReportClassificationEvent - fires the exploitation report classification event when it has classifications
+func setExploitationReportClassificationEvent(stub shim.ChaincodeStubInterface, exploitationReportClassificationEvent ExploitationReportClassificationEvent) error {
+	methodName := "setExploitationReportClassificationEvent"
+	if len(exploitationReportClassificationEvent.Classifications) == 0 {
+		logger.Infof("%s - event '%s' not fired, no classifications.", methodName, EventExploitationReportClassification)
+		return nil
+	}
+
+	eventPayloadBytes, err := objectToJSON(exploitationReportClassificationEvent)
+	if err != nil {
+		return err
+	}
+	logger.Infof("%s - firing event '%s' with payload: %s", methodName, EventExploitationReportClassification, string(eventPayloadBytes))
+	return stub.SetEvent(EventExploitationReportClassification, eventPayloadBytes)
+}
+
+/*
+* generateRoyaltyStatements function evaluates the copyright splits of an Exploitation Report and returns the
+* resulting royalty statements. The state of the exploitation report is set according to the splits found.
+*
+* @param    {ExploitationReport} - exploitation report to evaluate
+* @return   {Array}              - generated royalty statements
+* @return   {error}              - Error, when the withholding tax of a statement cannot be applied
+ */
+func generateRoyaltyStatements(stub shim.ChaincodeStubInterface, exploitationReport *ExploitationReport) ([]RoyaltyStatement, error) {
+	var methodName = "generateRoyaltyStatements"
+	logger.Info("ENTERING >", methodName, exploitationReport.ExploitationReportUUID)
+
+	royaltyStatements := []RoyaltyStatement{}
+	generatedRoyaltyStatements := []RoyaltyStatement{}
+
+	// create exploitation report parameters to evaluate the selector expressions
+	exploitationReportParameters, _ := getEvaluableParameters(exploitationReport)
+	selectorFunctions := getSelectorFunctions(stub)
+
+	// query copyright data reports, only the reports for the territory of the exploitation apply
+	queryString := fmt.Sprintf("{\"selector\":{\"docType\":\"%s\",\"isrc\":\"%s\", \"startDate\": { \"$lte\": \"%s\" }, \"endDate\": { \"$gte\": \"%s\" }}}", COPYRIGHTDATAREPORT, exploitationReport.Isrc, exploitationReport.ExploitationDate, exploitationReport.ExploitationDate)
+	queriedCopyrightDataReports, _ := queryCopyrightDataReports(stub, queryString)
+	copyrightDataReports := []CopyrightDataReport{}
+	for _, copyrightDataReport := range queriedCopyrightDataReports {
+		if len(copyrightDataReport.Territories) == 0 && len(copyrightDataReport.ExcludedTerritories) == 0 ||
+			isTerritoryInArea(stub, exploitationReport.Territory, copyrightDataReport.Territories, copyrightDataReport.ExcludedTerritories) {
+			copyrightDataReports = append(copyrightDataReports, copyrightDataReport)
+		}
+	}
+
+	// without copyright data for the recording, the split of its musical work applies
+	iswc := ""
+	if len(copyrightDataReports) == 0 {
+		musicalWork, err := getMusicalWorkForRecording(stub, exploitationReport.Isrc)
+		if err != nil {
+			logger.Errorf("%s - Failed to get the musical work of recording %s. Error: %s", methodName, exploitationReport.Isrc, err.Error())
+		}
+		if musicalWork != nil && isMusicalWorkInEffect(*musicalWork, exploitationReport.ExploitationDate) {
+			iswc = musicalWork.Iswc
+			copyrightDataReports = []CopyrightDataReport{{Isrc: exploitationReport.Isrc, RightHolders: musicalWork.RightHolders}}
+		}
+	}
+
+	// the right category of the usage type selects the share of each right holder
+	rightCategory, err := getRightCategoryForUsageType(stub, exploitationReport.UsageType)
+	if err != nil {
+		logger.Errorf("%s - Failed to get the right category of usage type %s. Error: %s", methodName, exploitationReport.UsageType, err.Error())
+	}
+
+	// set the percentage. used for calculating incomplete royalty statement splits
+	totalPercentage := 0.0
+
+	for _, copyrightDataReport := range copyrightDataReports {
+		// get all the right holders and evaluate againist right holder selector expression
+		for _, rightHolder := range copyrightDataReport.RightHolders {
+			isSelectorValid := false
+			// generate royalty statements for copy right holders with empty selector
+			if rightHolder.Selector == "" {
+				isSelectorValid = true
+			} else {
+				// the selector of a right holder can test its role
+				exploitationReportParameters["Role"] = rightHolder.Role
+				isSelectorValidResult, err := evaluateWithFunctions(rightHolder.Selector, exploitationReportParameters, selectorFunctions)
+				if err != nil {
+					logger.Errorf("%s - Failed to get a valid evaluator for right holder ipi %s, selector %s. Error: %s", methodName, rightHolder.IPI, rightHolder.Selector, err.Error())
+				}
+				//to prevent a crash when test cases are run.
+				if reflect.ValueOf(isSelectorValidResult).IsValid() {
+					isSelectorValid = isSelectorValidResult.(bool)
+				}
+			}
+			share := getRightHolderShare(rightHolder, rightCategory)
+			// a right holder with no share of the right category does not hold that right
+			if _, ok := rightHolder.Shares[rightCategory]; ok && rightCategory != "" && share == 0 {
+				isSelectorValid = false
+			}
+			if isSelectorValid {
+				// generate royalty statment
+				royaltyStatement := RoyaltyStatement{}
+				// set the royalty statment right holder
+				royaltyStatement.RightHolder = rightHolder.IPI
+				royaltyStatement.RightHolderRole = rightHolder.Role
+				// keep track of the copyright data report the split was taken from
+				royaltyStatement.CopyrightDataReportUUID = copyrightDataReport.CopyrightDataUUID
+				royaltyStatement.Iswc = iswc
+				// set the right type to OWNERSHIP as the royalty statement is between DSP and owner adminsitrator
+				royaltyStatement.RightType = OWNERSHIP
+				royaltyStatement.RightCategory = rightCategory
+				royaltyStatement.Amount = toFixed(exploitationReport.Amount*share*0.01, 2)
+				royaltyStatements = append(royaltyStatements, royaltyStatement)
+
+				totalPercentage += share
+			}
+		}
+	}
+
+	// check the total percentage
+	if totalPercentage > 100 {
+		// if totalPercentage > 100, do not generate royalty reports
+		exploitationReport.State = INCONSISTENT_COPYRIGHT_SPLIT
+	} else {
+		// if totalPercentage < 100, set the exploitation report state as incomplete
+		if len(royaltyStatements) == 0 {
+			exploitationReport.State = UNKOWN_ISRC
+		} else if totalPercentage == 0 { // if royal statements exisys and the total percentage is 0
+			exploitationReport.State = INCOMPLETE_COPYRIGHT_SPLIT
+		} else if totalPercentage < 100 { // totalPercentage < 100 if there are missing copyright holders
+			exploitationReport.State = MISSING_COPYRIGHT_HOLDER
+		}
+
+		// for all the royalty statments, find the owner administrator and affiliation
+		for _, royaltyStatement := range royaltyStatements {
+			royaltyStatement.DocType = ROYALTYSTATEMENT
+			royaltyStatement.Source = exploitationReport.Source
+			royaltyStatement.SongTitle = exploitationReport.SongTitle
+			royaltyStatement.Isrc = exploitationReport.Isrc
+			royaltyStatement.ExploitationReportUUID = exploitationReport.ExploitationReportUUID
+			royaltyStatement.ExploitationDate = exploitationReport.ExploitationDate
+			royaltyStatement.WriterName = exploitationReport.WriterName
+			royaltyStatement.Units = exploitationReport.Units
+			royaltyStatement.Territory = exploitationReport.Territory
+			royaltyStatement.UsageType = exploitationReport.UsageType
+			royaltyStatement.Currency = exploitationReport.Currency
+			royaltyStatement.Administrator = ""
+			royaltyStatement.Collector = ""
+
+			//removing the following since owner administrations and affiliations are deprecated.
+			// query owner administrations
+			/*queryString := fmt.Sprintf("{\"selector\":{\"docType\":\"%s\",\"owner\":\"%s\", \"startDate\": { \"$lte\": \"%s\" }, \"endDate\": { \"$gte\": \"%s\" }}}", OWNERADMINISTRATION, royaltyStatement.RightHolder, exploitationReport.ExploitationDate, exploitationReport.ExploitationDate)
+			ownerAdministrations, _ := queryOwnerAdministrations(stub, queryString)
+			royaltyStatement.State = MISSING_REPRESENTATIVE
+
+			for _, ownerAdministration := range ownerAdministrations {
+				for _, representation := range ownerAdministration.Representations {
+					isSelectorValid := false
+					// set owner representation for a royalty statement with empty selector
+					if representation.Selector == "" {
+						isSelectorValid = true
+					} else {
+						isSelectorValidResult, _ := evaluate(representation.Selector, exploitationReportParameters)
+						isSelectorValid = isSelectorValidResult.(bool)
+					}
+					if isSelectorValid {
+						royaltyStatement.State = MISSING_AFFILIATE
+						// set the royalty statement administrator
+						royaltyStatement.Administrator = representation.Representative
+
+						// query owner administrations
+						queryString := fmt.Sprintf("{\"selector\":{\"docType\":\"%s\",\"administrator\":\"%s\", \"startDate\": { \"$lte\": \"%s\" }, \"endDate\": { \"$gte\": \"%s\" }}}", ADMINISTRATORAFFILIATION, representation.Representative, exploitationReport.ExploitationDate, exploitationReport.ExploitationDate)
+						administratorAffiliations, _ := queryAdministratorAffiliations(stub, queryString)
+
+						for _, administratorAffiliation := range administratorAffiliations {
+							for _, affiliation := range administratorAffiliation.Affiliations {
+								isSelectorValid = false
+								// set administrator affiliation for a royalty statement with empty selector
+								if affiliation.Selector == "" {
+									isSelectorValid = true
+								} else {
+									isSelectorValidResult, _ := evaluate(affiliation.Selector, exploitationReportParameters)
+									isSelectorValid = isSelectorValidResult.(bool)
+								}
+								if isSelectorValid {
+									royaltyStatement.State = INITIAL
+									// set the royalty statement afflliation
+									royaltyStatement.Collector = affiliation.Affiliate
+									break
+								}
+							}
+						}
+						break
+					}
+				}
+			}*/
+			// the tax of the territory of the exploitation is withheld from the right holder
+			err = applyWithholdingTax(stub, &royaltyStatement)
+			if err != nil {
+				logger.Errorf("%s - Failed to apply the withholding tax for right holder %s. Error: %s", methodName, royaltyStatement.RightHolder, err.Error())
+				return nil, fmt.Errorf("Failed to apply the withholding tax for right holder '%s'. Error: %s", royaltyStatement.RightHolder, err.Error())
+			}
+			// add the royalty statement to output
+			generatedRoyaltyStatements = append(generatedRoyaltyStatements, royaltyStatement)
+		}
+	}
+
+	logger.Info("EXITING <", methodName, len(generatedRoyaltyStatements))
+	return generatedRoyaltyStatements, nil
+}
+
 /*
 * updateExploitationReports function contains business logic to update Exploitation Reports to the Ledger
 *
@@ -266,6 +476,9 @@ func updateExploitationReports(stub shim.ChaincodeStubInterface, args []string)
 	// iterate over Exploitation Reports
 	for _, exploitationReport := range *exploitationReports {
 		exploitationReport.DocType = EXPLOITATIONREPORT
+		if normalizeExploitationReportIsrc(&exploitationReport) {
+			validateExploitationReportTerritory(&exploitationReport)
+		}
 		exploitationReportResponse := ExploitationReportResponse{}
 		exploitationReportResponse.ExploitationReportUUID = exploitationReport.ExploitationReportUUID
 		exploitationReportResponse.Success = true
@@ -353,6 +566,9 @@ func insertExploitationReports(stub shim.ChaincodeStubInterface, args []string)
 	// iterate over Exploitation Reports
 	for _, exploitationReport := range *exploitationReports {
 		exploitationReport.DocType = EXPLOITATIONREPORT
+		if normalizeExploitationReportIsrc(&exploitationReport) {
+			validateExploitationReportTerritory(&exploitationReport)
+		}
 		exploitationReportResponse := ExploitationReportResponse{}
 		exploitationReportResponse.ExploitationReportUUID = exploitationReport.ExploitationReportUUID
 		exploitationReportResponse.Success = true
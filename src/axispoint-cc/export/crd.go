@@ -0,0 +1,125 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record types of a Claim Detail message
+const (
+	HeaderRecord      = "HEAD"
+	SummaryRecord     = "SY01"
+	PeriodTotalRecord = "SY02"
+	ClaimDetailRecord = "CD01"
+	FooterRecord      = "FOOT"
+)
+
+//crdColumns - the columns of the records of a Claim Detail message, defined at the top of the message
+var crdColumns = []struct {
+	recordType string
+	columns    []string
+}{
+	{HeaderRecord, []string{"MessageVersion", "Profile", "ProfileVersion", "MessageId", "MessageCreatedDateTime", "UsageStartDate", "UsageEndDate", "SenderPartyId", "RecipientPartyId", "PeriodStatementId"}},
+	{SummaryRecord, []string{"SummaryRecordId", "Currency", "NumberOfClaims", "Units", "Amount", "TaxWithheld", "RecoupedAmount", "PayableAmount"}},
+	{PeriodTotalRecord, []string{"SummaryRecordId", "Currency", "Dimension", "Key", "Amount"}},
+	{ClaimDetailRecord, []string{"ClaimId", "SummaryRecordId", "ExploitationReportId", "ISRC", "ISWC", "Title", "WriterName", "DspPartyId", "Territory", "UseType", "RightType", "RightCategory", "ExploitationDate", "NumberOfUsages", "Currency", "Amount", "TaxWithheld", "RecoupedAmount", "PayableAmount", "PayerPartyId", "PayeePartyId"}},
+	{FooterRecord, []string{"NumberOfLinesInFile", "NumberOfSummaryRecords", "NumberOfClaims"}},
+}
+
+//writeCRD - writes a Claim Detail message: a header, a summary record per currency, a claim detail record per line
+// and a footer, tab separated like the DSR flat files
+func writeCRD(lines []Line, totals []CurrencyTotal, exportPeriod period, options Options, decimals int) ([]byte, error) {
+	records := getDefinitionRecords()
+
+	payers := map[string]bool{}
+	recipient := ""
+	usageStartDate, usageEndDate := exportPeriod.start, exportPeriod.end
+	for _, line := range lines {
+		payers[line.Payer] = true
+		if recipient == "" {
+			recipient = line.Payee
+		}
+		if exportPeriod.statement == nil {
+			if usageStartDate == "" || line.Statement.ExploitationDate < usageStartDate {
+				usageStartDate = line.Statement.ExploitationDate
+			}
+			if line.Statement.ExploitationDate > usageEndDate {
+				usageEndDate = line.Statement.ExploitationDate
+			}
+		}
+	}
+	sender := options.Sender
+	if sender == "" && len(payers) == 1 {
+		for payer := range payers {
+			sender = payer
+		}
+	}
+	periodStatementID := ""
+	if exportPeriod.statement != nil {
+		periodStatementID = exportPeriod.statement.PeriodStatementUUID
+		recipient = exportPeriod.statement.Ipi
+	}
+	records = append(records, []string{HeaderRecord, "CRD/1.0", "ClaimDetail", "1.0", options.MessageID, options.Created.Format(time.RFC3339), usageStartDate, usageEndDate, sender, recipient, periodStatementID})
+
+	summaryRecordIDs := map[string]string{}
+	for i, total := range totals {
+		summaryRecordIDs[total.Currency] = "SY" + strconv.Itoa(i+1)
+		records = append(records, []string{SummaryRecord, summaryRecordIDs[total.Currency], total.Currency, strconv.Itoa(total.Lines), strconv.Itoa(total.Units),
+			formatAmount(total.Amount, decimals), formatAmount(total.TaxWithheld, decimals), formatAmount(total.RecoupedAmount, decimals), formatAmount(total.Payable, decimals)})
+	}
+
+	for _, line := range lines {
+		statement := line.Statement
+		records = append(records, []string{ClaimDetailRecord, statement.RoyaltyStatementUUID, summaryRecordIDs[statement.Currency], statement.ExploitationReportUUID,
+			statement.Isrc, statement.Iswc, statement.SongTitle, statement.WriterName, statement.Source, statement.Territory, statement.UsageType,
+			statement.RightType, statement.RightCategory, statement.ExploitationDate, strconv.Itoa(statement.Units), statement.Currency,
+			formatAmount(line.Amount, decimals), formatAmount(line.TaxWithheld, decimals), formatAmount(line.RecoupedAmount, decimals), formatAmount(line.Payable, decimals),
+			line.Payer, line.Payee})
+	}
+
+	records = append(records, []string{FooterRecord, strconv.Itoa(len(records) + 1), strconv.Itoa(len(totals)), strconv.Itoa(len(lines))})
+
+	return writeRecords(records)
+}
+
+//writeSummaryCRD - writes a Claim Detail message of the totals of a period statement, a period total record per row
+func writeSummaryCRD(rows []SummaryRow, periodStatement PeriodStatement, options Options, decimals int) ([]byte, error) {
+	records := getDefinitionRecords()
+	records = append(records, []string{HeaderRecord, "CRD/1.0", "ClaimDetail", "1.0", options.MessageID, options.Created.Format(time.RFC3339),
+		periodStatement.PeriodStart, periodStatement.PeriodEnd, options.Sender, periodStatement.Ipi, periodStatement.PeriodStatementUUID})
+	for i, row := range rows {
+		records = append(records, []string{PeriodTotalRecord, "SY" + strconv.Itoa(i+1), row.Currency, row.Dimension, row.Key, formatAmount(row.Amount, decimals)})
+	}
+	records = append(records, []string{FooterRecord, strconv.Itoa(len(records) + 1), strconv.Itoa(len(rows)), "0"})
+	return writeRecords(records)
+}
+
+//getDefinitionRecords - returns the records defining the columns of the records of a Claim Detail message
+func getDefinitionRecords() [][]string {
+	records := [][]string{}
+	for _, definition := range crdColumns {
+		records = append(records, append([]string{"#" + definition.recordType}, definition.columns...))
+	}
+	return records
+}
+
+//writeRecords - writes tab separated records, one per line
+func writeRecords(records [][]string) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	for _, record := range records {
+		for i, field := range record {
+			if strings.ContainsAny(field, "\t\r\n") {
+				return nil, fmt.Errorf("%s record: field '%s' has a tab or a line break", record[0], field)
+			}
+			if i > 0 {
+				buffer.WriteByte('\t')
+			}
+			buffer.WriteString(field)
+		}
+		buffer.WriteByte('\n')
+	}
+	return buffer.Bytes(), nil
+}
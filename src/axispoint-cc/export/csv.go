@@ -0,0 +1,218 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Column - a column of a CSV layout, the field of the line it holds under its header
+type Column struct {
+	Header string `json:"header"`
+	Field  string `json:"field"`
+}
+
+// Layout - the columns of a CSV export, its delimiter and the number of decimals of its amounts. With total
+// rows, a row per currency totals the amounts and units of the lines after the lines.
+type Layout struct {
+	Name      string   `json:"name"`
+	Delimiter string   `json:"delimiter,omitempty"`
+	Decimals  int      `json:"decimals,omitempty"`
+	TotalRows bool     `json:"totalRows,omitempty"`
+	Columns   []Column `json:"columns"`
+}
+
+// DefaultLayout - the layout of a CSV export when none is given
+var DefaultLayout = Layout{
+	Name:      "default",
+	Decimals:  2,
+	TotalRows: true,
+	Columns: []Column{
+		{Header: "Statement", Field: "royaltyStatementUUID"},
+		{Header: "Payer", Field: "payer"},
+		{Header: "Payee", Field: "payee"},
+		{Header: "ISRC", Field: "isrc"},
+		{Header: "ISWC", Field: "iswc"},
+		{Header: "Title", Field: "songTitle"},
+		{Header: "Source", Field: "source"},
+		{Header: "Territory", Field: "territory"},
+		{Header: "Usage Type", Field: "usageType"},
+		{Header: "Right Type", Field: "rightType"},
+		{Header: "Exploitation Date", Field: "exploitationDate"},
+		{Header: "Units", Field: "units"},
+		{Header: "Currency", Field: "currency"},
+		{Header: "Amount", Field: "amount"},
+		{Header: "Tax Withheld", Field: "taxWithheld"},
+		{Header: "Recouped", Field: "recoupedAmount"},
+		{Header: "Payable", Field: "payable"},
+	},
+}
+
+//amountFields - the fields of a line that are amounts, totalled in the total rows
+var amountFields = map[string]func(CurrencyTotal) float64{
+	"amount":         func(total CurrencyTotal) float64 { return total.Amount },
+	"taxWithheld":    func(total CurrencyTotal) float64 { return total.TaxWithheld },
+	"recoupedAmount": func(total CurrencyTotal) float64 { return total.RecoupedAmount },
+	"payable":        func(total CurrencyTotal) float64 { return total.Payable },
+}
+
+//textFields - the fields of a line that are not amounts
+var textFields = map[string]func(Line) string{
+	"royaltyStatementUUID":   func(line Line) string { return line.Statement.RoyaltyStatementUUID },
+	"exploitationReportUUID": func(line Line) string { return line.Statement.ExploitationReportUUID },
+	"periodStatementUUID":    func(line Line) string { return line.Statement.PeriodStatementUUID },
+	"payer":                  func(line Line) string { return line.Payer },
+	"payee":                  func(line Line) string { return line.Payee },
+	"rightHolder":            func(line Line) string { return line.Statement.RightHolder },
+	"rightHolderRole":        func(line Line) string { return line.Statement.RightHolderRole },
+	"administrator":          func(line Line) string { return line.Statement.Administrator },
+	"collector":              func(line Line) string { return line.Statement.Collector },
+	"source":                 func(line Line) string { return line.Statement.Source },
+	"isrc":                   func(line Line) string { return line.Statement.Isrc },
+	"iswc":                   func(line Line) string { return line.Statement.Iswc },
+	"songTitle":              func(line Line) string { return line.Statement.SongTitle },
+	"writerName":             func(line Line) string { return line.Statement.WriterName },
+	"territory":              func(line Line) string { return line.Statement.Territory },
+	"usageType":              func(line Line) string { return line.Statement.UsageType },
+	"rightType":              func(line Line) string { return line.Statement.RightType },
+	"rightCategory":          func(line Line) string { return line.Statement.RightCategory },
+	"exploitationDate":       func(line Line) string { return line.Statement.ExploitationDate },
+	"paymentState":           func(line Line) string { return line.Statement.PaymentState },
+	"currency":               func(line Line) string { return line.Statement.Currency },
+	"units":                  func(line Line) string { return strconv.Itoa(line.Statement.Units) },
+}
+
+// ReadLayout reads a CSV layout from JSON
+func ReadLayout(reader io.Reader) (*Layout, error) {
+	layout := Layout{}
+	err := json.NewDecoder(reader).Decode(&layout)
+	if err != nil {
+		return nil, fmt.Errorf("layout: %s", err.Error())
+	}
+	return &layout, layout.Validate()
+}
+
+// ParseColumns parses columns given as comma separated fields, each optionally preceded by its header and an
+// equal sign, e.g. "ISRC=isrc,Title=songTitle,payable"
+func ParseColumns(spec string) ([]Column, error) {
+	columns := []Column{}
+	for _, column := range strings.Split(spec, ",") {
+		column = strings.TrimSpace(column)
+		if column == "" {
+			continue
+		}
+		header, field := column, column
+		if i := strings.Index(column, "="); i >= 0 {
+			header, field = strings.TrimSpace(column[:i]), strings.TrimSpace(column[i+1:])
+		}
+		columns = append(columns, Column{Header: header, Field: field})
+	}
+	layout := Layout{Columns: columns}
+	return columns, layout.Validate()
+}
+
+// Validate checks that a layout has columns of known fields and a single character delimiter
+func (layout Layout) Validate() error {
+	if len(layout.Columns) == 0 {
+		return fmt.Errorf("layout '%s' has no column", layout.Name)
+	}
+	for _, column := range layout.Columns {
+		_, isAmount := amountFields[column.Field]
+		_, isText := textFields[column.Field]
+		if !isAmount && !isText {
+			return fmt.Errorf("unknown field '%s' of column '%s'", column.Field, column.Header)
+		}
+	}
+	if utf8.RuneCountInString(layout.Delimiter) > 1 {
+		return fmt.Errorf("delimiter '%s' of layout '%s' is not a single character", layout.Delimiter, layout.Name)
+	}
+	return nil
+}
+
+//writeCSV - writes a header row, a row per line and the total rows of a layout
+func writeCSV(lines []Line, totals []CurrencyTotal, layout Layout) ([]byte, error) {
+	err := layout.Validate()
+	if err != nil {
+		return nil, err
+	}
+	decimals := layout.Decimals
+	if decimals <= 0 {
+		decimals = 2
+	}
+
+	buffer := &bytes.Buffer{}
+	writer := csv.NewWriter(buffer)
+	if layout.Delimiter != "" {
+		writer.Comma, _ = utf8.DecodeRuneInString(layout.Delimiter)
+	}
+
+	row := make([]string, len(layout.Columns))
+	for i, column := range layout.Columns {
+		row[i] = column.Header
+	}
+	writer.Write(row)
+
+	for _, line := range lines {
+		row = make([]string, len(layout.Columns))
+		for i, column := range layout.Columns {
+			row[i] = getField(line, column.Field, decimals)
+		}
+		writer.Write(row)
+	}
+
+	if layout.TotalRows {
+		for _, total := range totals {
+			row = make([]string, len(layout.Columns))
+			for i, column := range layout.Columns {
+				switch {
+				case amountFields[column.Field] != nil:
+					row[i] = formatAmount(amountFields[column.Field](total), decimals)
+				case column.Field == "units":
+					row[i] = strconv.Itoa(total.Units)
+				case column.Field == "currency":
+					row[i] = total.Currency
+				}
+			}
+			row[0] = "TOTAL"
+			writer.Write(row)
+		}
+	}
+
+	writer.Flush()
+	return buffer.Bytes(), writer.Error()
+}
+
+//getField - returns the value of a field of a line
+func getField(line Line, field string, decimals int) string {
+	switch field {
+	case "amount":
+		return formatAmount(line.Amount, decimals)
+	case "taxWithheld":
+		return formatAmount(line.TaxWithheld, decimals)
+	case "recoupedAmount":
+		return formatAmount(line.RecoupedAmount, decimals)
+	case "payable":
+		return formatAmount(line.Payable, decimals)
+	}
+	return textFields[field](line)
+}
+
+//writeSummaryCSV - writes the rows of the totals of a period statement
+func writeSummaryCSV(rows []SummaryRow, delimiter string, decimals int) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	writer := csv.NewWriter(buffer)
+	if delimiter != "" {
+		writer.Comma, _ = utf8.DecodeRuneInString(delimiter)
+	}
+	writer.Write([]string{"Currency", "Dimension", "Key", "Amount"})
+	for _, row := range rows {
+		writer.Write([]string{row.Currency, row.Dimension, row.Key, formatAmount(row.Amount, decimals)})
+	}
+	writer.Flush()
+	return buffer.Bytes(), writer.Error()
+}
@@ -0,0 +1,440 @@
+/*
+Package export turns the royalty statements returned by getRoyaltyStatements, or the period statement returned
+by getPeriodStatement, into files payees load into their royalty software: CSV files of a configurable
+layout and DDEX Claim Detail (CRD) flat files.
+
+Every export comes with a manifest holding the totals of its lines by currency and the SHA-256 hash of its
+content, so the payee can reconcile the file it loads with the statements recorded on the ledger. The line of
+a royalty statement is the amount its payer owes its payee, less the tax withheld and the amount recouped for
+advances, the same amount the balances of the chaincode hold.
+*/
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"axispoint-cc/client"
+)
+
+// Formats of an export
+const (
+	CSV = "csv"
+	CRD = "crd"
+)
+
+// Right types of royalty statements
+const (
+	Ownership  = client.Ownership
+	Collection = client.Collection
+	Fee        = client.Fee
+)
+
+// UnspecifiedCurrency - the currency of the totals of statements without currency, as the chaincode reports it
+const UnspecifiedCurrency = "UNSPECIFIED"
+
+// The statements an export reads, as the client of the chaincode decodes them
+type (
+	RoyaltyStatement = client.RoyaltyStatement
+	PeriodStatement  = client.PeriodStatement
+	PeriodTotals     = client.PeriodTotals
+)
+
+// Line - a royalty statement, its payer, payee and the amounts of the line rounded to the decimals of the export
+type Line struct {
+	Statement      RoyaltyStatement
+	Payer          string
+	Payee          string
+	Amount         float64
+	TaxWithheld    float64
+	RecoupedAmount float64
+	Payable        float64
+}
+
+// CurrencyTotal - the totals of the lines of a currency
+type CurrencyTotal struct {
+	Currency       string  `json:"currency"`
+	Lines          int     `json:"lines"`
+	Units          int     `json:"units"`
+	Amount         float64 `json:"amount"`
+	TaxWithheld    float64 `json:"taxWithheld"`
+	RecoupedAmount float64 `json:"recoupedAmount"`
+	Payable        float64 `json:"payable"`
+}
+
+// Options - the options of an export
+type Options struct {
+	// Format is CSV or CRD, CSV by default
+	Format string
+	// Layout is the layout of a CSV export, DefaultLayout by default
+	Layout *Layout
+	// Decimals is the number of decimals of the amounts of a CRD export, 2 by default
+	Decimals int
+	// MessageID is the id of a CRD message, derived from its statements by default
+	MessageID string
+	// Sender is the party id of the sender of a CRD message, the payer of its statements when they have a single payer
+	Sender string
+	// Created is the creation time of a CRD message, the current time by default
+	Created time.Time
+}
+
+// Manifest - what a payee reconciles an export with
+type Manifest struct {
+	Format              string          `json:"format"`
+	Layout              string          `json:"layout,omitempty"`
+	MessageID           string          `json:"messageId,omitempty"`
+	PeriodStatementUUID string          `json:"periodStatementUUID,omitempty"`
+	PeriodStart         string          `json:"periodStart,omitempty"`
+	PeriodEnd           string          `json:"periodEnd,omitempty"`
+	Payees              []string        `json:"payees"`
+	Lines               int             `json:"lines"`
+	Totals              []CurrencyTotal `json:"totals"`
+	Discrepancies       []string        `json:"discrepancies,omitempty"`
+	Hash                string          `json:"hash"`
+}
+
+// Export - the content of an export and its manifest
+type Export struct {
+	Content  []byte   `json:"-"`
+	Manifest Manifest `json:"manifest"`
+}
+
+//period - the period of the statements of an export
+type period struct {
+	statement *PeriodStatement
+	start     string
+	end       string
+}
+
+// ReadStatements reads the royalty statements returned by getRoyaltyStatements
+func ReadStatements(reader io.Reader) ([]RoyaltyStatement, error) {
+	royaltyStatements := []RoyaltyStatement{}
+	err := json.NewDecoder(reader).Decode(&royaltyStatements)
+	if err != nil {
+		return nil, fmt.Errorf("royalty statements: %s", err.Error())
+	}
+	return royaltyStatements, nil
+}
+
+// ReadPeriodStatement reads the period statement returned by getPeriodStatement
+func ReadPeriodStatement(reader io.Reader) (*PeriodStatement, error) {
+	periodStatement := PeriodStatement{}
+	err := json.NewDecoder(reader).Decode(&periodStatement)
+	if err != nil {
+		return nil, fmt.Errorf("period statement: %s", err.Error())
+	}
+	if periodStatement.PeriodStatementUUID == "" {
+		return nil, fmt.Errorf("period statement: periodStatementUUID is required")
+	}
+	return &periodStatement, nil
+}
+
+// Statements exports royalty statements, sorted by royalty statement UUID
+func Statements(royaltyStatements []RoyaltyStatement, options Options) (*Export, error) {
+	return export(royaltyStatements, period{}, options)
+}
+
+// Period exports the royalty statements of a period statement, the statements the period statement does not
+// roll up are left out. A statement of the period that is missing, or totals that do not match the totals of
+// the period statement, are reported as discrepancies of the manifest.
+func Period(periodStatement PeriodStatement, royaltyStatements []RoyaltyStatement, options Options) (*Export, error) {
+	periodStatementUUIDs := map[string]bool{}
+	for _, royaltyStatementUUID := range periodStatement.RoyaltyStatementUUIDs {
+		periodStatementUUIDs[royaltyStatementUUID] = true
+	}
+	periodRoyaltyStatements := []RoyaltyStatement{}
+	found := map[string]bool{}
+	for _, royaltyStatement := range royaltyStatements {
+		if periodStatementUUIDs[royaltyStatement.RoyaltyStatementUUID] && !found[royaltyStatement.RoyaltyStatementUUID] {
+			found[royaltyStatement.RoyaltyStatementUUID] = true
+			periodRoyaltyStatements = append(periodRoyaltyStatements, royaltyStatement)
+		}
+	}
+
+	statementExport, err := export(periodRoyaltyStatements, period{statement: &periodStatement, start: periodStatement.PeriodStart, end: periodStatement.PeriodEnd}, options)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &statementExport.Manifest
+	for _, royaltyStatementUUID := range periodStatement.RoyaltyStatementUUIDs {
+		if !found[royaltyStatementUUID] {
+			manifest.Discrepancies = append(manifest.Discrepancies, fmt.Sprintf("royalty statement '%s' of the period is missing", royaltyStatementUUID))
+		}
+	}
+	manifest.Discrepancies = append(manifest.Discrepancies, reconcile(periodStatement, periodRoyaltyStatements)...)
+	return statementExport, nil
+}
+
+// SummaryRow - a total of a period statement in one currency: the total, the ownership and collection totals
+// and the totals by ISRC, territory, usage type and source
+type SummaryRow struct {
+	Currency  string
+	Dimension string
+	Key       string
+	Amount    float64
+}
+
+// PeriodSummary exports the totals of a period statement alone, for a payee that does not need the royalty
+// statements of the period
+func PeriodSummary(periodStatement PeriodStatement, options Options) (*Export, error) {
+	if options.Format == "" {
+		options.Format = CSV
+	}
+	decimals := options.Decimals
+	if options.Layout != nil && options.Format == CSV {
+		decimals = options.Layout.Decimals
+	}
+	if decimals <= 0 {
+		decimals = 2
+	}
+
+	rows := getSummaryRows(periodStatement, decimals)
+	manifest := Manifest{
+		Format:              options.Format,
+		PeriodStatementUUID: periodStatement.PeriodStatementUUID,
+		PeriodStart:         periodStatement.PeriodStart,
+		PeriodEnd:           periodStatement.PeriodEnd,
+		Payees:              []string{periodStatement.Ipi},
+		Lines:               len(rows),
+		Totals:              []CurrencyTotal{},
+	}
+	for _, row := range rows {
+		if row.Dimension == "TOTAL" {
+			manifest.Totals = append(manifest.Totals, CurrencyTotal{Currency: row.Currency, Amount: row.Amount})
+		}
+	}
+
+	var content []byte
+	var err error
+	switch options.Format {
+	case CSV:
+		delimiter := ""
+		if options.Layout != nil {
+			delimiter = options.Layout.Delimiter
+		}
+		content, err = writeSummaryCSV(rows, delimiter, decimals)
+	case CRD:
+		if options.MessageID == "" {
+			options.MessageID = "CRD-" + periodStatement.PeriodStatementUUID
+		}
+		if options.Created.IsZero() {
+			options.Created = time.Now().UTC()
+		}
+		manifest.MessageID = options.MessageID
+		content, err = writeSummaryCRD(rows, periodStatement, options, decimals)
+	default:
+		return nil, fmt.Errorf("unknown export format '%s', expected %s or %s", options.Format, CSV, CRD)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newExport(content, manifest), nil
+}
+
+//getSummaryRows - returns the rows of the totals of a period statement by currency, sorted by dimension and key
+func getSummaryRows(periodStatement PeriodStatement, decimals int) []SummaryRow {
+	currencies := []string{}
+	for currency := range periodStatement.Totals {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	rows := []SummaryRow{}
+	for _, currency := range currencies {
+		totals := periodStatement.Totals[currency]
+		rows = append(rows,
+			SummaryRow{Currency: currency, Dimension: "TOTAL", Amount: round(totals.Amount, decimals)},
+			SummaryRow{Currency: currency, Dimension: Ownership, Amount: round(totals.OwnershipAmount, decimals)},
+			SummaryRow{Currency: currency, Dimension: Collection, Amount: round(totals.CollectionAmount, decimals)})
+		for _, dimension := range []struct {
+			name    string
+			amounts map[string]float64
+		}{{"ISRC", totals.ByIsrc}, {"TERRITORY", totals.ByTerritory}, {"USAGETYPE", totals.ByUsageType}, {"SOURCE", totals.BySource}} {
+			keys := []string{}
+			for key := range dimension.amounts {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				rows = append(rows, SummaryRow{Currency: currency, Dimension: dimension.name, Key: key, Amount: round(dimension.amounts[key], decimals)})
+			}
+		}
+	}
+	return rows
+}
+
+//export - writes the lines of royalty statements in the format of the options and computes the manifest of the content
+func export(royaltyStatements []RoyaltyStatement, exportPeriod period, options Options) (*Export, error) {
+	if options.Format == "" {
+		options.Format = CSV
+	}
+	if options.Layout == nil {
+		options.Layout = &DefaultLayout
+	}
+	decimals := options.Decimals
+	if options.Format == CSV {
+		decimals = options.Layout.Decimals
+	}
+	if decimals <= 0 {
+		decimals = 2
+	}
+
+	lines := getLines(royaltyStatements, decimals)
+	totals := getTotals(lines, decimals)
+
+	manifest := Manifest{Format: options.Format, Payees: getPayees(lines), Lines: len(lines), Totals: totals}
+	if exportPeriod.statement != nil {
+		manifest.PeriodStatementUUID = exportPeriod.statement.PeriodStatementUUID
+		manifest.PeriodStart = exportPeriod.start
+		manifest.PeriodEnd = exportPeriod.end
+	}
+
+	var content []byte
+	var err error
+	switch options.Format {
+	case CSV:
+		manifest.Layout = options.Layout.Name
+		content, err = writeCSV(lines, totals, *options.Layout)
+	case CRD:
+		if options.MessageID == "" {
+			options.MessageID = getMessageID(lines)
+		}
+		if options.Created.IsZero() {
+			options.Created = time.Now().UTC()
+		}
+		manifest.MessageID = options.MessageID
+		content, err = writeCRD(lines, totals, exportPeriod, options, decimals)
+	default:
+		return nil, fmt.Errorf("unknown export format '%s', expected %s or %s", options.Format, CSV, CRD)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newExport(content, manifest), nil
+}
+
+//getLines - returns the lines of royalty statements sorted by royalty statement UUID
+func getLines(royaltyStatements []RoyaltyStatement, decimals int) []Line {
+	lines := []Line{}
+	for _, royaltyStatement := range royaltyStatements {
+		payer, payee, amount := royaltyStatement.Parties()
+		line := Line{
+			Statement:      royaltyStatement,
+			Payer:          payer,
+			Payee:          payee,
+			Amount:         round(amount, decimals),
+			TaxWithheld:    round(royaltyStatement.TaxWithheld, decimals),
+			RecoupedAmount: round(royaltyStatement.RecoupedAmount, decimals),
+		}
+		line.Payable = round(line.Amount-line.TaxWithheld-line.RecoupedAmount, decimals)
+		lines = append(lines, line)
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Statement.RoyaltyStatementUUID < lines[j].Statement.RoyaltyStatementUUID
+	})
+	return lines
+}
+
+//getTotals - returns the totals of the rounded lines by currency, so that the totals are the sums of the lines of the file
+func getTotals(lines []Line, decimals int) []CurrencyTotal {
+	totals := map[string]*CurrencyTotal{}
+	for _, line := range lines {
+		currency := line.Statement.Currency
+		if _, ok := totals[currency]; !ok {
+			totals[currency] = &CurrencyTotal{Currency: currency}
+		}
+		total := totals[currency]
+		total.Lines++
+		total.Units += line.Statement.Units
+		total.Amount = round(total.Amount+line.Amount, decimals)
+		total.TaxWithheld = round(total.TaxWithheld+line.TaxWithheld, decimals)
+		total.RecoupedAmount = round(total.RecoupedAmount+line.RecoupedAmount, decimals)
+		total.Payable = round(total.Payable+line.Payable, decimals)
+	}
+	currencyTotals := []CurrencyTotal{}
+	for _, total := range totals {
+		currencyTotals = append(currencyTotals, *total)
+	}
+	sort.Slice(currencyTotals, func(i, j int) bool { return currencyTotals[i].Currency < currencyTotals[j].Currency })
+	return currencyTotals
+}
+
+//getPayees - returns the sorted payees of lines
+func getPayees(lines []Line) []string {
+	payees := []string{}
+	seen := map[string]bool{}
+	for _, line := range lines {
+		if !seen[line.Payee] {
+			seen[line.Payee] = true
+			payees = append(payees, line.Payee)
+		}
+	}
+	sort.Strings(payees)
+	return payees
+}
+
+//reconcile - compares the unrounded amounts of the statements of a period to the totals of its period statement
+func reconcile(periodStatement PeriodStatement, royaltyStatements []RoyaltyStatement) []string {
+	amounts := map[string]float64{}
+	for _, royaltyStatement := range royaltyStatements {
+		currency := royaltyStatement.Currency
+		if currency == "" {
+			currency = UnspecifiedCurrency
+		}
+		_, _, amount := royaltyStatement.Parties()
+		amounts[currency] += amount
+	}
+	currencies := []string{}
+	for currency := range periodStatement.Totals {
+		currencies = append(currencies, currency)
+	}
+	for currency := range amounts {
+		if _, ok := periodStatement.Totals[currency]; !ok {
+			currencies = append(currencies, currency)
+		}
+	}
+	sort.Strings(currencies)
+
+	discrepancies := []string{}
+	for _, currency := range currencies {
+		if math.Abs(periodStatement.Totals[currency].Amount-amounts[currency]) >= 0.000001 {
+			discrepancies = append(discrepancies, fmt.Sprintf("period statement totals %v %s, its royalty statements %v", periodStatement.Totals[currency].Amount, currency, round(amounts[currency], 6)))
+		}
+	}
+	return discrepancies
+}
+
+//newExport - returns an export of content, its manifest holding the hash of the content
+func newExport(content []byte, manifest Manifest) *Export {
+	hash := sha256.Sum256(content)
+	manifest.Hash = "sha256:" + hex.EncodeToString(hash[:])
+	return &Export{Content: content, Manifest: manifest}
+}
+
+//getMessageID - returns an id of the message of lines that is the same for the same statements
+func getMessageID(lines []Line) string {
+	hash := sha256.New()
+	for _, line := range lines {
+		io.WriteString(hash, line.Statement.RoyaltyStatementUUID+"\n")
+	}
+	return "CRD-" + hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+//formatAmount - formats an amount with a fixed number of decimals
+func formatAmount(amount float64, decimals int) string {
+	return strconv.FormatFloat(amount, 'f', decimals, 64)
+}
+
+func round(value float64, decimals int) float64 {
+	output := math.Pow(10, float64(decimals))
+	return math.Round(value*output) / output
+}
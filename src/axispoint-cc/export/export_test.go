@@ -0,0 +1,179 @@
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readSample(t *testing.T) ([]RoyaltyStatement, *PeriodStatement) {
+	statementsFile, err := os.Open("testdata/statements.json")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer statementsFile.Close()
+	royaltyStatements, err := ReadStatements(statementsFile)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	periodFile, err := os.Open("testdata/period.json")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer periodFile.Close()
+	periodStatement, err := ReadPeriodStatement(periodFile)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	return royaltyStatements, periodStatement
+}
+
+func Test_Statements_CSV(t *testing.T) {
+	royaltyStatements, _ := readSample(t)
+
+	statementExport, err := Statements(royaltyStatements, Options{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	// the payable amount is less the tax withheld and the amount recouped, the collection statement is paid by the writer
+	expected := "Statement,Payer,Payee,ISRC,ISWC,Title,Source,Territory,Usage Type,Right Type,Exploitation Date,Units,Currency,Amount,Tax Withheld,Recouped,Payable\n" +
+		"rs0,spotify-IPI,PUB-IPI,QZAB11800001,,Second Song,spotify-IPI,US,STREAM,OWNERSHIP,2019-01-02,1,USD,1.00,0.00,0.00,1.00\n" +
+		"rs1,spotify-IPI,PUB-IPI,USRC17607839,T0345246801,Hello World,spotify-IPI,US,STREAM,OWNERSHIP,2018-12-30,100,USD,10.01,1.50,2.00,6.51\n" +
+		"rs2,deezer-IPI,PUB-IPI,QZAB11800001,,Second Song,deezer-IPI,FR,DOWNLOAD,OWNERSHIP,2018-12-01,7,EUR,3.33,0.00,0.00,3.33\n" +
+		"rs3,WRITER-IPI,PUB-IPI,USRC17607839,,Hello World,spotify-IPI,US,STREAM,COLLECTION,2018-12-30,100,USD,4.25,0.00,0.00,4.25\n" +
+		"TOTAL,,,,,,,,,,,7,EUR,3.33,0.00,0.00,3.33\n" +
+		"TOTAL,,,,,,,,,,,201,USD,15.26,1.50,2.00,11.76\n"
+	if string(statementExport.Content) != expected {
+		t.Fatalf("Actual content is not equal to expected content:\n%s", statementExport.Content)
+	}
+
+	hash := sha256.Sum256([]byte(expected))
+	expectedManifest := Manifest{
+		Format: CSV,
+		Layout: "default",
+		Payees: []string{"PUB-IPI"},
+		Lines:  4,
+		Totals: []CurrencyTotal{
+			{Currency: "EUR", Lines: 1, Units: 7, Amount: 3.33, Payable: 3.33},
+			{Currency: "USD", Lines: 3, Units: 201, Amount: 15.26, TaxWithheld: 1.5, RecoupedAmount: 2, Payable: 11.76},
+		},
+		Hash: "sha256:" + hex.EncodeToString(hash[:]),
+	}
+	if !reflect.DeepEqual(expectedManifest, statementExport.Manifest) {
+		t.Fatalf("Actual manifest is not equal to expected manifest: %+v", statementExport.Manifest)
+	}
+}
+
+func Test_Statements_Layout(t *testing.T) {
+	royaltyStatements, _ := readSample(t)
+
+	columns, err := ParseColumns("ISRC=isrc, payee ,Net=payable")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	layout, err := ReadLayout(strings.NewReader(`{"name":"semicolon","delimiter":";","decimals":3,"columns":[{"header":"ISRC","field":"isrc"},{"header":"payee","field":"payee"},{"header":"Net","field":"payable"}]}`))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !reflect.DeepEqual(columns, layout.Columns) {
+		t.Fatalf("Actual columns are not equal to expected columns: %+v", columns)
+	}
+	statementExport, err := Statements(royaltyStatements[:2], Options{Layout: layout})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := "ISRC;payee;Net\nUSRC17607839;PUB-IPI;6.506\nUSRC17607839;PUB-IPI;4.250\n"
+	if string(statementExport.Content) != expected || statementExport.Manifest.Layout != "semicolon" {
+		t.Fatalf("Actual content is not equal to expected content:\n%s", statementExport.Content)
+	}
+
+	_, err = ParseColumns("ISRC=isrc,Net=net")
+	if err == nil || err.Error() != "unknown field 'net' of column 'Net'" {
+		t.Fatalf("Expected an unknown field to be rejected: %v", err)
+	}
+	_, err = Statements(royaltyStatements, Options{Format: "xml"})
+	if err == nil {
+		t.Fatalf("Expected an unknown format to be rejected")
+	}
+}
+
+func Test_Period_CRD(t *testing.T) {
+	royaltyStatements, periodStatement := readSample(t)
+
+	statementExport, err := Period(*periodStatement, royaltyStatements, Options{Format: CRD, Sender: "AXISPOINT", Created: time.Date(2019, 1, 6, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	records := []string{}
+	for _, line := range strings.Split(strings.TrimSuffix(string(statementExport.Content), "\n"), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			records = append(records, line)
+		}
+	}
+	messageID := statementExport.Manifest.MessageID
+	expected := []string{
+		"HEAD\tCRD/1.0\tClaimDetail\t1.0\t" + messageID + "\t2019-01-06T00:00:00Z\t2018-12-01\t2018-12-31\tAXISPOINT\tPUB-IPI\tps1",
+		"SY01\tSY1\tEUR\t1\t7\t3.33\t0.00\t0.00\t3.33",
+		"SY01\tSY2\tUSD\t2\t200\t14.26\t1.50\t2.00\t10.76",
+		"CD01\trs1\tSY2\ter1\tUSRC17607839\tT0345246801\tHello World\tJohn Lennon\tspotify-IPI\tUS\tSTREAM\tOWNERSHIP\tPERFORMANCE\t2018-12-30\t100\tUSD\t10.01\t1.50\t2.00\t6.51\tspotify-IPI\tPUB-IPI",
+		"CD01\trs2\tSY1\ter3\tQZAB11800001\t\tSecond Song\tJane Doe\tdeezer-IPI\tFR\tDOWNLOAD\tOWNERSHIP\t\t2018-12-01\t7\tEUR\t3.33\t0.00\t0.00\t3.33\tdeezer-IPI\tPUB-IPI",
+		"CD01\trs3\tSY2\ter2\tUSRC17607839\t\tHello World\tJohn Lennon\tspotify-IPI\tUS\tSTREAM\tCOLLECTION\t\t2018-12-30\t100\tUSD\t4.25\t0.00\t0.00\t4.25\tWRITER-IPI\tPUB-IPI",
+		"FOOT\t12\t2\t3",
+	}
+	if !reflect.DeepEqual(expected, records) || !strings.HasPrefix(messageID, "CRD-") {
+		t.Fatalf("Actual records are not equal to expected records: %q", records)
+	}
+
+	// the statement outside of the period is left out, the missing statement is reported
+	expectedDiscrepancies := []string{
+		"royalty statement 'rs9' of the period is missing",
+		"period statement totals 15.256 USD, its royalty statements 14.256",
+	}
+	if !reflect.DeepEqual(expectedDiscrepancies, statementExport.Manifest.Discrepancies) || statementExport.Manifest.PeriodStatementUUID != "ps1" {
+		t.Fatalf("Actual manifest is not equal to expected manifest: %+v", statementExport.Manifest)
+	}
+
+	// the same statements in another order are the same message
+	reversed := []RoyaltyStatement{}
+	for i := len(royaltyStatements) - 1; i >= 0; i-- {
+		reversed = append(reversed, royaltyStatements[i])
+	}
+	reversedExport, err := Period(*periodStatement, reversed, Options{Format: CRD, Sender: "AXISPOINT", Created: time.Date(2019, 1, 6, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if reversedExport.Manifest.Hash != statementExport.Manifest.Hash {
+		t.Fatalf("Expected the hash not to depend on the order of the statements")
+	}
+}
+
+func Test_PeriodSummary(t *testing.T) {
+	_, periodStatement := readSample(t)
+
+	statementExport, err := PeriodSummary(*periodStatement, Options{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := "Currency,Dimension,Key,Amount\n" +
+		"EUR,TOTAL,,3.33\nEUR,OWNERSHIP,,3.33\nEUR,COLLECTION,,0.00\nEUR,ISRC,QZAB11800001,3.33\nEUR,TERRITORY,FR,3.33\nEUR,USAGETYPE,DOWNLOAD,3.33\nEUR,SOURCE,deezer-IPI,3.33\n" +
+		"USD,TOTAL,,15.26\nUSD,OWNERSHIP,,11.01\nUSD,COLLECTION,,4.25\nUSD,ISRC,QZAB11800001,1.00\nUSD,ISRC,USRC17607839,14.26\nUSD,TERRITORY,US,15.26\nUSD,USAGETYPE,STREAM,15.26\nUSD,SOURCE,spotify-IPI,15.26\n"
+	if string(statementExport.Content) != expected {
+		t.Fatalf("Actual content is not equal to expected content:\n%s", statementExport.Content)
+	}
+	expectedTotals := []CurrencyTotal{{Currency: "EUR", Amount: 3.33}, {Currency: "USD", Amount: 15.26}}
+	if !reflect.DeepEqual(expectedTotals, statementExport.Manifest.Totals) || statementExport.Manifest.Lines != 15 {
+		t.Fatalf("Actual manifest is not equal to expected manifest: %+v", statementExport.Manifest)
+	}
+
+	statementExport, err = PeriodSummary(*periodStatement, Options{Format: CRD, Created: time.Date(2019, 1, 6, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !strings.Contains(string(statementExport.Content), "SY02\tSY8\tUSD\tTOTAL\t\t15.26\n") || !strings.HasSuffix(string(statementExport.Content), "FOOT\t22\t15\t0\n") {
+		t.Fatalf("Actual content is not equal to expected content:\n%s", statementExport.Content)
+	}
+}
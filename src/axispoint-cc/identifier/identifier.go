@@ -0,0 +1,146 @@
+/*
+Package identifier normalizes and validates the industry identifiers used by the chaincode.
+
+ISRCs (ISO 3901) are normalized to their canonical 12 character form CCXXXYYNNNNN: a two letter country
+code, a three character registrant code, a two digit year of reference and a five digit designation code.
+
+IPI name numbers are normalized to their 11 digit form and validated against their two check digits.
+
+ISWCs (ISO 15707) are normalized to their 11 character form TNNNNNNNNNC and validated against their check digit.
+*/
+package identifier
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidIsrc is returned when a value cannot be normalized to a canonical ISRC
+var ErrInvalidIsrc = errors.New("invalid ISRC")
+
+// ErrInvalidIpi is returned when a value is not a valid IPI name number
+var ErrInvalidIpi = errors.New("invalid IPI name number")
+
+// ErrInvalidIswc is returned when a value is not a valid ISWC
+var ErrInvalidIswc = errors.New("invalid ISWC")
+
+// IsrcLength - length of a canonical ISRC
+const IsrcLength = 12
+
+// IpiLength - length of an IPI name number
+const IpiLength = 11
+
+// IswcLength - length of a canonical ISWC
+const IswcLength = 11
+
+var isrcPattern = regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{3}[0-9]{7}$`)
+var digitsPattern = regexp.MustCompile(`^[0-9]+$`)
+var iswcPattern = regexp.MustCompile(`^T[0-9]{10}$`)
+
+// labels that may precede an identifier; they must be followed by a colon or whitespace,
+// so that "ipi1" is not read as the label "IPI" and the number "1"
+var isrcLabelPattern = regexp.MustCompile(`(?i)^\s*ISRC(\s*:\s*|\s+)`)
+var ipiLabelPattern = regexp.MustCompile(`(?i)^\s*IPI(\s*:\s*|\s+)`)
+var iswcLabelPattern = regexp.MustCompile(`(?i)^\s*ISWC(\s*:\s*|\s+)`)
+
+// identifierSeparators - characters used to format identifiers that are not part of them
+var identifierSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "_", "", "\t", "", "\n", "", "\r", "")
+
+// clean - removes the optional label and the formatting of an identifier
+func clean(value string, labelPattern *regexp.Regexp) string {
+	cleaned := labelPattern.ReplaceAllString(value, "")
+	return strings.ToUpper(identifierSeparators.Replace(cleaned))
+}
+
+// NormalizeIsrc returns the canonical 12 character form of an ISRC. Case, whitespace, hyphens and
+// an "ISRC " or "ISRC:" label are ignored, e.g. "isrc us-rc1-76-07839" is normalized to "USRC17607839".
+func NormalizeIsrc(isrc string) (string, error) {
+	normalized := clean(isrc, isrcLabelPattern)
+	if len(normalized) != IsrcLength || !isrcPattern.MatchString(normalized) {
+		return "", fmt.Errorf("%s '%s'", ErrInvalidIsrc.Error(), isrc)
+	}
+	return normalized, nil
+}
+
+// IsValidIsrc returns whether the value normalizes to a canonical ISRC
+func IsValidIsrc(isrc string) bool {
+	_, err := NormalizeIsrc(isrc)
+	return err == nil
+}
+
+// IsIpiNameNumber returns whether the value is formatted as an IPI name number, i.e. only digits
+// once whitespace, separators and an "IPI" prefix are removed. It does not check the check digits.
+func IsIpiNameNumber(ipi string) bool {
+	normalized := clean(ipi, ipiLabelPattern)
+	return len(normalized) > 0 && len(normalized) <= IpiLength && digitsPattern.MatchString(normalized)
+}
+
+// NormalizeIpi returns the 11 digit form of an IPI name number, restoring the leading zeros that are
+// often dropped, and checks its check digits.
+func NormalizeIpi(ipi string) (string, error) {
+	if !IsIpiNameNumber(ipi) {
+		return "", fmt.Errorf("%s '%s'", ErrInvalidIpi.Error(), ipi)
+	}
+	normalized := clean(ipi, ipiLabelPattern)
+	normalized = strings.Repeat("0", IpiLength-len(normalized)) + normalized
+
+	checkDigits, err := IpiCheckDigits(normalized[:IpiLength-2])
+	if err != nil || checkDigits != normalized[IpiLength-2:] {
+		return "", fmt.Errorf("%s '%s': check digits do not match", ErrInvalidIpi.Error(), ipi)
+	}
+	return normalized, nil
+}
+
+// IsValidIpi returns whether the value is a valid IPI name number
+func IsValidIpi(ipi string) bool {
+	_, err := NormalizeIpi(ipi)
+	return err == nil
+}
+
+// IpiCheckDigits returns the two check digits of the 9 digit base of an IPI name number. The digits
+// of the base are weighted from 10 down to 2 and the check digits complement their sum modulo 101.
+func IpiCheckDigits(base string) (string, error) {
+	if len(base) != IpiLength-2 || !digitsPattern.MatchString(base) {
+		return "", fmt.Errorf("%s base '%s'", ErrInvalidIpi.Error(), base)
+	}
+	sum := 0
+	for i, digit := range base {
+		value, _ := strconv.Atoi(string(digit))
+		sum += value * (IpiLength - 1 - i)
+	}
+	check := (101 - sum%101) % 101
+	if check > 99 {
+		// bases whose check value does not fit in two digits are not assigned
+		return "", fmt.Errorf("%s base '%s'", ErrInvalidIpi.Error(), base)
+	}
+	return fmt.Sprintf("%02d", check), nil
+}
+
+// NormalizeIswc returns the canonical 11 character form of an ISWC and checks its check digit,
+// e.g. "T-034.524.680-1" is normalized to "T0345246801".
+func NormalizeIswc(iswc string) (string, error) {
+	normalized := clean(iswc, iswcLabelPattern)
+	if len(normalized) != IswcLength || !iswcPattern.MatchString(normalized) {
+		return "", fmt.Errorf("%s '%s'", ErrInvalidIswc.Error(), iswc)
+	}
+
+	// the digits are weighted by their position, the prefix T counts for 1
+	sum := 1
+	for i, digit := range normalized[1 : IswcLength-1] {
+		value, _ := strconv.Atoi(string(digit))
+		sum += value * (i + 1)
+	}
+	if strconv.Itoa((10-sum%10)%10) != normalized[IswcLength-1:] {
+		return "", fmt.Errorf("%s '%s': check digit does not match", ErrInvalidIswc.Error(), iswc)
+	}
+	return normalized, nil
+}
+
+// IsValidIswc returns whether the value is a valid ISWC
+func IsValidIswc(iswc string) bool {
+	_, err := NormalizeIswc(iswc)
+	return err == nil
+}
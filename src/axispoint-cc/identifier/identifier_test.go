@@ -0,0 +1,142 @@
+package identifier
+
+import (
+	"testing"
+)
+
+func Test_NormalizeIsrc(t *testing.T) {
+	for input, expected := range map[string]string{
+		"USRC17607839":          "USRC17607839",
+		"usrc17607839":          "USRC17607839",
+		"US-RC1-76-07839":       "USRC17607839",
+		" US RC1 76 07839 ":     "USRC17607839",
+		"ISRC US-RC1-76-07839":  "USRC17607839",
+		"isrc:gb-a1b-18-00001":  "GBA1B1800001",
+		"FR\tZ03\t98\t00212\n":  "FRZ039800212",
+		"DE-A12-05-12345":       "DEA120512345",
+		"QM-ZAB-20-00003":       "QMZAB2000003",
+		"gbayE0601498":          "GBAYE0601498",
+		"US.RC1.76.07839":       "USRC17607839",
+		"US_RC1_76_07839":       "USRC17607839",
+		"ISRC:USRC17607839":     "USRC17607839",
+		"  usrc1-76-07839  us ": "",
+	} {
+		actual, err := NormalizeIsrc(input)
+		if expected == "" {
+			if err == nil {
+				t.Fatalf("Expected '%s' to be an invalid ISRC, got '%s'", input, actual)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Expected '%s' to be a valid ISRC: %s", input, err.Error())
+		}
+		if actual != expected {
+			t.Fatalf("Expected '%s' to be normalized to '%s', got '%s'", input, expected, actual)
+		}
+	}
+}
+
+func Test_NormalizeIsrc_Invalid(t *testing.T) {
+	for _, input := range []string{"", "00029521", "123Src", "USRC1760783", "USRC176078390", "1SRC17607839", "USRC1760783X", "US$C17607839"} {
+		if IsValidIsrc(input) {
+			t.Fatalf("Expected '%s' to be an invalid ISRC", input)
+		}
+	}
+}
+
+func Test_NormalizeIpi(t *testing.T) {
+	for input, expected := range map[string]string{
+		"00014107338":     "00014107338",
+		"14107338":        "00014107338",
+		"IPI 00014107338": "00014107338",
+		"ipi:14107338":    "00014107338",
+		"000.141.073.38":  "00014107338",
+		"00-014-107-338":  "00014107338",
+	} {
+		actual, err := NormalizeIpi(input)
+		if err != nil {
+			t.Fatalf("Expected '%s' to be a valid IPI: %s", input, err.Error())
+		}
+		if actual != expected {
+			t.Fatalf("Expected '%s' to be normalized to '%s', got '%s'", input, expected, actual)
+		}
+	}
+}
+
+func Test_NormalizeIpi_Invalid(t *testing.T) {
+	for _, input := range []string{"", "00014107339", "00014107383", "000141073380", "ipi1", "Swedish-Publishing-IPI", "P8819H"} {
+		if IsValidIpi(input) {
+			t.Fatalf("Expected '%s' to be an invalid IPI", input)
+		}
+	}
+}
+
+func Test_IsIpiNameNumber(t *testing.T) {
+	for input, expected := range map[string]bool{
+		"00014107338":     true,
+		"00014107339":     true,
+		"IPI 14107338":    true,
+		"ipi1":            false,
+		"JayZ":            false,
+		"PA300001":        false,
+		"000141073380":    false,
+		"Ned-IPI":         false,
+		"spotify-IPI":     false,
+		"":                false,
+		"  ":              false,
+		"IPI":             false,
+		"ipi:":            false,
+		"IPI:":            false,
+		"ipi 1":           true,
+		"123-456-789-01":  true,
+		"0001410733X":     false,
+		"-":               false,
+		"IPI 000141-0733": true,
+	} {
+		if IsIpiNameNumber(input) != expected {
+			t.Fatalf("Expected IsIpiNameNumber('%s') to be %t", input, expected)
+		}
+	}
+}
+
+func Test_IpiCheckDigits(t *testing.T) {
+	checkDigits, err := IpiCheckDigits("000141073")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if checkDigits != "38" {
+		t.Fatalf("Expected check digits '38', got '%s'", checkDigits)
+	}
+	_, err = IpiCheckDigits("14107338")
+	if err == nil {
+		t.Fatalf("Expected an error for a base that is not 9 digits long")
+	}
+}
+
+func Test_NormalizeIswc(t *testing.T) {
+	for input, expected := range map[string]string{
+		"T0345246801":      "T0345246801",
+		"T-034.524.680-1":  "T0345246801",
+		"t-034524680-1":    "T0345246801",
+		"ISWC T0345246801": "T0345246801",
+		"T-034.524.680-2":  "",
+		"T034524680":       "",
+		"X0345246801":      "",
+		"":                 "",
+	} {
+		actual, err := NormalizeIswc(input)
+		if expected == "" {
+			if err == nil {
+				t.Fatalf("Expected '%s' to be an invalid ISWC, got '%s'", input, actual)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Expected '%s' to be a valid ISWC: %s", input, err.Error())
+		}
+		if actual != expected {
+			t.Fatalf("Expected '%s' to be normalized to '%s', got '%s'", input, expected, actual)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	pb "github.com/hyperledger/fabric/protos/peer"
@@ -87,17 +89,46 @@ func addUpdateIpiOrg(stub shim.ChaincodeStubInterface, ipiOrgObj string, updateF
 	}
 
 	ipiOrg.DocType = IPIORGMAP
+	ipiOrg.Ipi, err = normalizeIpi(ipiOrg.Ipi)
+	if err != nil {
+		return err
+	}
 	ipiOrgKey := ipiOrg.Ipi
 
+	prevIpiOrg, _ := stub.GetState(ipiOrgKey)
 	if !updateFlag {
 		//updateFlag==false; This is invoked by a POST request
 		//Checking the ledger to confirm that the mapping doesn't exist
-		prevIpiOrg, _ := stub.GetState(ipiOrgKey)
 		if prevIpiOrg != nil {
 			errorMessage := "IPI-Org mapping already exists with this key: " + ipiOrgKey
 			logger.Error(methodName, errorMessage)
 			return errors.New(errorMessage)
 		}
+	} else if prevIpiOrg != nil {
+		//an update corrects the mapping currently in effect, its history entry is replaced
+		prevIpiOrgMap := IpiOrgMap{}
+		err = jsonToObject(prevIpiOrg, &prevIpiOrgMap)
+		if err != nil {
+			return err
+		}
+		if ipiOrg.StartDate == "" {
+			ipiOrg.StartDate = prevIpiOrgMap.StartDate
+		}
+		if prevIpiOrgMap.StartDate != ipiOrg.StartDate {
+			err = deleteIpiOrgHistoryEntry(stub, prevIpiOrgMap)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = validateIpiOrgDates(ipiOrg)
+	if err != nil {
+		return err
+	}
+	err = putIpiOrgHistoryEntry(stub, ipiOrg)
+	if err != nil {
+		return err
 	}
 
 	byteVal, _ := objectToJSON(ipiOrg)
@@ -121,7 +152,29 @@ func addUpdateIpiOrg(stub shim.ChaincodeStubInterface, ipiOrgObj string, updateF
 func getIpiOrgByUUID(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	var methodName = "getIpiOrgByUUID"
 	logger.Info("ENTERING >", methodName, args)
-	return getAssetByUUID(stub, args)
+	if len(args) < 1 {
+		return getErrorResponse("Missing arguments: UUID is missing")
+	}
+	// the mapping is keyed by the normalized IPI
+	ipi, err := normalizeIpi(args[0])
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	// a transfer scheduled on the end date of the stored mapping replaces it once in effect
+	txDate, err := getTxDate(stub)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	ipiOrg, err := getIpiOrgInEffect(stub, ipi, txDate)
+	if err != nil || ipiOrg == nil {
+		return getAssetByUUID(stub, []string{ipi})
+	}
+	ipiOrgBytes, err := objectToJSON(ipiOrg)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	logger.Info("EXITING <", methodName, ipiOrg)
+	return shim.Success(ipiOrgBytes)
 
 }
 
@@ -179,6 +232,313 @@ func queryIpiOrgs(stub shim.ChaincodeStubInterface, queryString string) ([]IpiOr
 func deleteIpiOrgByUUID(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	var methodName = "deleteIpiOrgByUUID"
 	logger.Info("ENTERING >", methodName, args)
+
+	// the history of a deleted mapping is deleted with it
+	for _, ipi := range args {
+		history, err := getIpiOrgHistoryEntries(stub, ipi)
+		if err != nil {
+			return getErrorResponse(err.Error())
+		}
+		for _, ipiOrg := range history {
+			err = deleteIpiOrgHistoryEntry(stub, ipiOrg)
+			if err != nil {
+				return getErrorResponse(err.Error())
+			}
+		}
+	}
 	return deleteAssetByUUID(stub, args)
 
 }
+
+/*
+* transferIpi function moves an IPI to another org from an effective date. The mapping in effect is ended on
+* the effective date and kept in the history of the IPI, so that statements exploited before the transfer are
+* still routed to the previous org. A transfer effective after the transaction date is scheduled: the IPI stays
+* mapped to its current org until the effective date and a single transfer can be scheduled at a time.
+*
+* @params   {Array} args
+* @property {string} 0       - IPI
+* @property {string} 1       - new org
+* @property {string} 2       - effective date of the transfer
+* @return   {pb.Response}    - peer Response
+ */
+func transferIpi(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "transferIpi"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) != 3 {
+		return getErrorResponse(fmt.Sprintf("%s - Incorrect number of parameters provided '%d'. Needed IPI, org and effective date", methodName, len(args)))
+	}
+	ipi, err := normalizeIpi(args[0])
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	org, effectiveDate := args[1], args[2]
+
+	txDate, err := getTxDate(stub)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	ipiOrgInEffect, err := getIpiOrgInEffect(stub, ipi, txDate)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	if ipiOrgInEffect == nil {
+		return getErrorResponse(fmt.Sprintf("%s - IPI '%s' is not mapped to an org.", methodName, ipi))
+	}
+	currentIpiOrg := *ipiOrgInEffect
+	scheduledIpiOrg, err := getScheduledIpiOrg(stub, currentIpiOrg)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	if scheduledIpiOrg != nil {
+		return getErrorResponse(fmt.Sprintf("%s - IPI '%s' is already scheduled to transfer to org '%s' on '%s'.", methodName, ipi, scheduledIpiOrg.Org, scheduledIpiOrg.StartDate))
+	}
+	if currentIpiOrg.Org == org {
+		return getErrorResponse(fmt.Sprintf("%s - IPI '%s' is already mapped to org '%s'.", methodName, ipi, org))
+	}
+
+	transferDate, err := parseDate(effectiveDate, false)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	if currentIpiOrg.StartDate != "" {
+		currentStartDate, _ := parseDate(currentIpiOrg.StartDate, false)
+		if !transferDate.After(currentStartDate) {
+			return getErrorResponse(fmt.Sprintf("%s - Effective date '%s' must be after the start date '%s' of the current mapping.", methodName, effectiveDate, currentIpiOrg.StartDate))
+		}
+	}
+
+	// end the current mapping on the effective date, unless it already ended before
+	if currentIpiOrg.EndDate == "" {
+		currentIpiOrg.EndDate = effectiveDate
+	} else if currentEndDate, _ := parseDate(currentIpiOrg.EndDate, false); currentEndDate.After(transferDate) {
+		currentIpiOrg.EndDate = effectiveDate
+	}
+	err = putIpiOrgHistoryEntry(stub, currentIpiOrg)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	ipiOrg := IpiOrgMap{}
+	ipiOrg.DocType = IPIORGMAP
+	ipiOrg.Ipi = ipi
+	ipiOrg.Org = org
+	ipiOrg.StartDate = effectiveDate
+	err = putIpiOrgHistoryEntry(stub, ipiOrg)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	// a future transfer only ends the mapping under the IPI key, the new mapping takes effect from the history
+	message := `{"message": "IPI transferred successfully"}`
+	if transferDate.After(txDate) {
+		ipiOrg = currentIpiOrg
+		message = `{"message": "IPI transfer scheduled successfully"}`
+	}
+	ipiOrgBytes, _ := objectToJSON(ipiOrg)
+	err = stub.PutState(ipi, ipiOrgBytes)
+	if err != nil {
+		return getErrorResponse("Error committing data for key: " + ipi)
+	}
+
+	logger.Info("EXITING <", methodName, ipiOrg)
+	return shim.Success([]byte(message))
+}
+
+/*
+* getIpiOrgHistory function returns all the org mappings of an IPI sorted by start date
+*
+* @params   {Array} args
+* @property {string} 0       - IPI
+* @return   {pb.Response}    - peer Response
+ */
+func getIpiOrgHistory(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "getIpiOrgHistory"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) != 1 {
+		return getErrorResponse("Missing arguments: IPI is required")
+	}
+
+	ipi, err := normalizeIpi(args[0])
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	history, err := getIpiOrgHistoryEntries(stub, ipi)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	objBytes, err := objectToJSON(history)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	logger.Info("EXITING <", methodName, len(history))
+	return shim.Success(objBytes)
+}
+
+//getOrgForIpiOnDate - returns the org the IPI was mapped to on a date or an empty string when it was not mapped
+func getOrgForIpiOnDate(stub shim.ChaincodeStubInterface, ipi string, date string) (string, error) {
+	history, err := getIpiOrgHistoryEntries(stub, ipi)
+	if err != nil {
+		return "", err
+	}
+	onDate, err := parseDate(date, false)
+	if len(history) == 0 || err != nil {
+		// mappings created before effective dates were kept have no history
+		ipiOrg, err := getMappedIpiOrg(stub, ipi)
+		if err != nil || ipiOrg == nil {
+			return "", err
+		}
+		return ipiOrg.Org, nil
+	}
+	for _, ipiOrg := range history {
+		if ipiOrg.StartDate != "" {
+			startDate, _ := parseDate(ipiOrg.StartDate, false)
+			if onDate.Before(startDate) {
+				continue
+			}
+		}
+		if ipiOrg.EndDate != "" {
+			endDate, _ := parseDate(ipiOrg.EndDate, false)
+			if !onDate.Before(endDate) {
+				continue
+			}
+		}
+		return ipiOrg.Org, nil
+	}
+	return "", nil
+}
+
+//getMappedIpiOrg - returns the mapping stored under the IPI key or nil when the IPI is not mapped
+func getMappedIpiOrg(stub shim.ChaincodeStubInterface, ipi string) (*IpiOrgMap, error) {
+	ipiOrgBytes, err := stub.GetState(ipi)
+	if err != nil || ipiOrgBytes == nil {
+		return nil, err
+	}
+	ipiOrg := IpiOrgMap{}
+	err = jsonToObject(ipiOrgBytes, &ipiOrg)
+	if err != nil || ipiOrg.DocType != IPIORGMAP {
+		return nil, err
+	}
+	return &ipiOrg, nil
+}
+
+//getIpiOrgInEffect - returns the mapping of an IPI in effect on a date or nil when the IPI is not mapped. The
+//mapping under the IPI key stays in effect until a transfer scheduled on its end date replaces it.
+func getIpiOrgInEffect(stub shim.ChaincodeStubInterface, ipi string, date time.Time) (*IpiOrgMap, error) {
+	ipiOrg, err := getMappedIpiOrg(stub, ipi)
+	if err != nil || ipiOrg == nil || ipiOrg.EndDate == "" {
+		return ipiOrg, err
+	}
+	if endDate, err := parseDate(ipiOrg.EndDate, false); err != nil || date.Before(endDate) {
+		return ipiOrg, nil
+	}
+	scheduledIpiOrg, err := getScheduledIpiOrg(stub, *ipiOrg)
+	if err != nil || scheduledIpiOrg == nil {
+		return ipiOrg, err
+	}
+	scheduledIpiOrg.DocType = IPIORGMAP
+	return scheduledIpiOrg, nil
+}
+
+//getScheduledIpiOrg - returns the history entry of the transfer scheduled on the end date of a mapping or nil
+func getScheduledIpiOrg(stub shim.ChaincodeStubInterface, ipiOrg IpiOrgMap) (*IpiOrgMap, error) {
+	if ipiOrg.EndDate == "" {
+		return nil, nil
+	}
+	historyKey, err := stub.CreateCompositeKey(IPIORGHISTORY, []string{ipiOrg.Ipi, ipiOrg.EndDate})
+	if err != nil {
+		return nil, err
+	}
+	historyBytes, err := stub.GetState(historyKey)
+	if err != nil || historyBytes == nil {
+		return nil, err
+	}
+	scheduledIpiOrg := IpiOrgMap{}
+	err = jsonToObject(historyBytes, &scheduledIpiOrg)
+	if err != nil {
+		return nil, err
+	}
+	return &scheduledIpiOrg, nil
+}
+
+//getIpiOrgHistoryEntries - returns the history entries of an IPI sorted by start date
+func getIpiOrgHistoryEntries(stub shim.ChaincodeStubInterface, ipi string) ([]IpiOrgMap, error) {
+	historyIterator, err := stub.GetStateByPartialCompositeKey(IPIORGHISTORY, []string{ipi})
+	if err != nil {
+		return nil, err
+	}
+	defer historyIterator.Close()
+
+	history := []IpiOrgMap{}
+	for historyIterator.HasNext() {
+		historyEntry, err := historyIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		ipiOrg := IpiOrgMap{}
+		err = jsonToObject(historyEntry.Value, &ipiOrg)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, ipiOrg)
+	}
+
+	// mappings without a start date have been in effect since the beginning
+	sort.Slice(history, func(i, j int) bool {
+		if history[i].StartDate == "" || history[j].StartDate == "" {
+			return history[i].StartDate == "" && history[j].StartDate != ""
+		}
+		startDateI, _ := parseDate(history[i].StartDate, false)
+		startDateJ, _ := parseDate(history[j].StartDate, false)
+		return startDateI.Before(startDateJ)
+	})
+	return history, nil
+}
+
+//validateIpiOrgDates - checks the effective dates of a mapping
+func validateIpiOrgDates(ipiOrg IpiOrgMap) error {
+	var startDate, endDate time.Time
+	var err error
+	if ipiOrg.StartDate != "" {
+		startDate, err = parseDate(ipiOrg.StartDate, false)
+		if err != nil {
+			return err
+		}
+	}
+	if ipiOrg.EndDate != "" {
+		endDate, err = parseDate(ipiOrg.EndDate, false)
+		if err != nil {
+			return err
+		}
+		if ipiOrg.StartDate != "" && !endDate.After(startDate) {
+			return fmt.Errorf("IPI-Org mapping end date '%s' must be after start date '%s'", ipiOrg.EndDate, ipiOrg.StartDate)
+		}
+	}
+	return nil
+}
+
+//putIpiOrgHistoryEntry - records a mapping in the history of its IPI, keyed by its start date
+func putIpiOrgHistoryEntry(stub shim.ChaincodeStubInterface, ipiOrg IpiOrgMap) error {
+	historyKey, err := stub.CreateCompositeKey(IPIORGHISTORY, []string{ipiOrg.Ipi, ipiOrg.StartDate})
+	if err != nil {
+		return err
+	}
+	ipiOrg.DocType = IPIORGHISTORY
+	historyBytes, err := objectToJSON(ipiOrg)
+	if err != nil {
+		return err
+	}
+	return stub.PutState(historyKey, historyBytes)
+}
+
+//deleteIpiOrgHistoryEntry - removes a mapping from the history of its IPI
+func deleteIpiOrgHistoryEntry(stub shim.ChaincodeStubInterface, ipiOrg IpiOrgMap) error {
+	historyKey, err := stub.CreateCompositeKey(IPIORGHISTORY, []string{ipiOrg.Ipi, ipiOrg.StartDate})
+	if err != nil {
+		return err
+	}
+	return stub.DelState(historyKey)
+}
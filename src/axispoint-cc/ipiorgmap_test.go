@@ -2,9 +2,12 @@ package main
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
-	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"axispoint-cc/memstub"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
 )
 
 // *****************************************************************************
@@ -29,6 +32,14 @@ func MockIpiOrgResponse(functionName string) []byte {
 		return []byte(`[{"docType":"IPIORGMAP","ipi":"jay123","org":"org1"},{"docType":"IPIORGMAP","ipi":"pbull456","org":"org2"}]`)
 	case "Test_DeleteIpiOrgByUUID":
 		return []byte(`{"status":"200","message":"deleteAssetByUUID - deleted 1 records."}`)
+	case "Test_TransferIpi":
+		return []byte(`[{"docType":"IPIORGHISTORY","ipi":"JayZ","org":"org1","endDate":"2018-07-01"},{"docType":"IPIORGHISTORY","ipi":"JayZ","org":"org2","startDate":"2018-07-01"}]`)
+	case "Test_TransferIpi_SameOrg":
+		return []byte(`{"status":"500","message":"transferIpi - IPI 'JayZ' is already mapped to org 'org1'."}`)
+	case "Test_TransferIpi_Future":
+		return []byte(`{"message": "IPI transfer scheduled successfully"}`)
+	case "Test_TransferIpi_Future_Scheduled":
+		return []byte(`{"status":"500","message":"transferIpi - IPI 'JayZ' is already scheduled to transfer to org 'org2' on '2099-07-01'."}`)
 	case "Test_DeleteIpiOrgByUUID_QueryResult":
 		return []byte(`{"status":"500","message":"UUID: JayZ does not exist"}`)
 	default:
@@ -36,15 +47,11 @@ func MockIpiOrgResponse(functionName string) []byte {
 	}
 }
 
-func MockGetAllIpiOrgs(stub shim.ChaincodeStubInterface, queryString string) ([]string, error) {
-	return []string{`{"docType":"IPIORGMAP","ipi":"jay123","org":"org1"},{"docType":"IPIORGMAP","ipi":"pbull456","org":"org2"}`}, nil
-}
-
 // *****************************************************************************
 
 func Test_AddIpiOrg(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -62,7 +69,7 @@ func Test_AddIpiOrg(t *testing.T) {
 
 func Test_AddIpiOrg_MappingExists(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -91,7 +98,7 @@ func Test_AddIpiOrg_MappingExists(t *testing.T) {
 
 func Test_UpdateIpiOrg(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -109,7 +116,7 @@ func Test_UpdateIpiOrg(t *testing.T) {
 
 func Test_UpdateIpiOrg_MappingExists(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -138,7 +145,7 @@ func Test_UpdateIpiOrg_MappingExists(t *testing.T) {
 
 func Test_GetIpiOrgByUUID(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -166,11 +173,17 @@ func Test_GetIpiOrgByUUID(t *testing.T) {
 
 func Test_GetAllIpiOrgs(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
-	getIpiOrgForQueryString = MockGetAllIpiOrgs
+
+	for _, ipiOrg := range []string{`{"ipi":"pbull456","org":"org2"}`, `{"ipi":"jay123","org":"org1"}`} {
+		_, err := checkInvoke(t, stub, [][]byte{[]byte("addIpiOrg"), []byte(ipiOrg)})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
 
 	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getAllIpiOrgs"), []byte("")})
 	if err != nil {
@@ -186,7 +199,7 @@ func Test_GetAllIpiOrgs(t *testing.T) {
 
 func Test_DeleteIpiOrgByUUID(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -221,3 +234,142 @@ func Test_DeleteIpiOrgByUUID(t *testing.T) {
 		t.Fatalf("Actual response is not equal to expected response")
 	}
 }
+
+func Test_TransferIpi(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+
+	// Init
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addIpiOrg"), []byte(ipiOrg_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, err = checkInvoke(t, stub, [][]byte{[]byte("transferIpi"), []byte("JayZ"), []byte("org2"), []byte("2018-07-01")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	checkState(t, stub, "JayZ", `{"docType":"IPIORGMAP","ipi":"JayZ","org":"org2","startDate":"2018-07-01"}`)
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getIpiOrgHistory"), []byte("JayZ")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := MockIpiOrgResponse("Test_TransferIpi")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+
+	// statements are routed to the org in effect on the exploitation date
+	for date, expectedOrg := range map[string]string{"20180131": "org1", "2018-06-30": "org1", "2018-07-01": "org2", "20181231": "org2"} {
+		org, err := getOrgForIpiOnDate(stub, "JayZ", date)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if org != expectedOrg {
+			t.Fatalf("Expected org '%s' on '%s', got '%s'", expectedOrg, date, org)
+		}
+	}
+}
+
+func Test_TransferIpi_Future(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+
+	// Init
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addIpiOrg"), []byte(ipiOrg_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("transferIpi"), []byte("JayZ"), []byte("org2"), []byte("2099-07-01")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := MockIpiOrgResponse("Test_TransferIpi_Future")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+	// the IPI stays mapped to its current org until the effective date
+	checkState(t, stub, "JayZ", `{"docType":"IPIORGMAP","ipi":"JayZ","org":"org1","endDate":"2099-07-01"}`)
+
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("transferIpi"), []byte("JayZ"), []byte("org3"), []byte("2099-08-01")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected = MockIpiOrgResponse("Test_TransferIpi_Future_Scheduled")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+
+	for date, expectedOrg := range map[string]string{"2099-06-30": "org1", "2099-07-01": "org2"} {
+		txDate, _ := parseDate(date, false)
+		stub.TxTimestamp = &timestamp.Timestamp{Seconds: txDate.Unix()}
+		org, err := getOrgForIpi(stub, "JayZ")
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if org != expectedOrg {
+			t.Fatalf("Expected org '%s' on '%s', got '%s'", expectedOrg, date, org)
+		}
+	}
+	transferDate, _ := parseDate("2099-07-01", false)
+	stub.TxTimestamp = &timestamp.Timestamp{Seconds: transferDate.Unix()}
+	response := getIpiOrgByUUID(stub, []string{"JayZ"})
+	if string(response.Payload) != `{"docType":"IPIORGMAP","ipi":"JayZ","org":"org2","startDate":"2099-07-01"}` {
+		t.Fatalf("Expected the scheduled mapping once in effect, got '%s'", string(response.Payload))
+	}
+}
+
+func Test_TransferIpi_SameOrg(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+
+	// Init
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addIpiOrg"), []byte(ipiOrg_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("transferIpi"), []byte("JayZ"), []byte("org1"), []byte("2018-07-01")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := MockIpiOrgResponse("Test_TransferIpi_SameOrg")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_GetIpiOrg_Normalized(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+
+	// Init
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addIpiOrg"), []byte(`{"ipi":"IPI 141.073.38","org":"org1"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// the mapping and its history are found with any form of the IPI
+	respPayload, err := testQuery(t, stub, "getIpiOrgByUUID", "14107338")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := []byte(`{"docType":"IPIORGMAP","ipi":"00014107338","org":"org1"}`)
+	if !reflect.DeepEqual(expected, respPayload) {
+		t.Fatalf("Actual response is not equal to expected response: %s", respPayload)
+	}
+	respPayload, err = testQuery(t, stub, "getIpiOrgHistory", "ipi:14107338")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !strings.Contains(string(respPayload), `"ipi":"00014107338","org":"org1"`) {
+		t.Fatalf("Actual response is not equal to expected response: %s", respPayload)
+	}
+}
@@ -65,8 +65,41 @@ func (t *AxispointChaincode) initFunctionMaps() {
 	t.funcMap["getIpiOrgByUUID"] = getIpiOrgByUUID
 	t.funcMap["getAllIpiOrgs"] = getAllIpiOrgs
 	t.funcMap["deleteIpiOrgByUUID"] = deleteIpiOrgByUUID
+	t.funcMap["transferIpi"] = transferIpi
+	t.funcMap["getIpiOrgHistory"] = getIpiOrgHistory
 	t.funcMap["generateCollectionStatement"] = generateCollectionStatement
+	t.funcMap["resolveCollectionChain"] = resolveCollectionChain
+	t.funcMap["getCollectionGraph"] = getCollectionGraph
+	t.funcMap["addTerritoryGroups"] = addTerritoryGroups
+	t.funcMap["updateTerritoryGroups"] = updateTerritoryGroups
+	t.funcMap["getTerritory"] = getTerritory
+	t.funcMap["addUsageTypeCategories"] = addUsageTypeCategories
+	t.funcMap["updateUsageTypeCategories"] = updateUsageTypeCategories
+	t.funcMap["getUsageTypeCategories"] = getUsageTypeCategories
+	t.funcMap["addAdvances"] = addAdvances
+	t.funcMap["updateAdvances"] = updateAdvances
+	t.funcMap["getAdvances"] = getAdvances
+	t.funcMap["addTaxRules"] = addTaxRules
+	t.funcMap["updateTaxRules"] = updateTaxRules
+	t.funcMap["getTaxRules"] = getTaxRules
+	t.funcMap["addTaxResidencies"] = addTaxResidencies
+	t.funcMap["updateTaxResidencies"] = updateTaxResidencies
+	t.funcMap["getTaxResidency"] = getTaxResidency
 	t.funcMap["addRoyaltyStatementAndEvent"] = addRoyaltyStatementAndEvent
+	t.funcMap["payRoyaltyStatements"] = payRoyaltyStatements
+	t.funcMap["addDisputes"] = addDisputes
+	t.funcMap["getDisputes"] = getDisputes
+	t.funcMap["addDisputeComment"] = addDisputeComment
+	t.funcMap["resolveDispute"] = resolveDispute
+	t.funcMap["getBalances"] = getBalances
+	t.funcMap["compactBalances"] = compactBalances
+	t.funcMap["closeStatementPeriod"] = closeStatementPeriod
+	t.funcMap["getPeriodStatement"] = getPeriodStatement
+	t.funcMap["getEarningsReport"] = getEarningsReport
+	t.funcMap["addMusicalWorks"] = addMusicalWorks
+	t.funcMap["updateMusicalWorks"] = updateMusicalWorks
+	t.funcMap["getMusicalWork"] = getMusicalWork
+	t.funcMap["verifyRoyaltyStatementPrivateData"] = verifyRoyaltyStatementPrivateData
 
 }
 
@@ -100,10 +133,4 @@ func (t *AxispointChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Respons
 
 var isInit = false
 
-func main() {
-	err := shim.Start(new(AxispointChaincode))
-	if err != nil {
-		fmt.Printf("Error starting Axispoint chaincode: %s", err)
-	}
-}
 //EOF
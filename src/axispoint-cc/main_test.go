@@ -3,12 +3,16 @@ package main
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"testing"
 
+	"axispoint-cc/client"
+	"axispoint-cc/client/clienttest"
+	"axispoint-cc/memstub"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
 
-func checkInit(t *testing.T, stub *shim.MockStub, args [][]byte, retval []byte) {
+func checkInit(t *testing.T, stub *memstub.Stub, args [][]byte, retval []byte) {
 	res := stub.MockInit("1", args)
 	if res.Status != shim.OK {
 		fmt.Println("Init failed", string(res.Message))
@@ -26,7 +30,7 @@ func checkInit(t *testing.T, stub *shim.MockStub, args [][]byte, retval []byte)
 	}
 }
 
-func checkState(t *testing.T, stub *shim.MockStub, name string, value string) {
+func checkState(t *testing.T, stub *memstub.Stub, name string, value string) {
 	bytes := stub.State[name]
 	if bytes == nil {
 		fmt.Println("State", name, "failed to get value")
@@ -38,7 +42,7 @@ func checkState(t *testing.T, stub *shim.MockStub, name string, value string) {
 	}
 }
 
-func checkQuery(t *testing.T, stub *shim.MockStub, args [][]byte, retval []byte) {
+func checkQuery(t *testing.T, stub *memstub.Stub, args [][]byte, retval []byte) {
 	res := stub.MockInvoke("1", args)
 	if res.Status != shim.OK {
 		fmt.Println("Query", args[1], "failed", string(res.Message))
@@ -51,7 +55,25 @@ func checkQuery(t *testing.T, stub *shim.MockStub, args [][]byte, retval []byte)
 	fmt.Println("Query Result", " was: ", string(res.Payload))
 }
 
-func checkInvoke(t *testing.T, stub *shim.MockStub, args [][]byte) ([]byte, error) {
+// putDocuments - records documents on the ledger under the value of their UUID field, for the rich queries to find them
+func putDocuments(t *testing.T, stub *memstub.Stub, uuidField string, documents []string) {
+	stub.MockTransactionStart("documents")
+	defer stub.MockTransactionEnd("documents")
+	for _, document := range documents {
+		fields := map[string]interface{}{}
+		err := jsonToObject([]byte(document), &fields)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		uuid, _ := fields[uuidField].(string)
+		err = stub.PutState(uuid, []byte(document))
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+}
+
+func checkInvoke(t *testing.T, stub *memstub.Stub, args [][]byte) ([]byte, error) {
 	res := stub.MockInvoke("1", args)
 	if res.Status != shim.OK {
 		fmt.Println("Invoke", args, "failed", string(res.Message))
@@ -61,7 +83,22 @@ func checkInvoke(t *testing.T, stub *shim.MockStub, args [][]byte) ([]byte, erro
 	return res.Payload, nil
 }
 
-func testQuery(t *testing.T, stub *shim.MockStub, function string, id string) ([]byte, error) {
+// testSalt - random salt of the private details written by the tests
+var testSalt = []byte("5f0c1e9a7b3d4c2e8a6f0b1d3c5e7a9b")
+
+// checkInvokeTransient - invokes the chaincode with the private inputs of the transaction in the transient map
+func checkInvokeTransient(t *testing.T, stub *memstub.Stub, args [][]byte, transient map[string][]byte) ([]byte, error) {
+	stub.Transient = transient
+	defer func() { stub.Transient = nil }()
+	return checkInvoke(t, stub, args)
+}
+
+// getRoyaltyStatementsTransient - returns the transient map passing royalty statements with a random salt
+func getRoyaltyStatementsTransient(royaltyStatements string) map[string][]byte {
+	return map[string][]byte{TRANSIENT_ROYALTY_STATEMENTS: []byte(royaltyStatements), TRANSIENT_SALT: testSalt}
+}
+
+func testQuery(t *testing.T, stub *memstub.Stub, function string, id string) ([]byte, error) {
 	res := stub.MockInvoke("1", [][]byte{[]byte(function), []byte(id)})
 	if res.Status != shim.OK {
 		fmt.Println("Query", id, "failed", string(res.Message))
@@ -77,7 +114,7 @@ func testQuery(t *testing.T, stub *shim.MockStub, function string, id string) ([
 
 func Test_Init(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("OK")}, nil)
@@ -85,7 +122,7 @@ func Test_Init(t *testing.T) {
 
 func Test_ResetLedger(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -97,3 +134,45 @@ func Test_ResetLedger(t *testing.T) {
 
 	//verify # of records in the ledger are 0.
 }
+
+func Test_Client(t *testing.T) {
+	transport, err := clienttest.NewMockStubTransport("AxispointChaincode", new(AxispointChaincode))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	axispoint := client.New(transport)
+
+	message, err := axispoint.Ping()
+	if err != nil || message != "Ping OK" {
+		t.Fatalf("Actual ping is not equal to expected ping: %s %v", message, err)
+	}
+
+	_, err = axispoint.AddIpiOrg(client.IpiOrgMap{Ipi: "14107338", Org: "Org1", StartDate: "2018-01-01"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	ipiOrg, err := axispoint.GetIpiOrgByUUID("00014107338")
+	if err != nil || ipiOrg.Org != "Org1" || ipiOrg.DocType != IPIORGMAP {
+		t.Fatalf("Actual IPI-Org mapping is not equal to expected IPI-Org mapping: %+v %v", ipiOrg, err)
+	}
+	ipiOrgs, err := axispoint.GetAllIpiOrgs()
+	if err != nil || len(ipiOrgs) != 1 || ipiOrgs[0].Ipi != "00014107338" {
+		t.Fatalf("Actual IPI-Org mappings are not equal to expected IPI-Org mappings: %+v %v", ipiOrgs, err)
+	}
+
+	territoryOutput, err := axispoint.AddTerritoryGroups([]client.TerritoryGroup{{Code: "DACH", Name: "Germany, Austria, Switzerland", Includes: []string{"DE", "AT", "CH"}}})
+	if err != nil || territoryOutput.SuccessCount != 1 {
+		t.Fatalf("Actual territory group output is not equal to expected output: %+v %v", territoryOutput, err)
+	}
+	territoryGroup, err := axispoint.GetTerritory("dach")
+	if err != nil || !reflect.DeepEqual([]string{"AT", "CH", "DE"}, territoryGroup.Countries) {
+		t.Fatalf("Actual territory group is not equal to expected territory group: %+v %v", territoryGroup, err)
+	}
+
+	// the error envelope of the chaincode is an error of the client
+	_, err = axispoint.GetTaxResidency("00014107338")
+	clientError, ok := err.(*client.Error)
+	if !ok || clientError.Function != "getTaxResidency" || clientError.Status != 500 {
+		t.Fatalf("Expected a missing tax residency to be an error of the client: %v", err)
+	}
+}
@@ -0,0 +1,257 @@
+package memstub
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+* The selectors are evaluated the way CouchDB evaluates them. A field condition that is not an object is an
+* implicit $eq, an object condition holds selectors of the subfields and operators on the field, e.g.
+* {"rightHolders": {"$elemMatch": {"ipi": "00014107338"}}}. A condition on a missing field does not hold.
+* Values of different types are compared in the collation order of CouchDB: null, booleans, numbers, strings,
+* arrays then objects, so {"amount": {"$lte": 100}} does not hold for a string amount.
+ */
+
+// Match returns whether a JSON document, as decoded by encoding/json, matches a Mango selector
+func Match(selector map[string]interface{}, document interface{}) (bool, error) {
+	return matchSelector(selector, document, true)
+}
+
+//matchSelector - returns whether a value matches all the conditions of a selector, found is false for a missing field
+func matchSelector(selector map[string]interface{}, value interface{}, found bool) (bool, error) {
+	for _, key := range getSortedKeys(selector) {
+		var matches bool
+		var err error
+		switch {
+		case key == "$and" || key == "$or":
+			matches, err = matchCombination(key, selector[key], value, found)
+		case strings.HasPrefix(key, "$"):
+			matches, err = matchOperator(key, selector[key], value, found)
+		default:
+			fieldValue, fieldFound := getField(value, found, key)
+			matches, err = matchCondition(selector[key], fieldValue, fieldFound)
+		}
+		if err != nil || !matches {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+//matchCombination - returns whether a value matches all the selectors of an $and or any selector of an $or
+func matchCombination(operator string, argument interface{}, value interface{}, found bool) (bool, error) {
+	selectors, ok := argument.([]interface{})
+	if !ok || len(selectors) == 0 {
+		return false, fmt.Errorf("%s requires a non-empty array of selectors", operator)
+	}
+	for _, selector := range selectors {
+		selectorMap, ok := selector.(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf("%s requires a non-empty array of selectors", operator)
+		}
+		matches, err := matchSelector(selectorMap, value, found)
+		if err != nil {
+			return false, err
+		}
+		if matches == (operator == "$or") {
+			return matches, nil
+		}
+	}
+	return operator == "$and", nil
+}
+
+//matchCondition - returns whether a field matches a condition, an object of subfield selectors and operators or an implicit $eq
+func matchCondition(condition interface{}, value interface{}, found bool) (bool, error) {
+	if selector, ok := condition.(map[string]interface{}); ok {
+		return matchSelector(selector, value, found)
+	}
+	return matchOperator("$eq", condition, value, found)
+}
+
+//matchOperator - returns whether a field matches the argument of a condition operator
+func matchOperator(operator string, argument interface{}, value interface{}, found bool) (bool, error) {
+	switch operator {
+	case "$eq", "$gt", "$gte", "$lt", "$lte":
+		if !found {
+			return false, nil
+		}
+		order := collate(value, argument)
+		switch operator {
+		case "$eq":
+			return order == 0, nil
+		case "$gt":
+			return order > 0, nil
+		case "$gte":
+			return order >= 0, nil
+		case "$lt":
+			return order < 0, nil
+		}
+		return order <= 0, nil
+	case "$in":
+		arguments, ok := argument.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("$in requires an array")
+		}
+		if !found {
+			return false, nil
+		}
+		// an array field is in the list when one of its elements is
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{value}
+		}
+		for _, value := range values {
+			for _, argument := range arguments {
+				if collate(value, argument) == 0 {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	case "$regex":
+		pattern, ok := argument.(string)
+		if !ok {
+			return false, fmt.Errorf("$regex requires a string")
+		}
+		text, ok := value.(string)
+		if !found || !ok {
+			return false, nil
+		}
+		return regexp.MatchString(pattern, text)
+	case "$elemMatch":
+		selector, ok := argument.(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf("$elemMatch requires a selector")
+		}
+		elements, ok := value.([]interface{})
+		if !found || !ok {
+			return false, nil
+		}
+		for _, element := range elements {
+			matches, err := matchSelector(selector, element, true)
+			if err != nil || matches {
+				return matches, err
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("unsupported operator %s", operator)
+}
+
+//getField - returns the value of a field of a document by its dotted path, e.g. rightHolders.0.ipi, and whether it exists
+func getField(document interface{}, found bool, path string) (interface{}, bool) {
+	value := document
+	for _, name := range strings.Split(path, ".") {
+		if !found {
+			return nil, false
+		}
+		switch object := value.(type) {
+		case map[string]interface{}:
+			value, found = object[name]
+		case []interface{}:
+			index, err := strconv.Atoi(name)
+			found = err == nil && index >= 0 && index < len(object)
+			if found {
+				value = object[index]
+			}
+		default:
+			found = false
+		}
+	}
+	return value, found
+}
+
+//getCollationRank - returns the rank of the type of a value in the collation order of CouchDB
+func getCollationRank(value interface{}) int {
+	switch value.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case float64:
+		return 2
+	case string:
+		return 3
+	case []interface{}:
+		return 4
+	}
+	return 5
+}
+
+//collate - compares two JSON values in the collation order of CouchDB, returns -1, 0 or 1
+func collate(a interface{}, b interface{}) int {
+	rankA, rankB := getCollationRank(a), getCollationRank(b)
+	if rankA != rankB {
+		return compareInts(rankA, rankB)
+	}
+	switch a := a.(type) {
+	case bool:
+		b := b.(bool)
+		if a == b {
+			return 0
+		}
+		if !a {
+			return -1
+		}
+		return 1
+	case float64:
+		b := b.(float64)
+		if a < b {
+			return -1
+		}
+		if a > b {
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	case []interface{}:
+		b := b.([]interface{})
+		for i := 0; i < len(a) && i < len(b); i++ {
+			order := collate(a[i], b[i])
+			if order != 0 {
+				return order
+			}
+		}
+		return compareInts(len(a), len(b))
+	case map[string]interface{}:
+		b := b.(map[string]interface{})
+		keysA, keysB := getSortedKeys(a), getSortedKeys(b)
+		for i := 0; i < len(keysA) && i < len(keysB); i++ {
+			order := strings.Compare(keysA[i], keysB[i])
+			if order == 0 {
+				order = collate(a[keysA[i]], b[keysB[i]])
+			}
+			if order != 0 {
+				return order
+			}
+		}
+		return compareInts(len(keysA), len(keysB))
+	}
+	return 0
+}
+
+//compareInts - compares two ints, returns -1, 0 or 1
+func compareInts(a int, b int) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+//getSortedKeys - returns the keys of an object sorted
+func getSortedKeys(object map[string]interface{}) []string {
+	keys := []string{}
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
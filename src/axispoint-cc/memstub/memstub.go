@@ -0,0 +1,216 @@
+/*
+Package memstub runs a chaincode in process on an in-memory world state that answers rich queries.
+
+The MockStub of the shim keeps the world state in memory but has no state database behind it, GetQueryResult
+is not implemented. Stub is a MockStub that evaluates the CouchDB Mango selectors of rich queries over the JSON
+documents of its state, so that the code paths of a chaincode querying CouchDB run without a peer:
+
+	stub := memstub.NewStub("axispoint-cc", new(AxispointChaincode))
+	response := stub.MockInvoke("tx1", [][]byte{[]byte("getRoyaltyStatements"), []byte(`{"selector":{"isrc":"USRC17607839"}}`)})
+
+The values of the state that are not JSON objects are not documents and never match, like the binary values
+CouchDB stores as attachments. The results of a query are read when it is run, a chaincode may update the
+state while it iterates over them. The sort, limit, skip and bookmark of a query page its results; the
+bookmark of the next page is returned by the Bookmark method of the iterator, as CouchDB returns it with a
+page:
+
+	iterator, _ := stub.GetQueryResult(`{"selector":{"docType":"ROYALTYSTATEMENT"},"sort":["isrc"],"limit":10}`)
+	bookmark := iterator.(*memstub.QueryIterator).Bookmark()
+
+The transient map of the transactions is set on the stub, and the private data of its collections is read, deleted
+and scanned by partial composite key like the public state:
+
+	stub.Transient = map[string][]byte{"salt": salt}
+
+A MockStub shows a transaction its own writes, a peer does not. ReadCommitted makes GetState read the state
+committed before the transaction, to run the code paths of a batch writing and reading the same keys as a peer
+would.
+*/
+package memstub
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Stub - a MockStub answering rich queries from the JSON documents of its state
+type Stub struct {
+	*shim.MockStub
+	chaincode shim.Chaincode
+	args      [][]byte
+	// Transient - the transient map of the transactions invoked on the stub
+	Transient map[string][]byte
+	// ReadCommitted - GetState reads the state committed before the transaction instead of its own writes
+	ReadCommitted bool
+	committed     map[string][]byte
+}
+
+// NewStub returns the stub of a chaincode with an empty world state
+func NewStub(name string, chaincode shim.Chaincode) *Stub {
+	return &Stub{MockStub: shim.NewMockStub(name, chaincode), chaincode: chaincode}
+}
+
+// MockInit initializes the chaincode in a transaction
+func (stub *Stub) MockInit(uuid string, args [][]byte) pb.Response {
+	stub.args = args
+	stub.MockTransactionStart(uuid)
+	response := stub.chaincode.Init(stub)
+	stub.MockTransactionEnd(uuid)
+	return response
+}
+
+// MockInvoke invokes the chaincode in a transaction, the first argument is the function
+func (stub *Stub) MockInvoke(uuid string, args [][]byte) pb.Response {
+	stub.args = args
+	stub.MockTransactionStart(uuid)
+	if stub.ReadCommitted {
+		stub.committed = map[string][]byte{}
+		for key, value := range stub.State {
+			stub.committed[key] = value
+		}
+	}
+	response := stub.chaincode.Invoke(stub)
+	stub.committed = nil
+	stub.MockTransactionEnd(uuid)
+	return response
+}
+
+// GetState returns the value of a key, as committed before the transaction when ReadCommitted is set
+func (stub *Stub) GetState(key string) ([]byte, error) {
+	if stub.committed != nil {
+		return stub.committed[key], nil
+	}
+	return stub.MockStub.GetState(key)
+}
+
+// GetArgs returns the arguments of the transaction
+func (stub *Stub) GetArgs() [][]byte {
+	return stub.args
+}
+
+// GetStringArgs returns the arguments of the transaction as strings
+func (stub *Stub) GetStringArgs() []string {
+	args := make([]string, 0, len(stub.args))
+	for _, arg := range stub.args {
+		args = append(args, string(arg))
+	}
+	return args
+}
+
+// GetFunctionAndParameters returns the function of the transaction and its parameters
+func (stub *Stub) GetFunctionAndParameters() (string, []string) {
+	args := stub.GetStringArgs()
+	if len(args) == 0 {
+		return "", []string{}
+	}
+	return args[0], args[1:]
+}
+
+// GetArgsSlice returns the arguments of the transaction concatenated
+func (stub *Stub) GetArgsSlice() ([]byte, error) {
+	argsSlice := []byte{}
+	for _, arg := range stub.args {
+		argsSlice = append(argsSlice, arg...)
+	}
+	return argsSlice, nil
+}
+
+// GetTransient returns the transient map of the transaction
+func (stub *Stub) GetTransient() (map[string][]byte, error) {
+	return stub.Transient, nil
+}
+
+// DelPrivateData deletes a key of a private data collection
+func (stub *Stub) DelPrivateData(collection string, key string) error {
+	delete(stub.PvtState[collection], key)
+	return nil
+}
+
+// GetPrivateDataByPartialCompositeKey returns the keys of a private data collection with a composite key prefix,
+// sorted like the keys of the public state
+func (stub *Stub) GetPrivateDataByPartialCompositeKey(collection string, objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+	prefix, err := stub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{}
+	for key := range stub.PvtState[collection] {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	iterator := &QueryIterator{}
+	for _, key := range keys {
+		iterator.results = append(iterator.results, &queryresult.KV{Namespace: stub.Name, Key: key, Value: stub.PvtState[collection][key]})
+	}
+	return iterator, nil
+}
+
+// GetQueryResult returns the page of the documents of the state matching a rich query
+func (stub *Stub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	richQuery, err := parseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid query '%s': %s", query, err.Error())
+	}
+
+	documents := []*queryDocument{}
+	for element := stub.Keys.Front(); element != nil; element = element.Next() {
+		key := element.Value.(string)
+		document, matches, err := richQuery.getDocument(key, stub.State[key])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid query '%s': %s", query, err.Error())
+		}
+		if matches {
+			documents = append(documents, document)
+		}
+	}
+
+	iterator := &QueryIterator{}
+	documents, iterator.bookmark = richQuery.getPage(documents)
+	for _, document := range documents {
+		iterator.results = append(iterator.results, &queryresult.KV{Namespace: stub.Name, Key: document.key, Value: document.value})
+	}
+	return iterator, nil
+}
+
+// QueryIterator - iterates over the results of a rich query
+type QueryIterator struct {
+	results  []*queryresult.KV
+	bookmark string
+	closed   bool
+}
+
+// Bookmark returns the bookmark of the query for the page after its results
+func (iterator *QueryIterator) Bookmark() string {
+	return iterator.bookmark
+}
+
+// HasNext returns whether the query has more results
+func (iterator *QueryIterator) HasNext() bool {
+	return !iterator.closed && len(iterator.results) > 0
+}
+
+// Next returns the next result of the query
+func (iterator *QueryIterator) Next() (*queryresult.KV, error) {
+	if !iterator.HasNext() {
+		return nil, errors.New("No more results")
+	}
+	result := iterator.results[0]
+	iterator.results = iterator.results[1:]
+	return result, nil
+}
+
+// Close releases the results of the query
+func (iterator *QueryIterator) Close() error {
+	iterator.closed = true
+	iterator.results = nil
+	return nil
+}
@@ -0,0 +1,324 @@
+package memstub
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+const royaltyStatement = `{
+	"docType": "ROYALTYSTATEMENT",
+	"royaltyStatementUUID": "rs1",
+	"isrc": "USRC17607839",
+	"exploitationDate": "2018-03-15",
+	"amount": 12.5,
+	"paid": false,
+	"lineage": ["rs0"],
+	"rightHolders": [{"ipi": "00014107338", "role": "CA"}, {"ipi": "00052210040", "role": "E"}],
+	"territory": {"code": "DE", "group": "EU"}
+}`
+
+func Test_Match(t *testing.T) {
+	document := map[string]interface{}{}
+	err := json.Unmarshal([]byte(royaltyStatement), &document)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	for selector, expected := range map[string]bool{
+		`{}`: true,
+		`{"docType": "ROYALTYSTATEMENT", "isrc": "USRC17607839"}`:                     true,
+		`{"docType": "ROYALTYSTATEMENT", "isrc": "GBA1B1800001"}`:                     false,
+		`{"isrc": {"$eq": "USRC17607839"}}`:                                           true,
+		`{"amount": 12.5, "paid": false}`:                                             true,
+		`{"amount": "12.5"}`:                                                          false,
+		`{"missing": null}`:                                                           false,
+		`{"royaltyStatementUUID": {"$in": ["rs1", "rs2"]}}`:                           true,
+		`{"royaltyStatementUUID": {"$in": ["rs2", "rs3"]}}`:                           false,
+		`{"lineage": {"$in": ["rs0"]}}`:                                               true,
+		`{"lineage": ["rs0"]}`:                                                        true,
+		`{"lineage": "rs0"}`:                                                          false,
+		`{"exploitationDate": {"$gte": "2018-01-01", "$lte": "2018-03-31"}}`:          true,
+		`{"exploitationDate": {"$gte": "2018-04-01"}}`:                                false,
+		`{"exploitationDate": {"$gt": "2018-03-15"}}`:                                 false,
+		`{"exploitationDate": {"$lt": "2018-03-16"}}`:                                 true,
+		`{"amount": {"$gt": 10, "$lt": 20}}`:                                          true,
+		`{"amount": {"$lte": "0"}}`:                                                   true,
+		`{"isrc": {"$lte": 100}}`:                                                     false,
+		`{"missing": {"$lte": "2018-03-31"}}`:                                         false,
+		`{"territory": {"code": "DE"}}`:                                               true,
+		`{"territory.group": "EU"}`:                                                   true,
+		`{"rightHolders.1.ipi": "00052210040"}`:                                       true,
+		`{"rightHolders.2.ipi": "00052210040"}`:                                       false,
+		`{"rightHolders": {"$elemMatch": {"ipi": "00052210040", "role": "E"}}}`:       true,
+		`{"rightHolders": {"$elemMatch": {"ipi": "00052210040", "role": "CA"}}}`:      false,
+		`{"lineage": {"$elemMatch": {"$eq": "rs0"}}}`:                                 true,
+		`{"isrc": {"$elemMatch": {"$eq": "USRC17607839"}}}`:                           false,
+		`{"isrc": {"$regex": "^USRC"}}`:                                               true,
+		`{"isrc": {"$regex": "^GB"}}`:                                                 false,
+		`{"amount": {"$regex": "12"}}`:                                                false,
+		`{"$or": [{"isrc": "GBA1B1800001"}, {"amount": {"$gte": 12.5}}]}`:             true,
+		`{"$or": [{"isrc": "GBA1B1800001"}, {"amount": {"$gt": 12.5}}]}`:              false,
+		`{"$and": [{"isrc": "USRC17607839"}, {"paid": false}]}`:                       true,
+		`{"$and": [{"isrc": "USRC17607839"}, {"paid": true}]}`:                        false,
+		`{"docType": "ROYALTYSTATEMENT", "$or": [{"paid": true}, {"lineage": null}]}`: false,
+	} {
+		selectorMap := map[string]interface{}{}
+		err := json.Unmarshal([]byte(selector), &selectorMap)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		actual, err := Match(selectorMap, document)
+		if err != nil {
+			t.Fatalf("Expected selector %s to be valid: %s", selector, err.Error())
+		}
+		if actual != expected {
+			t.Fatalf("Expected selector %s to match %t, got %t", selector, expected, actual)
+		}
+	}
+
+	for selector, expected := range map[string]string{
+		`{"isrc": {"$like": "USRC%"}}`:                    "unsupported operator $like",
+		`{"isrc": {"$in": "USRC"}}`:                       "$in requires an array",
+		`{"$or": []}`:                                     "$or requires a non-empty array of selectors",
+		`{"$and": [1]}`:                                   "$and requires a non-empty array of selectors",
+		`{"rightHolders": {"$elemMatch": "00014107338"}}`: "$elemMatch requires a selector",
+	} {
+		selectorMap := map[string]interface{}{}
+		err := json.Unmarshal([]byte(selector), &selectorMap)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		_, err = Match(selectorMap, document)
+		if err == nil || err.Error() != expected {
+			t.Fatalf("Expected selector %s to be rejected with '%s': %v", selector, expected, err)
+		}
+	}
+}
+
+// queryChaincode - answers the keys of the results of a rich query, deleting them for the delete function
+type queryChaincode struct{}
+
+func (chaincode queryChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (chaincode queryChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	function, args := stub.GetFunctionAndParameters()
+	iterator, err := stub.GetQueryResult(args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	defer iterator.Close()
+	keys := []string{}
+	for iterator.HasNext() {
+		result, err := iterator.Next()
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if function == "delete" {
+			stub.DelState(result.Key)
+		}
+		keys = append(keys, result.Key)
+	}
+	return shim.Success([]byte(strings.Join(keys, ",")))
+}
+
+func Test_GetQueryResult(t *testing.T) {
+	stub := NewStub("query", queryChaincode{})
+	stub.MockTransactionStart("tx0")
+	stub.PutState("rs2", []byte(`{"docType":"ROYALTYSTATEMENT","isrc":"USRC17607839"}`))
+	stub.PutState("rs1", []byte(royaltyStatement))
+	stub.PutState("er1", []byte(`{"docType":"EXPLOITATIONREPORT","isrc":"USRC17607839"}`))
+	stub.PutState("raw", []byte(`USRC17607839`))
+	stub.MockTransactionEnd("tx0")
+
+	for query, expected := range map[string]string{
+		`{"selector":{"isrc":"USRC17607839"}}`:                            "er1,rs1,rs2",
+		`{"selector":{"docType":"ROYALTYSTATEMENT"}}`:                     "rs1,rs2",
+		`{"selector":{"_id":{"$in":["rs2","raw"]}}}`:                      "rs2",
+		`{"selector":{"docType":"ROYALTYSTATEMENT","amount":{"$gt":10}}}`: "rs1",
+		`{"selector":{"docType":"DISPUTE"}}`:                              "",
+	} {
+		response := stub.MockInvoke("tx1", [][]byte{[]byte("query"), []byte(query)})
+		if response.Status != shim.OK || string(response.Payload) != expected {
+			t.Fatalf("Expected query %s to return '%s', got %d '%s' %s", query, expected, response.Status, response.Payload, response.Message)
+		}
+	}
+
+	response := stub.MockInvoke("tx2", [][]byte{[]byte("query"), []byte(`{"docType":"ROYALTYSTATEMENT"}`)})
+	if response.Status != shim.ERROR || !strings.Contains(response.Message, "a selector is required") {
+		t.Fatalf("Expected a query without selector to be rejected: %d %s", response.Status, response.Message)
+	}
+
+	// the results are read when the query is run
+	response = stub.MockInvoke("tx3", [][]byte{[]byte("delete"), []byte(`{"selector":{"docType":"ROYALTYSTATEMENT"}}`)})
+	if response.Status != shim.OK || string(response.Payload) != "rs1,rs2" {
+		t.Fatalf("Expected the royalty statements to be deleted: %d '%s' %s", response.Status, response.Payload, response.Message)
+	}
+	if stub.State["rs1"] != nil || stub.State["rs2"] != nil || stub.State["er1"] == nil {
+		t.Fatalf("Expected only the royalty statements to be deleted")
+	}
+}
+
+// getQueryKeys - returns the keys of the results of a rich query and its bookmark
+func getQueryKeys(t *testing.T, stub *Stub, query string) ([]string, string) {
+	iterator, err := stub.GetQueryResult(query)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer iterator.Close()
+	keys := []string{}
+	for iterator.HasNext() {
+		result, err := iterator.Next()
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		keys = append(keys, result.Key)
+	}
+	return keys, iterator.(*QueryIterator).Bookmark()
+}
+
+func Test_GetQueryResult_Page(t *testing.T) {
+	stub := NewStub("query", queryChaincode{})
+	stub.MockTransactionStart("tx0")
+	for key, value := range map[string]string{
+		"rs1": `{"docType":"ROYALTYSTATEMENT","isrc":"USRC17607839","amount":12.5}`,
+		"rs2": `{"docType":"ROYALTYSTATEMENT","isrc":"GBA1B1800001","amount":3}`,
+		"rs3": `{"docType":"ROYALTYSTATEMENT","isrc":"USRC17607839","amount":7}`,
+		"rs4": `{"docType":"ROYALTYSTATEMENT","isrc":"FRZ039800212","amount":12.5}`,
+		"rs5": `{"docType":"ROYALTYSTATEMENT","amount":1}`,
+		"er1": `{"docType":"EXPLOITATIONREPORT","isrc":"USRC17607839","amount":100}`,
+	} {
+		stub.PutState(key, []byte(value))
+	}
+	stub.MockTransactionEnd("tx0")
+
+	for query, expected := range map[string][]string{
+		`{"selector":{"docType":"ROYALTYSTATEMENT"}}`:                                              {"rs1", "rs2", "rs3", "rs4", "rs5"},
+		`{"selector":{"docType":"ROYALTYSTATEMENT"},"sort":["amount"]}`:                            {"rs5", "rs2", "rs3", "rs1", "rs4"},
+		`{"selector":{"docType":"ROYALTYSTATEMENT"},"sort":[{"amount":"desc"}]}`:                   {"rs4", "rs1", "rs3", "rs2", "rs5"},
+		`{"selector":{"docType":"ROYALTYSTATEMENT"},"sort":[{"isrc":"asc"},{"amount":"asc"}]}`:     {"rs4", "rs2", "rs3", "rs1"},
+		`{"selector":{"docType":"ROYALTYSTATEMENT"},"sort":["amount"],"limit":2}`:                  {"rs5", "rs2"},
+		`{"selector":{"docType":"ROYALTYSTATEMENT"},"sort":["amount"],"skip":3,"limit":10}`:        {"rs1", "rs4"},
+		`{"selector":{"docType":"ROYALTYSTATEMENT"},"skip":5}`:                                     {},
+		`{"selector":{"amount":{"$gte":7}},"sort":["amount"],"use_index":["_design/indexAmount"]}`: {"rs3", "rs1", "rs4", "er1"},
+	} {
+		actual, _ := getQueryKeys(t, stub, query)
+		if !reflect.DeepEqual(expected, actual) {
+			t.Fatalf("Expected query %s to return %v, got %v", query, expected, actual)
+		}
+	}
+
+	// the bookmark of a page is passed back for the next page
+	for _, sort := range []string{`[]`, `[{"amount":"desc"}]`} {
+		pages := [][]string{}
+		bookmark := ""
+		for len(pages) < 10 {
+			bookmarkJSON, _ := json.Marshal(bookmark)
+			keys, nextBookmark := getQueryKeys(t, stub, `{"selector":{"docType":"ROYALTYSTATEMENT"},"sort":`+sort+`,"limit":2,"bookmark":`+string(bookmarkJSON)+`}`)
+			if len(keys) == 0 {
+				if nextBookmark != bookmark {
+					t.Fatalf("Expected the bookmark of an empty page to be kept")
+				}
+				break
+			}
+			pages = append(pages, keys)
+			bookmark = nextBookmark
+		}
+		expected := [][]string{{"rs1", "rs2"}, {"rs3", "rs4"}, {"rs5"}}
+		if sort != `[]` {
+			expected = [][]string{{"rs4", "rs1"}, {"rs3", "rs2"}, {"rs5"}}
+		}
+		if !reflect.DeepEqual(expected, pages) {
+			t.Fatalf("Expected the pages of the query sorted by %s to be %v, got %v", sort, expected, pages)
+		}
+	}
+
+	for query, expected := range map[string]string{
+		`{"selector":{"docType":"ROYALTYSTATEMENT"},"fields":["isrc"]}`:                         "unsupported attribute fields",
+		`{"selector":{"docType":"ROYALTYSTATEMENT"},"sort":["isrc",{"amount":"desc"}]}`:         "sort fields must have the same direction",
+		`{"selector":{"docType":"ROYALTYSTATEMENT"},"sort":[{"amount":"down"}]}`:                "invalid sort field map[amount:down]",
+		`{"selector":{"docType":"ROYALTYSTATEMENT"},"limit":-1}`:                                "limit and skip can not be negative",
+		`{"selector":{"docType":"ROYALTYSTATEMENT"},"sort":["amount"],"bookmark":"WyJyczEiXQ"}`: "invalid bookmark WyJyczEiXQ",
+	} {
+		_, err := stub.GetQueryResult(query)
+		if err == nil || err.Error() != "Invalid query '"+query+"': "+expected {
+			t.Fatalf("Expected query %s to be rejected with '%s': %v", query, expected, err)
+		}
+	}
+}
+
+func Test_GetPrivateDataByPartialCompositeKey(t *testing.T) {
+	stub := NewStub("private", queryChaincode{})
+	stub.MockTransactionStart("tx0")
+	for _, attributes := range [][]string{{"IPI", "00014107338", "rs2"}, {"IPI", "00014107338", "rs1"}, {"IPI", "00052210040", "rs1"}} {
+		key, _ := stub.CreateCompositeKey("BALANCE", attributes)
+		stub.PutPrivateData("royalties_Org1MSP_Org2MSP", key, []byte(`{"payable":1}`))
+	}
+	deletedKey, _ := stub.CreateCompositeKey("BALANCE", []string{"IPI", "00014107338", "rs3"})
+	stub.PutPrivateData("royalties_Org1MSP_Org2MSP", deletedKey, []byte(`{"payable":1}`))
+	stub.DelPrivateData("royalties_Org1MSP_Org2MSP", deletedKey)
+	stub.MockTransactionEnd("tx0")
+
+	iterator, err := stub.GetPrivateDataByPartialCompositeKey("royalties_Org1MSP_Org2MSP", "BALANCE", []string{"IPI", "00014107338"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actual := []string{}
+	for iterator.HasNext() {
+		kv, _ := iterator.Next()
+		_, attributes, _ := stub.SplitCompositeKey(kv.Key)
+		actual = append(actual, strings.Join(attributes, "~"))
+	}
+	if !reflect.DeepEqual([]string{"IPI~00014107338~rs1", "IPI~00014107338~rs2"}, actual) {
+		t.Fatalf("Unexpected private keys: %v", actual)
+	}
+
+	// the transient map is the one set on the stub
+	stub.Transient = map[string][]byte{"salt": []byte("0123456789abcdef")}
+	transient, _ := stub.GetTransient()
+	if string(transient["salt"]) != "0123456789abcdef" {
+		t.Fatalf("Unexpected transient map: %q", transient)
+	}
+}
+
+// writeReadChaincode - writes its argument to a key and returns the value it reads back
+type writeReadChaincode struct{}
+
+func (chaincode writeReadChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (chaincode writeReadChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	_, args := stub.GetFunctionAndParameters()
+	stub.PutState("key", []byte(args[0]))
+	value, err := stub.GetState("key")
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(value)
+}
+
+func Test_GetState_ReadCommitted(t *testing.T) {
+	stub := NewStub("writeread", writeReadChaincode{})
+	response := stub.MockInvoke("tx1", [][]byte{[]byte("write"), []byte("v1")})
+	if string(response.Payload) != "v1" {
+		t.Fatalf("Expected the MockStub to read the write of the transaction, got %q", response.Payload)
+	}
+
+	// a peer reads the value committed before the transaction
+	stub.ReadCommitted = true
+	response = stub.MockInvoke("tx2", [][]byte{[]byte("write"), []byte("v2")})
+	if string(response.Payload) != "v1" {
+		t.Fatalf("Expected the committed value, got %q", response.Payload)
+	}
+	value, _ := stub.GetState("key")
+	if string(value) != "v2" {
+		t.Fatalf("Expected the write to be committed, got %q", value)
+	}
+}
@@ -0,0 +1,166 @@
+package memstub
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+/*
+* A rich query is the body of a CouchDB _find request: a selector, and optionally sort, limit, skip, bookmark and
+* use_index, which is only a hint and is ignored. Without sort the documents are returned in key order. A sorted
+* query only returns the documents holding all the sort fields, as CouchDB only finds those in the index serving
+* the sort, and the documents with the same sort values are returned in key order. The bookmark of a page is the
+* position of its last document, a query with that bookmark returns the documents after it.
+ */
+
+// validQueryAttributes - the attributes of a rich query the stub evaluates
+var validQueryAttributes = map[string]bool{"selector": true, "sort": true, "limit": true, "skip": true, "bookmark": true, "use_index": true}
+
+// richQuery - a parsed rich query
+type richQuery struct {
+	Selector   map[string]interface{} `json:"selector"`
+	Sort       []interface{}          `json:"sort"`
+	Limit      int                    `json:"limit"`
+	Skip       int                    `json:"skip"`
+	Bookmark   string                 `json:"bookmark"`
+	sortFields []string
+	descending bool
+	position   []interface{}
+}
+
+// queryDocument - a document of the state and the values it is sorted by
+type queryDocument struct {
+	key        string
+	value      []byte
+	sortValues []interface{}
+}
+
+// parseQuery - parses a rich query, its sort and its bookmark
+func parseQuery(query string) (*richQuery, error) {
+	attributes := map[string]json.RawMessage{}
+	err := json.Unmarshal([]byte(query), &attributes)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := attributes["selector"]; !ok {
+		return nil, fmt.Errorf("a selector is required")
+	}
+	for attribute := range attributes {
+		if !validQueryAttributes[attribute] {
+			return nil, fmt.Errorf("unsupported attribute %s", attribute)
+		}
+	}
+	richQuery := &richQuery{}
+	err = json.Unmarshal([]byte(query), richQuery)
+	if err != nil {
+		return nil, err
+	}
+	if richQuery.Selector == nil {
+		return nil, fmt.Errorf("a selector is required")
+	}
+	if richQuery.Limit < 0 || richQuery.Skip < 0 {
+		return nil, fmt.Errorf("limit and skip can not be negative")
+	}
+
+	// a sort field is a name or an object of a name and a direction, all in the same direction
+	for i, sortField := range richQuery.Sort {
+		field, direction := "", "asc"
+		switch sortField := sortField.(type) {
+		case string:
+			field = sortField
+		case map[string]interface{}:
+			for name, value := range sortField {
+				field = name
+				direction, _ = value.(string)
+			}
+			if len(sortField) != 1 || (direction != "asc" && direction != "desc") {
+				return nil, fmt.Errorf("invalid sort field %v", sortField)
+			}
+		default:
+			return nil, fmt.Errorf("invalid sort field %v", sortField)
+		}
+		if i > 0 && richQuery.descending != (direction == "desc") {
+			return nil, fmt.Errorf("sort fields must have the same direction")
+		}
+		richQuery.descending = direction == "desc"
+		richQuery.sortFields = append(richQuery.sortFields, field)
+	}
+
+	if richQuery.Bookmark != "" {
+		positionBytes, err := base64.RawURLEncoding.DecodeString(richQuery.Bookmark)
+		if err == nil {
+			err = json.Unmarshal(positionBytes, &richQuery.position)
+		}
+		if err != nil || len(richQuery.position) != len(richQuery.sortFields)+1 {
+			return nil, fmt.Errorf("invalid bookmark %s", richQuery.Bookmark)
+		}
+	}
+	return richQuery, nil
+}
+
+// getDocument - returns a state value as a document of the query, false when it is not a JSON object, lacks a sort field or does not match the selector
+func (richQuery *richQuery) getDocument(key string, value []byte) (*queryDocument, bool, error) {
+	document := map[string]interface{}{}
+	if json.Unmarshal(value, &document) != nil {
+		return nil, false, nil
+	}
+	if _, ok := document["_id"]; !ok {
+		document["_id"] = key
+	}
+	queryDocument := &queryDocument{key: key, value: value}
+	for _, sortField := range richQuery.sortFields {
+		sortValue, found := getField(document, true, sortField)
+		if !found {
+			return nil, false, nil
+		}
+		queryDocument.sortValues = append(queryDocument.sortValues, sortValue)
+	}
+	matches, err := Match(richQuery.Selector, document)
+	if err != nil || !matches {
+		return nil, false, err
+	}
+	return queryDocument, true, nil
+}
+
+// getPosition - returns the position of a document in the order of the query, its sort values then its key
+func (queryDocument *queryDocument) getPosition() []interface{} {
+	return append(append([]interface{}{}, queryDocument.sortValues...), queryDocument.key)
+}
+
+// compare - compares the positions of two documents in the order of the query, returns -1, 0 or 1
+func (richQuery *richQuery) compare(a []interface{}, b []interface{}) int {
+	order := collate(a, b)
+	if richQuery.descending {
+		return -order
+	}
+	return order
+}
+
+// getPage - sorts the matching documents and returns the page of the query and its bookmark
+func (richQuery *richQuery) getPage(documents []*queryDocument) ([]*queryDocument, string) {
+	sort.SliceStable(documents, func(i, j int) bool {
+		return richQuery.compare(documents[i].getPosition(), documents[j].getPosition()) < 0
+	})
+	if richQuery.position != nil {
+		start := sort.Search(len(documents), func(i int) bool {
+			return richQuery.compare(documents[i].getPosition(), richQuery.position) > 0
+		})
+		documents = documents[start:]
+	}
+	if richQuery.Skip >= len(documents) {
+		documents = nil
+	} else {
+		documents = documents[richQuery.Skip:]
+	}
+	if richQuery.Limit > 0 && richQuery.Limit < len(documents) {
+		documents = documents[:richQuery.Limit]
+	}
+
+	if len(documents) == 0 {
+		return documents, richQuery.Bookmark
+	}
+	positionBytes, _ := json.Marshal(documents[len(documents)-1].getPosition())
+	return documents, base64.RawURLEncoding.EncodeToString(positionBytes)
+}
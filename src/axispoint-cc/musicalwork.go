@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+
+	"axispoint-cc/identifier"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// MusicalWorkResponse : defines response data from blockchain request
+type MusicalWorkResponse struct {
+	Iswc    string `json:"iswc"`
+	Message string `json:"message"`
+	Success bool   `json:"success"`
+}
+
+// MusicalWorkOutput : defines accumulated output of blockchain requests
+type MusicalWorkOutput struct {
+	SuccessCount         int                   `json:"successCount"`
+	FailureCount         int                   `json:"failureCount"`
+	MusicalWorkResponses []MusicalWorkResponse `json:"musicalWorkResponses"`
+}
+
+/*
+* addMusicalWorks function inserts new musical works to the Ledger. Each work is stored under its ISWC and
+* its recordings are linked to it, so that their royalties are split by the work when they have no copyright data.
+*
+* @params   {Array} args
+* @property {string} 0       - stringified JSON array of musical works.
+* @return   {pb.Response}    - peer Response
+ */
+func addMusicalWorks(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "addMusicalWorks"
+	logger.Info("ENTERING >", methodName, args)
+	return putMusicalWorks(stub, args, false)
+}
+
+/*
+* updateMusicalWorks function replaces existing musical works on the Ledger. Recordings no longer listed
+* by a work are unlinked from it.
+*
+* @params   {Array} args
+* @property {string} 0       - stringified JSON array of musical works.
+* @return   {pb.Response}    - peer Response
+ */
+func updateMusicalWorks(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "updateMusicalWorks"
+	logger.Info("ENTERING >", methodName, args)
+	return putMusicalWorks(stub, args, true)
+}
+
+//putMusicalWorks - contains the business logic to insert new or update existing musical works
+func putMusicalWorks(stub shim.ChaincodeStubInterface, args []string, updateFlag bool) pb.Response {
+	var methodName = "putMusicalWorks"
+
+	if len(args) != 1 {
+		return getErrorResponse("Missing arguments: Array of Musical Work objects is required")
+	}
+
+	musicalWorkOutput := MusicalWorkOutput{}
+	musicalWorks := &[]MusicalWork{}
+	musicalWorkResponses := []MusicalWorkResponse{}
+
+	err := jsonToObject([]byte(args[0]), musicalWorks)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	for _, musicalWork := range *musicalWorks {
+		musicalWorkResponse := MusicalWorkResponse{}
+		musicalWorkResponse.Iswc = musicalWork.Iswc
+		musicalWorkResponse.Success = true
+
+		err = putMusicalWork(stub, &musicalWork, updateFlag)
+		if err != nil {
+			musicalWorkResponse.Success = false
+			musicalWorkResponse.Message = err.Error()
+			musicalWorkResponses = append(musicalWorkResponses, musicalWorkResponse)
+			musicalWorkOutput.FailureCount++
+			continue
+		}
+		musicalWorkOutput.SuccessCount++
+	}
+
+	musicalWorkOutput.MusicalWorkResponses = musicalWorkResponses
+
+	objBytes, _ := objectToJSON(musicalWorkOutput)
+	logger.Info("EXITING <", methodName, musicalWorkOutput)
+	return shim.Success(objBytes)
+}
+
+/*
+* putMusicalWork function validates a musical work, links its recordings and records it on the Ledger.
+* Nothing is written when the work is invalid or one of its recordings is linked to another work.
+*
+* @param    {MusicalWork} - musical work
+* @param    {bool}        - updateFlag
+* @return   {error}       - Error
+ */
+func putMusicalWork(stub shim.ChaincodeStubInterface, musicalWork *MusicalWork, updateFlag bool) error {
+	err := normalizeMusicalWork(musicalWork)
+	if err != nil {
+		return err
+	}
+
+	previousMusicalWork, err := getMusicalWorkByIswc(stub, musicalWork.Iswc)
+	if err != nil {
+		return err
+	}
+	if !updateFlag && previousMusicalWork != nil {
+		return fmt.Errorf("Musical Work '%s' already exists!", musicalWork.Iswc)
+	}
+	if updateFlag && previousMusicalWork == nil {
+		return fmt.Errorf("Musical Work '%s' does not exist!", musicalWork.Iswc)
+	}
+
+	// a recording belongs to a single musical work
+	for _, isrc := range musicalWork.Isrcs {
+		linkedIswc, err := getRecordingWorkIswc(stub, isrc)
+		if err != nil {
+			return err
+		}
+		if linkedIswc != "" && linkedIswc != musicalWork.Iswc {
+			return fmt.Errorf("Recording '%s' is already linked to musical work '%s'", isrc, linkedIswc)
+		}
+	}
+
+	// unlink the recordings the work no longer lists
+	if previousMusicalWork != nil {
+		linked := map[string]bool{}
+		for _, isrc := range musicalWork.Isrcs {
+			linked[isrc] = true
+		}
+		for _, isrc := range previousMusicalWork.Isrcs {
+			if linked[isrc] {
+				continue
+			}
+			recordingWorkKey, err := stub.CreateCompositeKey(RECORDINGWORK, []string{isrc})
+			if err != nil {
+				return err
+			}
+			err = stub.DelState(recordingWorkKey)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, isrc := range musicalWork.Isrcs {
+		recordingWorkKey, err := stub.CreateCompositeKey(RECORDINGWORK, []string{isrc})
+		if err != nil {
+			return err
+		}
+		err = stub.PutState(recordingWorkKey, []byte(musicalWork.Iswc))
+		if err != nil {
+			return err
+		}
+	}
+
+	musicalWorkBytes, err := objectToJSON(musicalWork)
+	if err != nil {
+		return err
+	}
+	return stub.PutState(musicalWork.Iswc, musicalWorkBytes)
+}
+
+/*
+* getMusicalWork function returns a musical work by its ISWC or by the ISRC of one of its recordings
+*
+* @params   {Array} args
+* @property {string} 0       - ISWC or ISRC
+* @return   {pb.Response}    - peer Response
+ */
+func getMusicalWork(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "getMusicalWork"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) != 1 {
+		return getErrorResponse("Missing arguments: ISWC or ISRC is required")
+	}
+
+	var musicalWork *MusicalWork
+	var err error
+	if identifier.IsValidIswc(args[0]) {
+		iswc, _ := identifier.NormalizeIswc(args[0])
+		musicalWork, err = getMusicalWorkByIswc(stub, iswc)
+	} else {
+		musicalWork, err = getMusicalWorkForRecording(stub, args[0])
+	}
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	if musicalWork == nil {
+		return getErrorResponse(fmt.Sprintf("%s - No musical work found for '%s'.", methodName, args[0]))
+	}
+
+	objBytes, err := objectToJSON(musicalWork)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	logger.Info("EXITING <", methodName, musicalWork.Iswc)
+	return shim.Success(objBytes)
+}
+
+//normalizeMusicalWork - normalizes the identifiers of a musical work and checks its dates
+func normalizeMusicalWork(musicalWork *MusicalWork) error {
+	musicalWork.DocType = MUSICALWORK
+	iswc, err := identifier.NormalizeIswc(musicalWork.Iswc)
+	if err != nil {
+		return err
+	}
+	musicalWork.Iswc = iswc
+
+	isrcs := []string{}
+	seen := map[string]bool{}
+	for _, isrc := range musicalWork.Isrcs {
+		normalizedIsrc, err := identifier.NormalizeIsrc(isrc)
+		if err != nil {
+			return err
+		}
+		if !seen[normalizedIsrc] {
+			seen[normalizedIsrc] = true
+			isrcs = append(isrcs, normalizedIsrc)
+		}
+	}
+	musicalWork.Isrcs = isrcs
+	if musicalWork.RightHolders == nil {
+		musicalWork.RightHolders = []RightHolder{}
+	}
+
+	if musicalWork.StartDate != "" {
+		_, err = parseDate(musicalWork.StartDate, false)
+		if err != nil {
+			return err
+		}
+	}
+	if musicalWork.EndDate != "" {
+		_, err = parseDate(musicalWork.EndDate, true)
+		if err != nil {
+			return err
+		}
+	}
+	err = normalizeRightHolderIpis(musicalWork.RightHolders)
+	if err != nil {
+		return err
+	}
+	err = normalizeRightHolderShares(musicalWork.RightHolders)
+	if err != nil {
+		return err
+	}
+	return normalizeRightHolderRoles(musicalWork.RightHolders)
+}
+
+//getMusicalWorkByIswc - returns the musical work stored under an ISWC or nil when there is none
+func getMusicalWorkByIswc(stub shim.ChaincodeStubInterface, iswc string) (*MusicalWork, error) {
+	musicalWorkBytes, err := stub.GetState(iswc)
+	if err != nil || musicalWorkBytes == nil {
+		return nil, err
+	}
+	musicalWork := MusicalWork{}
+	err = jsonToObject(musicalWorkBytes, &musicalWork)
+	if err != nil {
+		return nil, err
+	}
+	if musicalWork.DocType != MUSICALWORK {
+		return nil, fmt.Errorf("'%s' is not a musical work", iswc)
+	}
+	return &musicalWork, nil
+}
+
+//getRecordingWorkIswc - returns the ISWC of the musical work a recording is linked to or an empty string
+func getRecordingWorkIswc(stub shim.ChaincodeStubInterface, isrc string) (string, error) {
+	recordingWorkKey, err := stub.CreateCompositeKey(RECORDINGWORK, []string{isrc})
+	if err != nil {
+		return "", err
+	}
+	iswcBytes, err := stub.GetState(recordingWorkKey)
+	if err != nil {
+		return "", err
+	}
+	return string(iswcBytes), nil
+}
+
+//getMusicalWorkForRecording - returns the musical work a recording is linked to or nil when it is not linked
+func getMusicalWorkForRecording(stub shim.ChaincodeStubInterface, isrc string) (*MusicalWork, error) {
+	normalizedIsrc, err := identifier.NormalizeIsrc(isrc)
+	if err != nil {
+		return nil, nil
+	}
+	iswc, err := getRecordingWorkIswc(stub, normalizedIsrc)
+	if err != nil || iswc == "" {
+		return nil, err
+	}
+	return getMusicalWorkByIswc(stub, iswc)
+}
+
+//isMusicalWorkInEffect - returns whether the split of a musical work applies on the exploitation date
+func isMusicalWorkInEffect(musicalWork MusicalWork, exploitationDate string) bool {
+	return isDateInEffect(musicalWork.StartDate, musicalWork.EndDate, exploitationDate)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"axispoint-cc/memstub"
+)
+
+// *****************************************************************************
+// ******************************* Mock Data ***********************************
+// *****************************************************************************
+
+var musicalWork_in = `[{"iswc":"T-034.524.680-1","title":"HOLD THE LINE","rightHolders":[{"ipi":"ipi1","percent":60},{"ipi":"ipi2","percent":40}],"isrcs":["qz-ab1-17-29521","QZAB11729524"]}]`
+var musicalWork_out = `{"docType":"MUSICALWORK","iswc":"T0345246801","title":"HOLD THE LINE","rightHolders":[{"selector":"","ipi":"ipi1","percent":60},{"selector":"","ipi":"ipi2","percent":40}],"isrcs":["QZAB11729521","QZAB11729524"]}`
+
+// *****************************************************************************
+func MockGetMusicalWorkResponse(functionName string) []byte {
+	switch functionName {
+	case "Test_AddMusicalWorks":
+		return []byte(`{"successCount":1,"failureCount":0,"musicalWorkResponses":[]}`)
+	case "Test_AddMusicalWorks_RecordingLinked":
+		return []byte(`{"successCount":0,"failureCount":2,"musicalWorkResponses":[{"iswc":"T-034.524.680-1","message":"Musical Work 'T0345246801' already exists!","success":false},{"iswc":"T0000000010","message":"Recording 'QZAB11729524' is already linked to musical work 'T0345246801'","success":false}]}`)
+	case "Test_GenerateExploitationReports_MusicalWork":
+		return []byte(`{"successCount":1,"failureCount":0,"exploitationReportResponses":[],"royaltyStatements":[{"docType":"ROYALTYSTATEMENT","royaltyStatementUUID":"","exploitationReportUUID":"er1","source":"P8819H","isrc":"QZAB11729524","songTitle":"","writerName":"","units":1,"exploitationDate":"20170131","amount":6,"rightType":"OWNERSHIP","territory":"","usageType":"","rightHolder":"ipi1","administrator":"","collector":"","state":"","iswc":"T0345246801","netPayable":6},{"docType":"ROYALTYSTATEMENT","royaltyStatementUUID":"","exploitationReportUUID":"er1","source":"P8819H","isrc":"QZAB11729524","songTitle":"","writerName":"","units":1,"exploitationDate":"20170131","amount":4,"rightType":"OWNERSHIP","territory":"","usageType":"","rightHolder":"ipi2","administrator":"","collector":"","state":"","iswc":"T0345246801","netPayable":4}],"exploitationReports":[{"docType":"EXPLOITATIONREPORT","source":"P8819H","songTitle":"","writerName":"","isrc":"QZAB11729524","units":1,"exploitationDate":"20170131","amount":10,"usageType":"","exploitationReportUUID":"er1","territory":"","state":"INITIAL"}]}`)
+	default:
+		return []byte("[]")
+	}
+}
+
+// *****************************************************************************
+
+func Test_AddMusicalWorks(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+
+	// Init
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("addMusicalWorks"), []byte(musicalWork_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	checkState(t, stub, "T0345246801", musicalWork_out)
+
+	expected := MockGetMusicalWorkResponse("Test_AddMusicalWorks")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+
+	// the work is found through any of its recordings
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("getMusicalWork"), []byte("QZAB11729521")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !reflect.DeepEqual([]byte(musicalWork_out), actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_AddMusicalWorks_RecordingLinked(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+
+	// Init
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addMusicalWorks"), []byte(musicalWork_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("addMusicalWorks"), []byte(`[{"iswc":"T-034.524.680-1","title":"HOLD THE LINE"},{"iswc":"T0000000010","title":"LIVE","isrcs":["QZAB11729524"]}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected := MockGetMusicalWorkResponse("Test_AddMusicalWorks_RecordingLinked")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_GenerateExploitationReports_MusicalWork(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+
+	// Init
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addMusicalWorks"), []byte(musicalWork_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// the recording has no copyright data so the split of its work applies
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("generateExploitationReports"), []byte(`[{"source":"P8819H","isrc":"QZAB11729524","units":1,"exploitationDate":"20170131","amount":10,"exploitationReportUUID":"er1"}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected := MockGetMusicalWorkResponse("Test_GenerateExploitationReports_MusicalWork")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+}
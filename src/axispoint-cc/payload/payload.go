@@ -0,0 +1,84 @@
+/*
+Package payload batches the assets of an import into chaincode invocations and writes them, with the validation
+report of the import, the way the import commands emit them.
+
+The payloads are written one per line to the standard output, or to batch-NNNN.json files of a directory, and
+the validation report to the standard error or a file.
+*/
+package payload
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Payload - the function and arguments of a chaincode invocation
+type Payload struct {
+	Function string   `json:"function"`
+	Args     []string `json:"args"`
+}
+
+// New returns the payload of a function taking the JSON of its input as its single argument
+func New(function string, input interface{}) (Payload, error) {
+	inputBytes, err := json.Marshal(input)
+	if err != nil {
+		return Payload{}, err
+	}
+	return Payload{Function: function, Args: []string{string(inputBytes)}}, nil
+}
+
+// Batches returns the payloads of a function taking count assets in batches of at most batchSize assets, batch
+// returns the assets from start to end
+func Batches(function string, count int, batchSize int, batch func(start int, end int) interface{}) ([]Payload, error) {
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("batch size %d is not positive", batchSize)
+	}
+	payloads := []Payload{}
+	for start := 0; start < count; start += batchSize {
+		end := start + batchSize
+		if end > count {
+			end = count
+		}
+		payload, err := New(function, batch(start, end))
+		if err != nil {
+			return nil, err
+		}
+		payloads = append(payloads, payload)
+	}
+	return payloads, nil
+}
+
+// WriteReport writes a validation report to a file or to the standard error
+func WriteReport(report interface{}, reportPath string) error {
+	reportBytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if reportPath == "" {
+		_, err = fmt.Fprintln(os.Stderr, string(reportBytes))
+		return err
+	}
+	return ioutil.WriteFile(reportPath, append(reportBytes, '\n'), 0644)
+}
+
+// Write writes payloads to numbered files of a directory or one per line to the standard output
+func Write(payloads []Payload, out string) error {
+	for i, payload := range payloads {
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		if out == "" {
+			_, err = fmt.Fprintln(os.Stdout, string(payloadBytes))
+		} else {
+			err = ioutil.WriteFile(filepath.Join(out, fmt.Sprintf("batch-%04d.json", i+1)), append(payloadBytes, '\n'), 0644)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
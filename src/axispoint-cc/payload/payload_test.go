@@ -0,0 +1,52 @@
+package payload
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_Batches(t *testing.T) {
+	assets := []string{"a", "b", "c"}
+	payloads, err := Batches("addAssets", len(assets), 2, func(start int, end int) interface{} { return assets[start:end] })
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := []Payload{{Function: "addAssets", Args: []string{`["a","b"]`}}, {Function: "addAssets", Args: []string{`["c"]`}}}
+	if !reflect.DeepEqual(expected, payloads) {
+		t.Fatalf("Actual payloads are not equal to expected payloads: %+v", payloads)
+	}
+
+	_, err = Batches("addAssets", len(assets), 0, func(start int, end int) interface{} { return assets[start:end] })
+	if err == nil || err.Error() != "batch size 0 is not positive" {
+		t.Fatalf("Expected a batch size of 0 to be rejected: %v", err)
+	}
+}
+
+func Test_Write(t *testing.T) {
+	out, err := ioutil.TempDir("", "payload")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(out)
+
+	err = Write([]Payload{{Function: "addAssets", Args: []string{`["a"]`}}, {Function: "addAssets", Args: []string{`["b"]`}}}, out)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actual, err := ioutil.ReadFile(filepath.Join(out, "batch-0002.json"))
+	if err != nil || string(actual) != "{\"function\":\"addAssets\",\"args\":[\"[\\\"b\\\"]\"]}\n" {
+		t.Fatalf("Actual payload file is not equal to expected payload file: %q %v", actual, err)
+	}
+
+	err = WriteReport(map[string]bool{"valid": true}, filepath.Join(out, "report.json"))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actual, err = ioutil.ReadFile(filepath.Join(out, "report.json"))
+	if err != nil || string(actual) != "{\n  \"valid\": true\n}\n" {
+		t.Fatalf("Actual report file is not equal to expected report file: %q %v", actual, err)
+	}
+}
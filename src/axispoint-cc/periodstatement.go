@@ -0,0 +1,356 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+var getPayeeRoyaltyStatementsForQueryString = getObjectByQueryFromLedger
+
+/*
+* closeStatementPeriod function rolls up all the ownership and collection royalty statements payable to an IPI
+* with an exploitation date within the period into a single period statement. The rolled up royalty statements
+* are linked to the period statement and locked against further updates. The totals of the royalty statements
+* between orgs are written to their collection. A period can only be closed once, so it is not closed when the
+* caller cannot read some of its statements.
+*
+* @params   {Array} args
+* @property {string} 0       - payee IPI
+* @property {string} 1       - period start date
+* @property {string} 2       - period end date
+* @return   {pb.Response}    - peer Response
+ */
+func closeStatementPeriod(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "closeStatementPeriod"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) != 3 {
+		return getErrorResponse(fmt.Sprintf("%s - Incorrect number of parameters provided '%d'. Needed IPI, period start and period end", methodName, len(args)))
+	}
+	ipi, periodStart, periodEnd := args[0], args[1], args[2]
+	ipi, err := normalizeIpi(ipi)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	periodStatement := PeriodStatement{}
+	periodStatement.DocType = PERIODSTATEMENT
+	periodStatement.PeriodStatementUUID = getPeriodStatementUUID(ipi, periodStart, periodEnd)
+	periodStatement.Ipi = ipi
+	periodStatement.PeriodStart = periodStart
+	periodStatement.PeriodEnd = periodEnd
+	periodStatement.Totals = map[string]PeriodTotals{}
+	periodStatement.RoyaltyStatementUUIDs = []string{}
+
+	// a period can only be closed once
+	periodStatementExistingBytes, err := stub.GetState(periodStatement.PeriodStatementUUID)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	if periodStatementExistingBytes != nil {
+		return getErrorResponse(fmt.Sprintf("%s - Period '%s' to '%s' is already closed for IPI '%s'.", methodName, periodStart, periodEnd, ipi))
+	}
+
+	periodStatement.ClosedDate, err = getTxTimestamp(stub)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	royaltyStatements, err := getPayeeRoyaltyStatements(stub, ipi, periodStart, periodEnd)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	// the period cannot be closed again later to roll up the statements the caller cannot read
+	unreadableRoyaltyStatementUUIDs := []string{}
+	for _, royaltyStatement := range royaltyStatements {
+		if royaltyStatement.PeriodStatementUUID == "" && royaltyStatement.PrivateCollection != "" && !isPrivateOrg(stub, royaltyStatement) {
+			unreadableRoyaltyStatementUUIDs = append(unreadableRoyaltyStatementUUIDs, royaltyStatement.RoyaltyStatementUUID)
+		}
+	}
+	if len(unreadableRoyaltyStatementUUIDs) > 0 {
+		return getErrorResponse(fmt.Sprintf("%s - Cannot close the period of IPI '%s', the caller cannot read the royalty statements '%s'.", methodName, ipi, strings.Join(unreadableRoyaltyStatementUUIDs, "', '")))
+	}
+
+	// the totals of the statements between orgs are kept in their collection
+	privateTotals := map[string]*PeriodPrivateTotals{}
+	for _, royaltyStatement := range royaltyStatements {
+		// statements already rolled up in another period statement stay there
+		if royaltyStatement.PeriodStatementUUID != "" {
+			logger.Infof("%s - skipping royalty statement '%s' locked by period statement '%s'.", methodName, royaltyStatement.RoyaltyStatementUUID, royaltyStatement.PeriodStatementUUID)
+			continue
+		}
+
+		if royaltyStatement.PrivateCollection == "" {
+			addToPeriodTotals(periodStatement.Totals, royaltyStatement)
+		} else {
+			periodPrivateTotals, ok := privateTotals[royaltyStatement.PrivateCollection]
+			if !ok {
+				periodPrivateTotals = &PeriodPrivateTotals{DocType: PERIODPRIVATETOTALS, PeriodStatementUUID: periodStatement.PeriodStatementUUID, PrivateCollection: royaltyStatement.PrivateCollection, Totals: map[string]PeriodTotals{}}
+				privateTotals[royaltyStatement.PrivateCollection] = periodPrivateTotals
+				periodStatement.PrivateCollections = append(periodStatement.PrivateCollections, royaltyStatement.PrivateCollection)
+			}
+			addToPeriodTotals(periodPrivateTotals.Totals, royaltyStatement)
+			periodPrivateTotals.StatementCount++
+		}
+		periodStatement.StatementCount++
+		periodStatement.RoyaltyStatementUUIDs = append(periodStatement.RoyaltyStatementUUIDs, royaltyStatement.RoyaltyStatementUUID)
+
+		// link and lock the royalty statement
+		royaltyStatement.PeriodStatementUUID = periodStatement.PeriodStatementUUID
+		err = putRoyaltyStatement(stub, royaltyStatement)
+		if err != nil {
+			return getErrorResponse(err.Error())
+		}
+	}
+
+	if periodStatement.StatementCount == 0 {
+		return getErrorResponse(fmt.Sprintf("%s - No open royalty statements found for IPI '%s' from '%s' to '%s'.", methodName, ipi, periodStart, periodEnd))
+	}
+
+	sort.Strings(periodStatement.PrivateCollections)
+	for _, collection := range periodStatement.PrivateCollections {
+		periodPrivateTotalsBytes, err := objectToJSON(privateTotals[collection])
+		if err != nil {
+			return getErrorResponse(err.Error())
+		}
+		err = stub.PutPrivateData(collection, periodStatement.PeriodStatementUUID, periodPrivateTotalsBytes)
+		if err != nil {
+			return getErrorResponse(err.Error())
+		}
+	}
+
+	// the response is recorded on the ledger, it only carries the totals of the public statements
+	periodStatementBytes, err := objectToJSON(periodStatement)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	err = stub.PutState(periodStatement.PeriodStatementUUID, periodStatementBytes)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	logger.Info("EXITING <", methodName, periodStatement.PeriodStatementUUID, periodStatement.StatementCount)
+	return shim.Success(periodStatementBytes)
+}
+
+/*
+* getPeriodStatement function returns a period statement with the totals of the public royalty statements and
+* of the private royalty statements of the collections the caller belongs to
+*
+* @params   {Array} args
+* @property {string} 0       - period statement UUID
+* @return   {pb.Response}    - peer Response
+ */
+func getPeriodStatement(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "getPeriodStatement"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) != 1 {
+		return getErrorResponse(fmt.Sprintf("%s - Incorrect number of parameters provided '%d'. Needed period statement UUID", methodName, len(args)))
+	}
+
+	periodStatementBytes, err := stub.GetState(args[0])
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	periodStatement := PeriodStatement{}
+	if periodStatementBytes != nil {
+		err = jsonToObject(periodStatementBytes, &periodStatement)
+		if err != nil {
+			return getErrorResponse(err.Error())
+		}
+	}
+	if periodStatement.DocType != PERIODSTATEMENT {
+		return getErrorResponse(fmt.Sprintf("%s - Period statement '%s' does not exist.", methodName, args[0]))
+	}
+
+	collections, err := getCallerPrivateCollections(stub)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	for _, collection := range collections {
+		periodPrivateTotalsBytes, err := stub.GetPrivateData(collection, periodStatement.PeriodStatementUUID)
+		if err != nil {
+			return getErrorResponse(err.Error())
+		}
+		if periodPrivateTotalsBytes == nil {
+			continue
+		}
+		periodPrivateTotals := PeriodPrivateTotals{}
+		err = jsonToObject(periodPrivateTotalsBytes, &periodPrivateTotals)
+		if err != nil {
+			return getErrorResponse(err.Error())
+		}
+		mergePeriodTotals(periodStatement.Totals, periodPrivateTotals.Totals)
+	}
+
+	objBytes, err := objectToJSON(periodStatement)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	logger.Info("EXITING <", methodName, periodStatement.PeriodStatementUUID)
+	return shim.Success(objBytes)
+}
+
+//getPeriodStatementUUID - returns the deterministic key of the period statement of an IPI
+func getPeriodStatementUUID(ipi string, periodStart string, periodEnd string) string {
+	return fmt.Sprintf("%s_%s_%s_%s", PERIODSTATEMENT, ipi, periodStart, periodEnd)
+}
+
+//addToPeriodTotals - adds the amount payable by a royalty statement to the totals of its currency
+func addToPeriodTotals(totals map[string]PeriodTotals, royaltyStatement RoyaltyStatement) {
+	currency := royaltyStatement.Currency
+	if currency == "" {
+		currency = UNSPECIFIED_CURRENCY
+	}
+	periodTotals, ok := totals[currency]
+	if !ok {
+		periodTotals = PeriodTotals{ByIsrc: map[string]float64{}, ByTerritory: map[string]float64{}, ByUsageType: map[string]float64{}, BySource: map[string]float64{}}
+	}
+
+	_, _, amount := getRoyaltyStatementParties(royaltyStatement)
+	periodTotals.Amount = toFixed(periodTotals.Amount+amount, 6)
+	if royaltyStatement.RightType == OWNERSHIP {
+		periodTotals.OwnershipAmount = toFixed(periodTotals.OwnershipAmount+amount, 6)
+	} else {
+		periodTotals.CollectionAmount = toFixed(periodTotals.CollectionAmount+amount, 6)
+	}
+	periodTotals.ByIsrc[royaltyStatement.Isrc] = toFixed(periodTotals.ByIsrc[royaltyStatement.Isrc]+amount, 6)
+	periodTotals.ByTerritory[royaltyStatement.Territory] = toFixed(periodTotals.ByTerritory[royaltyStatement.Territory]+amount, 6)
+	periodTotals.ByUsageType[royaltyStatement.UsageType] = toFixed(periodTotals.ByUsageType[royaltyStatement.UsageType]+amount, 6)
+	periodTotals.BySource[royaltyStatement.Source] = toFixed(periodTotals.BySource[royaltyStatement.Source]+amount, 6)
+	totals[currency] = periodTotals
+}
+
+//mergePeriodTotals - adds the totals of a period statement to the totals of another, by currency
+func mergePeriodTotals(totals map[string]PeriodTotals, otherTotals map[string]PeriodTotals) {
+	for currency, otherPeriodTotals := range otherTotals {
+		periodTotals, ok := totals[currency]
+		if !ok {
+			periodTotals = PeriodTotals{ByIsrc: map[string]float64{}, ByTerritory: map[string]float64{}, ByUsageType: map[string]float64{}, BySource: map[string]float64{}}
+		}
+		periodTotals.Amount = toFixed(periodTotals.Amount+otherPeriodTotals.Amount, 6)
+		periodTotals.OwnershipAmount = toFixed(periodTotals.OwnershipAmount+otherPeriodTotals.OwnershipAmount, 6)
+		periodTotals.CollectionAmount = toFixed(periodTotals.CollectionAmount+otherPeriodTotals.CollectionAmount, 6)
+		for _, byDimension := range []struct {
+			totals      map[string]float64
+			otherTotals map[string]float64
+		}{
+			{periodTotals.ByIsrc, otherPeriodTotals.ByIsrc},
+			{periodTotals.ByTerritory, otherPeriodTotals.ByTerritory},
+			{periodTotals.ByUsageType, otherPeriodTotals.ByUsageType},
+			{periodTotals.BySource, otherPeriodTotals.BySource},
+		} {
+			for key, amount := range byDimension.otherTotals {
+				byDimension.totals[key] = toFixed(byDimension.totals[key]+amount, 6)
+			}
+		}
+		totals[currency] = periodTotals
+	}
+}
+
+/*
+* getPayeeRoyaltyStatements function returns the royalty statements payable to an IPI with an exploitation date
+* within the period, sorted by royalty statement UUID. The statements between orgs the caller does not belong to
+* are returned without their amounts.
+*
+* @param    {string} - payee IPI
+* @param    {string} - period start date
+* @param    {string} - period end date
+* @return   {Array}  - royalty statements
+ */
+func getPayeeRoyaltyStatements(stub shim.ChaincodeStubInterface, ipi string, periodStart string, periodEnd string) ([]RoyaltyStatement, error) {
+	var methodName = "getPayeeRoyaltyStatements"
+
+	startDate, endDate, err := parsePeriod(periodStart, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	queryString := getPayeeRoyaltyStatementsQueryString(ipi, startDate, endDate)
+	logger.Infof("%s - executing rich query : %s.", methodName, queryString)
+
+	queryResult, err := getPayeeRoyaltyStatementsForQueryString(stub, queryString)
+	if err != nil {
+		return nil, err
+	}
+	return filterPayeeRoyaltyStatements(stub, ipi, startDate, endDate, queryResult)
+}
+
+//parsePeriod - parses the start and the end of a period, the end date covers the whole end day
+func parsePeriod(periodStart string, periodEnd string) (time.Time, time.Time, error) {
+	startDate, err := parseDate(periodStart, false)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	endDate, err := parseDate(periodEnd, true)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if endDate.Before(startDate) {
+		return time.Time{}, time.Time{}, fmt.Errorf("Period end '%s' is before period start '%s'", periodEnd, periodStart)
+	}
+	return startDate, endDate, nil
+}
+
+//getPayeeRoyaltyStatementsQueryString - builds the selector of the royalty statements of an IPI within a period.
+//The exploitation dates are not stored in a single format, so the selector matches a superset of the period: the
+//ISO dates widened by a day on each side for the timezone offsets, the compact dates and the non padded dates.
+func getPayeeRoyaltyStatementsQueryString(ipi string, startDate time.Time, endDate time.Time) string {
+	isoStart := startDate.UTC().AddDate(0, 0, -1).Format("2006-01-02")
+	isoEnd := endDate.UTC().AddDate(0, 0, 2).Format("2006-01-02")
+	compactStart := startDate.UTC().Format("20060102")
+	compactEnd := endDate.UTC().Format("20060102")
+
+	parties := fmt.Sprintf("{\"$or\":[{\"rightHolder\":\"%s\"},{\"administrator\":\"%s\"},{\"collector\":\"%s\"}]}", ipi, ipi, ipi)
+	dates := fmt.Sprintf("{\"$or\":[{\"exploitationDate\":{\"$gte\":\"%s\",\"$lt\":\"%s\"}},{\"exploitationDate\":{\"$gte\":\"%s\",\"$lte\":\"%s\"}},{\"exploitationDate\":{\"$regex\":\"%s\"}}]}", isoStart, isoEnd, compactStart, compactEnd, nonPaddedDatePattern)
+	return fmt.Sprintf("{\"selector\":{\"docType\":\"%s\",\"$and\":[%s,%s]}}", ROYALTYSTATEMENT, parties, dates)
+}
+
+// nonPaddedDatePattern - exploitation dates without a zero padded month or day, which do not sort as strings
+const nonPaddedDatePattern = "^[0-9]{4}-([0-9]-[0-9]{1,2}|[0-9]{2}-[0-9])$"
+
+//filterPayeeRoyaltyStatements - keeps the queried royalty statements payable to the IPI with an exploitation date
+//within the period, fills in the amounts the caller can read and sorts them by royalty statement UUID
+func filterPayeeRoyaltyStatements(stub shim.ChaincodeStubInterface, ipi string, startDate time.Time, endDate time.Time, queryResult []string) ([]RoyaltyStatement, error) {
+	var methodName = "filterPayeeRoyaltyStatements"
+
+	queriedRoyaltyStatements := []RoyaltyStatement{}
+	err := sliceToStruct(queryResult, &queriedRoyaltyStatements)
+	if err != nil {
+		return nil, err
+	}
+
+	royaltyStatements := []RoyaltyStatement{}
+	for _, royaltyStatement := range queriedRoyaltyStatements {
+		if royaltyStatement.DocType != ROYALTYSTATEMENT {
+			continue
+		}
+		_, payee, _ := getRoyaltyStatementParties(royaltyStatement)
+		if payee != ipi {
+			continue
+		}
+		exploitationDate, err := parseDate(royaltyStatement.ExploitationDate, false)
+		if err != nil {
+			logger.Errorf("%s - skipping royalty statement '%s': %s", methodName, royaltyStatement.RoyaltyStatementUUID, err.Error())
+			continue
+		}
+		if exploitationDate.Before(startDate) || exploitationDate.After(endDate) {
+			continue
+		}
+		royaltyStatements = append(royaltyStatements, royaltyStatement)
+	}
+	// the amounts of the statements between orgs are only filled for the orgs of the statements
+	mergeAuthorizedPrivateDetails(stub, royaltyStatements)
+
+	sort.Slice(royaltyStatements, func(i, j int) bool {
+		return royaltyStatements[i].RoyaltyStatementUUID < royaltyStatements[j].RoyaltyStatementUUID
+	})
+	return royaltyStatements, nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"axispoint-cc/memstub"
+)
+
+var periodRoyaltyStatements_in = `[{"royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":200,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":"MISSING_AFFILIATE"},{"royaltyStatementUUID":"5bbbda3a-6335-4248-9d10-019a73f59dfc","exploitationReportUUID":"6874280d-2897-3321-b238-0b4dfa0aa516","source":"deezer-IPI","isrc":"QZAB11800002","songTitle":"affiliated song","writerName":"Homer, Ned","units":500,"exploitationDate":"20181115","amount":50.5,"rightType":"OWNERSHIP","territory":"AUS","usageType":"PERF","rightHolder":"Ned-IPI","administrator":"ACME-Music-Corp-IPI","collector":"","state":""},{"royaltyStatementUUID":"7f384cbf-0d0d-3698-9714-841b8ecb73f9","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":500,"rightType":"COLLECTION","territory":"FRA","usageType":"MECH","rightHolder":"Homer-Simpson-IPI","administrator":"Swedish-Publishing-IPI","collector":"Ned-IPI","state":"","collectionRight":50,"collectionRightPercent":0.1},{"royaltyStatementUUID":"94c878c5-f754-3b04-b90e-4f01cbd54ad6","exploitationReportUUID":"8ab33826-399f-3707-a0af-dfedc3d3b7f3","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":100,"exploitationDate":"2019-01-02T00:00:00.000Z","amount":2,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":""}]`
+var periodRoyaltyStatementUpdate_in = `[{"royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":999,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":"MISSING_AFFILIATE"}]`
+
+func MockGetPeriodStatementResponse(functionName string) []byte {
+	switch functionName {
+	case "Test_CloseStatementPeriod_Totals":
+		return []byte(`{"UNSPECIFIED":{"amount":300.5,"ownershipAmount":250.5,"collectionAmount":50,"byIsrc":{"QZAB11800001":250,"QZAB11800002":50.5},"byTerritory":{"AUS":50.5,"FRA":250},"byUsageType":{"MECH":250,"PERF":50.5},"bySource":{"deezer-IPI":50.5,"spotify-IPI":250}}}`)
+	case "Test_CloseStatementPeriod_AlreadyClosed":
+		return []byte(`{"status":"500","message":"closeStatementPeriod - Period '2018-10-01' to '2018-12-31' is already closed for IPI 'Ned-IPI'."}`)
+	case "Test_CloseStatementPeriod_Unreadable":
+		return []byte(`{"status":"500","message":"closeStatementPeriod - Cannot close the period of IPI 'Ned-IPI', the caller cannot read the royalty statements '0daccfc9-9e3a-43f1-8e60-d0d0916a82e3'."}`)
+	case "Test_CloseStatementPeriod_Locked":
+		return []byte(`{"successCount":0,"failureCount":1,"royaltyStatements":[{"royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","message":"Royalty Statement is locked by period statement 'PERIODSTATEMENT_Ned-IPI_2018-10-01_2018-12-31'","success":false}]}`)
+	default:
+		return []byte("[]")
+	}
+}
+
+func closeNedStatementPeriod(t *testing.T) (*memstub.Stub, PeriodStatement) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+
+	// Init
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addRoyaltyStatements"), []byte(periodRoyaltyStatements_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("closeStatementPeriod"), []byte("Ned-IPI"), []byte("2018-10-01"), []byte("2018-12-31")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	periodStatement := PeriodStatement{}
+	err = jsonToObject(actual, &periodStatement)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	return stub, periodStatement
+}
+
+func Test_CloseStatementPeriod_Totals(t *testing.T) {
+	stub, periodStatement := closeNedStatementPeriod(t)
+
+	if periodStatement.PeriodStatementUUID != "PERIODSTATEMENT_Ned-IPI_2018-10-01_2018-12-31" || periodStatement.StatementCount != 3 {
+		t.Fatalf("Unexpected period statement: %+v", periodStatement)
+	}
+	expectedUUIDs := []string{"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3", "5bbbda3a-6335-4248-9d10-019a73f59dfc", "7f384cbf-0d0d-3698-9714-841b8ecb73f9"}
+	if !reflect.DeepEqual(expectedUUIDs, periodStatement.RoyaltyStatementUUIDs) {
+		t.Fatalf("Unexpected royalty statements in period: %v", periodStatement.RoyaltyStatementUUIDs)
+	}
+
+	actual, _ := objectToJSON(periodStatement.Totals)
+	expected := MockGetPeriodStatementResponse("Test_CloseStatementPeriod_Totals")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+
+	// the statement outside of the period is not linked
+	royaltyStatement := RoyaltyStatement{}
+	jsonToObject(stub.State["94c878c5-f754-3b04-b90e-4f01cbd54ad6"], &royaltyStatement)
+	if royaltyStatement.PeriodStatementUUID != "" {
+		t.Fatalf("Royalty statement outside of the period was linked to '%s'", royaltyStatement.PeriodStatementUUID)
+	}
+}
+
+func Test_CloseStatementPeriod_AlreadyClosed(t *testing.T) {
+	stub, _ := closeNedStatementPeriod(t)
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("closeStatementPeriod"), []byte("Ned-IPI"), []byte("2018-10-01"), []byte("2018-12-31")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected := MockGetPeriodStatementResponse("Test_CloseStatementPeriod_AlreadyClosed")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_CloseStatementPeriod_Locked(t *testing.T) {
+	stub, _ := closeNedStatementPeriod(t)
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("updateRoyaltyStatements"), []byte(periodRoyaltyStatementUpdate_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	expected := MockGetPeriodStatementResponse("Test_CloseStatementPeriod_Locked")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_CloseStatementPeriod_PrivateTotals(t *testing.T) {
+	defer func() { getCallerMspID = getCreatorMspID }()
+
+	for _, mockCaller := range []string{"Org2MSP", "Org3MSP"} {
+		scc := new(AxispointChaincode)
+		stub := memstub.NewStub("AxispointChaincode", scc)
+		checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+		// the statement of spotify to Ned is private to their orgs
+		_, err := checkInvoke(t, stub, [][]byte{[]byte("updateIpiOrg"), []byte(`{"ipi":"spotify-IPI","org":"Org1MSP"}`), []byte(`{"ipi":"Ned-IPI","org":"Org2MSP"}`)})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		_, err = checkInvokeTransient(t, stub, [][]byte{[]byte("addRoyaltyStatements")}, getRoyaltyStatementsTransient(periodRoyaltyStatements_in))
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		getCallerMspID = MockGetCallerOrg2MSP
+		if mockCaller == "Org3MSP" {
+			getCallerMspID = MockGetCallerOrg3MSP
+		}
+		actual, err := checkInvoke(t, stub, [][]byte{[]byte("closeStatementPeriod"), []byte("Ned-IPI"), []byte("2018-10-01"), []byte("2018-12-31")})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if mockCaller == "Org3MSP" {
+			// an other org cannot close the period without the private statement
+			if !reflect.DeepEqual(MockGetPeriodStatementResponse("Test_CloseStatementPeriod_Unreadable"), actual) {
+				t.Fatalf("Unexpected response for %s: %s", mockCaller, actual)
+			}
+			if stub.State["PERIODSTATEMENT_Ned-IPI_2018-10-01_2018-12-31"] != nil {
+				t.Fatalf("Period was closed by a non member org")
+			}
+			continue
+		}
+		periodStatement := PeriodStatement{}
+		err = jsonToObject(actual, &periodStatement)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		// the recorded period statement only has the totals of the public statements
+		if periodStatement.Totals[UNSPECIFIED_CURRENCY].Amount != 100.5 || periodStatement.Totals[UNSPECIFIED_CURRENCY].ByIsrc["QZAB11800001"] != 50 {
+			t.Fatalf("Unexpected public totals for %s: %+v", mockCaller, periodStatement.Totals)
+		}
+
+		actual, err = checkInvoke(t, stub, [][]byte{[]byte("getPeriodStatement"), []byte(periodStatement.PeriodStatementUUID)})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		err = jsonToObject(actual, &periodStatement)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		totals, _ := objectToJSON(periodStatement.Totals)
+
+		// an org of the statement closes it with the others and reads the totals of the collection
+		if periodStatement.StatementCount != 3 || !reflect.DeepEqual(MockGetPeriodStatementResponse("Test_CloseStatementPeriod_Totals"), totals) {
+			t.Fatalf("Unexpected period statement for %s: %+v", mockCaller, periodStatement)
+		}
+		if stub.PvtState["royalties_Org1MSP_Org2MSP"][periodStatement.PeriodStatementUUID] == nil {
+			t.Fatalf("Expected the private totals in the collection of the orgs")
+		}
+	}
+}
+
+func Test_GetPayeeRoyaltyStatementsQueryString_Dates(t *testing.T) {
+	startDate, endDate, err := parsePeriod("2018-10-01", "2018-12-31")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	query := map[string]interface{}{}
+	err = jsonToObject([]byte(getPayeeRoyaltyStatementsQueryString("Ned-IPI", startDate, endDate)), &query)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	selector := query["selector"].(map[string]interface{})
+
+	// the selector keeps every date of the period whatever its format, the non padded dates are all left to the exact check
+	dates := map[string]bool{
+		"2018-10-01":                true,
+		"2018-12-31T23:00:00Z":      true,
+		"2019-01-01T01:00:00+02:00": true,
+		"20181115":                  true,
+		"2018-11-5":                 true,
+		"2018-9-30":                 true,
+		"2018-09-15":                false,
+		"2019-01-02T00:00:00.000Z":  false,
+		"20190101":                  false,
+	}
+	for date, expected := range dates {
+		document := map[string]interface{}{"docType": ROYALTYSTATEMENT, "rightHolder": "Ned-IPI", "exploitationDate": date}
+		actual, err := memstub.Match(selector, document)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if actual != expected {
+			t.Fatalf("Exploitation date '%s' matches %t, expected %t", date, actual, expected)
+		}
+	}
+}
@@ -0,0 +1,462 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/lib/cid"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+/*
+* The amounts of a royalty statement are only shared by the orgs of its payer and payee. When both parties are
+* mapped to an org, the statement is split into a public skeleton written with PutState, holding the identifiers,
+* the parties and the hash of the private details, and the private details written to the collection of the two
+* orgs. Collections are named royalties_<org>_<org> with the org MSP IDs sorted, e.g. royalties_Org1MSP_Org2MSP,
+* and must be defined in the collection config of the chaincode.
+*
+* The arguments of a transaction are recorded on the ledger, so royalty statements between orgs are passed in the
+* transient map under "royaltyStatements", together with a random "salt" of at least 16 bytes. The balance deltas
+* and the period totals derived from private amounts are kept in the collection of the statement as well.
+ */
+
+var getCallerMspID = getCreatorMspID
+
+//getCreatorMspID - returns the MSP ID of the org that submitted the transaction
+func getCreatorMspID(stub shim.ChaincodeStubInterface) (string, error) {
+	return cid.GetMSPID(stub)
+}
+
+//getRoyaltyCollectionName - returns the name of the private data collection shared by orgs
+func getRoyaltyCollectionName(orgs []string) string {
+	return strings.Join(append([]string{ROYALTY_COLLECTION_PREFIX}, orgs...), "_")
+}
+
+//getRoyaltyStatementPrivateOrgs - returns the sorted orgs of the payer and payee of a royalty statement or nil when one of them is not mapped
+func getRoyaltyStatementPrivateOrgs(stub shim.ChaincodeStubInterface, royaltyStatement RoyaltyStatement) ([]string, error) {
+	payer, payee, _ := getRoyaltyStatementParties(royaltyStatement)
+	if payer == "" || payee == "" {
+		return nil, nil
+	}
+	payerOrg, err := getOrgForIpiOnDate(stub, payer, royaltyStatement.ExploitationDate)
+	if err != nil {
+		return nil, err
+	}
+	payeeOrg, err := getOrgForIpiOnDate(stub, payee, royaltyStatement.ExploitationDate)
+	if err != nil {
+		return nil, err
+	}
+	if payerOrg == "" || payeeOrg == "" {
+		return nil, nil
+	}
+	if payerOrg == payeeOrg {
+		return []string{payerOrg}, nil
+	}
+	orgs := []string{payerOrg, payeeOrg}
+	sort.Strings(orgs)
+	return orgs, nil
+}
+
+//getPrivateDataHash - returns the hex encoded SHA-256 hash of private details
+func getPrivateDataHash(privateDetailsBytes []byte) string {
+	hash := sha256.Sum256(privateDetailsBytes)
+	return hex.EncodeToString(hash[:])
+}
+
+/*
+* getPrivateDataSalt function returns the salt of the private details of a royalty statement. The salt is derived
+* from the random salt passed in the transient map, which is not recorded on the ledger, and from the royalty
+* statement UUID, so that sharing the details of one statement does not reveal the salt of the others. Details
+* rewritten without a transient salt keep the salt they were written with.
+*
+* @param    {string} - private data collection of the royalty statement
+* @param    {string} - royalty statement UUID
+* @return   {string} - hex encoded salt
+* @return   {error}  - Error
+ */
+func getPrivateDataSalt(stub shim.ChaincodeStubInterface, collection string, royaltyStatementUUID string) (string, error) {
+	transientMap, err := stub.GetTransient()
+	if err != nil {
+		return "", err
+	}
+	if salt, ok := transientMap[TRANSIENT_SALT]; ok {
+		if len(salt) < MIN_SALT_LENGTH {
+			return "", fmt.Errorf("The transient '%s' must be at least %d random bytes", TRANSIENT_SALT, MIN_SALT_LENGTH)
+		}
+		return hashPrivateDataSalt(salt, royaltyStatementUUID), nil
+	}
+
+	privateDetailsBytes, err := stub.GetPrivateData(collection, royaltyStatementUUID)
+	if err != nil {
+		return "", err
+	}
+	if privateDetailsBytes != nil {
+		privateDetails := RoyaltyStatementPrivateDetails{}
+		err = jsonToObject(privateDetailsBytes, &privateDetails)
+		if err != nil {
+			return "", err
+		}
+		if privateDetails.Salt != "" {
+			return privateDetails.Salt, nil
+		}
+	}
+	return "", fmt.Errorf("A random '%s' is required in the transient map to write the private details of royalty statement '%s'", TRANSIENT_SALT, royaltyStatementUUID)
+}
+
+//hashPrivateDataSalt - returns the hex encoded SHA-256 hash of a random salt and a royalty statement UUID
+func hashPrivateDataSalt(salt []byte, royaltyStatementUUID string) string {
+	hash := sha256.Sum256(append(append([]byte{}, salt...), royaltyStatementUUID...))
+	return hex.EncodeToString(hash[:])
+}
+
+//checkRoyaltyStatementWrite - checks that putRoyaltyStatement can record a royalty statement, so that a statement
+//is rejected before any of its writes
+func checkRoyaltyStatementWrite(stub shim.ChaincodeStubInterface, royaltyStatement RoyaltyStatement) error {
+	err := checkCollectedStatement(stub, royaltyStatement)
+	if err != nil {
+		return err
+	}
+	collection, err := getRoyaltyStatementCollection(stub, royaltyStatement)
+	if err != nil || collection == "" {
+		return err
+	}
+	_, err = getPrivateDataSalt(stub, collection, royaltyStatement.RoyaltyStatementUUID)
+	return err
+}
+
+/*
+* putRoyaltyStatement function records a royalty statement on the ledger. The amounts of a statement whose payer
+* and payee are mapped to orgs are written to the private data collection of the orgs and only their hash is public.
+*
+* @param    {RoyaltyStatement} - royalty statement with its amounts
+* @return   {error}            - Error
+ */
+func putRoyaltyStatement(stub shim.ChaincodeStubInterface, royaltyStatement RoyaltyStatement) error {
+	// a statement is collected once with each collection right, whether its chain is resolved or generated hop by hop
+	err := putCollectedStatement(stub, royaltyStatement)
+	if err != nil {
+		return err
+	}
+	orgs, err := getRoyaltyStatementPrivateOrgs(stub, royaltyStatement)
+	if err != nil {
+		return err
+	}
+
+	royaltyStatement.PrivateCollection = ""
+	royaltyStatement.PrivateOrgs = nil
+	royaltyStatement.PrivateDataHash = ""
+	if orgs != nil {
+		privateDetails := RoyaltyStatementPrivateDetails{}
+		privateDetails.DocType = ROYALTYPRIVATEDETAILS
+		privateDetails.RoyaltyStatementUUID = royaltyStatement.RoyaltyStatementUUID
+		privateDetails.Amount = royaltyStatement.Amount
+		privateDetails.CollectionRight = royaltyStatement.CollectionRight
+		privateDetails.CollectionRightPercent = royaltyStatement.CollectionRightPercent
+		privateDetails.GrossAmount = royaltyStatement.GrossAmount
+		privateDetails.FeeAmount = royaltyStatement.FeeAmount
+		privateDetails.NetAmount = royaltyStatement.NetAmount
+		privateDetails.RecoupedAmount = royaltyStatement.RecoupedAmount
+		privateDetails.UnrecoupedBalance = royaltyStatement.UnrecoupedBalance
+		privateDetails.TaxableAmount = royaltyStatement.TaxableAmount
+		privateDetails.TaxWithheld = royaltyStatement.TaxWithheld
+		privateDetails.NetPayable = royaltyStatement.NetPayable
+		royaltyStatement.PrivateCollection = getRoyaltyCollectionName(orgs)
+		royaltyStatement.PrivateOrgs = orgs
+
+		// the salt keeps the small range of amounts from being guessed from the public hash
+		privateDetails.Salt, err = getPrivateDataSalt(stub, royaltyStatement.PrivateCollection, royaltyStatement.RoyaltyStatementUUID)
+		if err != nil {
+			return err
+		}
+		privateDetailsBytes, err := objectToJSON(privateDetails)
+		if err != nil {
+			return err
+		}
+		royaltyStatement.PrivateDataHash = getPrivateDataHash(privateDetailsBytes)
+		err = stub.PutPrivateData(royaltyStatement.PrivateCollection, royaltyStatement.RoyaltyStatementUUID, privateDetailsBytes)
+		if err != nil {
+			return err
+		}
+		err = putPrivateCollection(stub, royaltyStatement.PrivateCollection, orgs)
+		if err != nil {
+			return err
+		}
+
+		// the public skeleton does not carry the amounts
+		royaltyStatement.Amount = 0
+		royaltyStatement.CollectionRight = 0
+		royaltyStatement.CollectionRightPercent = 0
+		royaltyStatement.GrossAmount = 0
+		royaltyStatement.FeeAmount = 0
+		royaltyStatement.NetAmount = 0
+		royaltyStatement.RecoupedAmount = 0
+		royaltyStatement.UnrecoupedBalance = 0
+		royaltyStatement.TaxableAmount = 0
+		royaltyStatement.TaxWithheld = 0
+		royaltyStatement.NetPayable = 0
+	}
+
+	royaltyStatementBytes, err := objectToJSON(royaltyStatement)
+	if err != nil {
+		return err
+	}
+	return stub.PutState(royaltyStatement.RoyaltyStatementUUID, royaltyStatementBytes)
+}
+
+//getTransientRoyaltyStatements - returns the royalty statements passed in the transient map or nil when they are passed as arguments
+func getTransientRoyaltyStatements(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	transientMap, err := stub.GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	return transientMap[TRANSIENT_ROYALTY_STATEMENTS], nil
+}
+
+//checkPublicRoyaltyStatement - rejects a royalty statement passed as arguments when its amounts are private to orgs, as the arguments are recorded on the ledger
+func checkPublicRoyaltyStatement(stub shim.ChaincodeStubInterface, royaltyStatement RoyaltyStatement) error {
+	orgs, err := getRoyaltyStatementPrivateOrgs(stub, royaltyStatement)
+	if err != nil {
+		return err
+	}
+	if orgs != nil {
+		return fmt.Errorf("The amounts of royalty statement '%s' are private to %s and must be passed in the transient map as '%s'", royaltyStatement.RoyaltyStatementUUID, strings.Join(orgs, ", "), TRANSIENT_ROYALTY_STATEMENTS)
+	}
+	return nil
+}
+
+/*
+* putPrivateCollection function registers a private data collection on the ledger so that the functions reading
+* the private data of the collections of the caller can find it. The registration does not carry any amount and
+* is written without being read, so that concurrent transactions registering the same collection do not conflict.
+*
+* @param    {string} - private data collection
+* @param    {Array}  - sorted orgs of the collection
+* @return   {error}  - Error
+ */
+func putPrivateCollection(stub shim.ChaincodeStubInterface, collection string, orgs []string) error {
+	privateCollectionKey, err := stub.CreateCompositeKey(PRIVATECOLLECTION, []string{collection})
+	if err != nil {
+		return err
+	}
+	privateCollectionBytes, err := objectToJSON(PrivateCollection{DocType: PRIVATECOLLECTION, PrivateCollection: collection, PrivateOrgs: orgs})
+	if err != nil {
+		return err
+	}
+	return stub.PutState(privateCollectionKey, privateCollectionBytes)
+}
+
+//getCallerPrivateCollections - returns the registered private data collections of the org that submitted the transaction
+func getCallerPrivateCollections(stub shim.ChaincodeStubInterface) ([]string, error) {
+	mspID, err := getCallerMspID(stub)
+	if err != nil {
+		// a caller that cannot be identified only reads the public state
+		logger.Errorf("Failed to get the MSP ID of the caller: %s", err.Error())
+		return []string{}, nil
+	}
+
+	privateCollectionIterator, err := stub.GetStateByPartialCompositeKey(PRIVATECOLLECTION, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer privateCollectionIterator.Close()
+
+	collections := []string{}
+	for privateCollectionIterator.HasNext() {
+		privateCollectionKV, err := privateCollectionIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		privateCollection := PrivateCollection{}
+		err = jsonToObject(privateCollectionKV.Value, &privateCollection)
+		if err != nil {
+			return nil, err
+		}
+		for _, org := range privateCollection.PrivateOrgs {
+			if org == mspID {
+				collections = append(collections, privateCollection.PrivateCollection)
+				break
+			}
+		}
+	}
+	return collections, nil
+}
+
+//getLedgerData - reads a key from the public state, for an empty collection, or from a private data collection
+func getLedgerData(stub shim.ChaincodeStubInterface, collection string, key string) ([]byte, error) {
+	if collection == "" {
+		return stub.GetState(key)
+	}
+	return stub.GetPrivateData(collection, key)
+}
+
+//putLedgerData - writes a key to the public state, for an empty collection, or to a private data collection
+func putLedgerData(stub shim.ChaincodeStubInterface, collection string, key string, value []byte) error {
+	if collection == "" {
+		return stub.PutState(key, value)
+	}
+	return stub.PutPrivateData(collection, key, value)
+}
+
+//delLedgerData - deletes a key from the public state, for an empty collection, or from a private data collection
+func delLedgerData(stub shim.ChaincodeStubInterface, collection string, key string) error {
+	if collection == "" {
+		return stub.DelState(key)
+	}
+	return stub.DelPrivateData(collection, key)
+}
+
+//getLedgerDataByPartialCompositeKey - returns the keys of the public state, for an empty collection, or of a private data collection with a composite key prefix
+func getLedgerDataByPartialCompositeKey(stub shim.ChaincodeStubInterface, collection string, objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+	if collection == "" {
+		return stub.GetStateByPartialCompositeKey(objectType, attributes)
+	}
+	return stub.GetPrivateDataByPartialCompositeKey(collection, objectType, attributes)
+}
+
+//getRoyaltyStatementCollection - returns the private data collection a royalty statement is written to or an empty string for a public statement
+func getRoyaltyStatementCollection(stub shim.ChaincodeStubInterface, royaltyStatement RoyaltyStatement) (string, error) {
+	orgs, err := getRoyaltyStatementPrivateOrgs(stub, royaltyStatement)
+	if err != nil || orgs == nil {
+		return "", err
+	}
+	return getRoyaltyCollectionName(orgs), nil
+}
+
+//getRoyaltyStatementPrivateDetails - returns the private details of a royalty statement as stored in its collection
+func getRoyaltyStatementPrivateDetails(stub shim.ChaincodeStubInterface, royaltyStatement RoyaltyStatement) ([]byte, error) {
+	privateDetailsBytes, err := stub.GetPrivateData(royaltyStatement.PrivateCollection, royaltyStatement.RoyaltyStatementUUID)
+	if err != nil {
+		return nil, err
+	}
+	if privateDetailsBytes == nil {
+		return nil, fmt.Errorf("Private details of royalty statement '%s' are not available", royaltyStatement.RoyaltyStatementUUID)
+	}
+	return privateDetailsBytes, nil
+}
+
+//mergeRoyaltyStatementPrivateDetails - fills the amounts of a royalty statement skeleton from its private details
+func mergeRoyaltyStatementPrivateDetails(stub shim.ChaincodeStubInterface, royaltyStatement *RoyaltyStatement) error {
+	if royaltyStatement.PrivateCollection == "" {
+		return nil
+	}
+	privateDetailsBytes, err := getRoyaltyStatementPrivateDetails(stub, *royaltyStatement)
+	if err != nil {
+		return err
+	}
+	privateDetails := RoyaltyStatementPrivateDetails{}
+	err = jsonToObject(privateDetailsBytes, &privateDetails)
+	if err != nil {
+		return err
+	}
+	royaltyStatement.Amount = privateDetails.Amount
+	royaltyStatement.CollectionRight = privateDetails.CollectionRight
+	royaltyStatement.CollectionRightPercent = privateDetails.CollectionRightPercent
+	royaltyStatement.GrossAmount = privateDetails.GrossAmount
+	royaltyStatement.FeeAmount = privateDetails.FeeAmount
+	royaltyStatement.NetAmount = privateDetails.NetAmount
+	royaltyStatement.RecoupedAmount = privateDetails.RecoupedAmount
+	royaltyStatement.UnrecoupedBalance = privateDetails.UnrecoupedBalance
+	royaltyStatement.TaxableAmount = privateDetails.TaxableAmount
+	royaltyStatement.TaxWithheld = privateDetails.TaxWithheld
+	royaltyStatement.NetPayable = privateDetails.NetPayable
+	return nil
+}
+
+//isPrivateOrg - returns whether the org that submitted the transaction shares the private details of a royalty statement
+func isPrivateOrg(stub shim.ChaincodeStubInterface, royaltyStatement RoyaltyStatement) bool {
+	mspID, err := getCallerMspID(stub)
+	if err != nil {
+		logger.Errorf("Failed to get the MSP ID of the caller: %s", err.Error())
+		return false
+	}
+	for _, org := range royaltyStatement.PrivateOrgs {
+		if org == mspID {
+			return true
+		}
+	}
+	return false
+}
+
+//mergeAuthorizedPrivateDetails - fills the amounts of the royalty statements the caller is authorized to see
+func mergeAuthorizedPrivateDetails(stub shim.ChaincodeStubInterface, royaltyStatements []RoyaltyStatement) {
+	for i := range royaltyStatements {
+		if royaltyStatements[i].PrivateCollection == "" || !isPrivateOrg(stub, royaltyStatements[i]) {
+			continue
+		}
+		err := mergeRoyaltyStatementPrivateDetails(stub, &royaltyStatements[i])
+		if err != nil {
+			logger.Errorf("Failed to read the private details of royalty statement '%s': %s", royaltyStatements[i].RoyaltyStatementUUID, err.Error())
+		}
+	}
+}
+
+/*
+* verifyRoyaltyStatementPrivateData function checks private details against the hash recorded on the public
+* royalty statement. The details are either passed by the caller, so that any org can check details shared
+* with it off chain, or read from the collection when the caller is one of the orgs of the statement.
+*
+* @params   {Array} args
+* @property {string} 0       - royalty statement UUID
+* @property {string} 1       - optional stringified JSON private details
+* @return   {pb.Response}    - peer Response
+ */
+func verifyRoyaltyStatementPrivateData(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "verifyRoyaltyStatementPrivateData"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) < 1 || len(args) > 2 {
+		return getErrorResponse(fmt.Sprintf("%s - Incorrect number of parameters provided '%d'. Needed royalty statement UUID and optional private details", methodName, len(args)))
+	}
+
+	royaltyStatementBytes, err := stub.GetState(args[0])
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	if royaltyStatementBytes == nil {
+		return getErrorResponse(fmt.Sprintf("UUID: %s does not exist", args[0]))
+	}
+	royaltyStatement := RoyaltyStatement{}
+	err = jsonToObject(royaltyStatementBytes, &royaltyStatement)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	if royaltyStatement.PrivateCollection == "" {
+		return getErrorResponse(fmt.Sprintf("%s - Royalty statement '%s' has no private details.", methodName, args[0]))
+	}
+
+	var privateDetailsBytes []byte
+	if len(args) == 2 {
+		// hash the details in the form they were written
+		privateDetails := RoyaltyStatementPrivateDetails{}
+		err = jsonToObject([]byte(args[1]), &privateDetails)
+		if err != nil {
+			return getErrorResponse(err.Error())
+		}
+		privateDetailsBytes, err = objectToJSON(privateDetails)
+	} else if isPrivateOrg(stub, royaltyStatement) {
+		privateDetailsBytes, err = getRoyaltyStatementPrivateDetails(stub, royaltyStatement)
+	} else {
+		return getErrorResponse(fmt.Sprintf("%s - Caller is not authorized to read the private details of royalty statement '%s'.", methodName, args[0]))
+	}
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	privateDataVerification := PrivateDataVerification{}
+	privateDataVerification.RoyaltyStatementUUID = royaltyStatement.RoyaltyStatementUUID
+	privateDataVerification.PrivateCollection = royaltyStatement.PrivateCollection
+	privateDataVerification.PrivateDataHash = royaltyStatement.PrivateDataHash
+	privateDataVerification.ComputedHash = getPrivateDataHash(privateDetailsBytes)
+	privateDataVerification.Verified = privateDataVerification.ComputedHash == privateDataVerification.PrivateDataHash
+
+	objBytes, err := objectToJSON(privateDataVerification)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	logger.Info("EXITING <", methodName, privateDataVerification.Verified)
+	return shim.Success(objBytes)
+}
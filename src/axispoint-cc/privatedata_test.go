@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"axispoint-cc/memstub"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// *****************************************************************************
+// ******************************* Mock Data ***********************************
+// *****************************************************************************
+
+var privateRoyaltyStatement_in = `[{"royaltyStatementUUID":"rs1","exploitationReportUUID":"er1","source":"spotify-IPI","isrc":"QZAB11800001","units":10,"exploitationDate":"2018-12-30","amount":100,"rightType":"OWNERSHIP","rightHolder":"Ned-IPI","currency":"EUR"}]`
+var privateRoyaltyStatement_out = `{"docType":"ROYALTYSTATEMENT","royaltyStatementUUID":"rs1","exploitationReportUUID":"er1","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"","writerName":"","units":10,"exploitationDate":"2018-12-30","amount":0,"rightType":"OWNERSHIP","territory":"","usageType":"","rightHolder":"Ned-IPI","administrator":"","collector":"","state":"","currency":"EUR","privateCollection":"royalties_Org1MSP_Org2MSP","privateOrgs":["Org1MSP","Org2MSP"],"privateDataHash":"%s"}`
+var privateRoyaltyDetails_out = `{"docType":"ROYALTYPRIVATEDETAILS","royaltyStatementUUID":"rs1","amount":100,"collectionRight":0,"collectionRightPercent":0,"netPayable":100,"salt":"` + hashPrivateDataSalt(testSalt, "rs1") + `"}`
+
+// *****************************************************************************
+func MockGetPrivateDataResponse(functionName string) []byte {
+	switch functionName {
+	case "Test_VerifyRoyaltyStatementPrivateData":
+		return []byte(`{"royaltyStatementUUID":"rs1","privateCollection":"royalties_Org1MSP_Org2MSP","privateDataHash":"` + getPrivateDataHash([]byte(privateRoyaltyDetails_out)) + `","computedHash":"` + getPrivateDataHash([]byte(privateRoyaltyDetails_out)) + `","verified":true}`)
+	case "Test_VerifyRoyaltyStatementPrivateData_Unauthorized":
+		return []byte(`{"status":"500","message":"verifyRoyaltyStatementPrivateData - Caller is not authorized to read the private details of royalty statement 'rs1'."}`)
+	default:
+		return []byte("[]")
+	}
+}
+
+func MockGetCallerOrg2MSP(stub shim.ChaincodeStubInterface) (string, error) {
+	return "Org2MSP", nil
+}
+
+func MockGetCallerOrg3MSP(stub shim.ChaincodeStubInterface) (string, error) {
+	return "Org3MSP", nil
+}
+
+// setupPrivateRoyaltyStatement - maps the DSP and the right holder to two orgs and adds a royalty statement between them
+func setupPrivateRoyaltyStatement(t *testing.T, stub *memstub.Stub) {
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+	for _, ipiOrg := range []string{`{"ipi":"spotify-IPI","org":"Org1MSP"}`, `{"ipi":"Ned-IPI","org":"Org2MSP"}`} {
+		_, err := checkInvoke(t, stub, [][]byte{[]byte("addIpiOrg"), []byte(ipiOrg)})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+	_, err := checkInvokeTransient(t, stub, [][]byte{[]byte("addRoyaltyStatements")}, getRoyaltyStatementsTransient(privateRoyaltyStatement_in))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+}
+
+// *****************************************************************************
+
+func Test_AddRoyaltyStatements_PrivateData(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	setupPrivateRoyaltyStatement(t, stub)
+
+	// the public skeleton only has the hash of the amounts
+	checkState(t, stub, "rs1", fmt.Sprintf(privateRoyaltyStatement_out, getPrivateDataHash([]byte(privateRoyaltyDetails_out))))
+	privateDetails := stub.PvtState["royalties_Org1MSP_Org2MSP"]["rs1"]
+	if string(privateDetails) != privateRoyaltyDetails_out {
+		t.Fatalf("Unexpected private details: %s", privateDetails)
+	}
+
+	// balances are kept from the private amounts
+	getCallerMspID = MockGetCallerOrg2MSP
+	defer func() { getCallerMspID = getCreatorMspID }()
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getBalances"), []byte(`{"ipi":"Ned-IPI"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := []byte(`[{"level":"IPI","party":"Ned-IPI","counterparty":"spotify-IPI","currency":"EUR","payable":0,"receivable":100,"net":100}]`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+}
+
+func Test_AddRoyaltyStatements_PrivateArguments(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	setupPrivateRoyaltyStatement(t, stub)
+
+	// the arguments of a transaction are recorded on the ledger, private amounts are rejected there
+	privateRoyaltyStatement := strings.Replace(privateRoyaltyStatement_in, `"rs1"`, `"rs2"`, 1)
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("addRoyaltyStatements"), []byte(privateRoyaltyStatement)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := []byte(`{"successCount":0,"failureCount":1,"royaltyStatements":[{"royaltyStatementUUID":"rs2","message":"The amounts of royalty statement 'rs2' are private to Org1MSP, Org2MSP and must be passed in the transient map as 'royaltyStatements'","success":false}]}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+
+	// and the transient map carries a random salt
+	actual, err = checkInvokeTransient(t, stub, [][]byte{[]byte("addRoyaltyStatements")}, map[string][]byte{TRANSIENT_ROYALTY_STATEMENTS: []byte(privateRoyaltyStatement)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected = []byte(`{"successCount":0,"failureCount":1,"royaltyStatements":[{"royaltyStatementUUID":"rs2","message":"A random 'salt' is required in the transient map to write the private details of royalty statement 'rs2'","success":false}]}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+	if stub.State["rs2"] != nil {
+		t.Fatalf("Royalty statement without a salt was recorded")
+	}
+
+	// a rejected collection statement does not leave its parent collected
+	collectionStatement := `[{"royaltyStatementUUID":"rs1-1","parentStatementUUID":"rs1","collectionRightUUID":"cr1","source":"spotify-IPI","isrc":"QZAB11800001","exploitationDate":"2018-12-30","amount":100,"rightType":"COLLECTION","rightHolder":"Ned-IPI","administrator":"spotify-IPI","collectionRight":10}]`
+	actual, err = checkInvokeTransient(t, stub, [][]byte{[]byte("addRoyaltyStatements")}, map[string][]byte{TRANSIENT_ROYALTY_STATEMENTS: []byte(collectionStatement)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected = []byte(`{"successCount":0,"failureCount":1,"royaltyStatements":[{"royaltyStatementUUID":"rs1-1","message":"A random 'salt' is required in the transient map to write the private details of royalty statement 'rs1-1'","success":false}]}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+	collectedStatementKey, _ := stub.CreateCompositeKey(COLLECTEDSTATEMENT, []string{"rs1", "cr1"})
+	if stub.State["rs1-1"] != nil || stub.State[collectedStatementKey] != nil {
+		t.Fatalf("Rejected collection statement was partly recorded")
+	}
+}
+
+func Test_GetRoyaltyStatements_PrivateData(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	setupPrivateRoyaltyStatement(t, stub)
+
+	defer func() {
+		getCallerMspID = getCreatorMspID
+	}()
+
+	// an org of the statement reads the amounts, others only the skeleton
+	for mockCaller, amount := range map[string]float64{"Org2MSP": 100, "Org3MSP": 0} {
+		getCallerMspID = MockGetCallerOrg2MSP
+		if mockCaller == "Org3MSP" {
+			getCallerMspID = MockGetCallerOrg3MSP
+		}
+		actual, err := checkInvoke(t, stub, [][]byte{[]byte("getRoyaltyStatements"), []byte(`{"selector":{"docType":"ROYALTYSTATEMENT"}}`)})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		royaltyStatements := []RoyaltyStatement{}
+		err = jsonToObject(actual, &royaltyStatements)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if len(royaltyStatements) != 1 || royaltyStatements[0].Amount != amount {
+			t.Fatalf("Expected amount %v for %s, got %s", amount, mockCaller, actual)
+		}
+	}
+}
+
+func Test_VerifyRoyaltyStatementPrivateData(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	setupPrivateRoyaltyStatement(t, stub)
+
+	getCallerMspID = MockGetCallerOrg2MSP
+	defer func() { getCallerMspID = getCreatorMspID }()
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("verifyRoyaltyStatementPrivateData"), []byte("rs1")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := MockGetPrivateDataResponse("Test_VerifyRoyaltyStatementPrivateData")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+
+	// details shared off chain are checked against the public hash
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("verifyRoyaltyStatementPrivateData"), []byte("rs1"), []byte(`{"docType":"ROYALTYPRIVATEDETAILS","royaltyStatementUUID":"rs1","amount":99,"collectionRight":0,"collectionRightPercent":0,"netPayable":100,"salt":"1"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	privateDataVerification := PrivateDataVerification{}
+	err = jsonToObject(actual, &privateDataVerification)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if privateDataVerification.Verified {
+		t.Fatalf("Expected tampered private details not to be verified")
+	}
+}
+
+func Test_VerifyRoyaltyStatementPrivateData_Unauthorized(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	setupPrivateRoyaltyStatement(t, stub)
+
+	getCallerMspID = MockGetCallerOrg3MSP
+	defer func() { getCallerMspID = getCreatorMspID }()
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("verifyRoyaltyStatementPrivateData"), []byte("rs1")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := MockGetPrivateDataResponse("Test_VerifyRoyaltyStatementPrivateData_Unauthorized")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
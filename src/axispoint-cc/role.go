@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+* The role of a right holder follows the CWR role codes: the writer designation codes of the SWR/OWR records
+* and the publisher type codes of the SPU/OPU records. The role is carried onto the royalty statements of the
+* right holder as rightHolderRole, so statements can be queried by role, e.g.
+* {"selector":{"docType":"ROYALTYSTATEMENT","rightHolderRole":"SE"}}, and selectors can test it as Role.
+ */
+
+// ///////////////////////////////////////////////////
+// CWR writer designation codes
+// ///////////////////////////////////////////////////
+const (
+	ROLE_ADAPTOR            string = "AD"
+	ROLE_ARRANGER           string = "AR"
+	ROLE_AUTHOR             string = "A"
+	ROLE_COMPOSER           string = "C"
+	ROLE_COMPOSER_AUTHOR    string = "CA"
+	ROLE_SUB_ARRANGER       string = "SR"
+	ROLE_SUB_AUTHOR         string = "SA"
+	ROLE_TRANSLATOR         string = "TR"
+	ROLE_INCOME_PARTICIPANT string = "PA"
+)
+
+// ///////////////////////////////////////////////////
+// CWR publisher type codes
+// ///////////////////////////////////////////////////
+const (
+	ROLE_ACQUIRER              string = "AQ"
+	ROLE_ADMINISTRATOR         string = "AM"
+	ROLE_ORIGINAL_PUBLISHER    string = "E"
+	ROLE_SUBSTITUTED_PUBLISHER string = "ES"
+	ROLE_SUB_PUBLISHER         string = "SE"
+)
+
+var rightHolderRoles = map[string]string{
+	ROLE_ADAPTOR:               "Adaptor",
+	ROLE_ARRANGER:              "Arranger",
+	ROLE_AUTHOR:                "Author, Writer, Author of Lyrics",
+	ROLE_COMPOSER:              "Composer, Writer",
+	ROLE_COMPOSER_AUTHOR:       "Composer/Author",
+	ROLE_SUB_ARRANGER:          "Sub Arranger",
+	ROLE_SUB_AUTHOR:            "Sub Author",
+	ROLE_TRANSLATOR:            "Translator",
+	ROLE_INCOME_PARTICIPANT:    "Income Participant",
+	ROLE_ACQUIRER:              "Acquirer",
+	ROLE_ADMINISTRATOR:         "Administrator",
+	ROLE_ORIGINAL_PUBLISHER:    "Original Publisher",
+	ROLE_SUBSTITUTED_PUBLISHER: "Substituted Publisher",
+	ROLE_SUB_PUBLISHER:         "Sub Publisher",
+}
+
+//normalizeRole - returns the upper case CWR role code or an error when it is unknown, no role is allowed
+func normalizeRole(role string) (string, error) {
+	normalizedRole := strings.ToUpper(strings.TrimSpace(role))
+	if normalizedRole == "" {
+		return "", nil
+	}
+	if _, ok := rightHolderRoles[normalizedRole]; !ok {
+		return "", fmt.Errorf("Unknown right holder role '%s', expected one of the CWR role codes %s", role, strings.Join(getRoleCodes(), ", "))
+	}
+	return normalizedRole, nil
+}
+
+//normalizeRightHolderRoles - normalizes the roles of right holders
+func normalizeRightHolderRoles(rightHolders []RightHolder) error {
+	for i := range rightHolders {
+		role, err := normalizeRole(rightHolders[i].Role)
+		if err != nil {
+			return err
+		}
+		rightHolders[i].Role = role
+	}
+	return nil
+}
+
+//getRoleCodes - returns the sorted CWR role codes
+func getRoleCodes() []string {
+	roles := []string{}
+	for role := range rightHolderRoles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"axispoint-cc/memstub"
+)
+
+var roleCopyrightDataReports = []string{
+	`{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"cdr1","isrc":"QZAB11800001","startDate":"2018-01-01","endDate":"2018-12-31","rightHolders":[` +
+		`{"selector":"","ipi":"WRITER-IPI","percent":50,"role":"CA"},` +
+		`{"selector":"Role == 'E' && !within(Territory, 'EU')","ipi":"PUB-IPI","percent":50,"role":"E"},` +
+		`{"selector":"Role == 'SE' && within(Territory, 'EU')","ipi":"SUBPUB-IPI","percent":50,"role":"SE"}]}`,
+}
+
+func Test_AddCopyrightDataReports_Roles(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("addCopyrightDataReports"), []byte(`[` +
+		`{"copyrightDataReportUUID":"cdr1","isrc":"QZAB11800001","rightHolders":[{"selector":"","ipi":"WRITER-IPI","percent":50,"role":"c"},{"selector":"","ipi":"PUB-IPI","percent":50,"role":" se "}]},` +
+		`{"copyrightDataReportUUID":"cdr2","isrc":"QZAB11800001","rightHolders":[{"selector":"","ipi":"WRITER-IPI","percent":100,"role":"LYRICIST"}]}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := []byte(`{"successCount":1,"failureCount":1,"copyrightDataReports":[{"copyrightDataReportUUID":"cdr2","message":"Unknown right holder role 'LYRICIST', expected one of the CWR role codes A, AD, AM, AQ, AR, C, CA, E, ES, PA, SA, SE, SR, TR","success":false}]}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+
+	copyrightDataReport := CopyrightDataReport{}
+	err = jsonToObject(stub.State["cdr1"], &copyrightDataReport)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actualRoles := []string{copyrightDataReport.RightHolders[0].Role, copyrightDataReport.RightHolders[1].Role}
+	if !reflect.DeepEqual([]string{ROLE_COMPOSER, ROLE_SUB_PUBLISHER}, actualRoles) {
+		t.Fatalf("Actual response is not equal to expected response: %v", actualRoles)
+	}
+}
+
+func Test_GenerateExploitationReports_Roles(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+	putDocuments(t, stub, "copyrightDataReportUUID", roleCopyrightDataReports)
+
+	// the sub-publisher collects the publisher share in the EU, the original publisher everywhere else
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("generateExploitationReports"), []byte(`[` +
+		`{"source":"spotify-IPI","isrc":"QZAB11800001","units":1,"exploitationDate":"2018-12-30","amount":10,"territory":"US","exploitationReportUUID":"er1"},` +
+		`{"source":"spotify-IPI","isrc":"QZAB11800001","units":1,"exploitationDate":"2018-12-30","amount":10,"territory":"FR","exploitationReportUUID":"er2"}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	output := struct {
+		RoyaltyStatements []RoyaltyStatement `json:"royaltyStatements"`
+	}{}
+	err = jsonToObject(actual, &output)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actualRoles := []string{}
+	for _, royaltyStatement := range output.RoyaltyStatements {
+		actualRoles = append(actualRoles, royaltyStatement.ExploitationReportUUID+" "+royaltyStatement.RightHolder+" "+royaltyStatement.RightHolderRole)
+	}
+	expectedRoles := []string{"er1 WRITER-IPI CA", "er1 PUB-IPI E", "er2 WRITER-IPI CA", "er2 SUBPUB-IPI SE"}
+	if !reflect.DeepEqual(expectedRoles, actualRoles) {
+		t.Fatalf("Actual response is not equal to expected response: %v", actualRoles)
+	}
+}
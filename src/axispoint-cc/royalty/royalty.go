@@ -0,0 +1,42 @@
+/*
+Package royalty books the amount of a royalty statement between its parties, the way the chaincode records it
+in the balances and the tools reading its statements report it.
+
+The source of an exploitation owes the owner share of an ownership statement to its right holder. The amount
+of a collection or fee statement is the collection right: the right holder owes it to its administrator, or the
+administrator owes it to the collector when the statement has one.
+*/
+package royalty
+
+// Right types of royalty statements
+const (
+	Ownership  = "OWNERSHIP"
+	Collection = "COLLECTION"
+	Fee        = "FEE"
+)
+
+// Statement - the fields of a royalty statement that decide who owes its amount to whom
+type Statement struct {
+	RightType       string
+	Source          string
+	RightHolder     string
+	Administrator   string
+	Collector       string
+	Amount          float64
+	CollectionRight float64
+}
+
+// Parties returns the payer, the payee and the amount of a royalty statement, no party when the statement books no amount
+func Parties(statement Statement) (string, string, float64) {
+	if len(statement.Collector) > 0 && len(statement.Administrator) > 0 {
+		// the administrator pays the collection right to the collector
+		return statement.Administrator, statement.Collector, statement.CollectionRight
+	} else if (statement.RightType == Collection || statement.RightType == Fee) && len(statement.RightHolder) > 0 && len(statement.Administrator) > 0 {
+		// the right holder pays the collection right to its administrator
+		return statement.RightHolder, statement.Administrator, statement.CollectionRight
+	} else if statement.RightType == Ownership {
+		// the source pays the owner share to the right holder
+		return statement.Source, statement.RightHolder, statement.Amount
+	}
+	return "", "", 0
+}
@@ -0,0 +1,25 @@
+package royalty
+
+import (
+	"testing"
+)
+
+func Test_Parties(t *testing.T) {
+	for _, test := range []struct {
+		statement Statement
+		payer     string
+		payee     string
+		amount    float64
+	}{
+		{Statement{RightType: Ownership, Source: "spotify-IPI", RightHolder: "Ned-IPI", Administrator: "ACME-IPI", Amount: 100, CollectionRight: 10}, "spotify-IPI", "Ned-IPI", 100},
+		{Statement{RightType: Collection, Source: "spotify-IPI", RightHolder: "Ned-IPI", Administrator: "ACME-IPI", Amount: 100, CollectionRight: 10}, "Ned-IPI", "ACME-IPI", 10},
+		{Statement{RightType: Fee, Source: "spotify-IPI", RightHolder: "Ned-IPI", Administrator: "ACME-IPI", Amount: 100, CollectionRight: 2}, "Ned-IPI", "ACME-IPI", 2},
+		{Statement{RightType: Collection, Source: "spotify-IPI", RightHolder: "Ned-IPI", Administrator: "ACME-IPI", Collector: "Sub-IPI", Amount: 100, CollectionRight: 5}, "ACME-IPI", "Sub-IPI", 5},
+		{Statement{RightType: Collection, Source: "spotify-IPI", RightHolder: "Ned-IPI", Amount: 100, CollectionRight: 5}, "", "", 0},
+	} {
+		payer, payee, amount := Parties(test.statement)
+		if payer != test.payer || payee != test.payee || amount != test.amount {
+			t.Fatalf("Actual parties of %+v are not equal to expected parties: %s %s %v", test.statement, payer, payee, amount)
+		}
+	}
+}
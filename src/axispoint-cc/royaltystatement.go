@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strings"
 
+	"axispoint-cc/identifier"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
@@ -46,7 +47,8 @@ var getExploitationReportForQueryString = getObjectByQueryFromLedger
 var getRoyaltyStatementsForQueryString = getObjectByQueryFromLedger
 
 /* addRoyaltyStatements function contains business logic to insert new
-Royalty Statements to the Ledger
+Royalty Statements to the Ledger. Royalty statements between orgs are passed in the transient map
+as "royaltyStatements" with a random "salt" instead of the arguments.
 * @params   {Array} args
 * @property {string} 0       - stringified JSON array of royalty statement.
 * @return   {pb.Response}    - peer Response
@@ -58,25 +60,35 @@ func addRoyaltyStatements(stub shim.ChaincodeStubInterface, args []string) pb.Re
 	var methodName = "addRoyaltyStatements"
 	logger.Info("ENTERING >", methodName, args)
 
-	//if this function is called with morethan 1 royalty statements
-	//only write if the IPI or the orgs are same for 2 or  more royalty statements
-	//otherwise the chaincode should not continue.
-	royaltyStatementsEventPayloadBytes := []byte{}
+	// the event groups the royalty statements of the transaction by target
+	royaltyStatementCreationEvent := RoyaltyStatementCreationEvent{Version: EventRoyaltyStatementCreationVersion, Targets: []RoyaltyStatementCreationEventTarget{}}
 
-	if len(args) != 1 {
-		return getErrorResponse("Missing arguments: Needed RoyaltyStatement object to Create")
+	// the royalty statements between orgs are passed in the transient map to keep their amounts off the ledger
+	royaltyStatementsBytes, err := getTransientRoyaltyStatements(stub)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	isTransient := royaltyStatementsBytes != nil
+	if !isTransient {
+		if len(args) != 1 {
+			return getErrorResponse("Missing arguments: Needed RoyaltyStatement object to Create")
+		}
+		royaltyStatementsBytes = []byte(args[0])
 	}
 
 	royaltyStatementOutput := RoyaltyStatementOutput{}
 	royaltyStatements := &[]RoyaltyStatement{}
 	royaltyStatementResponses := []RoyaltyStatementResponse{}
 
-	// Unmarshal the args input to an array of royalty statement records
-	err := jsonToObject([]byte(args[0]), royaltyStatements)
+	// Unmarshal the input to an array of royalty statement records
+	err = jsonToObject(royaltyStatementsBytes, royaltyStatements)
 	if err != nil {
 		return getErrorResponse(err.Error())
 	}
 
+	// the advances recouped by the statements of the transaction
+	pendingAdvances := map[string]Advance{}
+
 	// iterate over royalty statements
 	for _, royaltyStatement := range *royaltyStatements {
 		royaltyStatement.DocType = ROYALTYSTATEMENT
@@ -84,6 +96,19 @@ func addRoyaltyStatements(stub shim.ChaincodeStubInterface, args []string) pb.Re
 		royaltyStatementResponse.RoyaltyStatementUUID = royaltyStatement.RoyaltyStatementUUID
 		royaltyStatementResponse.Success = true
 
+		// reject the royalty statement when its identifiers are invalid or its private amounts were passed as arguments
+		err = normalizeRoyaltyStatementIdentifiers(&royaltyStatement)
+		if err == nil && !isTransient {
+			err = checkPublicRoyaltyStatement(stub, royaltyStatement)
+		}
+		if err != nil {
+			royaltyStatementResponse.Success = false
+			royaltyStatementResponse.Message = err.Error()
+			royaltyStatementResponses = append(royaltyStatementResponses, royaltyStatementResponse)
+			royaltyStatementOutput.FailureCount++
+			continue
+		}
+
 		// check if royalty statement already exists
 		royaltyStatementExistingBytes, err := stub.GetState(royaltyStatement.RoyaltyStatementUUID)
 		if royaltyStatementExistingBytes != nil {
@@ -94,8 +119,19 @@ func addRoyaltyStatements(stub shim.ChaincodeStubInterface, args []string) pb.Re
 			continue
 		}
 
-		// convert royalty statement to bytes
-		royaltyStatementBytes, err := objectToJSON(royaltyStatement)
+		// withhold the tax of the territory of the exploitation and the recoupable part of the statement
+		// for the advances of its payer to its payee
+		recoupments := []advanceRecoupment{}
+		err = checkRoyaltyStatementWrite(stub, royaltyStatement)
+		if err == nil {
+			err = checkCollectionFeeStatement(stub, royaltyStatement)
+		}
+		if err == nil {
+			err = applyWithholdingTax(stub, &royaltyStatement)
+		}
+		if err == nil {
+			recoupments, err = recoupRoyaltyStatement(stub, &royaltyStatement, pendingAdvances)
+		}
 		if err != nil {
 			royaltyStatementResponse.Success = false
 			royaltyStatementResponse.Message = err.Error()
@@ -104,71 +140,97 @@ func addRoyaltyStatements(stub shim.ChaincodeStubInterface, args []string) pb.Re
 			continue
 		}
 
-		// add royalty statement to the ledger
-		err = stub.PutState(royaltyStatement.RoyaltyStatementUUID, royaltyStatementBytes)
+		// add royalty statement to the ledger, its amounts are private to the orgs of its parties. The statement is
+		// checked, a failed write aborts the transaction instead of leaving the statement half recorded
+		err = putRoyaltyStatement(stub, royaltyStatement)
+		if err == nil {
+			// keep the running balances of the payer and the payee
+			err = updateRoyaltyStatementBalances(stub, nil, &royaltyStatement)
+		}
+		if err == nil {
+			err = putRecoupedAdvances(stub, royaltyStatement, recoupments, pendingAdvances)
+		}
+		if err == nil {
+			// the fee kept on a collection statement is its own statement to the collecting party
+			err = putCollectionFeeStatement(stub, royaltyStatement)
+		}
 		if err != nil {
-			royaltyStatementResponse.Success = false
-			royaltyStatementResponse.Message = err.Error()
-		} else {
-			//assumption: this method should be called with a single royalty statement for now.
-			//TODO: multiple royalty statements if ORG or IPI is the same.
-			payloadBytes, err := getRoyaltyStatementsEventPayload(stub, royaltyStatement)
+			return shim.Error(fmt.Sprintf("%s - Failed to record royalty statement '%s'.  Error: %s", methodName, royaltyStatement.RoyaltyStatementUUID, err.Error()))
+		}
+		if royaltyStatement.RightType == OWNERSHIP {
+			//fire an event for Ownership reports only
+			payload, err := getRoyaltyStatementsEventPayload(stub, royaltyStatement)
 			if err != nil {
-				return getErrorResponse(fmt.Sprintf("%s - Failed to construct '%s' payload.  Error: %s", methodName, EventRoyaltyStatementCreation, err.Error()))
+				return shim.Error(fmt.Sprintf("%s - Failed to construct '%s' payload.  Error: %s", methodName, EventRoyaltyStatementCreation, err.Error()))
 			}
-			royaltyStatementsEventPayloadBytes = append(royaltyStatementsEventPayloadBytes, payloadBytes...)
-		}
-
-		if royaltyStatementResponse.Success {
-			royaltyStatementOutput.SuccessCount++
-		} else {
-			royaltyStatementResponses = append(royaltyStatementResponses, royaltyStatementResponse)
-			royaltyStatementOutput.FailureCount++
+			addRoyaltyStatementEventTarget(&royaltyStatementCreationEvent, payload)
 		}
+		royaltyStatementOutput.SuccessCount++
 	}
 
 	royaltyStatementOutput.RoyaltyStatements = royaltyStatementResponses
 
 	objBytes, _ := objectToJSON(royaltyStatementOutput)
 
-	//fire an event for Ownership report only
-	if len(*royaltyStatements) == 1 && (*royaltyStatements)[0].RightType == OWNERSHIP {
-		logger.Infof("%s - firing event '%s'.", methodName, EventRoyaltyStatementCreation)
-		err = stub.SetEvent(EventRoyaltyStatementCreation, royaltyStatementsEventPayloadBytes)
-		if err != nil {
-			return getErrorResponse(fmt.Sprintf("%s - Failed to set event '%s' with payload '%s'.  Error: %s", methodName, EventRoyaltyStatementCreation, royaltyStatementsEventPayloadBytes, err.Error()))
-		}
+	err = setRoyaltyStatementCreationEvent(stub, royaltyStatementCreationEvent)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("%s - Failed to set event '%s'.  Error: %s", methodName, EventRoyaltyStatementCreation, err.Error()))
 	}
 
 	logger.Info("EXITING <", methodName, royaltyStatementOutput)
 	return shim.Success(objBytes)
 }
 
-//addRoyaltyStatementsAndEvent - save the royalty statement and fire an event
+//normalizeRoyaltyStatementIdentifiers - normalizes the ISRC and the IPIs of the parties of a royalty statement
+func normalizeRoyaltyStatementIdentifiers(royaltyStatement *RoyaltyStatement) error {
+	isrc, err := identifier.NormalizeIsrc(royaltyStatement.Isrc)
+	if err != nil {
+		return err
+	}
+	royaltyStatement.Isrc = isrc
+	for _, ipi := range []*string{&royaltyStatement.RightHolder, &royaltyStatement.Administrator, &royaltyStatement.Collector} {
+		*ipi, err = normalizeIpi(*ipi)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//addRoyaltyStatementsAndEvent - save the royalty statement and fire an event, royalty statements between orgs are passed in the transient map like in addRoyaltyStatements
 func addRoyaltyStatementAndEvent(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	var methodName = "addRoyaltyStatementAndEvent"
 	logger.Info("ENTERING >", methodName, args)
 
-	//if this function is called with morethan 1 royalty statements
-	//only write if the IPI or the orgs are same for 2 or  more royalty statements
-	//otherwise the chaincode should not continue.
-	royaltyStatementsEventPayloadBytes := []byte{}
-	isFinalRoyaltyStatement := false
+	// the event groups the royalty statements of the transaction by target
+	royaltyStatementCreationEvent := RoyaltyStatementCreationEvent{Version: EventRoyaltyStatementCreationVersion, Targets: []RoyaltyStatementCreationEventTarget{}}
 
-	if len(args) != 1 {
-		return getErrorResponse("Missing arguments: Needed RoyaltyStatement object to Create")
+	// the royalty statements between orgs are passed in the transient map to keep their amounts off the ledger
+	royaltyStatementsBytes, err := getTransientRoyaltyStatements(stub)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	isTransient := royaltyStatementsBytes != nil
+	if !isTransient {
+		if len(args) != 1 {
+			return getErrorResponse("Missing arguments: Needed RoyaltyStatement object to Create")
+		}
+		royaltyStatementsBytes = []byte(args[0])
 	}
 
 	royaltyStatementOutput := RoyaltyStatementOutput{}
 	royaltyStatements := &[]RoyaltyStatement{}
 	royaltyStatementResponses := []RoyaltyStatementResponse{}
 
-	// Unmarshal the args input to an array of royalty statement records
-	err := jsonToObject([]byte(args[0]), royaltyStatements)
+	// Unmarshal the input to an array of royalty statement records
+	err = jsonToObject(royaltyStatementsBytes, royaltyStatements)
 	if err != nil {
 		return getErrorResponse(err.Error())
 	}
 
+	// the advances recouped by the statements of the transaction
+	pendingAdvances := map[string]Advance{}
+
 	// iterate over royalty statements
 	for _, royaltyStatement := range *royaltyStatements {
 		royaltyStatement.DocType = ROYALTYSTATEMENT
@@ -176,66 +238,91 @@ func addRoyaltyStatementAndEvent(stub shim.ChaincodeStubInterface, args []string
 		royaltyStatementResponse.RoyaltyStatementUUID = royaltyStatement.RoyaltyStatementUUID
 		royaltyStatementResponse.Success = true
 
-		// check if royalty statement already exists
-		royaltyStatementExistingBytes, err := stub.GetState(royaltyStatement.RoyaltyStatementUUID)
-		if royaltyStatementExistingBytes != nil {
+		// reject the royalty statement when its identifiers are invalid or its private amounts were passed as arguments
+		err = normalizeRoyaltyStatementIdentifiers(&royaltyStatement)
+		if err == nil && !isTransient {
+			err = checkPublicRoyaltyStatement(stub, royaltyStatement)
+		}
+		if err != nil {
 			royaltyStatementResponse.Success = false
-			royaltyStatementResponse.Message = "Royalty Statement already exists!"
+			royaltyStatementResponse.Message = err.Error()
 			royaltyStatementResponses = append(royaltyStatementResponses, royaltyStatementResponse)
 			royaltyStatementOutput.FailureCount++
 			continue
 		}
 
-		// convert royalty statement to bytes
-		royaltyStatementBytes, err := objectToJSON(royaltyStatement)
-		if err != nil {
+		// check if royalty statement already exists
+		royaltyStatementExistingBytes, err := stub.GetState(royaltyStatement.RoyaltyStatementUUID)
+		if royaltyStatementExistingBytes != nil {
 			royaltyStatementResponse.Success = false
-			royaltyStatementResponse.Message = err.Error()
+			royaltyStatementResponse.Message = "Royalty Statement already exists!"
 			royaltyStatementResponses = append(royaltyStatementResponses, royaltyStatementResponse)
 			royaltyStatementOutput.FailureCount++
 			continue
 		}
 
+		isFinalRoyaltyStatement := false
 		if royaltyStatement.CollectionRight == 0 && royaltyStatement.CollectionRightPercent == 0 {
 			isFinalRoyaltyStatement = true
 			logger.Infof("%s - final royalty statement received with uuid : %s", methodName, royaltyStatement.RoyaltyStatementUUID)
 		}
 
-		// add royalty statement to the ledger
-		err = stub.PutState(royaltyStatement.RoyaltyStatementUUID, royaltyStatementBytes)
+		// withhold the tax of the territory of the exploitation and the recoupable part of the statement
+		// for the advances of its payer to its payee
+		recoupments := []advanceRecoupment{}
+		err = checkRoyaltyStatementWrite(stub, royaltyStatement)
+		if err == nil {
+			err = checkCollectionFeeStatement(stub, royaltyStatement)
+		}
+		if err == nil {
+			err = applyWithholdingTax(stub, &royaltyStatement)
+		}
+		if err == nil {
+			recoupments, err = recoupRoyaltyStatement(stub, &royaltyStatement, pendingAdvances)
+		}
 		if err != nil {
 			royaltyStatementResponse.Success = false
 			royaltyStatementResponse.Message = err.Error()
-		} else if isFinalRoyaltyStatement == false {
-			//assumption: this method should be called with a single royalty statement for now.
-			//TODO: multiple royalty statements if ORG or IPI is the same.
-			payloadBytes, err := getRoyaltyStatementsEventPayload(stub, royaltyStatement)
-			if err != nil {
-				return getErrorResponse(fmt.Sprintf("%s - Failed to construct '%s' payload.  Error: %s", methodName, EventRoyaltyStatementCreation, err.Error()))
-			}
-			royaltyStatementsEventPayloadBytes = append(royaltyStatementsEventPayloadBytes, payloadBytes...)
-		}
-
-		if royaltyStatementResponse.Success {
-			royaltyStatementOutput.SuccessCount++
-		} else {
 			royaltyStatementResponses = append(royaltyStatementResponses, royaltyStatementResponse)
 			royaltyStatementOutput.FailureCount++
+			continue
+		}
+
+		// add royalty statement to the ledger, its amounts are private to the orgs of its parties. The statement is
+		// checked, a failed write aborts the transaction instead of leaving the statement half recorded
+		err = putRoyaltyStatement(stub, royaltyStatement)
+		if err == nil {
+			// keep the running balances of the payer and the payee
+			err = updateRoyaltyStatementBalances(stub, nil, &royaltyStatement)
+		}
+		if err == nil {
+			err = putRecoupedAdvances(stub, royaltyStatement, recoupments, pendingAdvances)
+		}
+		if err == nil {
+			// the fee kept on a collection statement is its own statement to the collecting party
+			err = putCollectionFeeStatement(stub, royaltyStatement)
 		}
+		if err != nil {
+			return shim.Error(fmt.Sprintf("%s - Failed to record royalty statement '%s'.  Error: %s", methodName, royaltyStatement.RoyaltyStatementUUID, err.Error()))
+		}
+		if isFinalRoyaltyStatement == false {
+			//fire an event for any royalty statements as long as its not the last one.
+			payload, err := getRoyaltyStatementsEventPayload(stub, royaltyStatement)
+			if err != nil {
+				return shim.Error(fmt.Sprintf("%s - Failed to construct '%s' payload.  Error: %s", methodName, EventRoyaltyStatementCreation, err.Error()))
+			}
+			addRoyaltyStatementEventTarget(&royaltyStatementCreationEvent, payload)
+		}
+		royaltyStatementOutput.SuccessCount++
 	}
 
 	royaltyStatementOutput.RoyaltyStatements = royaltyStatementResponses
 
 	objBytes, _ := objectToJSON(royaltyStatementOutput)
 
-	//fire an event for any royalty statements as long as its not the last one.
-	if isFinalRoyaltyStatement == false {
-		err = stub.SetEvent(EventRoyaltyStatementCreation, royaltyStatementsEventPayloadBytes)
-		if err != nil {
-			return getErrorResponse(fmt.Sprintf("%s - Failed to set event '%s' with payload '%s'.  Error: %s", methodName, EventRoyaltyStatementCreation, royaltyStatementsEventPayloadBytes, err.Error()))
-		}
-	} else {
-		logger.Infof("%s - event '%s' not fired due to final royalty report.", methodName, EventRoyaltyStatementCreation)
+	err = setRoyaltyStatementCreationEvent(stub, royaltyStatementCreationEvent)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("%s - Failed to set event '%s'.  Error: %s", methodName, EventRoyaltyStatementCreation, err.Error()))
 	}
 
 	logger.Info("EXITING <", methodName, royaltyStatementOutput)
@@ -272,6 +359,7 @@ func getRoyaltyStatements(stub shim.ChaincodeStubInterface, args []string) pb.Re
 	if err != nil {
 		return getErrorResponse(err.Error())
 	}
+	mergeAuthorizedPrivateDetails(stub, resultRoyaltyStatements)
 
 	queryResultBytes, err := objectToJSON(resultRoyaltyStatements)
 	if err != nil {
@@ -320,6 +408,7 @@ func getRoyaltyStatementsByUUIDs(stub shim.ChaincodeStubInterface, args []string
 	if err != nil {
 		return getErrorResponse(err.Error())
 	}
+	mergeAuthorizedPrivateDetails(stub, resultRoyaltyStatements)
 
 	// we should just have a single item in the result array
 	royaltyStatementResultBytes, err := objectToJSON(resultRoyaltyStatements)
@@ -333,7 +422,8 @@ func getRoyaltyStatementsByUUIDs(stub shim.ChaincodeStubInterface, args []string
 }
 
 /* updateRoyaltyStatements function contains business logic to update
-Royalty Statements on the Ledger
+Royalty Statements on the Ledger. Royalty statements between orgs are passed in the transient map
+as "royaltyStatements" instead of the arguments.
 * @params   {Array} args
 * @property {string} 0       - stringified JSON array of royalty statement.
 * @return   {pb.Response}    - peer Response
@@ -342,17 +432,26 @@ func updateRoyaltyStatements(stub shim.ChaincodeStubInterface, args []string) pb
 	var methodName = "updateRoyaltyStatements"
 	logger.Info("ENTERING >", methodName, args)
 
-	//Check if array length is greater than 0
-	if len(args) < 1 {
-		return getErrorResponse("Missing arguments: Array of Royalty Statement objects is required")
+	// the royalty statements between orgs are passed in the transient map to keep their amounts off the ledger
+	royaltyStatementsBytes, err := getTransientRoyaltyStatements(stub)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	isTransient := royaltyStatementsBytes != nil
+	if !isTransient {
+		//Check if array length is greater than 0
+		if len(args) < 1 {
+			return getErrorResponse("Missing arguments: Array of Royalty Statement objects is required")
+		}
+		royaltyStatementsBytes = []byte(args[0])
 	}
 
 	royaltyStatementsOutput := RoyaltyStatementOutput{}
 	royaltyStatements := &[]RoyaltyStatement{}
 	royaltyStatementResponses := []RoyaltyStatementResponse{}
 
-	// Unmarshal the args input to an array of royalty statement records
-	err := jsonToObject([]byte(args[0]), royaltyStatements)
+	// Unmarshal the input to an array of royalty statement records
+	err = jsonToObject(royaltyStatementsBytes, royaltyStatements)
 	if err != nil {
 		return getErrorResponse(err.Error())
 	}
@@ -364,8 +463,11 @@ func updateRoyaltyStatements(stub shim.ChaincodeStubInterface, args []string) pb
 		royaltyStatementResponse.RoyaltyStatementUUID = royaltyStatement.RoyaltyStatementUUID
 		royaltyStatementResponse.Success = true
 
-		// convert royalty statement to bytes
-		royaltyStatementsBytes, err := objectToJSON(royaltyStatement)
+		// reject the royalty statement when its identifiers are invalid or its private amounts were passed as arguments
+		err = normalizeRoyaltyStatementIdentifiers(&royaltyStatement)
+		if err == nil && !isTransient {
+			err = checkPublicRoyaltyStatement(stub, royaltyStatement)
+		}
 		if err != nil {
 			royaltyStatementResponse.Success = false
 			royaltyStatementResponse.Message = err.Error()
@@ -384,19 +486,56 @@ func updateRoyaltyStatements(stub shim.ChaincodeStubInterface, args []string) pb
 			continue
 		}
 
-		// update royalty statement on the ledger
-		err = stub.PutState(royaltyStatement.RoyaltyStatementUUID, royaltyStatementsBytes)
+		previousRoyaltyStatement := RoyaltyStatement{}
+		err = jsonToObject(royaltyStatementExistingBytes, &previousRoyaltyStatement)
+		if err == nil {
+			err = mergeRoyaltyStatementPrivateDetails(stub, &previousRoyaltyStatement)
+		}
 		if err != nil {
 			royaltyStatementResponse.Success = false
 			royaltyStatementResponse.Message = err.Error()
+			royaltyStatementResponses = append(royaltyStatementResponses, royaltyStatementResponse)
+			royaltyStatementsOutput.FailureCount++
+			continue
 		}
 
-		if royaltyStatementResponse.Success {
-			royaltyStatementsOutput.SuccessCount++
-		} else {
+		// royalty statements rolled up in a period statement are locked
+		if previousRoyaltyStatement.PeriodStatementUUID != "" {
+			royaltyStatementResponse.Success = false
+			royaltyStatementResponse.Message = fmt.Sprintf("Royalty Statement is locked by period statement '%s'", previousRoyaltyStatement.PeriodStatementUUID)
 			royaltyStatementResponses = append(royaltyStatementResponses, royaltyStatementResponse)
 			royaltyStatementsOutput.FailureCount++
+			continue
+		}
+
+		// the recoupment of a statement is fixed when it is recorded
+		royaltyStatement.RecoupedAmount = previousRoyaltyStatement.RecoupedAmount
+		royaltyStatement.UnrecoupedBalance = previousRoyaltyStatement.UnrecoupedBalance
+		royaltyStatement.AdvanceUUIDs = previousRoyaltyStatement.AdvanceUUIDs
+
+		err = checkRoyaltyStatementWrite(stub, royaltyStatement)
+		if err == nil {
+			// the tax follows the updated amounts
+			err = applyWithholdingTax(stub, &royaltyStatement)
 		}
+		if err != nil {
+			royaltyStatementResponse.Success = false
+			royaltyStatementResponse.Message = err.Error()
+			royaltyStatementResponses = append(royaltyStatementResponses, royaltyStatementResponse)
+			royaltyStatementsOutput.FailureCount++
+			continue
+		}
+
+		// update royalty statement on the ledger, a failed write aborts the transaction
+		err = putRoyaltyStatement(stub, royaltyStatement)
+		if err == nil {
+			// move the running balances from the previous to the updated statement
+			err = updateRoyaltyStatementBalances(stub, &previousRoyaltyStatement, &royaltyStatement)
+		}
+		if err != nil {
+			return shim.Error(fmt.Sprintf("%s - Failed to record royalty statement '%s'.  Error: %s", methodName, royaltyStatement.RoyaltyStatementUUID, err.Error()))
+		}
+		royaltyStatementsOutput.SuccessCount++
 	}
 
 	royaltyStatementsOutput.RoyaltyStatements = royaltyStatementResponses
@@ -406,12 +545,111 @@ func updateRoyaltyStatements(stub shim.ChaincodeStubInterface, args []string) pb
 	return shim.Success(objBytes)
 }
 
-/* updateRoyaltyStatements function contains business logic to update
-Royalty Statements on the Ledger
+/* payRoyaltyStatements function marks Royalty Statements as paid.
+Statements under an open dispute are frozen and cannot be paid.
 * @params   {Array} args
-* @property {string} 0       - stringified JSON array of royalty statement.
+* @property {string} 0..n    - royalty statement UUIDs.
 * @return   {pb.Response}    - peer Response
 */
+func payRoyaltyStatements(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "payRoyaltyStatements"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) < 1 {
+		return getErrorResponse("Missing arguments: Royalty Statement UUIDs are required")
+	}
+
+	paymentDate, err := getTxTimestamp(stub)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	royaltyStatementsOutput := RoyaltyStatementOutput{}
+	royaltyStatementResponses := []RoyaltyStatementResponse{}
+
+	// iterate over royalty statement UUIDs
+	for _, royaltyStatementUUID := range args {
+		royaltyStatementResponse := RoyaltyStatementResponse{}
+		royaltyStatementResponse.RoyaltyStatementUUID = royaltyStatementUUID
+		royaltyStatementResponse.Success = true
+
+		// check if royalty statement with the UUID exists on the ledger.
+		royaltyStatementExistingBytes, err := stub.GetState(royaltyStatementUUID)
+		if royaltyStatementExistingBytes == nil {
+			royaltyStatementResponse.Success = false
+			royaltyStatementResponse.Message = "Royalty Statement does not exist!"
+			royaltyStatementResponses = append(royaltyStatementResponses, royaltyStatementResponse)
+			royaltyStatementsOutput.FailureCount++
+			continue
+		}
+
+		royaltyStatement := RoyaltyStatement{}
+		err = jsonToObject(royaltyStatementExistingBytes, &royaltyStatement)
+		if err == nil {
+			err = mergeRoyaltyStatementPrivateDetails(stub, &royaltyStatement)
+		}
+		if err != nil {
+			royaltyStatementResponse.Success = false
+			royaltyStatementResponse.Message = err.Error()
+			royaltyStatementResponses = append(royaltyStatementResponses, royaltyStatementResponse)
+			royaltyStatementsOutput.FailureCount++
+			continue
+		}
+
+		if royaltyStatement.PaymentState == PAID {
+			royaltyStatementResponse.Success = false
+			royaltyStatementResponse.Message = "Royalty Statement is already paid!"
+			royaltyStatementResponses = append(royaltyStatementResponses, royaltyStatementResponse)
+			royaltyStatementsOutput.FailureCount++
+			continue
+		}
+
+		// statements under an open dispute are frozen from payment
+		openDisputeUUID, err := getRoyaltyStatementOpenDispute(stub, royaltyStatement)
+		if err == nil && openDisputeUUID != "" {
+			err = fmt.Errorf("Royalty Statement is frozen by open dispute '%s'", openDisputeUUID)
+		}
+		if err != nil {
+			royaltyStatementResponse.Success = false
+			royaltyStatementResponse.Message = err.Error()
+			royaltyStatementResponses = append(royaltyStatementResponses, royaltyStatementResponse)
+			royaltyStatementsOutput.FailureCount++
+			continue
+		}
+
+		previousRoyaltyStatement := royaltyStatement
+		royaltyStatement.PaymentState = PAID
+		royaltyStatement.PaymentDate = paymentDate
+
+		err = checkRoyaltyStatementWrite(stub, royaltyStatement)
+		if err != nil {
+			royaltyStatementResponse.Success = false
+			royaltyStatementResponse.Message = err.Error()
+			royaltyStatementResponses = append(royaltyStatementResponses, royaltyStatementResponse)
+			royaltyStatementsOutput.FailureCount++
+			continue
+		}
+
+		// update the royalty statement on the ledger, a failed write aborts the transaction
+		err = putRoyaltyStatement(stub, royaltyStatement)
+		if err == nil {
+			// the paid amount is no longer outstanding
+			err = updateRoyaltyStatementBalances(stub, &previousRoyaltyStatement, &royaltyStatement)
+		}
+		if err != nil {
+			return shim.Error(fmt.Sprintf("%s - Failed to record royalty statement '%s'.  Error: %s", methodName, royaltyStatement.RoyaltyStatementUUID, err.Error()))
+		}
+		royaltyStatementsOutput.SuccessCount++
+	}
+
+	royaltyStatementsOutput.RoyaltyStatements = royaltyStatementResponses
+
+	objBytes, _ := objectToJSON(royaltyStatementsOutput)
+	logger.Info("EXITING <", methodName, royaltyStatementsOutput)
+	return shim.Success(objBytes)
+}
+
+//getExploitationReportUUID - returns the UUID of the exploitation report a royalty statement was generated from
 func getExploitationReportUUID(stub shim.ChaincodeStubInterface, royaltyStatement RoyaltyStatement) (string, error) {
 	var methodName = "getExploitationReportUUID"
 	logger.Info("ENTERING >", methodName)
@@ -442,23 +680,14 @@ func getExploitationReportUUID(stub shim.ChaincodeStubInterface, royaltyStatemen
 	return exploitationReportUUID, nil
 }
 
-func getRoyaltyStatementsEventPayload(stub shim.ChaincodeStubInterface, royaltyStatement RoyaltyStatement /*royaltyStatements *[]RoyaltyStatement*/) ([]byte, error) {
+//getRoyaltyStatementsEventPayload - determine the target of the royalty statement creation event for a royalty statement
+func getRoyaltyStatementsEventPayload(stub shim.ChaincodeStubInterface, royaltyStatement RoyaltyStatement) (RoyaltyStatementCreationEventPayload, error) {
 	methodName := "getRoyaltyStatementsEventPayload"
 
 	objRoyaltyStatementEventPayload := RoyaltyStatementCreationEventPayload{}
 	objRoyaltyStatementEventPayload.RoyaltyStatementUUID = royaltyStatement.RoyaltyStatementUUID
 	objRoyaltyStatementEventPayload.Type = COLLECTION
 	logger.Infof("%s - setting 'type' for event payload to '%s'.", methodName, objRoyaltyStatementEventPayload.Type)
-	/*if len(royaltyStatement.RightHolder) > 0 && len(royaltyStatement.Administrator) > 0 {
-		logger.Infof("%s - found a valid royalty statement right holder '%s' and administrator '%s'.", methodName, royaltyStatement.RightHolder, royaltyStatement.Administrator)
-		objRoyaltyStatementEventPayload.TargetIPI = royaltyStatement.Administrator
-		objRoyaltyStatementEventPayload.IsDSP = false
-	} else if len(royaltyStatement.Collector) > 0 && len(royaltyStatement.Administrator) > 0 {
-		logger.Infof("%s - found a valid royalty statement collector '%s' and administrator '%s'.", methodName, royaltyStatement.Collector, royaltyStatement.Administrator)
-		objRoyaltyStatementEventPayload.TargetIPI = royaltyStatement.Collector
-		objRoyaltyStatementEventPayload.IsDSP = false
-
-	}*/
 	if len(royaltyStatement.Collector) > 0 && len(royaltyStatement.Administrator) > 0 {
 		logger.Infof("%s - found a valid royalty statement collector '%s' and administrator '%s'.", methodName, royaltyStatement.Collector, royaltyStatement.Administrator)
 		objRoyaltyStatementEventPayload.TargetIPI = royaltyStatement.Collector
@@ -481,29 +710,51 @@ func getRoyaltyStatementsEventPayload(stub shim.ChaincodeStubInterface, royaltyS
 		//error condition
 		message := fmt.Sprintf("%s - incorrect condition found when determining the target IPI and dsp status.  Operation cannot continue", methodName)
 		logger.Error(message)
-		return nil, errors.New(message)
-	}
-	//get the org from the mapping stored on the chain
-	//ipiToOrgBytes, err := getAssetByUUID(stub, []string{royaltyStatement.RightHolder}]).//stub.GetState(royaltyStatement.RightHolder)
-	response := getAssetByUUID(stub, []string{royaltyStatement.RightHolder})
-	if response.GetStatus() != shim.OK {
-		//if err != nil {
-		//message := fmt.Sprintf("%s - Failed to get org for IPI '%s'.  Error: %s", methodName, royaltyStatement.RightHolder, err.Error())
-		message := fmt.Sprintf("%s - Failed to get org for IPI '%s'.  Error: %s", methodName, royaltyStatement.RightHolder, response.GetMessage())
-		logger.Error(message)
-		return nil, errors.New(message)
+		return objRoyaltyStatementEventPayload, errors.New(message)
 	}
-	ipiToOrgBytes := response.GetPayload()
 
-	objRoyaltyStatementEventPayload.TargetOrg = string(ipiToOrgBytes) //`{"org":"org2"}`
+	//get the org of the target from the mapping in effect on the exploitation date
+	targetOrg, err := getOrgForIpiOnDate(stub, objRoyaltyStatementEventPayload.TargetIPI, royaltyStatement.ExploitationDate)
+	if err != nil {
+		message := fmt.Sprintf("%s - Failed to get org for IPI '%s'.  Error: %s", methodName, objRoyaltyStatementEventPayload.TargetIPI, err.Error())
+		logger.Error(message)
+		return objRoyaltyStatementEventPayload, errors.New(message)
+	}
+	objRoyaltyStatementEventPayload.TargetOrg = targetOrg
 	logger.Infof("%s - setting 'target org' for event payload to '%s'.", methodName, objRoyaltyStatementEventPayload.TargetOrg)
 
-	objResultBytes, err := objectToJSON(objRoyaltyStatementEventPayload)
+	return objRoyaltyStatementEventPayload, nil
+}
+
+//addRoyaltyStatementEventTarget - add a royalty statement to the event target with the same org, IPI and type
+func addRoyaltyStatementEventTarget(royaltyStatementCreationEvent *RoyaltyStatementCreationEvent, payload RoyaltyStatementCreationEventPayload) {
+	for i, target := range royaltyStatementCreationEvent.Targets {
+		if target.TargetOrg == payload.TargetOrg && target.TargetIPI == payload.TargetIPI && target.Type == payload.Type && target.IsDSP == payload.IsDSP {
+			royaltyStatementCreationEvent.Targets[i].RoyaltyStatementUUIDs = append(target.RoyaltyStatementUUIDs, payload.RoyaltyStatementUUID)
+			return
+		}
+	}
+	royaltyStatementCreationEvent.Targets = append(royaltyStatementCreationEvent.Targets, RoyaltyStatementCreationEventTarget{
+		Type:                  payload.Type,
+		TargetOrg:             payload.TargetOrg,
+		TargetIPI:             payload.TargetIPI,
+		IsDSP:                 payload.IsDSP,
+		RoyaltyStatementUUIDs: []string{payload.RoyaltyStatementUUID},
+	})
+}
+
+//setRoyaltyStatementCreationEvent - fire the royalty statement creation event when it has at least one target
+func setRoyaltyStatementCreationEvent(stub shim.ChaincodeStubInterface, royaltyStatementCreationEvent RoyaltyStatementCreationEvent) error {
+	methodName := "setRoyaltyStatementCreationEvent"
+	if len(royaltyStatementCreationEvent.Targets) == 0 {
+		logger.Infof("%s - event '%s' not fired, no targets.", methodName, EventRoyaltyStatementCreation)
+		return nil
+	}
+
+	eventPayloadBytes, err := objectToJSON(royaltyStatementCreationEvent)
 	if err != nil {
-		message := fmt.Sprintf("%s - Failed get payload in bytes for IPI to org mapping.  Error: %s", methodName, err.Error())
-		logger.Error(message)
-		return nil, errors.New(message)
+		return err
 	}
-	logger.Infof("%s - event payload being returned: %s", methodName, string(objResultBytes))
-	return objResultBytes, nil
+	logger.Infof("%s - firing event '%s' with payload: %s", methodName, EventRoyaltyStatementCreation, string(eventPayloadBytes))
+	return stub.SetEvent(EventRoyaltyStatementCreation, eventPayloadBytes)
 }
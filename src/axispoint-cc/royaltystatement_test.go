@@ -4,15 +4,16 @@ import (
 	"reflect"
 	"testing"
 
-	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"axispoint-cc/memstub"
+	pb "github.com/hyperledger/fabric/protos/peer"
 )
 
-var royaltyStatementSingle1_in = `[{"royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"FlowThroughSong1-ISRC","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":200,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":"MISSING_AFFILIATE"}]`
-var royaltyStatementSingle1_out = `{"docType":"ROYALTYSTATEMENT","royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"FlowThroughSong1-ISRC","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":200,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":"MISSING_AFFILIATE"}`
-var royaltyStatementMultiple1_in = `[{"royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"FlowThroughSong1-ISRC","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":200,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":"MISSING_AFFILIATE"},{"royaltyStatementUUID":"5bbbda3a-6335-4248-9d10-019a73f59dfc","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"FlowThroughSong1-ISRC","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":300,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Homer-Simpson-IPI","administrator":"ACME-Music-Corp-IPI","collector":"","state":"MISSING_AFFILIATE"}]`
-var royaltyStatementSingle2_out = `{"docType":"ROYALTYSTATEMENT","royaltyStatementUUID":"5bbbda3a-6335-4248-9d10-019a73f59dfc","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"FlowThroughSong1-ISRC","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":300,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Homer-Simpson-IPI","administrator":"ACME-Music-Corp-IPI","collector":"","state":"MISSING_AFFILIATE"}`
-var royaltyStatementSingle2_in = `[{"royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"FlowThroughSong1-ISRC","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":200,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":"MISSING_AFFILIATE"}]`
-var royaltyStatementMultiple2_in = `[{"royaltyStatementUUID":"a4c7408b-d68b-499e-8dfa-ff81b43ca8fe","source":"M86321","isrc":"00029524","exploitationDate":"20170131","amount":"7341.31000000","rightType":"SMECH","territory":"AUS","usageType":"SDIGM","target":"M86322"},{"royaltyStatementUUID":"a4c7408b-d68b-499e-8dfa-ff81b43ca8ff","source":"M86321","isrc":"00029525","exploitationDate":"20170131","amount":"7341.31000000","rightType":"SMECH","territory":"AUS","usageType":"SDIGM","target":"M86322"}]`
+var royaltyStatementSingle1_in = `[{"royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":200,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":"MISSING_AFFILIATE"}]`
+var royaltyStatementSingle1_out = `{"docType":"ROYALTYSTATEMENT","royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":200,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":"MISSING_AFFILIATE","netPayable":200}`
+var royaltyStatementMultiple1_in = `[{"royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":200,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":"MISSING_AFFILIATE"},{"royaltyStatementUUID":"5bbbda3a-6335-4248-9d10-019a73f59dfc","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":300,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Homer-Simpson-IPI","administrator":"ACME-Music-Corp-IPI","collector":"","state":"MISSING_AFFILIATE"}]`
+var royaltyStatementSingle2_out = `{"docType":"ROYALTYSTATEMENT","royaltyStatementUUID":"5bbbda3a-6335-4248-9d10-019a73f59dfc","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":300,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Homer-Simpson-IPI","administrator":"ACME-Music-Corp-IPI","collector":"","state":"MISSING_AFFILIATE","netPayable":300}`
+var royaltyStatementSingle2_in = `[{"royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":200,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":"MISSING_AFFILIATE"}]`
+var royaltyStatementMultiple2_in = `[{"royaltyStatementUUID":"a4c7408b-d68b-499e-8dfa-ff81b43ca8fe","source":"M86321","isrc":"QZAB11729524","exploitationDate":"20170131","amount":"7341.31000000","rightType":"SMECH","territory":"AUS","usageType":"SDIGM","target":"M86322"},{"royaltyStatementUUID":"a4c7408b-d68b-499e-8dfa-ff81b43ca8ff","source":"M86321","isrc":"QZAB11729525","exploitationDate":"20170131","amount":"7341.31000000","rightType":"SMECH","territory":"AUS","usageType":"SDIGM","target":"M86322"}]`
 
 func MockGetRoyaltyStatementResponse(functionName string) []byte {
 	switch functionName {
@@ -25,9 +26,9 @@ func MockGetRoyaltyStatementResponse(functionName string) []byte {
 	case "Test_addRoyaltyStatements_Multiple_Failure":
 		return []byte(`{"successCount":0,"failureCount":2,"royaltyStatements":[{"royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","message":"Royalty Statement already exists!","success":false},{"royaltyStatementUUID":"5bbbda3a-6335-4248-9d10-019a73f59dfc","message":"Royalty Statement already exists!","success":false}]}`)
 	case "Test_GetRoyaltyStatements":
-		return []byte(`[{"docType":"ROYALTYSTATEMENT","royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"FlowThroughSong1-ISRC","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":200,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":"MISSING_AFFILIATE"}]`)
+		return []byte(`[{"docType":"ROYALTYSTATEMENT","royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":200,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":"MISSING_AFFILIATE","netPayable":200}]`)
 	case "Test_GetRoyaltyStatementByUUID":
-		return []byte(`{"docType":"ROYALTYSTATEMENT","royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"FlowThroughSong1-ISRC","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":200,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":"MISSING_AFFILIATE"}`)
+		return []byte(`{"docType":"ROYALTYSTATEMENT","royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"QZAB11800001","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":200,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":"MISSING_AFFILIATE","netPayable":200}`)
 	case "Test_GetRoyaltyStatementByUUID_Failure":
 		return []byte(`{"status":"500","message":"UUID: 85fff2bf-00a2-423b-9567-55c6f4ee6ee2 does not exist"}`)
 	case "Test_UpdateRoyaltyStatements_Single":
@@ -39,14 +40,9 @@ func MockGetRoyaltyStatementResponse(functionName string) []byte {
 	}
 }
 
-func MockGetRoyaltyStatementQueryResultForQueryString(stub shim.ChaincodeStubInterface, queryString string) ([]string, error) {
-	//return []string{`{"amount":"18.5034375","docType":"ROYALTYSTATEMENT","exploitationDate":"20170131","exploitationReportUUID":"7cb134b2-156f-32e0-a4d9-6165c6ad1aca","isrc":"00055524","rightType":"PERF","royaltyStatementUUID":"2e0d3d18-f25f-36d0-81ef-6e3da893d4aa","songTitle":"GECKOS!!","source":"P8819H","target":"W998","territory":"AUS","units":226,"usageType":"SDIGP","writerName":"KIERAN CASH"},{"amount":"12.404250000000001","docType":"ROYALTYSTATEMENT","exploitationDate":"20170131","exploitationReportUUID":"6874280d-2897-3321-b238-0b4dfa0aa516","isrc":"00055524","rightType":"MECH","royaltyStatementUUID":"7f384cbf-0d0d-3698-9714-841b8ecb73f9","songTitle":"GECKOS!!","source":"P8819H","target":"W998","territory":"AUS","units":150,"usageType":"SDIGM","writerName":"KIERAN CASH"},{"amount":"12.366","docType":"ROYALTYSTATEMENT","exploitationDate":"20170131","exploitationReportUUID":"8ab33826-399f-3707-a0af-dfedc3d3b7f3","isrc":"00055524","rightType":"MECH","royaltyStatementUUID":"94c878c5-f754-3b04-b90e-4f01cbd54ad6","songTitle":"GECKOS!!","source":"P8819H","target":"W998","territory":"AUS","units":164,"usageType":"SMECH","writerName":"KIERAN CASH"}`}, nil
-	return []string{`{"docType":"ROYALTYSTATEMENT","royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","exploitationReportUUID":"5c42d472-d137-4218-94a3-2825999a6f10","source":"spotify-IPI","isrc":"FlowThroughSong1-ISRC","songTitle":"missing affiliation song","writerName":"Homer, Ned","units":10000,"exploitationDate":"2018-12-30T00:00:00.000Z","amount":200,"rightType":"OWNERSHIP","territory":"FRA","usageType":"MECH","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":"","state":"MISSING_AFFILIATE"}`}, nil
-}
-
 func Test_addRoyaltyStatements_Single(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -68,7 +64,7 @@ func Test_addRoyaltyStatements_Single(t *testing.T) {
 
 func Test_addRoyaltyStatements_Single_Failure(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -91,7 +87,7 @@ func Test_addRoyaltyStatements_Single_Failure(t *testing.T) {
 
 func Test_addRoyaltyStatements_Multiple(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -116,7 +112,7 @@ func Test_addRoyaltyStatements_Multiple(t *testing.T) {
 
 func Test_addRoyaltyStatements_Multiple_Failure(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -139,7 +135,7 @@ func Test_addRoyaltyStatements_Multiple_Failure(t *testing.T) {
 
 func Test_AddRoyaltyStatements_Empty1(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -153,7 +149,7 @@ func Test_AddRoyaltyStatements_Empty1(t *testing.T) {
 //Test the Edge cases
 func Test_AddRoyaltyStatements_Empty2(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -167,13 +163,17 @@ func Test_AddRoyaltyStatements_Empty2(t *testing.T) {
 //Test the Edge cases
 func Test_GetRoyaltyStatements(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
-	getRoyaltyStatementsForQueryString = MockGetRoyaltyStatementQueryResultForQueryString
 
-	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getRoyaltyStatements"), []byte("")})
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addRoyaltyStatements"), []byte(royaltyStatementMultiple1_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getRoyaltyStatements"), []byte(`{"selector":{"docType":"ROYALTYSTATEMENT","rightHolder":"Ned-IPI"}}`)})
 	if err != nil {
 		t.Fatalf(err.Error())
 	}
@@ -188,7 +188,7 @@ func Test_GetRoyaltyStatements(t *testing.T) {
 //Test the Edge cases
 func Test_GetRoyaltyStatementByUUID(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -213,7 +213,7 @@ func Test_GetRoyaltyStatementByUUID(t *testing.T) {
 //Test the Edge cases
 func Test_GetRoyaltyStatementByUUID_Failure(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -237,7 +237,7 @@ func Test_GetRoyaltyStatementByUUID_Failure(t *testing.T) {
 
 func Test_UpdateRoyaltyStatements_Single(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -264,7 +264,7 @@ func Test_UpdateRoyaltyStatements_Single(t *testing.T) {
 
 func Test_UpdateRoyaltyStatements_Multiple(t *testing.T) {
 	scc := new(AxispointChaincode)
-	stub := shim.NewMockStub("AxispointChaincode", scc)
+	stub := memstub.NewStub("AxispointChaincode", scc)
 
 	// Init
 	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
@@ -292,3 +292,36 @@ func Test_UpdateRoyaltyStatements_Multiple(t *testing.T) {
 		t.Fatalf("Actual response is not equal to expected response")
 	}
 }
+
+func Test_AddRoyaltyStatements_Event_Multiple(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+
+	// Init
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("updateIpiOrg"), []byte(`{"ipi":"Swedish-Publishing-IPI","org":"org2"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	royaltyStatements := `[{"royaltyStatementUUID":"0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","source":"spotify-IPI","isrc":"QZAB11800001","amount":200,"rightType":"OWNERSHIP","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":""},{"royaltyStatementUUID":"5bbbda3a-6335-4248-9d10-019a73f59dfc","source":"spotify-IPI","isrc":"QZAB11800001","amount":300,"rightType":"OWNERSHIP","rightHolder":"Homer-Simpson-IPI","administrator":"ACME-Music-Corp-IPI","collector":""},{"royaltyStatementUUID":"94c878c5-f754-3b04-b90e-4f01cbd54ad6","source":"spotify-IPI","isrc":"QZAB11800002","amount":100,"rightType":"OWNERSHIP","rightHolder":"Ned-IPI","administrator":"Swedish-Publishing-IPI","collector":""}]`
+	_, err = checkInvoke(t, stub, [][]byte{[]byte("addRoyaltyStatements"), []byte(royaltyStatements)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	var event *pb.ChaincodeEvent
+	select {
+	case event = <-stub.ChaincodeEventsChannel:
+	default:
+		t.Fatalf("No event was fired")
+	}
+	if event.EventName != EventRoyaltyStatementCreation {
+		t.Fatalf("Unexpected event '%s'", event.EventName)
+	}
+	expected := []byte(`{"version":"2.0","targets":[{"type":"COLLECTION","targetOrg":"org2","targetIPI":"Swedish-Publishing-IPI","isDSP":false,"royaltyStatementUUIDs":["0daccfc9-9e3a-43f1-8e60-d0d0916a82e3","94c878c5-f754-3b04-b90e-4f01cbd54ad6"]},{"type":"COLLECTION","targetOrg":"","targetIPI":"ACME-Music-Corp-IPI","isDSP":false,"royaltyStatementUUIDs":["5bbbda3a-6335-4248-9d10-019a73f59dfc"]}]}`)
+	if !reflect.DeepEqual(expected, event.Payload) {
+		t.Fatalf("Actual event payload is not equal to expected event payload: %s", event.Payload)
+	}
+}
@@ -0,0 +1,516 @@
+// +build simulate
+
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+
+	"axispoint-cc/memstub"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+/*
+* The simulate build of the chaincode runs it in process on an in-memory world state answering the rich queries,
+* to try a copyright split or a collection right selector against exploitation reports without a peer or CouchDB.
+* The simulation and the in-memory world state are only compiled with the simulate build tag, the chaincode the
+* peer builds starts the shim instead:
+*
+*	go build -tags simulate -o axispoint-simulate
+*	axispoint-simulate -copyright-data cdrs.json -collection-rights rights.json -ipi-orgs orgs.json sales.json
+*
+* The fixtures are JSON arrays of copyright data reports, collection rights and IPI-org mappings recorded before
+* the replay. Any other reference data, e.g. advances or tax rules, is recorded from a -payloads file of chaincode
+* payloads, one {"function":...,"args":[...]} per line as cwrimport writes them. Each exploitation report file, a
+* JSON array or the payloads of dsrimport, is replayed the way the clients of the chaincode record exploitation:
+* generateExploitationReports, then insertExploitationReports of the classified reports and addRoyaltyStatements
+* of their ownership statements. Each RoyaltyStatementCreation event is then handled the way the listeners of the
+* target orgs handle it: generateCollectionStatement of every statement of a target, then
+* addRoyaltyStatementAndEvent of the generated statements, until the last hops fire no event. The statements, as
+* recorded with their amounts and sorted by UUID, and the events of the replay are written as JSON to the standard
+* output. The simulation exits with status 2 when a report or a statement could not be recorded.
+ */
+
+// SimulationEvent - an event fired by a transaction of a simulation
+type SimulationEvent struct {
+	TxID     string          `json:"txID"`
+	Function string          `json:"function"`
+	Name     string          `json:"name"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+// SimulationFailure - an exploitation report or royalty statement a simulation could not record
+type SimulationFailure struct {
+	Function string `json:"function"`
+	UUID     string `json:"uuid"`
+	Message  string `json:"message"`
+}
+
+// SimulationOutput - the assets recorded by the replay of exploitation reports and the events it fired
+type SimulationOutput struct {
+	ExploitationReports []ExploitationReport `json:"exploitationReports"`
+	RoyaltyStatements   []RoyaltyStatement   `json:"royaltyStatements"`
+	Events              []SimulationEvent    `json:"events"`
+	Failures            []SimulationFailure  `json:"failures"`
+}
+
+// SimulationPayload - the invocation of a chaincode function, as written by the import commands
+type SimulationPayload struct {
+	Function string   `json:"function"`
+	Args     []string `json:"args"`
+}
+
+//simulationResponse - the response of a function recording exploitation reports or royalty statements for one of them
+type simulationResponse struct {
+	ExploitationReportUUID string `json:"exploitationReportUUID"`
+	RoyaltyStatementUUID   string `json:"royaltyStatementUUID"`
+	Message                string `json:"message"`
+	Success                bool   `json:"success"`
+}
+
+//simulation - the chaincode running on an in-memory world state, the royalty statement creation events left to
+// handle and the outcome of its replay
+type simulation struct {
+	stub    *memstub.Stub
+	txs     int
+	pending []RoyaltyStatementCreationEvent
+	hops    map[string]simulationHop
+	output  SimulationOutput
+}
+
+//simulationHop - the royalty statement a statement generated by the simulation collects from, at the hop it collects
+type simulationHop struct {
+	root string
+	hop  int
+}
+
+//newSimulation - returns a simulation of the chaincode initialized on an empty world state
+func newSimulation() (*simulation, error) {
+	simulation := &simulation{stub: memstub.NewStub("axispoint-cc", new(AxispointChaincode)), hops: map[string]simulationHop{}}
+	// the private details of the statements between orgs are salted like the clients salt them
+	salt := make([]byte, 32)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+	simulation.stub.Transient = map[string][]byte{TRANSIENT_SALT: salt}
+	simulation.output = SimulationOutput{ExploitationReports: []ExploitationReport{}, RoyaltyStatements: []RoyaltyStatement{}, Events: []SimulationEvent{}, Failures: []SimulationFailure{}}
+	response := simulation.stub.MockInit(simulation.nextTxID(), [][]byte{[]byte("init")})
+	if response.Status != shim.OK {
+		return nil, fmt.Errorf("init - %s", response.Message)
+	}
+	return simulation, nil
+}
+
+//nextTxID - returns the id of the next transaction, the chaincode keys the deltas of balances by transaction
+func (simulation *simulation) nextTxID() string {
+	simulation.txs++
+	return "tx" + strconv.Itoa(simulation.txs)
+}
+
+//invoke - invokes a chaincode function in a transaction of its own and collects its events, the royalty statement
+// creation events are left to handle. An error response is returned as an error.
+func (simulation *simulation) invoke(function string, args ...string) ([]byte, error) {
+	invokeArgs := [][]byte{[]byte(function)}
+	for _, arg := range args {
+		invokeArgs = append(invokeArgs, []byte(arg))
+	}
+	txID := simulation.nextTxID()
+	response := simulation.stub.MockInvoke(txID, invokeArgs)
+	for len(simulation.stub.ChaincodeEventsChannel) > 0 {
+		event := <-simulation.stub.ChaincodeEventsChannel
+		simulation.output.Events = append(simulation.output.Events, SimulationEvent{TxID: txID, Function: function, Name: event.EventName, Payload: event.Payload})
+		if response.Status != shim.OK || event.EventName != EventRoyaltyStatementCreation {
+			continue
+		}
+		royaltyStatementCreationEvent := RoyaltyStatementCreationEvent{}
+		err := jsonToObject(event.Payload, &royaltyStatementCreationEvent)
+		if err != nil {
+			return nil, err
+		}
+		simulation.pending = append(simulation.pending, royaltyStatementCreationEvent)
+	}
+	if response.Status != shim.OK {
+		return nil, fmt.Errorf("%s - %s", function, response.Message)
+	}
+
+	// most functions answer their errors with a successful response
+	errorResponse := struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}{}
+	if json.Unmarshal(response.Payload, &errorResponse) == nil && errorResponse.Status == "500" {
+		return nil, fmt.Errorf("%s - %s", function, errorResponse.Message)
+	}
+	return response.Payload, nil
+}
+
+//record - invokes a chaincode function recording fixtures, which fails when one of them is not recorded
+func (simulation *simulation) record(function string, args ...string) error {
+	payload, err := simulation.invoke(function, args...)
+	if err != nil {
+		return err
+	}
+	output := struct {
+		FailureCount int `json:"failureCount"`
+	}{}
+	if json.Unmarshal(payload, &output) == nil && output.FailureCount > 0 {
+		return fmt.Errorf("%s - %d fixtures were not recorded: %s", function, output.FailureCount, payload)
+	}
+	return nil
+}
+
+//loadFixtures - records the fixtures of the files that are set
+func (simulation *simulation) loadFixtures(copyrightDataPath string, collectionRightsPath string, ipiOrgsPath string, payloadsPath string) error {
+	for _, fixture := range []struct{ path, function string }{
+		{copyrightDataPath, "addCopyrightDataReports"},
+		{collectionRightsPath, "addCollectionRights"},
+	} {
+		if fixture.path == "" {
+			continue
+		}
+		fixtureBytes, err := ioutil.ReadFile(fixture.path)
+		if err != nil {
+			return err
+		}
+		err = simulation.record(fixture.function, string(fixtureBytes))
+		if err != nil {
+			return fmt.Errorf("%s: %s", fixture.path, err.Error())
+		}
+	}
+
+	if ipiOrgsPath != "" {
+		ipiOrgsBytes, err := ioutil.ReadFile(ipiOrgsPath)
+		if err != nil {
+			return err
+		}
+		ipiOrgs := []json.RawMessage{}
+		err = json.Unmarshal(ipiOrgsBytes, &ipiOrgs)
+		if err != nil {
+			return fmt.Errorf("%s: %s", ipiOrgsPath, err.Error())
+		}
+		// the IPI-org mappings are recorded one at a time
+		for _, ipiOrg := range ipiOrgs {
+			err = simulation.record("addIpiOrg", string(ipiOrg))
+			if err != nil {
+				return fmt.Errorf("%s: %s", ipiOrgsPath, err.Error())
+			}
+		}
+	}
+
+	if payloadsPath != "" {
+		payloads, err := readSimulationPayloads(payloadsPath, "")
+		if err != nil {
+			return err
+		}
+		for _, payload := range payloads {
+			err = simulation.record(payload.Function, payload.Args...)
+			if err != nil {
+				return fmt.Errorf("%s: %s", payloadsPath, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+//readSimulationPayloads - reads a file of payloads, one per line, or a JSON array that is the argument of a payload of the default function
+func readSimulationPayloads(path string, defaultFunction string) ([]SimulationPayload, error) {
+	payloadsBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if defaultFunction != "" && bytes.HasPrefix(bytes.TrimSpace(payloadsBytes), []byte("[")) {
+		return []SimulationPayload{{Function: defaultFunction, Args: []string{string(payloadsBytes)}}}, nil
+	}
+
+	payloads := []SimulationPayload{}
+	decoder := json.NewDecoder(bytes.NewReader(payloadsBytes))
+	for {
+		payload := SimulationPayload{}
+		err = decoder.Decode(&payload)
+		if err == io.EOF {
+			return payloads, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err.Error())
+		}
+		if payload.Function == "" {
+			return nil, fmt.Errorf("%s: payload %d has no function", path, len(payloads)+1)
+		}
+		if defaultFunction != "" && payload.Function != defaultFunction {
+			return nil, fmt.Errorf("%s: payload %d is a payload of %s, not of %s", path, len(payloads)+1, payload.Function, defaultFunction)
+		}
+		payloads = append(payloads, payload)
+	}
+}
+
+//replay - replays the exploitation reports of a file
+func (simulation *simulation) replay(path string) error {
+	payloads, err := readSimulationPayloads(path, "generateExploitationReports")
+	if err != nil {
+		return err
+	}
+	for _, payload := range payloads {
+		if len(payload.Args) != 1 {
+			return fmt.Errorf("%s: a payload of generateExploitationReports has one argument", path)
+		}
+		err = simulation.replayExploitationReports(payload.Args[0])
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err.Error())
+		}
+	}
+	return nil
+}
+
+//replayExploitationReports - generates the royalty statements of exploitation reports, records them and resolves the collection chains of the ownership statements
+func (simulation *simulation) replayExploitationReports(exploitationReportsArg string) error {
+	payload, err := simulation.invoke("generateExploitationReports", exploitationReportsArg)
+	if err != nil {
+		return err
+	}
+	generated := struct {
+		ExploitationReportResponses []simulationResponse `json:"exploitationReportResponses"`
+		RoyaltyStatements           []RoyaltyStatement   `json:"royaltyStatements"`
+		ExploitationReports         []ExploitationReport `json:"exploitationReports"`
+	}{}
+	err = jsonToObject(payload, &generated)
+	if err != nil {
+		return err
+	}
+	simulation.addFailures("generateExploitationReports", generated.ExploitationReportResponses)
+
+	if len(generated.ExploitationReports) > 0 {
+		failed, err := simulation.recordAll("insertExploitationReports", generated.ExploitationReports)
+		if err != nil {
+			return err
+		}
+		for _, exploitationReport := range generated.ExploitationReports {
+			if !failed[exploitationReport.ExploitationReportUUID] {
+				simulation.output.ExploitationReports = append(simulation.output.ExploitationReports, exploitationReport)
+			}
+		}
+	}
+
+	if len(generated.RoyaltyStatements) == 0 {
+		return nil
+	}
+	// the clients of the chaincode assign the UUIDs of the generated royalty statements, the simulation names them
+	// after their exploitation report, e.g. er1-rs1
+	royaltyStatementCounts := map[string]int{}
+	for i := range generated.RoyaltyStatements {
+		royaltyStatement := &generated.RoyaltyStatements[i]
+		royaltyStatementCounts[royaltyStatement.ExploitationReportUUID]++
+		if royaltyStatement.RoyaltyStatementUUID == "" {
+			royaltyStatement.RoyaltyStatementUUID = fmt.Sprintf("%s-rs%d", royaltyStatement.ExploitationReportUUID, royaltyStatementCounts[royaltyStatement.ExploitationReportUUID])
+		}
+	}
+	failed, err := simulation.recordAll("addRoyaltyStatements", generated.RoyaltyStatements)
+	if err != nil {
+		return err
+	}
+	for _, royaltyStatement := range generated.RoyaltyStatements {
+		if failed[royaltyStatement.RoyaltyStatementUUID] {
+			continue
+		}
+		err = simulation.addRoyaltyStatement(royaltyStatement.RoyaltyStatementUUID)
+		if err != nil {
+			return err
+		}
+	}
+	return simulation.handleEvents()
+}
+
+//handleEvents - handles the royalty statement creation events the way the listeners of their target orgs do: the
+// statement collecting each statement of a target is generated, then the statements of the target are recorded,
+// which fires the events of the next hops
+func (simulation *simulation) handleEvents() error {
+	for len(simulation.pending) > 0 {
+		royaltyStatementCreationEvent := simulation.pending[0]
+		simulation.pending = simulation.pending[1:]
+		for _, target := range royaltyStatementCreationEvent.Targets {
+			royaltyStatements := []RoyaltyStatement{}
+			for _, royaltyStatementUUID := range target.RoyaltyStatementUUIDs {
+				payload, err := simulation.invoke("generateCollectionStatement", royaltyStatementUUID, target.TargetIPI, target.Type)
+				if err != nil {
+					simulation.output.Failures = append(simulation.output.Failures, SimulationFailure{Function: "generateCollectionStatement", UUID: royaltyStatementUUID, Message: err.Error()})
+					continue
+				}
+				royaltyStatement := RoyaltyStatement{}
+				err = jsonToObject(payload, &royaltyStatement)
+				if err != nil {
+					return err
+				}
+				royaltyStatement.RoyaltyStatementUUID = simulation.nextHopUUID(royaltyStatementUUID)
+				royaltyStatements = append(royaltyStatements, royaltyStatement)
+			}
+			if len(royaltyStatements) == 0 {
+				continue
+			}
+			failed, err := simulation.recordAll("addRoyaltyStatementAndEvent", royaltyStatements)
+			if err != nil {
+				return err
+			}
+			for _, royaltyStatement := range royaltyStatements {
+				if failed[royaltyStatement.RoyaltyStatementUUID] {
+					continue
+				}
+				err = simulation.addRoyaltyStatement(royaltyStatement.RoyaltyStatementUUID)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+//nextHopUUID - names the statement collecting a royalty statement after the statement the chain starts from and
+// its hop, e.g. er1-rs1-2 for the second hop from er1-rs1, as the clients of the chaincode assign the UUIDs
+func (simulation *simulation) nextHopUUID(royaltyStatementUUID string) string {
+	hop, ok := simulation.hops[royaltyStatementUUID]
+	if !ok {
+		hop = simulationHop{root: royaltyStatementUUID}
+	}
+	hop.hop++
+	uuid := fmt.Sprintf("%s-%d", hop.root, hop.hop)
+	simulation.hops[uuid] = hop
+	return uuid
+}
+
+//recordAll - invokes a chaincode function recording assets, adds the failures of its responses and returns the UUIDs that failed
+func (simulation *simulation) recordAll(function string, assets interface{}) (map[string]bool, error) {
+	assetsBytes, err := objectToJSON(assets)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{string(assetsBytes)}
+	if function == "addRoyaltyStatements" || function == "addRoyaltyStatementAndEvent" {
+		// the royalty statements between orgs are passed in the transient map
+		simulation.stub.Transient[TRANSIENT_ROYALTY_STATEMENTS] = assetsBytes
+		defer delete(simulation.stub.Transient, TRANSIENT_ROYALTY_STATEMENTS)
+		args = nil
+	}
+	payload, err := simulation.invoke(function, args...)
+	if err != nil {
+		return nil, err
+	}
+	output := struct {
+		ExploitationReports []simulationResponse `json:"exploitationReports"`
+		RoyaltyStatements   []simulationResponse `json:"royaltyStatements"`
+	}{}
+	err = jsonToObject(payload, &output)
+	if err != nil {
+		return nil, err
+	}
+	return simulation.addFailures(function, append(output.ExploitationReports, output.RoyaltyStatements...)), nil
+}
+
+//addFailures - adds the unsuccessful responses of a function to the failures of the simulation
+func (simulation *simulation) addFailures(function string, responses []simulationResponse) map[string]bool {
+	failed := map[string]bool{}
+	for _, response := range responses {
+		if response.Success {
+			continue
+		}
+		uuid := response.ExploitationReportUUID
+		if uuid == "" {
+			uuid = response.RoyaltyStatementUUID
+		}
+		failed[uuid] = true
+		simulation.output.Failures = append(simulation.output.Failures, SimulationFailure{Function: function, UUID: uuid, Message: response.Message})
+	}
+	return failed
+}
+
+//addRoyaltyStatement - adds a royalty statement to the output as it is recorded, with the amounts of its private details
+func (simulation *simulation) addRoyaltyStatement(royaltyStatementUUID string) error {
+	royaltyStatementBytes, err := simulation.stub.GetState(royaltyStatementUUID)
+	if err != nil {
+		return err
+	}
+	if royaltyStatementBytes == nil {
+		return fmt.Errorf("Royalty statement '%s' was not recorded", royaltyStatementUUID)
+	}
+	royaltyStatement := RoyaltyStatement{}
+	err = jsonToObject(royaltyStatementBytes, &royaltyStatement)
+	if err == nil {
+		err = mergeRoyaltyStatementPrivateDetails(simulation.stub, &royaltyStatement)
+	}
+	if err != nil {
+		return err
+	}
+	simulation.output.RoyaltyStatements = append(simulation.output.RoyaltyStatements, royaltyStatement)
+	return nil
+}
+
+// main runs the simulation instead of starting the chaincode for the peer
+func main() {
+	os.Exit(simulate(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// simulate runs the simulation with its command line arguments and returns its exit status
+func simulate(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("axispoint-simulate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	copyrightDataPath := flags.String("copyright-data", "", "JSON array of the copyright data reports recorded before the replay")
+	collectionRightsPath := flags.String("collection-rights", "", "JSON array of the collection rights recorded before the replay")
+	ipiOrgsPath := flags.String("ipi-orgs", "", "JSON array of the IPI-org mappings recorded before the replay")
+	payloadsPath := flags.String("payloads", "", "chaincode payloads, one per line, invoked before the replay")
+	logLevel := flags.String("log-level", "WARNING", "level of the chaincode logs written to the standard error")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: axispoint-simulate [flags] <exploitation report file>...")
+		flags.PrintDefaults()
+	}
+	if flags.Parse(args) != nil {
+		return 1
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 1
+	}
+
+	level, err := shim.LogLevel(*logLevel)
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	shim.SetLoggingLevel(level)
+	logger.SetLevel(level)
+
+	simulation, err := newSimulation()
+	if err == nil {
+		err = simulation.loadFixtures(*copyrightDataPath, *collectionRightsPath, *ipiOrgsPath, *payloadsPath)
+	}
+	for _, path := range flags.Args() {
+		if err != nil {
+			break
+		}
+		err = simulation.replay(path)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+
+	sort.SliceStable(simulation.output.RoyaltyStatements, func(i, j int) bool {
+		return simulation.output.RoyaltyStatements[i].RoyaltyStatementUUID < simulation.output.RoyaltyStatements[j].RoyaltyStatementUUID
+	})
+	outputBytes, err := json.MarshalIndent(simulation.output, "", "  ")
+	if err != nil {
+		fmt.Fprintln(stderr, err.Error())
+		return 1
+	}
+	fmt.Fprintln(stdout, string(outputBytes))
+	if len(simulation.output.Failures) > 0 {
+		return 2
+	}
+	return 0
+}
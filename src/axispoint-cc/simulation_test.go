@@ -0,0 +1,156 @@
+// +build simulate
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// simulationSummary - the UUID, right type and amounts of the royalty statements of a simulation
+func simulationSummary(t *testing.T, stdout *bytes.Buffer) ([]string, SimulationOutput) {
+	output := SimulationOutput{}
+	err := json.Unmarshal(stdout.Bytes(), &output)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	summary := []string{}
+	for _, royaltyStatement := range output.RoyaltyStatements {
+		summary = append(summary, strings.Join([]string{royaltyStatement.RoyaltyStatementUUID, royaltyStatement.RightType, royaltyStatement.RightHolder,
+			royaltyStatement.Administrator, royaltyStatement.Collector, formatAmount(royaltyStatement.Amount), formatAmount(royaltyStatement.CollectionRight), royaltyStatement.PrivateCollection}, " "))
+	}
+	return summary, output
+}
+
+// formatAmount - formats an amount as JSON does
+func formatAmount(amount float64) string {
+	amountBytes, _ := json.Marshal(amount)
+	return string(amountBytes)
+}
+
+var expectedSimulationSummary = []string{
+	"er1-rs1 OWNERSHIP 00014107338   60 0 royalties_Org1MSP_Org3MSP",
+	"er1-rs1-1 COLLECTION 00014107338 00052110241  60 30 royalties_Org1MSP_Org2MSP",
+	"er1-rs1-2 COLLECTION 00014107338 00052110241 00073960066 60 12 ",
+	"er1-rs1-3 COLLECTION 00014107338  00073960066 60 0 ",
+	"er1-rs2 OWNERSHIP 00036478269   40 0 ",
+	"er1-rs2-1 COLLECTION 00036478269  00036478269 40 0 ",
+}
+
+func Test_Simulate(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	status := simulate([]string{
+		"-copyright-data", "testdata/simulation/copyright-data.json",
+		"-collection-rights", "testdata/simulation/collection-rights.json",
+		"-ipi-orgs", "testdata/simulation/ipi-orgs.json",
+		"testdata/simulation/exploitation-reports.json",
+	}, stdout, stderr)
+	if status != 0 {
+		t.Fatalf("Expected the simulation to succeed, got status %d: %s", status, stderr.String())
+	}
+
+	summary, output := simulationSummary(t, stdout)
+	if !reflect.DeepEqual(expectedSimulationSummary, summary) {
+		t.Fatalf("Actual royalty statements are not equal to expected royalty statements: %q", summary)
+	}
+	states := []string{}
+	for _, exploitationReport := range output.ExploitationReports {
+		states = append(states, exploitationReport.ExploitationReportUUID+" "+exploitationReport.State)
+	}
+	if !reflect.DeepEqual([]string{"er1 " + INITIAL, "er2 " + UNKOWN_ISRC}, states) {
+		t.Fatalf("Actual exploitation reports are not equal to expected exploitation reports: %q", states)
+	}
+	// the ownership statements and every hop but the last fire the event handled by the next hop
+	events := []string{}
+	for _, event := range output.Events {
+		events = append(events, event.Function+" "+event.Name)
+	}
+	expectedEvents := []string{
+		"generateExploitationReports " + EventExploitationReportClassification,
+		"addRoyaltyStatements " + EventRoyaltyStatementCreation,
+		"addRoyaltyStatementAndEvent " + EventRoyaltyStatementCreation,
+		"addRoyaltyStatementAndEvent " + EventRoyaltyStatementCreation,
+	}
+	if !reflect.DeepEqual(expectedEvents, events) {
+		t.Fatalf("Actual events are not equal to expected events: %q", events)
+	}
+	expectedPayload := `{"version":"1.0","classifications":[{"type":"UNKOWN_ISRC","isrc":"QZAB11800002","source":"00099999960","rightHolders":[],"orgs":[],"exploitationReportUUIDs":["er2"]}]}`
+	payload := &bytes.Buffer{}
+	json.Compact(payload, output.Events[0].Payload)
+	if payload.String() != expectedPayload {
+		t.Fatalf("Actual event payload is not equal to expected event payload: %s", payload)
+	}
+	if len(output.Failures) != 0 {
+		t.Fatalf("Expected no failure: %+v", output.Failures)
+	}
+}
+
+func Test_Simulate_Payloads(t *testing.T) {
+	directory, err := ioutil.TempDir("", "simulation")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(directory)
+
+	// the collection rights and the exploitation reports as the import commands write them
+	collectionRights, err := ioutil.ReadFile("testdata/simulation/collection-rights.json")
+	if err == nil {
+		collectionRights, err = json.Marshal(SimulationPayload{Function: "addCollectionRights", Args: []string{string(collectionRights)}})
+	}
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	exploitationReports, err := ioutil.ReadFile("testdata/simulation/exploitation-reports.json")
+	if err == nil {
+		exploitationReports, err = json.Marshal(SimulationPayload{Function: "generateExploitationReports", Args: []string{string(exploitationReports)}})
+	}
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	payloadsPath := filepath.Join(directory, "payloads.json")
+	reportsPath := filepath.Join(directory, "reports.json")
+	err = ioutil.WriteFile(payloadsPath, append(collectionRights, '\n'), 0644)
+	if err == nil {
+		err = ioutil.WriteFile(reportsPath, append(exploitationReports, '\n'), 0644)
+	}
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// the exploitation reports are replayed twice, the second replay can not record them
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	status := simulate([]string{
+		"-copyright-data", "testdata/simulation/copyright-data.json",
+		"-ipi-orgs", "testdata/simulation/ipi-orgs.json",
+		"-payloads", payloadsPath,
+		reportsPath, "testdata/simulation/exploitation-reports.json",
+	}, stdout, stderr)
+	if status != 2 {
+		t.Fatalf("Expected the simulation to fail to record, got status %d: %s", status, stderr.String())
+	}
+	summary, output := simulationSummary(t, stdout)
+	if !reflect.DeepEqual(expectedSimulationSummary, summary) {
+		t.Fatalf("Actual royalty statements are not equal to expected royalty statements: %q", summary)
+	}
+	expectedFailures := []SimulationFailure{
+		{Function: "generateExploitationReports", UUID: "er1", Message: "Exploitation Report already exists!"},
+		{Function: "generateExploitationReports", UUID: "er2", Message: "Exploitation Report already exists!"},
+	}
+	if !reflect.DeepEqual(expectedFailures, output.Failures) {
+		t.Fatalf("Actual failures are not equal to expected failures: %+v", output.Failures)
+	}
+
+	// a payload of another function is not an exploitation report file
+	stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+	status = simulate([]string{payloadsPath}, stdout, stderr)
+	expectedError := payloadsPath + ": payload 1 is a payload of addCollectionRights, not of generateExploitationReports\n"
+	if status != 1 || stderr.String() != expectedError {
+		t.Fatalf("Expected the payloads to be rejected, got status %d: %s", status, stderr.String())
+	}
+}
@@ -0,0 +1,17 @@
+// +build !simulate
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// main starts the chaincode for the peer, the simulate build tag replaces it with the simulation of simulation.go
+func main() {
+	err := shim.Start(new(AxispointChaincode))
+	if err != nil {
+		fmt.Printf("Error starting Axispoint chaincode: %s", err)
+	}
+}
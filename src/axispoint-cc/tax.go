@@ -0,0 +1,489 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"axispoint-cc/territory"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+/*
+* Royalties paid across borders are subject to withholding tax in the territory of the exploitation. Tax rules
+* are recorded on the ledger under TAXRULE~<source territory>~<residency>~<tax rule UUID> and the tax residency
+* of payees under TAXRESIDENCY~<ipi>. The rule for the residency of the payee applies, otherwise the rule of the
+* source territory without a residency, which never applies to payees resident in the source territory.
+*
+* The treaty rate of a rule applies instead of its rate when the payee claims treaty benefits. Payees exempt
+* from withholding and income types exempted by a rule are not taxed. A royalty statement keeps the taxable
+* amount, the tax withheld, the net payable and the rule applied, and only the net payable is outstanding.
+ */
+
+// TaxRuleResponse : defines response data from blockchain request
+type TaxRuleResponse struct {
+	TaxRuleUUID string `json:"taxRuleUUID"`
+	Message     string `json:"message"`
+	Success     bool   `json:"success"`
+}
+
+// TaxRuleOutput : defines accumulated output of blockchain requests
+type TaxRuleOutput struct {
+	SuccessCount     int               `json:"successCount"`
+	FailureCount     int               `json:"failureCount"`
+	TaxRuleResponses []TaxRuleResponse `json:"taxRuleResponses"`
+}
+
+// TaxResidencyResponse : defines response data from blockchain request
+type TaxResidencyResponse struct {
+	Ipi     string `json:"ipi"`
+	Message string `json:"message"`
+	Success bool   `json:"success"`
+}
+
+// TaxResidencyOutput : defines accumulated output of blockchain requests
+type TaxResidencyOutput struct {
+	SuccessCount          int                    `json:"successCount"`
+	FailureCount          int                    `json:"failureCount"`
+	TaxResidencyResponses []TaxResidencyResponse `json:"taxResidencyResponses"`
+}
+
+/*
+* addTaxRules function inserts new withholding tax rules to the Ledger
+*
+* @params   {Array} args
+* @property {string} 0       - stringified JSON array of tax rules.
+* @return   {pb.Response}    - peer Response
+ */
+func addTaxRules(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "addTaxRules"
+	logger.Info("ENTERING >", methodName, args)
+	return putTaxRules(stub, args, false)
+}
+
+/*
+* updateTaxRules function replaces withholding tax rules on the Ledger
+*
+* @params   {Array} args
+* @property {string} 0       - stringified JSON array of tax rules.
+* @return   {pb.Response}    - peer Response
+ */
+func updateTaxRules(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "updateTaxRules"
+	logger.Info("ENTERING >", methodName, args)
+	return putTaxRules(stub, args, true)
+}
+
+//putTaxRules - contains the business logic to insert new or update existing tax rules
+func putTaxRules(stub shim.ChaincodeStubInterface, args []string, updateFlag bool) pb.Response {
+	var methodName = "putTaxRules"
+
+	if len(args) != 1 {
+		return getErrorResponse("Missing arguments: Array of Tax Rule objects is required")
+	}
+
+	taxRuleOutput := TaxRuleOutput{}
+	taxRules := &[]TaxRule{}
+	taxRuleResponses := []TaxRuleResponse{}
+
+	err := jsonToObject([]byte(args[0]), taxRules)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	for _, taxRule := range *taxRules {
+		taxRuleResponse := TaxRuleResponse{}
+		taxRuleResponse.TaxRuleUUID = taxRule.TaxRuleUUID
+		taxRuleResponse.Success = true
+
+		err = putTaxRule(stub, &taxRule, updateFlag)
+		if err != nil {
+			taxRuleResponse.Success = false
+			taxRuleResponse.Message = err.Error()
+			taxRuleResponses = append(taxRuleResponses, taxRuleResponse)
+			taxRuleOutput.FailureCount++
+			continue
+		}
+		taxRuleOutput.SuccessCount++
+	}
+
+	taxRuleOutput.TaxRuleResponses = taxRuleResponses
+
+	objBytes, _ := objectToJSON(taxRuleOutput)
+	logger.Info("EXITING <", methodName, taxRuleOutput)
+	return shim.Success(objBytes)
+}
+
+/*
+* putTaxRule function validates a tax rule and records it on the Ledger. The source territory and the
+* residency of a rule are ISO 3166-1 country codes and cannot be updated.
+*
+* @param    {TaxRule} - tax rule
+* @param    {bool}    - updateFlag
+* @return   {error}   - Error
+ */
+func putTaxRule(stub shim.ChaincodeStubInterface, taxRule *TaxRule, updateFlag bool) error {
+	taxRule.DocType = TAXRULE
+	if taxRule.TaxRuleUUID == "" {
+		return fmt.Errorf("Tax rule UUID is required")
+	}
+	sourceTerritory, err := territory.NormalizeCountry(taxRule.SourceTerritory)
+	if err != nil {
+		return err
+	}
+	taxRule.SourceTerritory = sourceTerritory
+	if taxRule.Residency != "" {
+		residency, err := territory.NormalizeCountry(taxRule.Residency)
+		if err != nil {
+			return err
+		}
+		taxRule.Residency = residency
+	}
+	if taxRule.Rate < 0 || taxRule.Rate > 100 {
+		return fmt.Errorf("Rate %v of tax rule '%s' is not a percentage", taxRule.Rate, taxRule.TaxRuleUUID)
+	}
+	if taxRule.TreatyRate != nil && (*taxRule.TreatyRate < 0 || *taxRule.TreatyRate > 100) {
+		return fmt.Errorf("Treaty rate %v of tax rule '%s' is not a percentage", *taxRule.TreatyRate, taxRule.TaxRuleUUID)
+	}
+	for i, incomeType := range taxRule.ExemptIncomeTypes {
+		taxRule.ExemptIncomeTypes[i] = normalizeUsageType(incomeType)
+	}
+	for _, date := range []string{taxRule.StartDate, taxRule.EndDate} {
+		if date == "" {
+			continue
+		}
+		_, err = parseDate(date, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	taxRuleKey, err := stub.CreateCompositeKey(TAXRULE, []string{taxRule.SourceTerritory, taxRule.Residency, taxRule.TaxRuleUUID})
+	if err != nil {
+		return err
+	}
+	taxRuleExistingBytes, err := stub.GetState(taxRuleKey)
+	if err != nil {
+		return err
+	}
+	if !updateFlag && taxRuleExistingBytes != nil {
+		return fmt.Errorf("Tax Rule '%s' already exists!", taxRule.TaxRuleUUID)
+	}
+	if updateFlag && taxRuleExistingBytes == nil {
+		return fmt.Errorf("Tax Rule '%s' for '%s' and residency '%s' does not exist!", taxRule.TaxRuleUUID, taxRule.SourceTerritory, taxRule.Residency)
+	}
+
+	taxRuleBytes, err := objectToJSON(taxRule)
+	if err != nil {
+		return err
+	}
+	return stub.PutState(taxRuleKey, taxRuleBytes)
+}
+
+/*
+* getTaxRules function returns the tax rules of a source territory, or of a source territory and a residency
+*
+* @params   {Array} args
+* @property {string} 0       - source territory
+* @property {string} 1       - optional residency
+* @return   {pb.Response}    - peer Response
+ */
+func getTaxRules(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "getTaxRules"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) < 1 || len(args) > 2 {
+		return getErrorResponse(fmt.Sprintf("%s - Incorrect number of parameters provided '%d'. Needed source territory and optional residency", methodName, len(args)))
+	}
+
+	attributes := []string{}
+	for _, arg := range args {
+		country, err := territory.NormalizeCountry(arg)
+		if err != nil {
+			return getErrorResponse(err.Error())
+		}
+		attributes = append(attributes, country)
+	}
+	taxRules, err := getTaxRulesFromLedger(stub, attributes)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	objBytes, err := objectToJSON(taxRules)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	logger.Info("EXITING <", methodName, len(taxRules))
+	return shim.Success(objBytes)
+}
+
+//getTaxRulesFromLedger - returns the tax rules recorded on the ledger under the partial key of a source territory and optional residency
+func getTaxRulesFromLedger(stub shim.ChaincodeStubInterface, attributes []string) ([]TaxRule, error) {
+	taxRules := []TaxRule{}
+	taxRuleIterator, err := stub.GetStateByPartialCompositeKey(TAXRULE, attributes)
+	if err != nil {
+		return nil, err
+	}
+	defer taxRuleIterator.Close()
+	for taxRuleIterator.HasNext() {
+		queryResponse, err := taxRuleIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		taxRule := TaxRule{}
+		err = jsonToObject(queryResponse.Value, &taxRule)
+		if err != nil {
+			return nil, err
+		}
+		taxRules = append(taxRules, taxRule)
+	}
+	return taxRules, nil
+}
+
+/*
+* addTaxResidencies function records the tax residency of payees on the Ledger
+*
+* @params   {Array} args
+* @property {string} 0       - stringified JSON array of tax residencies.
+* @return   {pb.Response}    - peer Response
+ */
+func addTaxResidencies(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "addTaxResidencies"
+	logger.Info("ENTERING >", methodName, args)
+	return putTaxResidencies(stub, args, false)
+}
+
+/*
+* updateTaxResidencies function replaces the tax residency of payees on the Ledger
+*
+* @params   {Array} args
+* @property {string} 0       - stringified JSON array of tax residencies.
+* @return   {pb.Response}    - peer Response
+ */
+func updateTaxResidencies(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "updateTaxResidencies"
+	logger.Info("ENTERING >", methodName, args)
+	return putTaxResidencies(stub, args, true)
+}
+
+//putTaxResidencies - contains the business logic to insert new or update existing tax residencies
+func putTaxResidencies(stub shim.ChaincodeStubInterface, args []string, updateFlag bool) pb.Response {
+	var methodName = "putTaxResidencies"
+
+	if len(args) != 1 {
+		return getErrorResponse("Missing arguments: Array of Tax Residency objects is required")
+	}
+
+	taxResidencyOutput := TaxResidencyOutput{}
+	taxResidencies := &[]TaxResidency{}
+	taxResidencyResponses := []TaxResidencyResponse{}
+
+	err := jsonToObject([]byte(args[0]), taxResidencies)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	for _, taxResidency := range *taxResidencies {
+		taxResidencyResponse := TaxResidencyResponse{}
+		taxResidencyResponse.Ipi = taxResidency.Ipi
+		taxResidencyResponse.Success = true
+
+		err = putTaxResidency(stub, &taxResidency, updateFlag)
+		if err != nil {
+			taxResidencyResponse.Success = false
+			taxResidencyResponse.Message = err.Error()
+			taxResidencyResponses = append(taxResidencyResponses, taxResidencyResponse)
+			taxResidencyOutput.FailureCount++
+			continue
+		}
+		taxResidencyOutput.SuccessCount++
+	}
+
+	taxResidencyOutput.TaxResidencyResponses = taxResidencyResponses
+
+	objBytes, _ := objectToJSON(taxResidencyOutput)
+	logger.Info("EXITING <", methodName, taxResidencyOutput)
+	return shim.Success(objBytes)
+}
+
+//putTaxResidency - validates the tax residency of a payee and records it on the Ledger
+func putTaxResidency(stub shim.ChaincodeStubInterface, taxResidency *TaxResidency, updateFlag bool) error {
+	taxResidency.DocType = TAXRESIDENCY
+	ipi, err := normalizeIpi(taxResidency.Ipi)
+	if err != nil {
+		return err
+	}
+	if ipi == "" {
+		return fmt.Errorf("IPI of the tax residency is required")
+	}
+	taxResidency.Ipi = ipi
+	country, err := territory.NormalizeCountry(taxResidency.Country)
+	if err != nil {
+		return err
+	}
+	taxResidency.Country = country
+
+	previousTaxResidency, err := getTaxResidencyFromLedger(stub, taxResidency.Ipi)
+	if err != nil {
+		return err
+	}
+	if !updateFlag && previousTaxResidency != nil {
+		return fmt.Errorf("Tax Residency of '%s' already exists!", taxResidency.Ipi)
+	}
+	if updateFlag && previousTaxResidency == nil {
+		return fmt.Errorf("Tax Residency of '%s' does not exist!", taxResidency.Ipi)
+	}
+
+	taxResidencyKey, err := stub.CreateCompositeKey(TAXRESIDENCY, []string{taxResidency.Ipi})
+	if err != nil {
+		return err
+	}
+	taxResidencyBytes, err := objectToJSON(taxResidency)
+	if err != nil {
+		return err
+	}
+	return stub.PutState(taxResidencyKey, taxResidencyBytes)
+}
+
+/*
+* getTaxResidency function returns the tax residency of a payee
+*
+* @params   {Array} args
+* @property {string} 0       - payee IPI
+* @return   {pb.Response}    - peer Response
+ */
+func getTaxResidency(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "getTaxResidency"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) != 1 {
+		return getErrorResponse("Missing arguments: Payee IPI is required")
+	}
+	ipi, err := normalizeIpi(args[0])
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	taxResidency, err := getTaxResidencyFromLedger(stub, ipi)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	if taxResidency == nil {
+		return getErrorResponse(fmt.Sprintf("Tax Residency of '%s' does not exist!", ipi))
+	}
+
+	objBytes, err := objectToJSON(taxResidency)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	logger.Info("EXITING <", methodName, taxResidency.Country)
+	return shim.Success(objBytes)
+}
+
+//getTaxResidencyFromLedger - returns the tax residency of a payee recorded on the ledger or nil
+func getTaxResidencyFromLedger(stub shim.ChaincodeStubInterface, ipi string) (*TaxResidency, error) {
+	taxResidencyKey, err := stub.CreateCompositeKey(TAXRESIDENCY, []string{ipi})
+	if err != nil {
+		return nil, err
+	}
+	taxResidencyBytes, err := stub.GetState(taxResidencyKey)
+	if err != nil || taxResidencyBytes == nil {
+		return nil, err
+	}
+	taxResidency := TaxResidency{}
+	err = jsonToObject(taxResidencyBytes, &taxResidency)
+	if err != nil {
+		return nil, err
+	}
+	return &taxResidency, nil
+}
+
+//getApplicableTaxRule - returns the tax rule for a payee resident in a country on a date, the rule of its residency before the rule without residency
+func getApplicableTaxRule(taxRules []TaxRule, residency string, date string) *TaxRule {
+	sort.SliceStable(taxRules, func(i, j int) bool {
+		if (taxRules[i].Residency == "") != (taxRules[j].Residency == "") {
+			return taxRules[i].Residency != ""
+		}
+		return taxRules[i].TaxRuleUUID < taxRules[j].TaxRuleUUID
+	})
+	for i := range taxRules {
+		if taxRules[i].Residency != "" && taxRules[i].Residency != residency {
+			continue
+		}
+		// the rule without residency is for foreign payees
+		if taxRules[i].Residency == "" && residency == taxRules[i].SourceTerritory {
+			continue
+		}
+		if !isDateInEffect(taxRules[i].StartDate, taxRules[i].EndDate, date) {
+			continue
+		}
+		return &taxRules[i]
+	}
+	return nil
+}
+
+//isIncomeTypeExempt - returns whether a tax rule exempts the income type of a royalty statement
+func isIncomeTypeExempt(taxRule TaxRule, royaltyStatement RoyaltyStatement) bool {
+	for _, incomeType := range taxRule.ExemptIncomeTypes {
+		if incomeType == royaltyStatement.RightCategory || incomeType == normalizeUsageType(royaltyStatement.UsageType) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+* applyWithholdingTax function sets the withholding tax of a royalty statement from the rule for the territory
+* of its exploitation and the residency of its payee. Statements without an applicable rule are not taxed. The tax
+* is withheld from the whole amount, advances are recouped from what is left after tax.
+*
+* @param    {RoyaltyStatement} - royalty statement, its taxable amount, tax withheld and net payable are set
+* @return   {error}            - Error
+ */
+func applyWithholdingTax(stub shim.ChaincodeStubInterface, royaltyStatement *RoyaltyStatement) error {
+	royaltyStatement.TaxRuleUUID = ""
+	royaltyStatement.TaxRate = 0
+	royaltyStatement.TaxableAmount = 0
+	royaltyStatement.TaxWithheld = 0
+	defer setNetPayable(royaltyStatement)
+
+	_, payee, amount := getRoyaltyStatementParties(*royaltyStatement)
+	sourceTerritory, err := territory.NormalizeCountry(royaltyStatement.Territory)
+	if payee == "" || amount <= 0 || err != nil {
+		return nil
+	}
+	taxRules, err := getTaxRulesFromLedger(stub, []string{sourceTerritory})
+	if err != nil || len(taxRules) == 0 {
+		return err
+	}
+	taxResidency, err := getTaxResidencyFromLedger(stub, payee)
+	if err != nil {
+		return err
+	}
+	residency := ""
+	if taxResidency != nil {
+		residency = taxResidency.Country
+	}
+	taxRule := getApplicableTaxRule(taxRules, residency, royaltyStatement.ExploitationDate)
+	if taxRule == nil {
+		return nil
+	}
+
+	rate := taxRule.Rate
+	if taxRule.TreatyRate != nil && taxResidency != nil && taxResidency.TreatyClaimed {
+		rate = *taxRule.TreatyRate
+	}
+	if (taxResidency != nil && taxResidency.Exempt) || isIncomeTypeExempt(*taxRule, *royaltyStatement) {
+		rate = 0
+	}
+	royaltyStatement.TaxRuleUUID = taxRule.TaxRuleUUID
+	royaltyStatement.TaxRate = rate
+	royaltyStatement.TaxableAmount = amount
+	royaltyStatement.TaxWithheld = toFixed(amount*rate*0.01, 2)
+	return nil
+}
+
+//setNetPayable - sets the amount of a royalty statement paid to its payee, after the tax withheld and the amount recouped for advances
+func setNetPayable(royaltyStatement *RoyaltyStatement) {
+	_, _, amount := getRoyaltyStatementParties(*royaltyStatement)
+	royaltyStatement.NetPayable = toFixed(amount-royaltyStatement.TaxWithheld-royaltyStatement.RecoupedAmount, 2)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"axispoint-cc/memstub"
+)
+
+var taxRules_in = `[` +
+	`{"taxRuleUUID":"tr1","sourceTerritory":"USA","rate":30},` +
+	`{"taxRuleUUID":"tr2","sourceTerritory":"US","residency":"GBR","rate":30,"treatyRate":0},` +
+	`{"taxRuleUUID":"tr3","sourceTerritory":"US","residency":"DE","rate":30,"treatyRate":15,"exemptIncomeTypes":["performance"]}]`
+
+var taxResidencies_in = `[` +
+	`{"ipi":"GB-IPI","country":"GB","treatyClaimed":true},` +
+	`{"ipi":"DE-IPI","country":"DEU"},` +
+	`{"ipi":"US-IPI","country":"US"},` +
+	`{"ipi":"CMO-IPI","country":"FR","exempt":true}]`
+
+func MockGetTaxResponse(functionName string) []byte {
+	switch functionName {
+	case "Test_AddTaxRules":
+		return []byte(`{"successCount":0,"failureCount":3,"taxRuleResponses":[` +
+			`{"taxRuleUUID":"tr1","message":"Tax Rule 'tr1' already exists!","success":false},` +
+			`{"taxRuleUUID":"tr4","message":"unknown territory 'XX'","success":false},` +
+			`{"taxRuleUUID":"tr5","message":"Treaty rate 120 of tax rule 'tr5' is not a percentage","success":false}]}`)
+	case "Test_GetTaxRules":
+		return []byte(`[{"docType":"TAXRULE","taxRuleUUID":"tr3","sourceTerritory":"US","residency":"DE","rate":30,"treatyRate":15,"exemptIncomeTypes":["PERFORMANCE"]}]`)
+	default:
+		return []byte("[]")
+	}
+}
+
+var taxedCopyrightDataReports = []string{
+	`{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"cdr1","isrc":"QZAB11800001","startDate":"2018-01-01","endDate":"2018-12-31","rightHolders":[` +
+		`{"selector":"","ipi":"GB-IPI","percent":20},{"selector":"","ipi":"DE-IPI","percent":20},{"selector":"","ipi":"US-IPI","percent":20},` +
+		`{"selector":"","ipi":"CMO-IPI","percent":20},{"selector":"","ipi":"NORES-IPI","percent":20}]}`,
+}
+
+func setupTaxRules(t *testing.T) *memstub.Stub {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addTaxRules"), []byte(taxRules_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, err = checkInvoke(t, stub, [][]byte{[]byte("addTaxResidencies"), []byte(taxResidencies_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	return stub
+}
+
+func Test_AddTaxRules(t *testing.T) {
+	stub := setupTaxRules(t)
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("addTaxRules"), []byte(`[` +
+		`{"taxRuleUUID":"tr1","sourceTerritory":"US","rate":25},` +
+		`{"taxRuleUUID":"tr4","sourceTerritory":"XX","rate":25},` +
+		`{"taxRuleUUID":"tr5","sourceTerritory":"FR","rate":25,"treatyRate":120}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := MockGetTaxResponse("Test_AddTaxRules")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("addTaxResidencies"), []byte(`[{"ipi":"GB-IPI","country":"FR"}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected = []byte(`{"successCount":0,"failureCount":1,"taxResidencyResponses":[{"ipi":"GB-IPI","message":"Tax Residency of 'GB-IPI' already exists!","success":false}]}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_GetTaxRules(t *testing.T) {
+	stub := setupTaxRules(t)
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getTaxRules"), []byte("usa"), []byte("deu")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := MockGetTaxResponse("Test_GetTaxRules")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("getTaxResidency"), []byte("DE-IPI")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected = []byte(`{"docType":"TAXRESIDENCY","ipi":"DE-IPI","country":"DE","treatyClaimed":false,"exempt":false}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_GenerateExploitationReports_WithholdingTax(t *testing.T) {
+	stub := setupTaxRules(t)
+	putDocuments(t, stub, "copyrightDataReportUUID", taxedCopyrightDataReports)
+
+	// the treaty rate applies to GB-IPI, DE-IPI does not claim its treaty, US-IPI is a domestic payee,
+	// CMO-IPI is exempt and NORES-IPI has no known residency
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("generateExploitationReports"), []byte(`[` +
+		`{"source":"spotify-IPI","isrc":"QZAB11800001","units":1,"exploitationDate":"2018-12-30","amount":100,"territory":"US","exploitationReportUUID":"er1"}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	output := struct {
+		RoyaltyStatements []RoyaltyStatement `json:"royaltyStatements"`
+	}{}
+	err = jsonToObject(actual, &output)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actualTaxes := map[string][]interface{}{}
+	for _, royaltyStatement := range output.RoyaltyStatements {
+		actualTaxes[royaltyStatement.RightHolder] = []interface{}{royaltyStatement.TaxRuleUUID, royaltyStatement.TaxableAmount, royaltyStatement.TaxWithheld, royaltyStatement.NetPayable}
+	}
+	expectedTaxes := map[string][]interface{}{
+		"GB-IPI":    {"tr2", 20.0, 0.0, 20.0},
+		"DE-IPI":    {"tr3", 20.0, 6.0, 14.0},
+		"US-IPI":    {"", 0.0, 0.0, 20.0},
+		"CMO-IPI":   {"tr1", 20.0, 0.0, 20.0},
+		"NORES-IPI": {"tr1", 20.0, 6.0, 14.0},
+	}
+	if !reflect.DeepEqual(expectedTaxes, actualTaxes) {
+		t.Fatalf("Actual response is not equal to expected response: %v", actualTaxes)
+	}
+}
+
+func Test_AddRoyaltyStatements_WithholdingTax(t *testing.T) {
+	stub := setupTaxRules(t)
+
+	// the performance income of DE-IPI is exempt
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addRoyaltyStatements"), []byte(`[` +
+		`{"royaltyStatementUUID":"rs1","source":"spotify-IPI","isrc":"QZAB11800001","exploitationDate":"2018-12-30","amount":10,"rightType":"OWNERSHIP","territory":"US","usageType":"DOWNLOAD","rightHolder":"DE-IPI"},` +
+		`{"royaltyStatementUUID":"rs2","source":"spotify-IPI","isrc":"QZAB11800001","exploitationDate":"2018-12-30","amount":10,"rightType":"OWNERSHIP","territory":"US","usageType":"PERFORMANCE","rightHolder":"DE-IPI"}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	royaltyStatement := RoyaltyStatement{}
+	err = jsonToObject(stub.State["rs1"], &royaltyStatement)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if royaltyStatement.TaxWithheld != 3 || royaltyStatement.NetPayable != 7 {
+		t.Fatalf("Actual response is not equal to expected response: %s", stub.State["rs1"])
+	}
+
+	// only the net payable is outstanding
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getBalances"), []byte(`{"ipi":"DE-IPI"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	balances := []Balance{}
+	err = jsonToObject(actual, &balances)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(balances) != 1 || balances[0].Receivable != 17 {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+}
+
+func Test_AddRoyaltyStatements_WithholdingTaxAndRecoupment(t *testing.T) {
+	stub := setupTaxRules(t)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addAdvances"), []byte(`[` +
+		`{"advanceUUID":"adv1","payee":"NORES-IPI","payer":"spotify-IPI","amount":200,"recoupmentRate":100},` +
+		`{"advanceUUID":"adv2","payee":"US-IPI","payer":"spotify-IPI","amount":200,"recoupmentRate":50}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	// the advance of NORES-IPI is recouped from what is left after tax, US-IPI is not taxed
+	_, err = checkInvoke(t, stub, [][]byte{[]byte("addRoyaltyStatements"), []byte(`[` +
+		`{"royaltyStatementUUID":"rs1","source":"spotify-IPI","isrc":"QZAB11800001","exploitationDate":"2018-12-30","amount":100,"rightType":"OWNERSHIP","territory":"US","rightHolder":"NORES-IPI"},` +
+		`{"royaltyStatementUUID":"rs2","source":"spotify-IPI","isrc":"QZAB11800001","exploitationDate":"2018-12-30","amount":100,"rightType":"OWNERSHIP","territory":"US","rightHolder":"US-IPI"}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actualAmounts := [][]float64{}
+	for _, royaltyStatementUUID := range []string{"rs1", "rs2"} {
+		royaltyStatement := RoyaltyStatement{}
+		err = jsonToObject(stub.State[royaltyStatementUUID], &royaltyStatement)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		actualAmounts = append(actualAmounts, []float64{royaltyStatement.TaxWithheld, royaltyStatement.RecoupedAmount, royaltyStatement.NetPayable})
+	}
+	expectedAmounts := [][]float64{{30, 70, 0}, {0, 50, 50}}
+	if !reflect.DeepEqual(expectedAmounts, actualAmounts) {
+		t.Fatalf("Actual response is not equal to expected response: %v", actualAmounts)
+	}
+
+	// the net payable is what is outstanding
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getBalances"), []byte(`{"ipi":"US-IPI"}`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	balances := []Balance{}
+	err = jsonToObject(actual, &balances)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(balances) != 1 || balances[0].Receivable != 50 {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+}
+
+func Test_GenerateExploitationReports_WithholdingTaxFailure(t *testing.T) {
+	stub := setupTaxRules(t)
+	putDocuments(t, stub, "copyrightDataReportUUID", taxedCopyrightDataReports)
+
+	// the residency of DE-IPI cannot be read, none of the statements of the report are generated
+	taxResidencyKey, _ := stub.CreateCompositeKey(TAXRESIDENCY, []string{"DE-IPI"})
+	stub.State[taxResidencyKey] = []byte("{")
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("generateExploitationReports"), []byte(`[` +
+		`{"source":"spotify-IPI","isrc":"QZAB11800001","units":1,"exploitationDate":"2018-12-30","amount":100,"territory":"US","exploitationReportUUID":"er1"}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	output := struct {
+		FailureCount      int                `json:"failureCount"`
+		RoyaltyStatements []RoyaltyStatement `json:"royaltyStatements"`
+	}{}
+	err = jsonToObject(actual, &output)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if output.FailureCount != 1 || len(output.RoyaltyStatements) != 0 || !strings.Contains(string(actual), "DE-IPI") {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+}
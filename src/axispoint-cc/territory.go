@@ -0,0 +1,300 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"axispoint-cc/territory"
+	"github.com/Knetic/govaluate"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+/*
+* Territories are ISO 3166-1 country codes or named groups of territories. Groups are recorded on the ledger
+* under TERRITORYGROUP~<code> and include countries and other groups minus their exclusions, e.g. a deal for
+* the "World excluding US/CA" is written for a group including WORLD and excluding US and CA. The WORLD, EU
+* and LATAM groups are predefined and can be redefined on the ledger.
+*
+* Copyright data reports and collection rights apply in their territories minus their excluded territories,
+* everywhere when they name none, and selectors test territories with within(Territory, 'EU', ...).
+ */
+
+// TerritoryGroupResponse : defines response data from blockchain request
+type TerritoryGroupResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Success bool   `json:"success"`
+}
+
+// TerritoryGroupOutput : defines accumulated output of blockchain requests
+type TerritoryGroupOutput struct {
+	SuccessCount            int                      `json:"successCount"`
+	FailureCount            int                      `json:"failureCount"`
+	TerritoryGroupResponses []TerritoryGroupResponse `json:"territoryGroupResponses"`
+}
+
+/*
+* addTerritoryGroups function inserts new territory groups to the Ledger
+*
+* @params   {Array} args
+* @property {string} 0       - stringified JSON array of territory groups.
+* @return   {pb.Response}    - peer Response
+ */
+func addTerritoryGroups(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "addTerritoryGroups"
+	logger.Info("ENTERING >", methodName, args)
+	return putTerritoryGroups(stub, args, false)
+}
+
+/*
+* updateTerritoryGroups function replaces territory groups on the Ledger, a predefined group is redefined
+* by its first update
+*
+* @params   {Array} args
+* @property {string} 0       - stringified JSON array of territory groups.
+* @return   {pb.Response}    - peer Response
+ */
+func updateTerritoryGroups(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "updateTerritoryGroups"
+	logger.Info("ENTERING >", methodName, args)
+	return putTerritoryGroups(stub, args, true)
+}
+
+//putTerritoryGroups - contains the business logic to insert new or update existing territory groups
+func putTerritoryGroups(stub shim.ChaincodeStubInterface, args []string, updateFlag bool) pb.Response {
+	var methodName = "putTerritoryGroups"
+
+	if len(args) != 1 {
+		return getErrorResponse("Missing arguments: Array of Territory Group objects is required")
+	}
+
+	territoryGroupOutput := TerritoryGroupOutput{}
+	territoryGroups := &[]TerritoryGroup{}
+	territoryGroupResponses := []TerritoryGroupResponse{}
+
+	err := jsonToObject([]byte(args[0]), territoryGroups)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	for _, territoryGroup := range *territoryGroups {
+		territoryGroupResponse := TerritoryGroupResponse{}
+		territoryGroupResponse.Code = territoryGroup.Code
+		territoryGroupResponse.Success = true
+
+		err = putTerritoryGroup(stub, &territoryGroup, updateFlag)
+		if err != nil {
+			territoryGroupResponse.Success = false
+			territoryGroupResponse.Message = err.Error()
+			territoryGroupResponses = append(territoryGroupResponses, territoryGroupResponse)
+			territoryGroupOutput.FailureCount++
+			continue
+		}
+		territoryGroupOutput.SuccessCount++
+	}
+
+	territoryGroupOutput.TerritoryGroupResponses = territoryGroupResponses
+
+	objBytes, _ := objectToJSON(territoryGroupOutput)
+	logger.Info("EXITING <", methodName, territoryGroupOutput)
+	return shim.Success(objBytes)
+}
+
+/*
+* putTerritoryGroup function validates a territory group and records it on the Ledger. Its includes and
+* excludes must be countries or known groups and it may not include itself.
+*
+* @param    {TerritoryGroup} - territory group
+* @param    {bool}           - updateFlag
+* @return   {error}          - Error
+ */
+func putTerritoryGroup(stub shim.ChaincodeStubInterface, territoryGroup *TerritoryGroup, updateFlag bool) error {
+	territoryGroup.DocType = TERRITORYGROUP
+	territoryGroup.Code = territory.NormalizeGroupCode(territoryGroup.Code)
+	territoryGroup.Countries = nil
+	if territoryGroup.Code == "" {
+		return fmt.Errorf("Territory group code is required")
+	}
+	if territory.IsCountry(territoryGroup.Code) {
+		return fmt.Errorf("Territory group '%s' cannot use the code of a country", territoryGroup.Code)
+	}
+
+	previousTerritoryGroup, err := getTerritoryGroupFromLedger(stub, territoryGroup.Code)
+	if err != nil {
+		return err
+	}
+	_, isDefaultGroup := territory.DefaultGroup(territoryGroup.Code)
+	if !updateFlag && (previousTerritoryGroup != nil || isDefaultGroup) {
+		return fmt.Errorf("Territory Group '%s' already exists!", territoryGroup.Code)
+	}
+	if updateFlag && previousTerritoryGroup == nil && !isDefaultGroup {
+		return fmt.Errorf("Territory Group '%s' does not exist!", territoryGroup.Code)
+	}
+
+	// resolve the group as it will be recorded to reject unknown territories and groups including themselves
+	lookup := getTerritoryGroupLookup(stub)
+	_, err = territory.Resolve(territoryGroup.Code, func(code string) (territory.Group, bool, error) {
+		if code == territoryGroup.Code {
+			return toGroup(*territoryGroup), true, nil
+		}
+		return lookup(code)
+	})
+	if err != nil {
+		return err
+	}
+
+	territoryGroupKey, err := stub.CreateCompositeKey(TERRITORYGROUP, []string{territoryGroup.Code})
+	if err != nil {
+		return err
+	}
+	territoryGroupBytes, err := objectToJSON(territoryGroup)
+	if err != nil {
+		return err
+	}
+	return stub.PutState(territoryGroupKey, territoryGroupBytes)
+}
+
+/*
+* getTerritory function returns the sorted ISO 3166-1 alpha-2 codes of the countries of a territory, with
+* the definition of the group when the territory is a group
+*
+* @params   {Array} args
+* @property {string} 0       - country code or group code
+* @return   {pb.Response}    - peer Response
+ */
+func getTerritory(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "getTerritory"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) != 1 {
+		return getErrorResponse("Missing arguments: Territory code is required")
+	}
+
+	territoryGroup := TerritoryGroup{DocType: TERRITORYGROUP, Code: territory.NormalizeGroupCode(args[0])}
+	lookup := getTerritoryGroupLookup(stub)
+	if country, err := territory.NormalizeCountry(args[0]); err == nil {
+		territoryGroup.Code = country
+	} else {
+		group, found, err := lookup(territoryGroup.Code)
+		if err != nil {
+			return getErrorResponse(err.Error())
+		}
+		if found {
+			territoryGroup.Name = group.Name
+			territoryGroup.Includes = group.Includes
+			territoryGroup.Excludes = group.Excludes
+		}
+	}
+
+	countrySet, err := territory.Resolve(territoryGroup.Code, lookup)
+	if err != nil {
+		return getErrorResponse(fmt.Sprintf("%s - %s", methodName, err.Error()))
+	}
+	territoryGroup.Countries = []string{}
+	for country := range countrySet {
+		territoryGroup.Countries = append(territoryGroup.Countries, country)
+	}
+	sort.Strings(territoryGroup.Countries)
+
+	objBytes, err := objectToJSON(territoryGroup)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	logger.Info("EXITING <", methodName, territoryGroup.Code, len(territoryGroup.Countries))
+	return shim.Success(objBytes)
+}
+
+//getTerritoryGroupFromLedger - returns the territory group recorded on the ledger or nil
+func getTerritoryGroupFromLedger(stub shim.ChaincodeStubInterface, code string) (*TerritoryGroup, error) {
+	territoryGroupKey, err := stub.CreateCompositeKey(TERRITORYGROUP, []string{code})
+	if err != nil {
+		return nil, err
+	}
+	territoryGroupBytes, err := stub.GetState(territoryGroupKey)
+	if err != nil || territoryGroupBytes == nil {
+		return nil, err
+	}
+	territoryGroup := TerritoryGroup{}
+	err = jsonToObject(territoryGroupBytes, &territoryGroup)
+	if err != nil {
+		return nil, err
+	}
+	return &territoryGroup, nil
+}
+
+//getTerritoryGroupLookup - returns the lookup of the groups on the ledger, falling back to the predefined groups
+func getTerritoryGroupLookup(stub shim.ChaincodeStubInterface) territory.GroupLookup {
+	return func(code string) (territory.Group, bool, error) {
+		territoryGroup, err := getTerritoryGroupFromLedger(stub, code)
+		if err != nil {
+			return territory.Group{}, false, err
+		}
+		if territoryGroup != nil {
+			return toGroup(*territoryGroup), true, nil
+		}
+		group, found := territory.DefaultGroup(code)
+		return group, found, nil
+	}
+}
+
+//toGroup - converts a territory group asset to a group of the territory package
+func toGroup(territoryGroup TerritoryGroup) territory.Group {
+	return territory.Group{Code: territoryGroup.Code, Name: territoryGroup.Name, Includes: territoryGroup.Includes, Excludes: territoryGroup.Excludes}
+}
+
+//isTerritoryInArea - returns whether a territory is within the included territories and none of the excluded ones,
+//no included territories stand for the world
+func isTerritoryInArea(stub shim.ChaincodeStubInterface, exploitationTerritory string, includes []string, excludes []string) bool {
+	within, err := territory.IsWithin(exploitationTerritory, includes, excludes, getTerritoryGroupLookup(stub))
+	if err != nil {
+		logger.Errorf("Failed to resolve the territories %v excluding %v. Error: %s", includes, excludes, err.Error())
+		return false
+	}
+	return within
+}
+
+//validateTerritories - checks that territories are countries or known groups
+func validateTerritories(stub shim.ChaincodeStubInterface, territoryLists ...[]string) error {
+	lookup := getTerritoryGroupLookup(stub)
+	for _, territories := range territoryLists {
+		for _, code := range territories {
+			_, err := territory.Resolve(code, lookup)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//getSelectorFunctions - returns the functions selectors can call, within(Territory, 'EU', ...) tests whether a territory is within any of the areas
+func getSelectorFunctions(stub shim.ChaincodeStubInterface) map[string]govaluate.ExpressionFunction {
+	return map[string]govaluate.ExpressionFunction{
+		"within": func(arguments ...interface{}) (interface{}, error) {
+			if len(arguments) < 2 {
+				return false, fmt.Errorf("within needs a territory and at least one area")
+			}
+			exploitationTerritory, _ := arguments[0].(string)
+			areas := []string{}
+			for _, argument := range arguments[1:] {
+				area, ok := argument.(string)
+				if !ok {
+					return false, fmt.Errorf("within expects territory codes, got '%v'", argument)
+				}
+				areas = append(areas, area)
+			}
+			return territory.IsWithin(exploitationTerritory, areas, nil, getTerritoryGroupLookup(stub))
+		},
+	}
+}
+
+//validateExploitationReportTerritory - flags an exploitation report with the INVALID_TERRITORY state when its territory is not a country code
+func validateExploitationReportTerritory(exploitationReport *ExploitationReport) bool {
+	if exploitationReport.Territory == "" || territory.IsCountry(exploitationReport.Territory) {
+		return true
+	}
+	logger.Warningf("Exploitation report '%s' flagged: unknown territory '%s'", exploitationReport.ExploitationReportUUID, exploitationReport.Territory)
+	exploitationReport.State = INVALID_TERRITORY
+	return false
+}
@@ -0,0 +1,173 @@
+/*
+Package territory resolves the territories of exploitations, rights and selectors.
+
+Countries are identified by their ISO 3166-1 codes, alpha-2 or alpha-3, and are normalized to alpha-2. Groups
+of territories are named, e.g. EU, and include countries and other groups minus their exclusions, e.g. a
+group "World excluding US/CA" includes WORLD and excludes US and CA. The WORLD, EU and LATAM groups are
+predefined.
+*/
+package territory
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownTerritory is returned when a code is neither an ISO 3166-1 country code nor a known group
+var ErrUnknownTerritory = errors.New("unknown territory")
+
+// Predefined group codes
+const (
+	World = "WORLD"
+	EU    = "EU"
+	LATAM = "LATAM"
+)
+
+// Group - named group of territories, its countries are the countries of its includes minus the countries of its excludes
+type Group struct {
+	Code     string
+	Name     string
+	Includes []string
+	Excludes []string
+}
+
+// alpha3Countries - alpha-2 codes by alpha-3 code
+var alpha3Countries = map[string]string{}
+
+func init() {
+	for alpha2, alpha3 := range countries {
+		alpha3Countries[alpha3] = alpha2
+	}
+}
+
+// NormalizeCountry returns the ISO 3166-1 alpha-2 code of a country given its alpha-2 or alpha-3 code, in any case
+func NormalizeCountry(code string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(code))
+	if _, ok := countries[normalized]; ok {
+		return normalized, nil
+	}
+	if alpha2, ok := alpha3Countries[normalized]; ok {
+		return alpha2, nil
+	}
+	return "", fmt.Errorf("%s '%s'", ErrUnknownTerritory.Error(), code)
+}
+
+// IsCountry returns whether a code is an ISO 3166-1 alpha-2 or alpha-3 country code
+func IsCountry(code string) bool {
+	_, err := NormalizeCountry(code)
+	return err == nil
+}
+
+// NormalizeGroupCode returns the code of a group as it is recorded
+func NormalizeGroupCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Countries returns the sorted alpha-2 codes of all countries
+func Countries() []string {
+	codes := make([]string, 0, len(countries))
+	for alpha2 := range countries {
+		codes = append(codes, alpha2)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// DefaultGroup returns a predefined group
+func DefaultGroup(code string) (Group, bool) {
+	switch NormalizeGroupCode(code) {
+	case World:
+		return Group{Code: World, Name: "World", Includes: Countries()}, true
+	case EU:
+		return Group{Code: EU, Name: "European Union", Includes: append([]string{}, euMembers...)}, true
+	case LATAM:
+		return Group{Code: LATAM, Name: "Latin America", Includes: append([]string{}, latamMembers...)}, true
+	}
+	return Group{}, false
+}
+
+// GroupLookup returns the group with a code, or false when there is none
+type GroupLookup func(code string) (Group, bool, error)
+
+// Resolve returns the set of alpha-2 country codes of a country or a group
+func Resolve(code string, lookup GroupLookup) (map[string]bool, error) {
+	return resolve(code, lookup, map[string]bool{})
+}
+
+func resolve(code string, lookup GroupLookup, resolving map[string]bool) (map[string]bool, error) {
+	if country, err := NormalizeCountry(code); err == nil {
+		return map[string]bool{country: true}, nil
+	}
+	groupCode := NormalizeGroupCode(code)
+	if resolving[groupCode] {
+		return nil, fmt.Errorf("territory group '%s' includes itself", groupCode)
+	}
+	group, found, err := lookup(groupCode)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%s '%s'", ErrUnknownTerritory.Error(), code)
+	}
+
+	resolving[groupCode] = true
+	defer delete(resolving, groupCode)
+	countrySet := map[string]bool{}
+	for _, include := range group.Includes {
+		includedCountries, err := resolve(include, lookup, resolving)
+		if err != nil {
+			return nil, err
+		}
+		for country := range includedCountries {
+			countrySet[country] = true
+		}
+	}
+	for _, exclude := range group.Excludes {
+		excludedCountries, err := resolve(exclude, lookup, resolving)
+		if err != nil {
+			return nil, err
+		}
+		for country := range excludedCountries {
+			delete(countrySet, country)
+		}
+	}
+	return countrySet, nil
+}
+
+// IsWithin returns whether a country is within any of the includes and none of the excludes. No includes
+// stand for the world. A territory that is not a country is never within an area.
+func IsWithin(code string, includes []string, excludes []string, lookup GroupLookup) (bool, error) {
+	country, err := NormalizeCountry(code)
+	if err != nil {
+		return false, nil
+	}
+	if len(includes) == 0 {
+		includes = []string{World}
+	}
+	within := false
+	for _, include := range includes {
+		countrySet, err := Resolve(include, lookup)
+		if err != nil {
+			return false, err
+		}
+		if countrySet[country] {
+			within = true
+			break
+		}
+	}
+	if !within {
+		return false, nil
+	}
+	for _, exclude := range excludes {
+		countrySet, err := Resolve(exclude, lookup)
+		if err != nil {
+			return false, err
+		}
+		if countrySet[country] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
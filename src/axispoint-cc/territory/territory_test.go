@@ -0,0 +1,83 @@
+package territory
+
+import (
+	"reflect"
+	"testing"
+)
+
+func lookupGroups(groups ...Group) GroupLookup {
+	return func(code string) (Group, bool, error) {
+		for _, group := range groups {
+			if group.Code == code {
+				return group, true, nil
+			}
+		}
+		group, found := DefaultGroup(code)
+		return group, found, nil
+	}
+}
+
+func Test_NormalizeCountry(t *testing.T) {
+	for code, expected := range map[string]string{"FR": "FR", "fra": "FR", " usa ": "US", "Deu": "DE"} {
+		actual, err := NormalizeCountry(code)
+		if err != nil || actual != expected {
+			t.Fatalf("Expected '%s' for '%s', got '%s' (%v)", expected, code, actual, err)
+		}
+	}
+	_, err := NormalizeCountry("GER")
+	if err == nil || err.Error() != "unknown territory 'GER'" {
+		t.Fatalf("Expected GER to be an unknown territory, got %v", err)
+	}
+	if len(Countries()) != 249 {
+		t.Fatalf("Expected 249 countries, got %d", len(Countries()))
+	}
+}
+
+func Test_Resolve(t *testing.T) {
+	worldExUsCa := Group{Code: "WORLD_EX_USCA", Includes: []string{World}, Excludes: []string{"USA", "CA"}}
+	nordics := Group{Code: "NORDICS", Includes: []string{"DK", "FI", "IS", "NO", "SE"}}
+	euNoNordics := Group{Code: "EU_NO_NORDICS", Includes: []string{EU}, Excludes: []string{"NORDICS"}}
+	lookup := lookupGroups(worldExUsCa, nordics, euNoNordics)
+
+	countrySet, err := Resolve("WORLD_EX_USCA", lookup)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(countrySet) != 247 || countrySet["US"] || countrySet["CA"] || !countrySet["MX"] {
+		t.Fatalf("Unexpected countries of WORLD_EX_USCA: %d", len(countrySet))
+	}
+
+	countrySet, err = Resolve("eu_no_nordics", lookup)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(countrySet) != 24 || countrySet["SE"] || !countrySet["FR"] {
+		t.Fatalf("Unexpected countries of EU_NO_NORDICS: %d", len(countrySet))
+	}
+
+	_, err = Resolve("LOOP", lookupGroups(Group{Code: "LOOP", Includes: []string{"FR", "LOOP"}}))
+	if err == nil || err.Error() != "territory group 'LOOP' includes itself" {
+		t.Fatalf("Expected a loop error, got %v", err)
+	}
+}
+
+func Test_IsWithin(t *testing.T) {
+	lookup := lookupGroups()
+	actual := []bool{}
+	for _, code := range []string{"FRA", "US", "BR", "", "GER"} {
+		within, err := IsWithin(code, []string{EU, LATAM}, []string{"BR"}, lookup)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		actual = append(actual, within)
+	}
+	expected := []bool{true, false, false, false, false}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %v", actual)
+	}
+
+	within, _ := IsWithin("AUS", nil, nil, lookup)
+	if !within {
+		t.Fatalf("Expected the world to include AUS")
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"axispoint-cc/memstub"
+)
+
+var worldExcludingUsCa_in = `[{"code":"world_ex_usca","name":"World excluding US/CA","includes":["WORLD"],"excludes":["USA","CA"]}]`
+
+func MockGetTerritoryResponse(functionName string) []byte {
+	switch functionName {
+	case "Test_AddTerritoryGroups":
+		return []byte(`{"successCount":1,"failureCount":3,"territoryGroupResponses":[` +
+			`{"code":"EU","message":"Territory Group 'EU' already exists!","success":false},` +
+			`{"code":"FR","message":"Territory group 'FR' cannot use the code of a country","success":false},` +
+			`{"code":"LOOP","message":"territory group 'LOOP' includes itself","success":false}]}`)
+	case "Test_GetTerritory":
+		return []byte(`{"docType":"TERRITORYGROUP","code":"NORDICS","name":"Nordics","includes":["DK","FI","IS","NO","SE"],"excludes":["IS"],"countries":["DK","FI","NO","SE"]}`)
+	default:
+		return []byte("[]")
+	}
+}
+
+var territorialCopyrightDataReports = []string{
+	`{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"cdr1","isrc":"QZAB11800001","startDate":"2018-01-01","endDate":"2018-12-31","excludedTerritories":["US","CA"],"rightHolders":[{"selector":"within(Territory, 'EU')","ipi":"EU-IPI","percent":100},{"selector":"!within(Territory, 'EU')","ipi":"ROW-IPI","percent":100}]}`,
+	`{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"cdr2","isrc":"QZAB11800001","startDate":"2018-01-01","endDate":"2018-12-31","territories":["US","CA"],"rightHolders":[{"selector":"","ipi":"NA-IPI","percent":100}]}`,
+}
+
+func Test_AddTerritoryGroups(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("addTerritoryGroups"), []byte(`[{"code":"LATAM_EX_BR","includes":["LATAM"],"excludes":["BR"]},{"code":"EU","includes":["FR"]},{"code":"FR","includes":["FR"]},{"code":"LOOP","includes":["FR","LOOP"]}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := MockGetTerritoryResponse("Test_AddTerritoryGroups")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+
+	// the predefined groups can be redefined
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("updateTerritoryGroups"), []byte(`[{"code":"EU","name":"European Union","includes":["EU","GB"]},{"code":"NORDICS","includes":["DK"]}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected = []byte(`{"successCount":0,"failureCount":2,"territoryGroupResponses":[{"code":"EU","message":"territory group 'EU' includes itself","success":false},{"code":"NORDICS","message":"Territory Group 'NORDICS' does not exist!","success":false}]}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_GetTerritory(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addTerritoryGroups"), []byte(`[{"code":"NORDICS","name":"Nordics","includes":["DK","FI","IS","NO","SE"],"excludes":["IS"]}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getTerritory"), []byte("nordics")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := MockGetTerritoryResponse("Test_GetTerritory")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("getTerritory"), []byte("fra")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected = []byte(`{"docType":"TERRITORYGROUP","code":"FR","name":"","includes":null,"countries":["FR"]}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_GenerateExploitationReports_Territories(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+	putDocuments(t, stub, "copyrightDataReportUUID", territorialCopyrightDataReports)
+
+	// the split of the recording differs in North America, in the EU and in the rest of the world
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("generateExploitationReports"), []byte(`[` +
+		`{"source":"spotify-IPI","isrc":"QZAB11800001","units":1,"exploitationDate":"2018-12-30","amount":10,"territory":"USA","exploitationReportUUID":"er1"},` +
+		`{"source":"spotify-IPI","isrc":"QZAB11800001","units":1,"exploitationDate":"2018-12-30","amount":10,"territory":"FRA","exploitationReportUUID":"er2"},` +
+		`{"source":"spotify-IPI","isrc":"QZAB11800001","units":1,"exploitationDate":"2018-12-30","amount":10,"territory":"AU","exploitationReportUUID":"er3"},` +
+		`{"source":"spotify-IPI","isrc":"QZAB11800001","units":1,"exploitationDate":"2018-12-30","amount":10,"territory":"GER","exploitationReportUUID":"er4"}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	output := struct {
+		RoyaltyStatements   []RoyaltyStatement   `json:"royaltyStatements"`
+		ExploitationReports []ExploitationReport `json:"exploitationReports"`
+	}{}
+	err = jsonToObject(actual, &output)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actualRightHolders := []string{}
+	for _, royaltyStatement := range output.RoyaltyStatements {
+		actualRightHolders = append(actualRightHolders, royaltyStatement.ExploitationReportUUID+" "+royaltyStatement.RightHolder)
+	}
+	expectedRightHolders := []string{"er1 NA-IPI", "er2 EU-IPI", "er3 ROW-IPI"}
+	if !reflect.DeepEqual(expectedRightHolders, actualRightHolders) {
+		t.Fatalf("Actual response is not equal to expected response: %v", actualRightHolders)
+	}
+	if len(output.ExploitationReports) != 4 || output.ExploitationReports[3].State != INVALID_TERRITORY {
+		t.Fatalf("Expected exploitation report 'er4' to be flagged with an invalid territory: %s", actual)
+	}
+}
+
+func Test_GetCollectionRightsMatchingIpi_TerritoryGroup(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addTerritoryGroups"), []byte(worldExcludingUsCa_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, err = checkInvoke(t, stub, [][]byte{[]byte("addCollectionRights"), []byte(`[` +
+		`{"collectionRightUUID":"cr1","from":"P-IPI","territories":["WORLD_EX_USCA"],"rightHolders":[{"selector":"","ipi":"ROW-IPI","percent":10}]},` +
+		`{"collectionRightUUID":"cr2","from":"P-IPI","territories":["EU"],"excludedTerritories":["FR"],"rightHolders":[{"selector":"","ipi":"EU-IPI","percent":10}]}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	actual := map[string][]string{}
+	for _, exploitationTerritory := range []string{"US", "DE", "FR"} {
+		collectionRights, err := getCollectionRightsMatchingIpi(stub, "P-IPI", ExploitationReport{ExploitationDate: "2018-12-30", Territory: exploitationTerritory})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		actual[exploitationTerritory] = []string{}
+		for _, collectionRight := range collectionRights {
+			actual[exploitationTerritory] = append(actual[exploitationTerritory], collectionRight.CollectionRightUUID)
+		}
+	}
+	expected := map[string][]string{"US": {}, "DE": {"cr1", "cr2"}, "FR": {"cr1"}}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %v", actual)
+	}
+
+	// rights for unknown territories are rejected
+	actualBytes, err := checkInvoke(t, stub, [][]byte{[]byte("addCollectionRights"), []byte(`[{"collectionRightUUID":"cr3","from":"P-IPI","territories":["GER"],"rightHolders":[]}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expectedBytes := []byte(`{"successCount":0,"failureCount":1,"collectionRightsResponses":[{"collectionRightUUID":"cr3","message":"unknown territory 'GER'","success":false}]}`)
+	if !reflect.DeepEqual(expectedBytes, actualBytes) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actualBytes)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+/*
+* Split sheets give right holders different shares of the mechanical, performance, sync and print rights of a
+* work. Which right an exploitation pays for depends on its usage type, e.g. a stream pays both mechanical and
+* performance royalties while a download only pays mechanical ones. The right category of a usage type is
+* recorded on the ledger under USAGETYPECATEGORY~<usage type>.
+*
+* A right holder with a share for the right category of an exploitation is paid that share, otherwise its
+* percent applies. Exploitations whose usage type is not mapped are split by percent.
+ */
+
+var rightCategories = []string{MECHANICAL, PERFORMANCE, SYNC, PRINT}
+
+// UsageTypeCategoryResponse : defines response data from blockchain request
+type UsageTypeCategoryResponse struct {
+	UsageType string `json:"usageType"`
+	Message   string `json:"message"`
+	Success   bool   `json:"success"`
+}
+
+// UsageTypeCategoryOutput : defines accumulated output of blockchain requests
+type UsageTypeCategoryOutput struct {
+	SuccessCount               int                         `json:"successCount"`
+	FailureCount               int                         `json:"failureCount"`
+	UsageTypeCategoryResponses []UsageTypeCategoryResponse `json:"usageTypeCategoryResponses"`
+}
+
+/*
+* addUsageTypeCategories function maps usage types to right categories on the Ledger
+*
+* @params   {Array} args
+* @property {string} 0       - stringified JSON array of usage type categories.
+* @return   {pb.Response}    - peer Response
+ */
+func addUsageTypeCategories(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "addUsageTypeCategories"
+	logger.Info("ENTERING >", methodName, args)
+	return putUsageTypeCategories(stub, args, false)
+}
+
+/*
+* updateUsageTypeCategories function changes the right category of mapped usage types on the Ledger
+*
+* @params   {Array} args
+* @property {string} 0       - stringified JSON array of usage type categories.
+* @return   {pb.Response}    - peer Response
+ */
+func updateUsageTypeCategories(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "updateUsageTypeCategories"
+	logger.Info("ENTERING >", methodName, args)
+	return putUsageTypeCategories(stub, args, true)
+}
+
+//putUsageTypeCategories - contains the business logic to insert new or update existing usage type categories
+func putUsageTypeCategories(stub shim.ChaincodeStubInterface, args []string, updateFlag bool) pb.Response {
+	var methodName = "putUsageTypeCategories"
+
+	if len(args) != 1 {
+		return getErrorResponse("Missing arguments: Array of Usage Type Category objects is required")
+	}
+
+	usageTypeCategoryOutput := UsageTypeCategoryOutput{}
+	usageTypeCategories := &[]UsageTypeCategory{}
+	usageTypeCategoryResponses := []UsageTypeCategoryResponse{}
+
+	err := jsonToObject([]byte(args[0]), usageTypeCategories)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+
+	for _, usageTypeCategory := range *usageTypeCategories {
+		usageTypeCategoryResponse := UsageTypeCategoryResponse{}
+		usageTypeCategoryResponse.UsageType = usageTypeCategory.UsageType
+		usageTypeCategoryResponse.Success = true
+
+		err = putUsageTypeCategory(stub, &usageTypeCategory, updateFlag)
+		if err != nil {
+			usageTypeCategoryResponse.Success = false
+			usageTypeCategoryResponse.Message = err.Error()
+			usageTypeCategoryResponses = append(usageTypeCategoryResponses, usageTypeCategoryResponse)
+			usageTypeCategoryOutput.FailureCount++
+			continue
+		}
+		usageTypeCategoryOutput.SuccessCount++
+	}
+
+	usageTypeCategoryOutput.UsageTypeCategoryResponses = usageTypeCategoryResponses
+
+	objBytes, _ := objectToJSON(usageTypeCategoryOutput)
+	logger.Info("EXITING <", methodName, usageTypeCategoryOutput)
+	return shim.Success(objBytes)
+}
+
+/*
+* putUsageTypeCategory function validates a usage type category and records it on the Ledger
+*
+* @param    {UsageTypeCategory} - usage type category
+* @param    {bool}              - updateFlag
+* @return   {error}             - Error
+ */
+func putUsageTypeCategory(stub shim.ChaincodeStubInterface, usageTypeCategory *UsageTypeCategory, updateFlag bool) error {
+	usageTypeCategory.DocType = USAGETYPECATEGORY
+	usageTypeCategory.UsageType = normalizeUsageType(usageTypeCategory.UsageType)
+	if usageTypeCategory.UsageType == "" {
+		return fmt.Errorf("Usage type is required")
+	}
+	rightCategory, err := normalizeRightCategory(usageTypeCategory.RightCategory)
+	if err != nil {
+		return err
+	}
+	usageTypeCategory.RightCategory = rightCategory
+
+	previousUsageTypeCategory, err := getUsageTypeCategoryFromLedger(stub, usageTypeCategory.UsageType)
+	if err != nil {
+		return err
+	}
+	if !updateFlag && previousUsageTypeCategory != nil {
+		return fmt.Errorf("Usage Type '%s' is already mapped!", usageTypeCategory.UsageType)
+	}
+	if updateFlag && previousUsageTypeCategory == nil {
+		return fmt.Errorf("Usage Type '%s' is not mapped!", usageTypeCategory.UsageType)
+	}
+
+	usageTypeCategoryKey, err := stub.CreateCompositeKey(USAGETYPECATEGORY, []string{usageTypeCategory.UsageType})
+	if err != nil {
+		return err
+	}
+	usageTypeCategoryBytes, err := objectToJSON(usageTypeCategory)
+	if err != nil {
+		return err
+	}
+	return stub.PutState(usageTypeCategoryKey, usageTypeCategoryBytes)
+}
+
+/*
+* getUsageTypeCategories function returns the mapped usage types, or the mapping of one usage type
+*
+* @params   {Array} args
+* @property {string} 0       - optional usage type
+* @return   {pb.Response}    - peer Response
+ */
+func getUsageTypeCategories(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	var methodName = "getUsageTypeCategories"
+	logger.Info("ENTERING >", methodName, args)
+
+	if len(args) > 1 {
+		return getErrorResponse(fmt.Sprintf("%s - Incorrect number of parameters provided '%d'. Needed an optional usage type", methodName, len(args)))
+	}
+
+	usageTypeCategories := []UsageTypeCategory{}
+	if len(args) == 1 {
+		usageTypeCategory, err := getUsageTypeCategoryFromLedger(stub, normalizeUsageType(args[0]))
+		if err != nil {
+			return getErrorResponse(err.Error())
+		}
+		if usageTypeCategory == nil {
+			return getErrorResponse(fmt.Sprintf("Usage Type '%s' is not mapped!", args[0]))
+		}
+		usageTypeCategories = append(usageTypeCategories, *usageTypeCategory)
+	} else {
+		usageTypeCategoryIterator, err := stub.GetStateByPartialCompositeKey(USAGETYPECATEGORY, []string{})
+		if err != nil {
+			return getErrorResponse(err.Error())
+		}
+		defer usageTypeCategoryIterator.Close()
+		for usageTypeCategoryIterator.HasNext() {
+			queryResponse, err := usageTypeCategoryIterator.Next()
+			if err != nil {
+				return getErrorResponse(err.Error())
+			}
+			usageTypeCategory := UsageTypeCategory{}
+			err = jsonToObject(queryResponse.Value, &usageTypeCategory)
+			if err != nil {
+				return getErrorResponse(err.Error())
+			}
+			usageTypeCategories = append(usageTypeCategories, usageTypeCategory)
+		}
+	}
+
+	objBytes, err := objectToJSON(usageTypeCategories)
+	if err != nil {
+		return getErrorResponse(err.Error())
+	}
+	logger.Info("EXITING <", methodName, len(usageTypeCategories))
+	return shim.Success(objBytes)
+}
+
+//getUsageTypeCategoryFromLedger - returns the usage type category recorded on the ledger or nil
+func getUsageTypeCategoryFromLedger(stub shim.ChaincodeStubInterface, usageType string) (*UsageTypeCategory, error) {
+	usageTypeCategoryKey, err := stub.CreateCompositeKey(USAGETYPECATEGORY, []string{usageType})
+	if err != nil {
+		return nil, err
+	}
+	usageTypeCategoryBytes, err := stub.GetState(usageTypeCategoryKey)
+	if err != nil || usageTypeCategoryBytes == nil {
+		return nil, err
+	}
+	usageTypeCategory := UsageTypeCategory{}
+	err = jsonToObject(usageTypeCategoryBytes, &usageTypeCategory)
+	if err != nil {
+		return nil, err
+	}
+	return &usageTypeCategory, nil
+}
+
+//getRightCategoryForUsageType - returns the right category of a usage type or "" when it is not mapped
+func getRightCategoryForUsageType(stub shim.ChaincodeStubInterface, usageType string) (string, error) {
+	if normalizeUsageType(usageType) == "" {
+		return "", nil
+	}
+	usageTypeCategory, err := getUsageTypeCategoryFromLedger(stub, normalizeUsageType(usageType))
+	if err != nil || usageTypeCategory == nil {
+		return "", err
+	}
+	return usageTypeCategory.RightCategory, nil
+}
+
+//normalizeUsageType - returns the upper case usage type without surrounding spaces
+func normalizeUsageType(usageType string) string {
+	return strings.ToUpper(strings.TrimSpace(usageType))
+}
+
+//normalizeRightCategory - returns the upper case right category or an error when it is unknown
+func normalizeRightCategory(rightCategory string) (string, error) {
+	normalizedRightCategory := strings.ToUpper(strings.TrimSpace(rightCategory))
+	for _, knownRightCategory := range rightCategories {
+		if normalizedRightCategory == knownRightCategory {
+			return normalizedRightCategory, nil
+		}
+	}
+	return "", fmt.Errorf("Unknown right category '%s', expected one of %s", rightCategory, strings.Join(rightCategories, ", "))
+}
+
+//normalizeRightHolderShares - normalizes the right categories of the shares of right holders and checks their percentages
+func normalizeRightHolderShares(rightHolders []RightHolder) error {
+	for i := range rightHolders {
+		if len(rightHolders[i].Shares) == 0 {
+			continue
+		}
+		shares := map[string]float64{}
+		for rightCategory, share := range rightHolders[i].Shares {
+			normalizedRightCategory, err := normalizeRightCategory(rightCategory)
+			if err != nil {
+				return err
+			}
+			if share < 0 || share > 100 {
+				return fmt.Errorf("Share %v of right holder '%s' for %s is not a percentage", share, rightHolders[i].IPI, normalizedRightCategory)
+			}
+			shares[normalizedRightCategory] = share
+		}
+		rightHolders[i].Shares = shares
+	}
+	return nil
+}
+
+//getRightHolderShare - returns the share of a right holder for a right category, its percent when it has no share for it
+func getRightHolderShare(rightHolder RightHolder, rightCategory string) float64 {
+	if share, ok := rightHolder.Shares[rightCategory]; ok && rightCategory != "" {
+		return share
+	}
+	return rightHolder.Percent
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"axispoint-cc/memstub"
+)
+
+var usageTypeCategories_in = `[{"usageType":"stream","rightCategory":"performance"},{"usageType":"DOWNLOAD","rightCategory":"MECHANICAL"},{"usageType":"SYNC","rightCategory":"SYNC"}]`
+
+func MockGetUsageTypeResponse(functionName string) []byte {
+	switch functionName {
+	case "Test_AddUsageTypeCategories":
+		return []byte(`{"successCount":3,"failureCount":2,"usageTypeCategoryResponses":[` +
+			`{"usageType":"Stream ","message":"Usage Type 'STREAM' is already mapped!","success":false},` +
+			`{"usageType":"RADIO","message":"Unknown right category 'NEIGHBOURING', expected one of MECHANICAL, PERFORMANCE, SYNC, PRINT","success":false}]}`)
+	case "Test_GetUsageTypeCategories":
+		return []byte(`[{"docType":"USAGETYPECATEGORY","usageType":"DOWNLOAD","rightCategory":"MECHANICAL"},{"docType":"USAGETYPECATEGORY","usageType":"STREAM","rightCategory":"MECHANICAL"},{"docType":"USAGETYPECATEGORY","usageType":"SYNC","rightCategory":"SYNC"}]`)
+	default:
+		return []byte("[]")
+	}
+}
+
+var categoryCopyrightDataReports = []string{
+	`{"docType":"COPYRIGHTDATAREPORT","copyrightDataReportUUID":"cdr1","isrc":"QZAB11800001","startDate":"2018-01-01","endDate":"2018-12-31","rightHolders":[` +
+		`{"selector":"","ipi":"PUB-IPI","percent":50,"shares":{"MECHANICAL":75,"PERFORMANCE":50,"SYNC":100}},` +
+		`{"selector":"","ipi":"PRO-IPI","percent":50,"shares":{"MECHANICAL":25,"PERFORMANCE":50,"SYNC":0}}]}`,
+}
+
+func Test_AddUsageTypeCategories(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addUsageTypeCategories"), []byte(usageTypeCategories_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("addUsageTypeCategories"), []byte(`[{"usageType":"Stream ","rightCategory":"MECHANICAL"},{"usageType":"RADIO","rightCategory":"NEIGHBOURING"},{"usageType":"PRINT","rightCategory":"PRINT"},{"usageType":"SHEET","rightCategory":"print"},{"usageType":"VIDEO","rightCategory":"SYNC"}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := MockGetUsageTypeResponse("Test_AddUsageTypeCategories")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("updateUsageTypeCategories"), []byte(`[{"usageType":"CD","rightCategory":"MECHANICAL"}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected = []byte(`{"successCount":0,"failureCount":1,"usageTypeCategoryResponses":[{"usageType":"CD","message":"Usage Type 'CD' is not mapped!","success":false}]}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_GetUsageTypeCategories(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addUsageTypeCategories"), []byte(usageTypeCategories_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	_, err = checkInvoke(t, stub, [][]byte{[]byte("updateUsageTypeCategories"), []byte(`[{"usageType":"STREAM","rightCategory":"MECHANICAL"}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("getUsageTypeCategories")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := MockGetUsageTypeResponse("Test_GetUsageTypeCategories")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+
+	actual, err = checkInvoke(t, stub, [][]byte{[]byte("getUsageTypeCategories"), []byte("download")})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected = []byte(`[{"docType":"USAGETYPECATEGORY","usageType":"DOWNLOAD","rightCategory":"MECHANICAL"}]`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response")
+	}
+}
+
+func Test_GenerateExploitationReports_RightCategories(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+	putDocuments(t, stub, "copyrightDataReportUUID", categoryCopyrightDataReports)
+
+	_, err := checkInvoke(t, stub, [][]byte{[]byte("addUsageTypeCategories"), []byte(usageTypeCategories_in)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	// streams pay the performance shares, downloads the mechanical shares, sync licences the sync shares and
+	// unmapped usage types the percent of the right holders
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("generateExploitationReports"), []byte(`[` +
+		`{"source":"spotify-IPI","isrc":"QZAB11800001","units":1,"exploitationDate":"2018-12-30","amount":10,"usageType":"STREAM","exploitationReportUUID":"er1"},` +
+		`{"source":"spotify-IPI","isrc":"QZAB11800001","units":1,"exploitationDate":"2018-12-30","amount":10,"usageType":"download","exploitationReportUUID":"er2"},` +
+		`{"source":"spotify-IPI","isrc":"QZAB11800001","units":1,"exploitationDate":"2018-12-30","amount":10,"usageType":"SYNC","exploitationReportUUID":"er3"},` +
+		`{"source":"spotify-IPI","isrc":"QZAB11800001","units":1,"exploitationDate":"2018-12-30","amount":10,"usageType":"MECH","exploitationReportUUID":"er4"}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	output := struct {
+		RoyaltyStatements []RoyaltyStatement `json:"royaltyStatements"`
+	}{}
+	err = jsonToObject(actual, &output)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	actualSplits := map[string]float64{}
+	for _, royaltyStatement := range output.RoyaltyStatements {
+		actualSplits[royaltyStatement.ExploitationReportUUID+" "+royaltyStatement.RightCategory+" "+royaltyStatement.RightHolder] = royaltyStatement.Amount
+	}
+	expectedSplits := map[string]float64{
+		"er1 PERFORMANCE PUB-IPI": 5,
+		"er1 PERFORMANCE PRO-IPI": 5,
+		"er2 MECHANICAL PUB-IPI":  7.5,
+		"er2 MECHANICAL PRO-IPI":  2.5,
+		"er3 SYNC PUB-IPI":        10,
+		"er4  PUB-IPI":            5,
+		"er4  PRO-IPI":            5,
+	}
+	if !reflect.DeepEqual(expectedSplits, actualSplits) {
+		t.Fatalf("Actual response is not equal to expected response: %v", actualSplits)
+	}
+}
+
+func Test_AddCopyrightDataReports_InvalidShares(t *testing.T) {
+	scc := new(AxispointChaincode)
+	stub := memstub.NewStub("AxispointChaincode", scc)
+	checkInit(t, stub, [][]byte{[]byte("init"), []byte("")}, nil)
+
+	actual, err := checkInvoke(t, stub, [][]byte{[]byte("addCopyrightDataReports"), []byte(`[` +
+		`{"copyrightDataReportUUID":"cdr1","isrc":"QZAB11800001","rightHolders":[{"selector":"","ipi":"PUB-IPI","percent":50,"shares":{"GRAND":50}}]},` +
+		`{"copyrightDataReportUUID":"cdr2","isrc":"QZAB11800001","rightHolders":[{"selector":"","ipi":"PUB-IPI","percent":50,"shares":{"print":150}}]}]`)})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	expected := []byte(`{"successCount":0,"failureCount":2,"copyrightDataReports":[` +
+		`{"copyrightDataReportUUID":"cdr1","message":"Unknown right category 'GRAND', expected one of MECHANICAL, PERFORMANCE, SYNC, PRINT","success":false},` +
+		`{"copyrightDataReportUUID":"cdr2","message":"Share 150 of right holder 'PUB-IPI' for PRINT is not a percentage","success":false}]}`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Actual response is not equal to expected response: %s", actual)
+	}
+}
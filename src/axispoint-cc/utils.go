@@ -25,6 +25,7 @@ import (
 	"strings"
 	"time"
 
+	"axispoint-cc/identifier"
 	"github.com/Knetic/govaluate"
 
 	"github.com/hyperledger/fabric/core/chaincode/shim"
@@ -152,6 +153,9 @@ func getErrorResponse(message string) pb.Response {
 	return shim.Success(response)
 }
 
+//compositeKeyObjectTypes - object types of the composite keys written by the chaincode
+var compositeKeyObjectTypes = []string{OPENDISPUTE, BALANCE, IPIORGHISTORY, RECORDINGWORK, TERRITORYGROUP, USAGETYPECATEGORY, ADVANCE, TAXRULE, TAXRESIDENCY}
+
 // resetWorldState - remove all data from the world state
 // ================================================================================
 func resetWorldState(stub shim.ChaincodeStubInterface) (int, error) {
@@ -189,6 +193,31 @@ func resetWorldState(stub shim.ChaincodeStubInterface) (int, error) {
 		recordsDeletedCount++
 		logger.Debugf("%s - Successfully deleted record '%d' with key: %s", methodName, recordsDeletedCount, recordKey)
 	}
+	// composite keys are not returned by a range query, delete them by object type
+	for _, objectType := range compositeKeyObjectTypes {
+		compositeIterator, err := stub.GetStateByPartialCompositeKey(objectType, []string{})
+		if err != nil {
+			logger.Errorf("%s - Failed to get state by partial composite key '%s' with error: %s", methodName, objectType, err)
+			return recordsDeletedCount, err
+		}
+		compositeKeys := []string{}
+		for compositeIterator.HasNext() {
+			responseRange, err := compositeIterator.Next()
+			if err != nil {
+				compositeIterator.Close()
+				return recordsDeletedCount, err
+			}
+			compositeKeys = append(compositeKeys, responseRange.GetKey())
+		}
+		compositeIterator.Close()
+		for _, compositeKey := range compositeKeys {
+			err = stub.DelState(compositeKey)
+			if err != nil {
+				return recordsDeletedCount, err
+			}
+			recordsDeletedCount++
+		}
+	}
 	logger.Infof("%s - Total # of records deleted : %d", methodName, recordsDeletedCount)
 	return recordsDeletedCount, nil
 }
@@ -313,8 +342,13 @@ func getEvaluableParameters(asset interface{}) (map[string]interface{}, error) {
 
 //evaluate - Evaluates the selector againist the parameters and returns true/false
 func evaluate(selector string, parameters map[string]interface{}) (interface{}, error) {
+	return evaluateWithFunctions(selector, parameters, nil)
+}
+
+//evaluateWithFunctions - Evaluates the selector calling the functions, e.g. within(Territory, 'EU'), againist the parameters and returns true/false
+func evaluateWithFunctions(selector string, parameters map[string]interface{}, functions map[string]govaluate.ExpressionFunction) (interface{}, error) {
 	logger.Infof("evaluate selector: %s", selector)
-	expression, err := govaluate.NewEvaluableExpression(selector)
+	expression, err := govaluate.NewEvaluableExpressionWithFunctions(selector, functions)
 
 	if err != nil {
 		logger.Error(err)
@@ -358,3 +392,109 @@ func toFixed(num float64, precision int) float64 {
 	output := math.Pow(10, float64(precision))
 	return float64(round(num*output)) / output
 }
+
+// getTxTimestamp - Return the transaction timestamp formatted as RFC3339
+func getTxTimestamp(stub shim.ChaincodeStubInterface) (string, error) {
+	txTimestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return "", err
+	}
+
+	return time.Unix(txTimestamp.GetSeconds(), int64(txTimestamp.GetNanos())).UTC().Format(time.RFC3339), nil
+}
+
+// getTxDate - Return the transaction timestamp as a UTC time
+func getTxDate(stub shim.ChaincodeStubInterface) (time.Time, error) {
+	txTimestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(txTimestamp.GetSeconds(), int64(txTimestamp.GetNanos())).UTC(), nil
+}
+
+// dateLayouts - date formats accepted for exploitation and period dates
+var dateLayouts = []string{time.RFC3339, "2006-01-02", "20060102", "2006-1-2"}
+
+// parseDate - Parse a date in one of the accepted layouts. Dates without a time of day are returned
+// at the start of the day, or at its last instant when endOfDay is set.
+func parseDate(date string, endOfDay bool) (time.Time, error) {
+	for i, layout := range dateLayouts {
+		parsedDate, err := time.Parse(layout, date)
+		if err != nil {
+			continue
+		}
+		if i > 0 && endOfDay {
+			parsedDate = parsedDate.Add(24*time.Hour - time.Nanosecond)
+		}
+		return parsedDate, nil
+	}
+	return time.Time{}, fmt.Errorf("Invalid date '%s'", date)
+}
+
+//isDateInEffect - returns whether a date falls within an optional start and end date, both inclusive
+func isDateInEffect(startDate string, endDate string, date string) bool {
+	if startDate == "" && endDate == "" {
+		return true
+	}
+	parsedDate, err := parseDate(date, false)
+	if err != nil {
+		return false
+	}
+	if startDate != "" {
+		parsedStartDate, _ := parseDate(startDate, false)
+		if parsedDate.Before(parsedStartDate) {
+			return false
+		}
+	}
+	if endDate != "" {
+		parsedEndDate, _ := parseDate(endDate, true)
+		if parsedDate.After(parsedEndDate) {
+			return false
+		}
+	}
+	return true
+}
+
+//isDateRangeOverlapping - returns whether two date ranges with optional start and end dates share at least one day
+func isDateRangeOverlapping(startDate1 string, endDate1 string, startDate2 string, endDate2 string) bool {
+	return isDateBeforeEnd(startDate1, endDate2) && isDateBeforeEnd(startDate2, endDate1)
+}
+
+//isDateBeforeEnd - returns whether an optional start date is on or before an optional end date
+func isDateBeforeEnd(startDate string, endDate string) bool {
+	parsedStartDate, err := parseDate(startDate, false)
+	if err != nil {
+		return true
+	}
+	parsedEndDate, err := parseDate(endDate, true)
+	if err != nil {
+		return true
+	}
+	return !parsedEndDate.Before(parsedStartDate)
+}
+
+//normalizeIpi - returns the 11 digit form of an IPI name number. Parties that are not identified by an IPI
+//name number, such as DSPs, keep their identifier and are flagged in the log.
+func normalizeIpi(ipi string) (string, error) {
+	if ipi == "" {
+		return ipi, nil
+	}
+	if identifier.IsIpiNameNumber(ipi) {
+		return identifier.NormalizeIpi(ipi)
+	}
+	logger.Warningf("'%s' is not an IPI name number", ipi)
+	return ipi, nil
+}
+
+//normalizeRightHolderIpis - normalizes the IPIs of right holders
+func normalizeRightHolderIpis(rightHolders []RightHolder) error {
+	for i := range rightHolders {
+		ipi, err := normalizeIpi(rightHolders[i].IPI)
+		if err != nil {
+			return err
+		}
+		rightHolders[i].IPI = ipi
+	}
+	return nil
+}